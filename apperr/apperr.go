@@ -31,6 +31,20 @@ var (
 	Unauthorized = NewStatus(http.StatusUnauthorized)
 )
 
+// StatusError is implemented by errors that carry their own HTTP status, such as errors
+// returned by the broker client
+type StatusError interface {
+	error
+	HTTPStatus() int
+}
+
+// BodyError is implemented by errors that carry their own HTTP status and response body, such
+// as orders rejected by the pre-trade checks
+type BodyError interface {
+	StatusError
+	Body() interface{}
+}
+
 // NewStatus generates new error containing only http status code
 func NewStatus(status int) *APPError {
 	return &APPError{Status: status}
@@ -77,6 +91,12 @@ func Response(c *gin.Context, err error) {
 			errMsg = append(errMsg, fmt.Sprintf("%s%s", v.Name, getVldErrorMsg(v.ActualTag)))
 		}
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": errMsg})
+	case BodyError:
+		e := err.(BodyError)
+		c.AbortWithStatusJSON(e.HTTPStatus(), e.Body())
+	case StatusError:
+		e := err.(StatusError)
+		c.AbortWithStatusJSON(e.HTTPStatus(), gin.H{"message": e.Error()})
 	default:
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -102,6 +122,12 @@ func ResponseV2(c *gin.Context, code int, err error) {
 			errMsg = append(errMsg, fmt.Sprintf("%s%s", v.Name, getVldErrorMsg(v.ActualTag)))
 		}
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": errMsg})
+	case BodyError:
+		e := err.(BodyError)
+		c.AbortWithStatusJSON(e.HTTPStatus(), e.Body())
+	case StatusError:
+		e := err.(StatusError)
+		c.AbortWithStatusJSON(e.HTTPStatus(), gin.H{"message": e.Error()})
 	default:
 		c.AbortWithStatusJSON(code, gin.H{
 			"message": err.Error(),
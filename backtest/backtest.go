@@ -0,0 +1,183 @@
+package backtest
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/strategy"
+)
+
+// tradingDays is the number of daily bars in a year, used to annualize the Sharpe ratio
+const tradingDays = 252
+
+// Config holds the simulation settings of a backtest
+type Config struct {
+	// InitialCash is the cash the backtest starts with
+	InitialCash float64
+	// Slippage is the fraction of the price lost on every fill, e.g. 0.001 for 10 basis points
+	Slippage float64
+	// Commission is the flat fee charged per fill
+	Commission float64
+	// CommissionRate is the fee charged per fill as a fraction of its notional
+	CommissionRate float64
+	// PeriodsPerYear is the number of bars in a year. It defaults to 252, for daily bars.
+	PeriodsPerYear float64
+}
+
+// Trade is a simulated fill
+type Trade struct {
+	Time       time.Time `json:"time"`
+	Side       string    `json:"side"`
+	Qty        float64   `json:"qty"`
+	Price      float64   `json:"price"`
+	Commission float64   `json:"commission"`
+	// ProfitLoss is the realized profit of a sell against the average cost of the position,
+	// net of commissions. It is zero for buys.
+	ProfitLoss float64 `json:"profit_loss"`
+}
+
+// Point is the equity of the backtest at the close of a bar
+type Point struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Result is the outcome of a backtest
+type Result struct {
+	EquityCurve []Point `json:"equity_curve"`
+	Trades      []Trade `json:"trades"`
+	TotalReturn float64 `json:"total_return"`
+	MaxDrawdown float64 `json:"max_drawdown"`
+	Sharpe      float64 `json:"sharpe"`
+	WinRate     float64 `json:"win_rate"`
+}
+
+// ErrNoBars is returned when a backtest is run without any bars
+var ErrNoBars = errors.New("backtest: no bars")
+
+// Run replays bars, oldest first, through s. The target position the strategy returns after a
+// bar closes is filled at the open of the next bar, so the strategy never trades on prices it
+// could not have seen. Positions are long only and may be fractional.
+func Run(s strategy.Strategy, bars []broker.Bar, cfg Config) (*Result, error) {
+	if len(bars) == 0 {
+		return nil, ErrNoBars
+	}
+	if cfg.InitialCash <= 0 {
+		return nil, errors.New("backtest: initial cash must be positive")
+	}
+	if cfg.PeriodsPerYear <= 0 {
+		cfg.PeriodsPerYear = tradingDays
+	}
+
+	p := &portfolio{cfg: cfg, cash: cfg.InitialCash}
+	res := &Result{Trades: []Trade{}}
+	held, target := 0.0, 0.0
+	for i, bar := range bars {
+		if i > 0 && target != held {
+			held = p.rebalance(bar, target, res)
+		}
+		res.EquityCurve = append(res.EquityCurve, Point{Time: bar.Timestamp, Equity: p.equity(bar.Close)})
+		target = math.Max(0, math.Min(1, s.Target(bars[:i+1])))
+	}
+
+	equity := make([]float64, len(res.EquityCurve))
+	for i, pt := range res.EquityCurve {
+		equity[i] = pt.Equity
+	}
+	res.TotalReturn = equity[len(equity)-1]/cfg.InitialCash - 1
+	res.MaxDrawdown = MaxDrawdown(equity)
+	res.Sharpe = Sharpe(Returns(equity), cfg.PeriodsPerYear)
+	res.WinRate = winRate(res.Trades)
+	return res, nil
+}
+
+// portfolio is the simulated cash and position of a backtest
+type portfolio struct {
+	cfg  Config
+	cash float64
+	qty  float64
+	cost float64 // cost basis of qty, including commissions
+}
+
+func (p *portfolio) equity(price float64) float64 {
+	return p.cash + p.qty*price
+}
+
+func (p *portfolio) fee(notional float64) float64 {
+	return p.cfg.Commission + notional*p.cfg.CommissionRate
+}
+
+// weight is the fraction of the equity invested at price
+func (p *portfolio) weight(price float64) float64 {
+	if equity := p.equity(price); equity > 0 {
+		return p.qty * price / equity
+	}
+	return 0
+}
+
+// rebalance trades at the open of bar until target of the equity is invested, and returns the
+// fraction invested after trading: target once reached, or the position actually held when the
+// cash or the position did not allow it, so that the trade is retried at the next bar
+func (p *portfolio) rebalance(bar broker.Bar, target float64, res *Result) float64 {
+	diff := target*p.equity(bar.Open) - p.qty*bar.Open
+	if diff > 0 {
+		price := bar.Open * (1 + p.cfg.Slippage)
+		qty := diff / price
+		filled := true
+		if qty*price+p.fee(qty*price) > p.cash {
+			qty = (p.cash - p.cfg.Commission) / (price * (1 + p.cfg.CommissionRate))
+			filled = false
+		}
+		if qty <= 0 {
+			return p.weight(bar.Open)
+		}
+		fee := p.fee(qty * price)
+		p.cash -= qty*price + fee
+		p.qty += qty
+		p.cost += qty*price + fee
+		res.Trades = append(res.Trades, Trade{Time: bar.Timestamp, Side: broker.Buy, Qty: qty, Price: price, Commission: fee})
+		if !filled {
+			return p.weight(bar.Open)
+		}
+	} else if diff < 0 && p.qty > 0 {
+		price := bar.Open * (1 - p.cfg.Slippage)
+		qty := math.Min(p.qty, -diff/bar.Open)
+		if target == 0 {
+			qty = p.qty
+		}
+		fee := p.fee(qty * price)
+		cost := p.cost * qty / p.qty
+		p.cash += qty*price - fee
+		p.qty -= qty
+		p.cost -= cost
+		res.Trades = append(res.Trades, Trade{
+			Time:       bar.Timestamp,
+			Side:       broker.Sell,
+			Qty:        qty,
+			Price:      price,
+			Commission: fee,
+			ProfitLoss: qty*price - fee - cost,
+		})
+	}
+	return target
+}
+
+// winRate is the fraction of sells that realized a profit
+func winRate(trades []Trade) float64 {
+	sells, wins := 0, 0
+	for _, t := range trades {
+		if t.Side != broker.Sell {
+			continue
+		}
+		sells++
+		if t.ProfitLoss > 0 {
+			wins++
+		}
+	}
+	if sells == 0 {
+		return 0
+	}
+	return float64(wins) / float64(sells)
+}
@@ -0,0 +1,118 @@
+package backtest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/backtest"
+	"github.com/zcoriarty/Backend/broker"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// targets returns the i-th target after the i-th bar
+type targets []float64
+
+func (t targets) Target(bars []broker.Bar) float64 {
+	return t[len(bars)-1]
+}
+
+func bars(ohlc ...[2]float64) []broker.Bar {
+	res := make([]broker.Bar, len(ohlc))
+	for i, oc := range ohlc {
+		res[i] = broker.Bar{
+			Timestamp: time.Date(2023, 1, 2+i, 0, 0, 0, 0, time.UTC),
+			Open:      oc[0],
+			Close:     oc[1],
+		}
+	}
+	return res
+}
+
+func TestRun(t *testing.T) {
+	history := bars([2]float64{10, 10}, [2]float64{10, 12}, [2]float64{12, 15}, [2]float64{15, 15})
+	cases := []struct {
+		name       string
+		targets    targets
+		cfg        backtest.Config
+		wantEquity []float64
+		wantTrades []backtest.Trade
+		wantReturn float64
+		wantWin    float64
+	}{
+		{
+			name:       "Flat",
+			targets:    targets{0, 0, 0, 0},
+			cfg:        backtest.Config{InitialCash: 1000},
+			wantEquity: []float64{1000, 1000, 1000, 1000},
+			wantTrades: []backtest.Trade{},
+		},
+		{
+			name:       "Fills at next open",
+			targets:    targets{1, 1, 0, 0},
+			cfg:        backtest.Config{InitialCash: 1000},
+			wantEquity: []float64{1000, 1200, 1500, 1500},
+			wantTrades: []backtest.Trade{
+				{Time: history[1].Timestamp, Side: broker.Buy, Qty: 100, Price: 10},
+				{Time: history[3].Timestamp, Side: broker.Sell, Qty: 100, Price: 15, ProfitLoss: 500},
+			},
+			wantReturn: 0.5,
+			wantWin:    1,
+		},
+		{
+			name:       "Slippage and commissions",
+			targets:    targets{1, 1, 0, 0},
+			cfg:        backtest.Config{InitialCash: 1000, Slippage: 0.01, Commission: 1},
+			wantEquity: []float64{1000, 999 / 10.1 * 12, 999 / 10.1 * 15, 999/10.1*14.85 - 1},
+			wantTrades: []backtest.Trade{
+				{Time: history[1].Timestamp, Side: broker.Buy, Qty: 999 / 10.1, Price: 10.1, Commission: 1},
+				{Time: history[3].Timestamp, Side: broker.Sell, Qty: 999 / 10.1, Price: 14.85, Commission: 1, ProfitLoss: 999/10.1*14.85 - 1001},
+			},
+			wantReturn: (999/10.1*14.85-1)/1000 - 1,
+			wantWin:    1,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := backtest.Run(tt.targets, history, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if assert.Len(t, res.EquityCurve, len(tt.wantEquity)) {
+				for i, p := range res.EquityCurve {
+					assert.Equal(t, history[i].Timestamp, p.Time)
+					assert.InDelta(t, tt.wantEquity[i], p.Equity, 1e-9)
+				}
+			}
+			if assert.Len(t, res.Trades, len(tt.wantTrades)) {
+				for i, trade := range res.Trades {
+					want := tt.wantTrades[i]
+					assert.Equal(t, want.Time, trade.Time)
+					assert.Equal(t, want.Side, trade.Side)
+					assert.InDelta(t, want.Qty, trade.Qty, 1e-9)
+					assert.InDelta(t, want.Price, trade.Price, 1e-9)
+					assert.InDelta(t, want.Commission, trade.Commission, 1e-9)
+					assert.InDelta(t, want.ProfitLoss, trade.ProfitLoss, 1e-9)
+				}
+			}
+			assert.InDelta(t, tt.wantReturn, res.TotalReturn, 1e-9)
+			assert.InDelta(t, tt.wantWin, res.WinRate, 1e-9)
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	_, err := backtest.Run(targets{1}, nil, backtest.Config{InitialCash: 1000})
+	assert.Equal(t, backtest.ErrNoBars, err)
+	_, err = backtest.Run(targets{1}, bars([2]float64{10, 10}), backtest.Config{})
+	assert.Error(t, err)
+}
+
+func TestMetrics(t *testing.T) {
+	equity := []float64{100, 120, 90, 130}
+	assert.InDelta(t, 0.25, backtest.MaxDrawdown(equity), 1e-9)
+	returns := backtest.Returns(equity)
+	assert.InDeltaSlice(t, []float64{0.2, -0.25, 130.0/90 - 1}, returns, 1e-9)
+	assert.Equal(t, 0.0, backtest.Sharpe([]float64{0.01, 0.01, 0.01}, 252))
+	assert.True(t, backtest.Sharpe(returns, 252) > 0)
+}
@@ -0,0 +1,55 @@
+package backtest
+
+import "math"
+
+// Returns converts a series of values into the simple return of each period
+func Returns(values []float64) []float64 {
+	if len(values) < 2 {
+		return nil
+	}
+	res := make([]float64, 0, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		if values[i-1] == 0 {
+			res = append(res, 0)
+			continue
+		}
+		res = append(res, values[i]/values[i-1]-1)
+	}
+	return res
+}
+
+// MaxDrawdown is the largest decline of values from a previous peak, as a positive fraction
+// of that peak
+func MaxDrawdown(values []float64) float64 {
+	peak, res := 0.0, 0.0
+	for _, v := range values {
+		if v > peak {
+			peak = v
+		}
+		if peak > 0 && (peak-v)/peak > res {
+			res = (peak - v) / peak
+		}
+	}
+	return res
+}
+
+// Sharpe is the annualized Sharpe ratio of periodic returns, with a risk-free rate of zero
+func Sharpe(returns []float64, periodsPerYear float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(returns)-1))
+	if stddev == 0 {
+		return 0
+	}
+	return mean / stddev * math.Sqrt(periodsPerYear)
+}
@@ -0,0 +1,173 @@
+// Package benchmark compares the value of a portfolio over time with the price of a benchmark,
+// e.g. SPY or another index ETF, over the same days.
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zcoriarty/Backend/backtest"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/market"
+)
+
+// ErrNoBars is returned when the benchmark has no bar over the days of the portfolio
+var ErrNoBars = errors.New("benchmark: no bars in this period")
+
+// Bars lists the bars of a symbol between two times, bounds included, like the bars store
+type Bars interface {
+	List(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]broker.Bar, error)
+}
+
+// Point is the value of a portfolio at the close of a trading day. Values are compared by
+// their returns, so they can be an equity without cash flows or a growth index.
+type Point struct {
+	// Day is any time of the trading day in the market's time zone
+	Day   time.Time
+	Value float64
+}
+
+// SeriesPoint is the return of the portfolio and of the benchmark since the first day
+type SeriesPoint struct {
+	Day             time.Time `json:"day"`
+	Return          float64   `json:"return"`
+	BenchmarkReturn float64   `json:"benchmark_return"`
+}
+
+// Comparison measures a portfolio against a benchmark over the same days. Returns are
+// fractions, and alpha and tracking error are annualized.
+type Comparison struct {
+	Symbol          string  `json:"symbol"`
+	Return          float64 `json:"return"`
+	BenchmarkReturn float64 `json:"benchmark_return"`
+	ExcessReturn    float64 `json:"excess_return"`
+	// Alpha is the return of the portfolio its exposure to the benchmark does not explain,
+	// with a risk-free rate of zero
+	Alpha float64 `json:"alpha"`
+	// Beta is the sensitivity of the returns of the portfolio to the returns of the benchmark
+	Beta float64 `json:"beta"`
+	// TrackingError is the standard deviation of the difference between the returns
+	TrackingError float64       `json:"tracking_error"`
+	Correlation   float64       `json:"correlation"`
+	Series        []SeriesPoint `json:"series"`
+}
+
+// Against fetches the daily bars of symbol over the days of points and compares the points
+// with them
+func Against(ctx context.Context, bars Bars, symbol string, points []Point, periodsPerYear float64) (*Comparison, error) {
+	if len(points) == 0 {
+		return nil, ErrNoBars
+	}
+	points = sorted(points)
+	start := points[0].Day.In(market.Location)
+	end := points[len(points)-1].Day.In(market.Location)
+	// the first bar at or before the first day, which a holiday might miss
+	start = time.Date(start.Year(), start.Month(), start.Day()-7, 0, 0, 0, 0, market.Location)
+	end = time.Date(end.Year(), end.Month(), end.Day()+1, 0, 0, 0, 0, market.Location).Add(-time.Nanosecond)
+	symbol = strings.ToUpper(symbol)
+	b, err := bars.List(ctx, symbol, "1Day", start, end)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(symbol, points, b, periodsPerYear)
+}
+
+// Compare compares points with the daily bars of the benchmark symbol. Every point is aligned
+// with the close of the benchmark on its day, or on the last day before it the benchmark
+// traded. Points before the first bar are left out.
+func Compare(symbol string, points []Point, bars []broker.Bar, periodsPerYear float64) (*Comparison, error) {
+	points = sorted(points)
+	bars = append([]broker.Bar(nil), bars...)
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Timestamp.Before(bars[j].Timestamp) })
+
+	var values, closes []float64
+	var days []time.Time
+	for _, p := range points {
+		d := day(p.Day)
+		i := sort.Search(len(bars), func(i int) bool { return day(bars[i].Timestamp).After(d) })
+		if i == 0 {
+			continue
+		}
+		values = append(values, p.Value)
+		closes = append(closes, bars[i-1].Close)
+		days = append(days, p.Day)
+	}
+	if len(values) == 0 {
+		return nil, ErrNoBars
+	}
+
+	c := &Comparison{Symbol: symbol, Series: make([]SeriesPoint, len(values))}
+	for i := range values {
+		c.Series[i] = SeriesPoint{
+			Day:             days[i],
+			Return:          growth(values[0], values[i]),
+			BenchmarkReturn: growth(closes[0], closes[i]),
+		}
+	}
+	last := c.Series[len(c.Series)-1]
+	c.Return, c.BenchmarkReturn = last.Return, last.BenchmarkReturn
+	c.ExcessReturn = c.Return - c.BenchmarkReturn
+
+	rp, rb := backtest.Returns(values), backtest.Returns(closes)
+	if len(rp) < 2 {
+		return c, nil
+	}
+	varP, varB, cov := moments(rp, rb)
+	diff := make([]float64, len(rp))
+	for i := range rp {
+		diff[i] = rp[i] - rb[i]
+	}
+	v, _, _ := moments(diff, diff)
+	c.TrackingError = math.Sqrt(v * periodsPerYear)
+	if varB > 0 {
+		c.Beta = cov / varB
+		if varP > 0 {
+			c.Correlation = cov / math.Sqrt(varP*varB)
+		}
+	}
+	c.Alpha = (mean(rp) - c.Beta*mean(rb)) * periodsPerYear
+	return c, nil
+}
+
+// moments returns the sample variances of a and b and their sample covariance
+func moments(a, b []float64) (varA, varB, cov float64) {
+	ma, mb := mean(a), mean(b)
+	for i := range a {
+		varA += (a[i] - ma) * (a[i] - ma)
+		varB += (b[i] - mb) * (b[i] - mb)
+		cov += (a[i] - ma) * (b[i] - mb)
+	}
+	n := float64(len(a) - 1)
+	return varA / n, varB / n, cov / n
+}
+
+func mean(values []float64) float64 {
+	res := 0.0
+	for _, v := range values {
+		res += v
+	}
+	return res / float64(len(values))
+}
+
+func growth(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return to/from - 1
+}
+
+// day returns the trading day of t
+func day(t time.Time) time.Time {
+	y, m, d := t.In(market.Location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func sorted(points []Point) []Point {
+	res := append([]Point(nil), points...)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Day.Before(res[j].Day) })
+	return res
+}
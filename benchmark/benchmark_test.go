@@ -0,0 +1,93 @@
+package benchmark_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/broker"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ny, _ = time.LoadLocation("America/New_York")
+
+func day(d int) time.Time {
+	return time.Date(2025, time.March, d, 0, 0, 0, 0, ny)
+}
+
+func bars(closes map[int]float64) []broker.Bar {
+	var res []broker.Bar
+	for d, c := range closes {
+		res = append(res, broker.Bar{Timestamp: day(d), Close: c})
+	}
+	return res
+}
+
+func TestCompare(t *testing.T) {
+	spy := bars(map[int]float64{3: 100, 4: 102, 5: 99, 6: 101, 7: 104})
+	// twice the returns of the benchmark
+	points := []benchmark.Point{{Day: day(3), Value: 1000}}
+	closes := []float64{100, 102, 99, 101, 104}
+	for i := 1; i < len(closes); i++ {
+		prev := points[i-1].Value
+		points = append(points, benchmark.Point{Day: day(3 + i).Add(16 * time.Hour), Value: prev * (1 + 2*(closes[i]/closes[i-1]-1))})
+	}
+	c, err := benchmark.Compare("SPY", points, spy, 252)
+	assert.Nil(t, err)
+	assert.InDelta(t, 2, c.Beta, 1e-9)
+	assert.InDelta(t, 1, c.Correlation, 1e-9)
+	assert.InDelta(t, 0, c.Alpha, 1e-9)
+	assert.InDelta(t, 0.04, c.BenchmarkReturn, 1e-9)
+	assert.InDelta(t, c.Return-0.04, c.ExcessReturn, 1e-9)
+	assert.True(t, c.TrackingError > 0)
+	assert.Len(t, c.Series, 5)
+	assert.Equal(t, 0.0, c.Series[0].Return)
+
+	// a constant excess return is alpha, and tracks the benchmark perfectly
+	for i := range points {
+		points[i].Value = 1000 * (closes[i] / 100) * (1 + 0.001*float64(i))
+	}
+	c, err = benchmark.Compare("SPY", points, spy, 252)
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, c.Beta, 0.01)
+	assert.True(t, c.Alpha > 0.2)
+}
+
+func TestCompareAlignment(t *testing.T) {
+	// the benchmark did not trade on the 5th, and the point before its first bar is left out
+	spy := bars(map[int]float64{3: 100, 4: 110, 6: 121})
+	points := []benchmark.Point{{Day: day(2), Value: 5}, {Day: day(3), Value: 10}, {Day: day(5), Value: 11}, {Day: day(6), Value: 12}}
+	c, err := benchmark.Compare("SPY", points, spy, 252)
+	assert.Nil(t, err)
+	assert.Len(t, c.Series, 3)
+	assert.InDelta(t, 0.1, c.Series[1].BenchmarkReturn, 1e-9)
+	assert.InDelta(t, 0.21, c.Series[2].BenchmarkReturn, 1e-9)
+	assert.InDelta(t, 0.2, c.Return, 1e-9)
+
+	_, err = benchmark.Compare("SPY", points, nil, 252)
+	assert.Equal(t, benchmark.ErrNoBars, err)
+}
+
+type store []broker.Bar
+
+func (s store) List(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]broker.Bar, error) {
+	var res []broker.Bar
+	for _, b := range s {
+		if !b.Timestamp.Before(start) && !b.Timestamp.After(end) {
+			res = append(res, b)
+		}
+	}
+	return res, nil
+}
+
+func TestAgainst(t *testing.T) {
+	// the last day's bar is included, and so is the bar before a first day the market was closed
+	spy := store(bars(map[int]float64{1: 90, 3: 100, 4: 110, 7: 50}))
+	points := []benchmark.Point{{Day: day(2), Value: 10}, {Day: day(4).Add(20 * time.Hour), Value: 11}}
+	c, err := benchmark.Against(context.Background(), spy, "spy", points, 252)
+	assert.Nil(t, err)
+	assert.Equal(t, "SPY", c.Symbol)
+	assert.InDelta(t, 110.0/90-1, c.BenchmarkReturn, 1e-9)
+}
@@ -0,0 +1,178 @@
+package broker
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Contact holds the contact details submitted when opening a brokerage account
+type Contact struct {
+	Email   string   `json:"email_address"`
+	Phone   string   `json:"phone_number"`
+	Address []string `json:"street_address"`
+	City    string   `json:"city"`
+	State   string   `json:"state"`
+	Country string   `json:"country"`
+}
+
+// Identity holds the KYC identity submitted when opening a brokerage account
+type Identity struct {
+	FirstName             string   `json:"given_name"`
+	LastName              string   `json:"family_name"`
+	DateOfBirth           string   `json:"date_of_birth"`
+	TaxID                 string   `json:"tax_id"`
+	TaxIDType             string   `json:"tax_id_type"`
+	CountryOfCitizenship  string   `json:"country_of_citizenship"`
+	CountryOfBirth        string   `json:"country_of_birth"`
+	CountryOfTaxResidence string   `json:"country_of_tax_residence"`
+	FundingSource         []string `json:"funding_source"`
+}
+
+// Disclosures holds the regulatory disclosures submitted when opening a brokerage account
+type Disclosures struct {
+	IsControlPerson             bool `json:"is_control_person"`
+	IsAffiliatedExchangeOrFinra bool `json:"is_affiliated_exchange_or_finra"`
+	IsPoliticallyExposed        bool `json:"is_politically_exposed"`
+	ImmediateFamilyExposed      bool `json:"immediate_family_exposed"`
+}
+
+// Agreement is a signed customer agreement
+type Agreement struct {
+	Agreement string `json:"agreement"`
+	SignedAt  string `json:"signed_at"`
+	IPAddress string `json:"ip_address"`
+}
+
+// AccountRequest opens a new brokerage account
+type AccountRequest struct {
+	Contact     Contact     `json:"contact"`
+	Identity    Identity    `json:"identity"`
+	Disclosures Disclosures `json:"disclosures"`
+	Agreements  []Agreement `json:"agreements"`
+}
+
+// Account is a brokerage account, as returned by the accounts API
+type Account struct {
+	ID            string    `json:"id"`
+	AccountNumber string    `json:"account_number"`
+	Status        string    `json:"status"`
+	CryptoStatus  string    `json:"crypto_status,omitempty"`
+	Currency      string    `json:"currency"`
+	LastEquity    float64   `json:"last_equity,string"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TradingAccount holds the trading details (balances, buying power and restrictions) of an account
+type TradingAccount struct {
+	ID                        string    `json:"id"`
+	AccountNumber             string    `json:"account_number"`
+	Status                    string    `json:"status"`
+	CryptoStatus              string    `json:"crypto_status"`
+	Currency                  string    `json:"currency"`
+	BuyingPower               float64   `json:"buying_power,string"`
+	RegtBuyingPower           float64   `json:"regt_buying_power,string"`
+	DaytradingBuyingPower     float64   `json:"daytrading_buying_power,string"`
+	EffectiveBuyingPower      float64   `json:"effective_buying_power,string"`
+	NonMarginableBuyingPower  float64   `json:"non_marginable_buying_power,string"`
+	BodDtbp                   float64   `json:"bod_dtbp,string"`
+	Cash                      float64   `json:"cash,string"`
+	CashWithdrawable          float64   `json:"cash_withdrawable,string"`
+	CashTransferable          float64   `json:"cash_transferable,string"`
+	AccruedFees               float64   `json:"accrued_fees,string"`
+	PendingTransferOut        float64   `json:"pending_transfer_out,string"`
+	PendingTransferIn         float64   `json:"pending_transfer_in,string"`
+	PortfolioValue            float64   `json:"portfolio_value,string"`
+	PatternDayTrader          bool      `json:"pattern_day_trader"`
+	TradingBlocked            bool      `json:"trading_blocked"`
+	TransfersBlocked          bool      `json:"transfers_blocked"`
+	AccountBlocked            bool      `json:"account_blocked"`
+	CreatedAt                 time.Time `json:"created_at"`
+	TradeSuspendedByUser      bool      `json:"trade_suspended_by_user"`
+	Multiplier                string    `json:"multiplier"`
+	ShortingEnabled           bool      `json:"shorting_enabled"`
+	Equity                    float64   `json:"equity,string"`
+	LastEquity                float64   `json:"last_equity,string"`
+	LongMarketValue           float64   `json:"long_market_value,string"`
+	ShortMarketValue          float64   `json:"short_market_value,string"`
+	PositionMarketValue       float64   `json:"position_market_value,string"`
+	InitialMargin             float64   `json:"initial_margin,string"`
+	MaintenanceMargin         float64   `json:"maintenance_margin,string"`
+	LastMaintenanceMargin     float64   `json:"last_maintenance_margin,string"`
+	Sma                       float64   `json:"sma,string"`
+	DaytradeCount             int       `json:"daytrade_count"`
+	BalanceAsof               string    `json:"balance_asof"`
+	PreviousClose             string    `json:"previous_close"`
+	LastLongMarketValue       float64   `json:"last_long_market_value,string"`
+	LastShortMarketValue      float64   `json:"last_short_market_value,string"`
+	LastCash                  float64   `json:"last_cash,string"`
+	LastInitialMargin         float64   `json:"last_initial_margin,string"`
+	LastRegtBuyingPower       float64   `json:"last_regt_buying_power,string"`
+	LastDaytradingBuyingPower float64   `json:"last_daytrading_buying_power,string"`
+	LastBuyingPower           float64   `json:"last_buying_power,string"`
+	LastDaytradeCount         int       `json:"last_daytrade_count"`
+	ClearingBroker            string    `json:"clearing_broker"`
+}
+
+// PortfolioHistoryRequest filters the equity series returned by GetPortfolioHistory
+type PortfolioHistoryRequest struct {
+	Period        string `form:"period"`
+	Timeframe     string `form:"timeframe"`
+	DateEnd       string `form:"date_end"`
+	ExtendedHours string `form:"extended_hours"`
+}
+
+// PortfolioHistory is the equity and profit/loss time series of an account
+type PortfolioHistory struct {
+	Timestamp     []int64   `json:"timestamp"`
+	Equity        []float64 `json:"equity"`
+	ProfitLoss    []float64 `json:"profit_loss"`
+	ProfitLossPct []float64 `json:"profit_loss_pct"`
+	BaseValue     float64   `json:"base_value"`
+	Timeframe     string    `json:"timeframe"`
+}
+
+// CreateAccount submits a new brokerage account application
+func (b *Broker) CreateAccount(ctx context.Context, a *AccountRequest) (*Account, error) {
+	r := b.api(http.MethodPost, "/v1/accounts")
+	r.body = a
+	res := new(Account)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetAccount returns the brokerage account with the given id
+func (b *Broker) GetAccount(ctx context.Context, accountID string) (*Account, error) {
+	res := new(Account)
+	if err := b.do(ctx, b.api(http.MethodGet, accountPath(accountID)), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetTradingAccount returns the trading details of an account
+func (b *Broker) GetTradingAccount(ctx context.Context, accountID string) (*TradingAccount, error) {
+	res := new(TradingAccount)
+	if err := b.do(ctx, b.api(http.MethodGet, tradingPath(accountID, "account")), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetPortfolioHistory returns the equity series of an account
+func (b *Broker) GetPortfolioHistory(ctx context.Context, accountID string, p *PortfolioHistoryRequest) (*PortfolioHistory, error) {
+	r := b.api(http.MethodGet, tradingPath(accountID, "account", "portfolio", "history"))
+	r.query = url.Values{}
+	setIf(r.query, "period", p.Period)
+	setIf(r.query, "timeframe", p.Timeframe)
+	setIf(r.query, "date_end", p.DateEnd)
+	setIf(r.query, "extended_hours", p.ExtendedHours)
+	res := new(PortfolioHistory)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,94 @@
+package broker
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Asset is a tradable instrument
+type Asset struct {
+	ID                           string   `json:"id"`
+	Class                        string   `json:"class"`
+	Exchange                     string   `json:"exchange"`
+	Symbol                       string   `json:"symbol"`
+	Name                         string   `json:"name"`
+	Status                       string   `json:"status"`
+	Tradable                     bool     `json:"tradable"`
+	Marginable                   bool     `json:"marginable"`
+	MaintenanceMarginRequirement float64  `json:"maintenance_margin_requirement,omitempty"`
+	Shortable                    bool     `json:"shortable"`
+	EasyToBorrow                 bool     `json:"easy_to_borrow"`
+	Fractionable                 bool     `json:"fractionable"`
+	Attributes                   []string `json:"attributes,omitempty"`
+}
+
+// ListAssetsRequest filters the assets returned by ListAssets
+type ListAssetsRequest struct {
+	Status     string
+	AssetClass string
+}
+
+// Clock is the current market clock
+type Clock struct {
+	Timestamp time.Time `json:"timestamp"`
+	IsOpen    bool      `json:"is_open"`
+	NextOpen  time.Time `json:"next_open"`
+	NextClose time.Time `json:"next_close"`
+}
+
+// CalendarDay is a single trading day of the market calendar
+type CalendarDay struct {
+	Date         string `json:"date"`
+	Open         string `json:"open"`
+	Close        string `json:"close"`
+	SessionOpen  string `json:"session_open,omitempty"`
+	SessionClose string `json:"session_close,omitempty"`
+}
+
+// ListAssets returns the assets known to the broker
+func (b *Broker) ListAssets(ctx context.Context, p *ListAssetsRequest) ([]Asset, error) {
+	r := b.api(http.MethodGet, "/v1/assets")
+	r.query = url.Values{}
+	if p != nil {
+		setIf(r.query, "status", p.Status)
+		setIf(r.query, "asset_class", p.AssetClass)
+	}
+	res := []Asset{}
+	if err := b.do(ctx, r, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetAsset returns a single asset by symbol or id
+func (b *Broker) GetAsset(ctx context.Context, symbolOrID string) (*Asset, error) {
+	res := new(Asset)
+	if err := b.do(ctx, b.api(http.MethodGet, "/v1/assets/"+url.PathEscape(symbolOrID)), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetClock returns the current market clock
+func (b *Broker) GetClock(ctx context.Context) (*Clock, error) {
+	res := new(Clock)
+	if err := b.do(ctx, b.api(http.MethodGet, "/v1/clock"), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetCalendar returns the trading days between start and end (YYYY-MM-DD), both optional
+func (b *Broker) GetCalendar(ctx context.Context, start, end string) ([]CalendarDay, error) {
+	r := b.api(http.MethodGet, "/v1/calendar")
+	r.query = url.Values{}
+	setIf(r.query, "start", start)
+	setIf(r.query, "end", end)
+	res := []CalendarDay{}
+	if err := b.do(ctx, r, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
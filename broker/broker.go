@@ -0,0 +1,238 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zcoriarty/Backend/config"
+)
+
+// NewBroker creates a new broker API client
+func NewBroker(c *config.BrokerConfig) *Broker {
+	return &Broker{
+		config: c,
+		http:   &http.Client{Timeout: c.Timeout},
+		// event streams stay open indefinitely, so they are only bounded by their context
+		stream: &http.Client{},
+	}
+}
+
+// Broker provides a broker service implementation over the broker's REST API
+type Broker struct {
+	config *config.BrokerConfig
+	http   *http.Client
+	stream *http.Client
+}
+
+// Error is returned whenever a broker call does not succeed. It carries the HTTP status
+// that should be relayed to our own clients, so apperr.Response can render it directly.
+type Error struct {
+	// StatusCode is the HTTP status returned by the broker, or a gateway status for transport failures
+	StatusCode int `json:"-"`
+	// Code is the broker specific error code, if any
+	Code int `json:"code,omitempty"`
+	// Message is the error message returned by the broker
+	Message string `json:"message"`
+	// Err is the underlying transport error, if any
+	Err error `json:"-"`
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return http.StatusText(e.StatusCode)
+}
+
+// Unwrap returns the underlying transport error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus returns the status code to respond with
+func (e *Error) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// IsNotFound reports whether err is a broker 404
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// request describes a single call to either the broker or the market data API
+type request struct {
+	method string
+	base   string
+	path   string
+	query  url.Values
+	body   interface{}
+}
+
+func (b *Broker) api(method, path string) *request {
+	return &request{method: method, base: b.config.APIBase, path: path}
+}
+
+func (b *Broker) data(method, path string) *request {
+	return &request{method: method, base: b.config.DataBase, path: path}
+}
+
+// do executes r, retrying on 429 and, for idempotent methods, on 5xx and transport errors.
+// The decoded response body is written into out when out is not nil.
+func (b *Broker) do(ctx context.Context, r *request, out interface{}) error {
+	var payload []byte
+	if r.body != nil {
+		var err error
+		if payload, err = json.Marshal(r.body); err != nil {
+			return err
+		}
+	}
+	u := strings.TrimRight(r.base, "/") + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		res, err := b.send(ctx, r.method, u, payload)
+		if err != nil {
+			if ctx.Err() != nil {
+				return &Error{StatusCode: http.StatusGatewayTimeout, Message: "Broker request cancelled.", Err: ctx.Err()}
+			}
+			if attempt < b.config.MaxRetries && idempotent(r.method) {
+				if werr := b.wait(ctx, attempt, ""); werr != nil {
+					return werr
+				}
+				continue
+			}
+			return &Error{StatusCode: http.StatusBadGateway, Message: "Broker is unavailable. Try again later.", Err: err}
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return &Error{StatusCode: http.StatusBadGateway, Message: "Broker is unavailable. Try again later.", Err: err}
+		}
+
+		if retryable(r.method, res.StatusCode) && attempt < b.config.MaxRetries {
+			if werr := b.wait(ctx, attempt, res.Header.Get("Retry-After")); werr != nil {
+				return werr
+			}
+			continue
+		}
+
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			e := &Error{StatusCode: res.StatusCode}
+			_ = json.Unmarshal(body, e)
+			return e
+		}
+
+		if out == nil || len(bytes.TrimSpace(body)) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return &Error{StatusCode: http.StatusBadGateway, Message: "Unexpected response from broker.", Err: err}
+		}
+		return nil
+	}
+}
+
+func (b *Broker) send(ctx context.Context, method, u string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", b.config.Token)
+	req.Header.Add("Accept", "application/json")
+	if payload != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	return b.http.Do(req)
+}
+
+// wait sleeps before the next attempt, using Retry-After when the broker provides it
+// and exponential backoff with jitter otherwise
+func (b *Broker) wait(ctx context.Context, attempt int, retryAfter string) error {
+	d := b.config.RetryWaitMin << uint(attempt)
+	if d <= 0 || d > b.config.RetryWaitMax {
+		d = b.config.RetryWaitMax
+	}
+	if d > 0 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+	if s, err := strconv.Atoi(retryAfter); err == nil && s >= 0 {
+		d = time.Duration(s) * time.Second
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return &Error{StatusCode: http.StatusGatewayTimeout, Message: "Broker request cancelled.", Err: ctx.Err()}
+	case <-t.C:
+		return nil
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a response status should be retried. A 429 means the request
+// was never processed, so it is safe to retry for every method.
+func retryable(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	return status >= 500 && idempotent(method)
+}
+
+func accountPath(accountID string, parts ...string) string {
+	p := "/v1/accounts/" + url.PathEscape(accountID)
+	for _, part := range parts {
+		p += "/" + url.PathEscape(part)
+	}
+	return p
+}
+
+func tradingPath(accountID string, parts ...string) string {
+	p := "/v1/trading/accounts/" + url.PathEscape(accountID)
+	for _, part := range parts {
+		p += "/" + url.PathEscape(part)
+	}
+	return p
+}
+
+func setIf(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+func setIntIf(q url.Values, key string, value int) {
+	if value > 0 {
+		q.Set(key, fmt.Sprint(value))
+	}
+}
@@ -0,0 +1,55 @@
+package broker
+
+import "context"
+
+// Service is the interface to our broker and its market data API
+type Service interface {
+	CreateAccount(ctx context.Context, a *AccountRequest) (*Account, error)
+	GetAccount(ctx context.Context, accountID string) (*Account, error)
+	GetTradingAccount(ctx context.Context, accountID string) (*TradingAccount, error)
+	GetPortfolioHistory(ctx context.Context, accountID string, p *PortfolioHistoryRequest) (*PortfolioHistory, error)
+
+	ListOrders(ctx context.Context, accountID string, p *ListOrdersRequest) ([]Order, error)
+	CreateOrder(ctx context.Context, accountID string, o *OrderRequest) (*Order, error)
+	GetOrder(ctx context.Context, accountID, orderID string) (*Order, error)
+	ReplaceOrder(ctx context.Context, accountID, orderID string, o *ReplaceOrderRequest) (*Order, error)
+	CancelOrder(ctx context.Context, accountID, orderID string) error
+	CancelAllOrders(ctx context.Context, accountID string) ([]CancelStatus, error)
+
+	ListPositions(ctx context.Context, accountID string) ([]Position, error)
+	GetPosition(ctx context.Context, accountID, symbol string) (*Position, error)
+	ClosePosition(ctx context.Context, accountID, symbol string) (*Order, error)
+	CloseAllPositions(ctx context.Context, accountID string) ([]CloseStatus, error)
+
+	ListWatchlists(ctx context.Context, accountID string) ([]Watchlist, error)
+	GetWatchlist(ctx context.Context, accountID, watchlistID string) (*Watchlist, error)
+	CreateWatchlist(ctx context.Context, accountID string, w *WatchlistRequest) (*Watchlist, error)
+	UpdateWatchlist(ctx context.Context, accountID, watchlistID string, w *WatchlistRequest) (*Watchlist, error)
+	AddAssetToWatchlist(ctx context.Context, accountID, watchlistID, symbol string) (*Watchlist, error)
+	RemoveAssetFromWatchlist(ctx context.Context, accountID, watchlistID, symbol string) (*Watchlist, error)
+	DeleteWatchlist(ctx context.Context, accountID, watchlistID string) error
+
+	ListTransfers(ctx context.Context, accountID string, p *ListTransfersRequest) ([]Transfer, error)
+	CreateTransfer(ctx context.Context, accountID string, t *TransferRequest) (*Transfer, error)
+	DeleteTransfer(ctx context.Context, accountID, transferID string) error
+
+	ListACHRelationships(ctx context.Context, accountID string, statuses ...string) ([]ACHRelationship, error)
+	CreateACHRelationship(ctx context.Context, accountID string, a *ACHRelationshipRequest) (*ACHRelationship, error)
+	DeleteACHRelationship(ctx context.Context, accountID, relationshipID string) error
+
+	ListAssets(ctx context.Context, p *ListAssetsRequest) ([]Asset, error)
+	GetAsset(ctx context.Context, symbolOrID string) (*Asset, error)
+	GetClock(ctx context.Context) (*Clock, error)
+	GetCalendar(ctx context.Context, start, end string) ([]CalendarDay, error)
+
+	GetSnapshots(ctx context.Context, symbols []string) (map[string]*Snapshot, error)
+	GetSnapshot(ctx context.Context, symbol string) (*Snapshot, error)
+	GetBars(ctx context.Context, symbol string, p *MarketDataRequest) (*BarsResponse, error)
+	GetTrades(ctx context.Context, symbol string, p *MarketDataRequest) (*TradesResponse, error)
+	GetQuotes(ctx context.Context, symbol string, p *MarketDataRequest) (*QuotesResponse, error)
+	GetLatestTrade(ctx context.Context, symbol string) (*LatestTradeResponse, error)
+	GetLatestQuote(ctx context.Context, symbol string) (*LatestQuoteResponse, error)
+
+	ConnectMarketData(ctx context.Context) (*MarketStream, error)
+	StreamEvents(ctx context.Context, stream, sinceID string, fn func(Event) error) error
+}
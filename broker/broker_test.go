@@ -0,0 +1,211 @@
+package broker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newBroker(url string) *broker.Broker {
+	return broker.NewBroker(&config.BrokerConfig{
+		APIBase:      url,
+		DataBase:     url,
+		Token:        "Basic token",
+		Timeout:      time.Second,
+		MaxRetries:   2,
+		RetryWaitMin: time.Millisecond,
+		RetryWaitMax: 5 * time.Millisecond,
+	})
+}
+
+func TestRetries(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		statuses   []int
+		wantCalls  int
+		wantStatus int
+	}{
+		{
+			name:      "GET is retried on 5xx",
+			method:    http.MethodGet,
+			statuses:  []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			wantCalls: 3,
+		},
+		{
+			name:      "POST is retried on 429",
+			method:    http.MethodPost,
+			statuses:  []int{http.StatusTooManyRequests, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:       "POST is not retried on 5xx",
+			method:     http.MethodPost,
+			statuses:   []int{http.StatusInternalServerError, http.StatusOK},
+			wantCalls:  1,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "retries are bounded",
+			method:     http.MethodGet,
+			statuses:   []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			wantCalls:  3,
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.method, r.Method)
+				assert.Equal(t, "Basic token", r.Header.Get("Authorization"))
+				status := tt.statuses[calls]
+				calls++
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					json.NewEncoder(w).Encode(map[string]string{"id": "order-1", "status": "new"})
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"code": 50010000, "message": "try again"})
+			}))
+			defer ts.Close()
+			b := newBroker(ts.URL)
+
+			var err error
+			if tt.method == http.MethodGet {
+				_, err = b.GetOrder(context.Background(), "acc", "order-1")
+			} else {
+				qty := 1.0
+				_, err = b.CreateOrder(context.Background(), "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: &qty, Side: broker.Buy, Type: "market", TimeInForce: "day"})
+			}
+
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantStatus == 0 {
+				assert.Nil(t, err)
+				return
+			}
+			var e *broker.Error
+			if assert.True(t, errors.As(err, &e)) {
+				assert.Equal(t, tt.wantStatus, e.HTTPStatus())
+				assert.Equal(t, 50010000, e.Code)
+				assert.Equal(t, "try again", e.Error())
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/trading/accounts/acc/positions/TSLA":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":40410000,"message":"position does not exist"}`))
+		case "/v1/clock":
+			w.Write([]byte(`not json`))
+		case "/v1/trading/accounts/acc/account":
+			<-r.Context().Done()
+		}
+	}))
+	defer ts.Close()
+	b := newBroker(ts.URL)
+
+	_, err := b.GetPosition(context.Background(), "acc", "TSLA")
+	assert.True(t, broker.IsNotFound(err))
+	assert.Equal(t, "position does not exist", err.Error())
+
+	_, err = b.GetClock(context.Background())
+	var e *broker.Error
+	if assert.True(t, errors.As(err, &e)) {
+		assert.Equal(t, http.StatusBadGateway, e.HTTPStatus())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = b.GetTradingAccount(ctx, "acc")
+	if assert.True(t, errors.As(err, &e)) {
+		assert.Equal(t, http.StatusGatewayTimeout, e.HTTPStatus())
+	}
+}
+
+func TestTypedResponses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/trading/accounts/acc/account":
+			w.Write([]byte(`{"id":"acc","buying_power":"1500.25","cash":"1000","daytrade_count":2}`))
+		case "/v2/stocks/snapshots":
+			assert.Equal(t, "AAPL,NOPE", r.URL.Query().Get("symbols"))
+			w.Write([]byte(`{"AAPL":{"latestTrade":{"p":189.5,"s":100}},"NOPE":null}`))
+		}
+	}))
+	defer ts.Close()
+	b := newBroker(ts.URL)
+
+	account, err := b.GetTradingAccount(context.Background(), "acc")
+	assert.Nil(t, err)
+	assert.Equal(t, 1500.25, account.BuyingPower)
+	assert.Equal(t, 1000.0, account.Cash)
+	assert.Equal(t, 2, account.DaytradeCount)
+
+	snapshots, err := b.GetSnapshots(context.Background(), []string{"AAPL", "NOPE"})
+	assert.Nil(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, 189.5, snapshots["AAPL"].LatestTrade.Price)
+}
+
+func TestOrderRequestUnmarshal(t *testing.T) {
+	cases := []struct {
+		name    string
+		req     string
+		wantQty *float64
+		wantErr bool
+	}{
+		{
+			name:    "number",
+			req:     `{"symbol":"AAPL","qty":2.5}`,
+			wantQty: func() *float64 { f := 2.5; return &f }(),
+		},
+		{
+			name:    "string",
+			req:     `{"symbol":"AAPL","qty":"2.5"}`,
+			wantQty: func() *float64 { f := 2.5; return &f }(),
+		},
+		{
+			name: "missing",
+			req:  `{"symbol":"AAPL","notional":"100"}`,
+		},
+		{
+			name:    "invalid",
+			req:     `{"symbol":"AAPL","qty":"lots"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			o := new(broker.OrderRequest)
+			err := json.Unmarshal([]byte(tt.req), o)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, "AAPL", o.Symbol)
+			assert.Equal(t, tt.wantQty, o.Qty)
+
+			// decimals are sent to the broker as strings
+			if tt.wantQty != nil {
+				out, _ := json.Marshal(o)
+				assert.Contains(t, string(out), `"qty":"2.5"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,418 @@
+package brokertest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zcoriarty/Backend/broker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// account is the complete state of a single fake brokerage account
+type account struct {
+	broker.Account
+	cash          float64
+	daytradeCount int
+	blocked       bool
+	orders        []*broker.Order
+	positions     map[string]*broker.Position
+	watchlists    []*broker.Watchlist
+	transfers     []*broker.Transfer
+	relationships []*broker.ACHRelationship
+	// history replaces the portfolio history made of the current equity when set
+	history *broker.PortfolioHistory
+}
+
+// AddAccount creates an active account with the given id and cash balance
+func (s *Server) AddAccount(id string, cash float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addAccount(id, cash)
+}
+
+func (s *Server) addAccount(id string, cash float64) *account {
+	a := &account{
+		Account: broker.Account{
+			ID:            id,
+			AccountNumber: strconv.Itoa(100000000 + len(s.accounts)),
+			Status:        "ACTIVE",
+			Currency:      "USD",
+			LastEquity:    cash,
+			CreatedAt:     s.Now(),
+		},
+		cash:      cash,
+		positions: map[string]*broker.Position{},
+	}
+	s.accounts[id] = a
+	return a
+}
+
+// SetDaytradeCount sets the number of day trades the account made in the last five days
+func (s *Server) SetDaytradeCount(accountID string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[accountID]; ok {
+		a.daytradeCount = n
+	}
+}
+
+// SetTradingBlocked blocks or unblocks trading on the account
+func (s *Server) SetTradingBlocked(accountID string, blocked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[accountID]; ok {
+		a.blocked = blocked
+	}
+}
+
+// SetPortfolioHistory sets the equity series the portfolio history of the account returns
+func (s *Server) SetPortfolioHistory(accountID string, h broker.PortfolioHistory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[accountID]; ok {
+		a.history = &h
+	}
+}
+
+// SetCash sets the cash balance of the account
+func (s *Server) SetCash(accountID string, cash float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[accountID]; ok {
+		a.cash = cash
+	}
+}
+
+// Cash returns the cash balance of the account
+func (s *Server) Cash(accountID string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[accountID]; ok {
+		return a.cash
+	}
+	return 0
+}
+
+// AddACHRelationship links an approved bank account to the account and returns its id
+func (s *Server) AddACHRelationship(accountID, nickname string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.accounts[accountID]
+	if !ok {
+		return ""
+	}
+	r := &broker.ACHRelationship{
+		ID:              s.id("ach"),
+		AccountID:       accountID,
+		Status:          "APPROVED",
+		BankAccountType: "CHECKING",
+		Nickname:        nickname,
+		CreatedAt:       s.Now(),
+		UpdatedAt:       s.Now(),
+	}
+	a.relationships = append(a.relationships, r)
+	return r.ID
+}
+
+// SettleTransfers completes the queued transfers of the account, moving their cash
+func (s *Server) SettleTransfers(accountID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.accounts[accountID]
+	if !ok {
+		return
+	}
+	now := s.Now()
+	for _, t := range a.transfers {
+		if t.Status != "QUEUED" {
+			continue
+		}
+		if t.Direction == broker.Incoming {
+			a.cash += t.Amount
+		} else {
+			a.cash -= t.Amount
+		}
+		s.transfer(t, "COMPLETE")
+		t.UpdatedAt = &now
+	}
+}
+
+// lookup returns the account of the request, writing a 404 when it does not exist.
+// Callers must hold s.mu.
+func (s *Server) lookup(c *gin.Context) *account {
+	a, ok := s.accounts[c.Param("account_id")]
+	if !ok {
+		abort(c, http.StatusNotFound, "account not found")
+		return nil
+	}
+	return a
+}
+
+func (s *Server) createAccount(c *gin.Context) {
+	req := new(broker.AccountRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Contact.Email == "" {
+		abort(c, http.StatusUnprocessableEntity, "contact.email_address is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.addAccount(s.id("account"), 0)
+	c.JSON(http.StatusOK, a.Account)
+}
+
+func (s *Server) getAccount(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.lookup(c); a != nil {
+		c.JSON(http.StatusOK, a.Account)
+	}
+}
+
+// trading computes the trading details of a from its cash and positions. Callers must hold s.mu.
+func (s *Server) trading(a *account) *broker.TradingAccount {
+	long := 0.0
+	for _, p := range a.positions {
+		long += p.MarketValue
+	}
+	return &broker.TradingAccount{
+		ID:                    a.ID,
+		AccountNumber:         a.AccountNumber,
+		Status:                a.Status,
+		Currency:              a.Currency,
+		BuyingPower:           a.cash,
+		RegtBuyingPower:       a.cash,
+		DaytradingBuyingPower: a.cash,
+		EffectiveBuyingPower:  a.cash,
+		Cash:                  a.cash,
+		CashWithdrawable:      a.cash,
+		CashTransferable:      a.cash,
+		PortfolioValue:        a.cash + long,
+		Equity:                a.cash + long,
+		LastEquity:            a.LastEquity,
+		LongMarketValue:       long,
+		PositionMarketValue:   long,
+		TradingBlocked:        a.blocked,
+		DaytradeCount:         a.daytradeCount,
+		Multiplier:            "1",
+		CreatedAt:             a.CreatedAt,
+	}
+}
+
+func (s *Server) getTradingAccount(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.lookup(c); a != nil {
+		c.JSON(http.StatusOK, s.trading(a))
+	}
+}
+
+func (s *Server) getPortfolioHistory(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	if a.history != nil {
+		c.JSON(http.StatusOK, a.history)
+		return
+	}
+	equity := s.trading(a).Equity
+	c.JSON(http.StatusOK, broker.PortfolioHistory{
+		Timestamp:     []int64{s.Now().Unix()},
+		Equity:        []float64{equity},
+		ProfitLoss:    []float64{equity - a.LastEquity},
+		ProfitLossPct: []float64{pct(equity-a.LastEquity, a.LastEquity)},
+		BaseValue:     a.LastEquity,
+		Timeframe:     c.DefaultQuery("timeframe", "1D"),
+	})
+}
+
+func (s *Server) listTransfers(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	res := []*broker.Transfer{}
+	for _, t := range a.transfers {
+		if d := c.Query("direction"); d == "" || d == t.Direction {
+			res = append(res, t)
+		}
+	}
+	// newest first, as the broker does
+	sort.SliceStable(res, func(i, j int) bool { return res[i].CreatedAt.After(res[j].CreatedAt) })
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset > len(res) {
+		offset = len(res)
+	}
+	res = res[offset:]
+	if limit, _ := strconv.Atoi(c.Query("limit")); limit > 0 && limit < len(res) {
+		res = res[:limit]
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) createTransfer(c *gin.Context) {
+	req := new(broker.TransferRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	if req.Amount <= 0 {
+		abort(c, http.StatusUnprocessableEntity, "amount must be greater than 0")
+		return
+	}
+	if req.Direction != broker.Incoming && req.Direction != broker.Outgoing {
+		abort(c, http.StatusUnprocessableEntity, "invalid direction")
+		return
+	}
+	if req.Direction == broker.Outgoing && req.Amount > a.cash {
+		abort(c, http.StatusForbidden, "insufficient withdrawable cash")
+		return
+	}
+	found := false
+	for _, r := range a.relationships {
+		found = found || (r.ID == req.RelationshipID && r.Status != "CANCELED")
+	}
+	if !found {
+		abort(c, http.StatusNotFound, "relationship not found")
+		return
+	}
+	t := &broker.Transfer{
+		ID:             s.id("transfer"),
+		RelationshipID: req.RelationshipID,
+		AccountID:      a.ID,
+		Type:           req.TransferType,
+		Status:         "QUEUED",
+		Amount:         req.Amount,
+		Direction:      req.Direction,
+		CreatedAt:      s.Now(),
+	}
+	a.transfers = append(a.transfers, t)
+	c.JSON(http.StatusOK, t)
+}
+
+func (s *Server) deleteTransfer(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	for _, t := range a.transfers {
+		if t.ID != c.Param("transfer_id") {
+			continue
+		}
+		if t.Status != "QUEUED" {
+			abort(c, http.StatusUnprocessableEntity, "transfer cannot be canceled")
+			return
+		}
+		now := s.Now()
+		s.transfer(t, "CANCELED")
+		t.UpdatedAt = &now
+		c.Status(http.StatusNoContent)
+		return
+	}
+	abort(c, http.StatusNotFound, "transfer not found")
+}
+
+func (s *Server) listACHRelationships(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	var statuses []string
+	if q := c.Query("statuses"); q != "" {
+		statuses = strings.Split(q, ",")
+	}
+	res := []*broker.ACHRelationship{}
+	for _, r := range a.relationships {
+		if len(statuses) == 0 || contains(statuses, r.Status) {
+			res = append(res, r)
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) createACHRelationship(c *gin.Context) {
+	req := new(broker.ACHRelationshipRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	if req.BankAccountNumber == "" || req.BankRoutingNumber == "" {
+		abort(c, http.StatusUnprocessableEntity, "bank account and routing numbers are required")
+		return
+	}
+	r := &broker.ACHRelationship{
+		ID:               s.id("ach"),
+		AccountID:        a.ID,
+		Status:           "QUEUED",
+		AccountOwnerName: req.AccountOwnerName,
+		BankAccountType:  req.BankAccountType,
+		BankAccountNum:   req.BankAccountNumber,
+		BankRoutingNum:   req.BankRoutingNumber,
+		Nickname:         req.Nickname,
+		CreatedAt:        s.Now(),
+		UpdatedAt:        s.Now(),
+	}
+	a.relationships = append(a.relationships, r)
+	c.JSON(http.StatusOK, r)
+}
+
+func (s *Server) deleteACHRelationship(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	for _, r := range a.relationships {
+		if r.ID == c.Param("relationship_id") && r.Status != "CANCELED" {
+			r.Status = "CANCELED"
+			r.UpdatedAt = s.Now()
+			c.Status(http.StatusNoContent)
+			return
+		}
+	}
+	abort(c, http.StatusNotFound, "relationship not found")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func pct(v, base float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return v / base
+}
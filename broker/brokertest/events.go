@@ -0,0 +1,139 @@
+package brokertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zcoriarty/Backend/broker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// event is a single event of one of the fake's event streams
+type event struct {
+	id     int
+	stream string
+	data   []byte
+}
+
+// Emit appends payload to the event stream, e.g. a broker.JournalStatusEvent to
+// broker.JournalStatusStream. Order, transfer and account status changes made through the fake
+// emit their own events.
+func (s *Server) Emit(stream string, payload interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emit(stream, payload)
+}
+
+// SetAccountStatus changes the status of the account, emitting an account status event
+func (s *Server) SetAccountStatus(accountID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.accounts[accountID]
+	if !ok {
+		return
+	}
+	s.emit(broker.AccountStatusStream, broker.AccountStatusEvent{
+		AccountID:     a.ID,
+		AccountNumber: a.AccountNumber,
+		StatusFrom:    a.Status,
+		StatusTo:      status,
+		At:            s.Now(),
+	})
+	a.Status = status
+}
+
+// DropStreams disconnects every open event stream, as the broker does on deploys
+func (s *Server) DropStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.drop)
+	s.drop = make(chan struct{})
+}
+
+// emit appends payload to stream and wakes up the streams waiting for it. Callers must hold s.mu.
+func (s *Server) emit(stream string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		panic(err)
+	}
+	s.events = append(s.events, event{id: len(s.events) + 1, stream: stream, data: data})
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// trade emits a trade event for o. Callers must hold s.mu.
+func (s *Server) trade(a *account, name string, o *broker.Order) {
+	filled, held := o.FilledQty, 0.0
+	if p, ok := a.positions[o.Symbol]; ok {
+		held = p.Qty
+	}
+	s.emit(broker.TradeStream, broker.TradeEvent{
+		AccountID:   a.ID,
+		Event:       name,
+		Order:       *o,
+		Price:       o.FilledAvgPrice,
+		Qty:         &filled,
+		PositionQty: &held,
+		At:          s.Now(),
+	})
+}
+
+// transfer emits a transfer status event for t moving to status. Callers must hold s.mu.
+func (s *Server) transfer(t *broker.Transfer, status string) {
+	s.emit(broker.TransferStatusStream, broker.TransferStatusEvent{
+		AccountID:  t.AccountID,
+		TransferID: t.ID,
+		StatusFrom: t.Status,
+		StatusTo:   status,
+		At:         s.Now(),
+	})
+	t.Status = status
+}
+
+func (s *Server) streamEvents(c *gin.Context) {
+	stream := strings.TrimPrefix(c.Param("stream"), "/")
+	s.mu.Lock()
+	since := len(s.events)
+	s.mu.Unlock()
+	if q := c.Query("since_id"); q != "" {
+		id, err := strconv.Atoi(q)
+		if err != nil {
+			abort(c, http.StatusBadRequest, "invalid since_id")
+			return
+		}
+		since = id
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	for {
+		s.mu.Lock()
+		var pending []event
+		for _, e := range s.events {
+			if e.id > since && e.stream == stream {
+				pending = append(pending, e)
+			}
+		}
+		since = len(s.events)
+		notify, drop := s.notify, s.drop
+		s.mu.Unlock()
+
+		for _, e := range pending {
+			fmt.Fprintf(c.Writer, "id: %d\ndata: %s\n\n", e.id, e.data)
+		}
+		c.Writer.Flush()
+		select {
+		case <-notify:
+		case <-drop:
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package brokertest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddAsset makes an asset known to the fake. Once any asset is known, orders for unknown or
+// untradable symbols are rejected.
+func (s *Server) AddAsset(a broker.Asset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.Class == "" {
+		a.Class = "us_equity"
+	}
+	if a.Status == "" {
+		a.Status = "active"
+	}
+	if a.ID == "" {
+		a.ID = s.id("asset")
+	}
+	s.assets[a.Symbol] = a
+}
+
+// SetPrice sets the last trade price of symbol, marking positions to it and filling the
+// open orders it crosses. The trade, and a quote at the same price, are sent to the market
+// data stream connections subscribed to symbol.
+func (s *Server) SetPrice(symbol string, price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[symbol] = price
+	s.reprice(symbol, price)
+	s.broadcast(symbol, price)
+}
+
+// SetBars sets the historical bars of symbol, served by the bars endpoint for every timeframe
+func (s *Server) SetBars(symbol string, bars []broker.Bar) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sorted := append([]broker.Bar(nil), bars...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+	s.bars[symbol] = sorted
+}
+
+// SetMarketOpen opens or closes the market as reported by the clock
+func (s *Server) SetMarketOpen(open bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.open = open
+	s.clock = nil
+}
+
+// SetClock overrides the market clock entirely
+func (s *Server) SetClock(c broker.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = &c
+}
+
+func (s *Server) listAssets(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := []broker.Asset{}
+	for _, a := range s.assets {
+		if (c.Query("status") == "" || c.Query("status") == a.Status) &&
+			(c.Query("asset_class") == "" || c.Query("asset_class") == a.Class) {
+			res = append(res, a)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Symbol < res[j].Symbol })
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) getAsset(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.assets {
+		if a.Symbol == c.Param("symbol") || a.ID == c.Param("symbol") {
+			c.JSON(http.StatusOK, a)
+			return
+		}
+	}
+	if len(s.assets) == 0 {
+		c.JSON(http.StatusOK, s.asset(c.Param("symbol")))
+		return
+	}
+	abort(c, http.StatusNotFound, "asset not found for "+c.Param("symbol"))
+}
+
+func (s *Server) getClock(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clock != nil {
+		c.JSON(http.StatusOK, s.clock)
+		return
+	}
+	now := s.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	clock := broker.Clock{
+		Timestamp: now,
+		IsOpen:    s.open,
+		NextOpen:  day.AddDate(0, 0, 1).Add(9*time.Hour + 30*time.Minute),
+		NextClose: day.Add(16 * time.Hour),
+	}
+	if !clock.NextClose.After(now) {
+		clock.NextClose = clock.NextClose.AddDate(0, 0, 1)
+	}
+	c.JSON(http.StatusOK, clock)
+}
+
+func (s *Server) getCalendar(c *gin.Context) {
+	start, err := parseTime(c.Query("start"))
+	if err != nil {
+		abort(c, http.StatusUnprocessableEntity, "invalid start")
+		return
+	}
+	end, err := parseTime(c.Query("end"))
+	if err != nil {
+		abort(c, http.StatusUnprocessableEntity, "invalid end")
+		return
+	}
+	if start.IsZero() {
+		start = s.Now()
+	}
+	if end.IsZero() {
+		end = start.AddDate(0, 0, 30)
+	}
+	res := []broker.CalendarDay{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		res = append(res, broker.CalendarDay{Date: d.Format("2006-01-02"), Open: "09:30", Close: "16:00"})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// snapshot builds the snapshot of symbol from its price and bars. Callers must hold s.mu.
+func (s *Server) snapshot(symbol string) (*broker.Snapshot, bool) {
+	price, ok := s.prices[symbol]
+	if !ok {
+		return nil, false
+	}
+	now := s.Now()
+	bar := &broker.Bar{Timestamp: now, Open: price, High: price, Low: price, Close: price, VWAP: price}
+	snap := &broker.Snapshot{
+		Symbol:       symbol,
+		LatestTrade:  &broker.Trade{Timestamp: now, Price: price, Size: 100, Exchange: "V", Tape: "C"},
+		LatestQuote:  &broker.Quote{Timestamp: now, AskPrice: price, AskSize: 1, BidPrice: price, BidSize: 1},
+		MinuteBar:    bar,
+		DailyBar:     bar,
+		PrevDailyBar: bar,
+	}
+	if bars := s.bars[symbol]; len(bars) > 0 {
+		snap.DailyBar = &bars[len(bars)-1]
+		if len(bars) > 1 {
+			snap.PrevDailyBar = &bars[len(bars)-2]
+		}
+	}
+	return snap, true
+}
+
+func (s *Server) getSnapshots(c *gin.Context) {
+	if c.Param("symbol") != "snapshots" {
+		abort(c, http.StatusNotFound, "not found")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := map[string]*broker.Snapshot{}
+	for _, symbol := range strings.Split(c.Query("symbols"), ",") {
+		if symbol == "" {
+			continue
+		}
+		// unknown symbols are returned as null
+		snap, _ := s.snapshot(symbol)
+		res[symbol] = snap
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) getSnapshot(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.snapshot(c.Param("symbol"))
+	if !ok {
+		abort(c, http.StatusNotFound, "no data found for "+c.Param("symbol"))
+		return
+	}
+	c.JSON(http.StatusOK, snap)
+}
+
+func (s *Server) getBars(c *gin.Context) {
+	start, err := parseTime(c.Query("start"))
+	if err != nil {
+		abort(c, http.StatusUnprocessableEntity, "invalid start")
+		return
+	}
+	end, err := parseTime(c.Query("end"))
+	if err != nil {
+		abort(c, http.StatusUnprocessableEntity, "invalid end")
+		return
+	}
+	offset, _ := strconv.Atoi(c.Query("page_token"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bars := []broker.Bar{}
+	for _, b := range s.bars[c.Param("symbol")] {
+		if (start.IsZero() || !b.Timestamp.Before(start)) && (end.IsZero() || !b.Timestamp.After(end)) {
+			bars = append(bars, b)
+		}
+	}
+	if offset > len(bars) {
+		offset = len(bars)
+	}
+	bars = bars[offset:]
+	res := broker.BarsResponse{Symbol: c.Param("symbol"), Bars: bars}
+	if len(bars) > limit {
+		res.Bars = bars[:limit]
+		next := strconv.Itoa(offset + limit)
+		res.NextPageToken = &next
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) getTrades(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := broker.TradesResponse{Symbol: c.Param("symbol"), Trades: []broker.Trade{}}
+	if snap, ok := s.snapshot(c.Param("symbol")); ok {
+		res.Trades = append(res.Trades, *snap.LatestTrade)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) getLatestTrade(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.snapshot(c.Param("symbol"))
+	if !ok {
+		abort(c, http.StatusNotFound, "no trade found for "+c.Param("symbol"))
+		return
+	}
+	c.JSON(http.StatusOK, broker.LatestTradeResponse{Symbol: snap.Symbol, Trade: snap.LatestTrade})
+}
+
+func (s *Server) getQuotes(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := broker.QuotesResponse{Symbol: c.Param("symbol"), Quotes: []broker.Quote{}}
+	if snap, ok := s.snapshot(c.Param("symbol")); ok {
+		res.Quotes = append(res.Quotes, *snap.LatestQuote)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) getLatestQuote(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.snapshot(c.Param("symbol"))
+	if !ok {
+		abort(c, http.StatusNotFound, "no quote found for "+c.Param("symbol"))
+		return
+	}
+	c.JSON(http.StatusOK, broker.LatestQuoteResponse{Symbol: snap.Symbol, Quote: snap.LatestQuote})
+}
+
+// parseTime parses an RFC3339 timestamp or a date, returning the zero time for an empty string
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,509 @@
+package brokertest
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zcoriarty/Backend/broker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Orders returns a copy of every order of the account, oldest first
+func (s *Server) Orders(accountID string) []broker.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := []broker.Order{}
+	if a, ok := s.accounts[accountID]; ok {
+		for _, o := range a.orders {
+			res = append(res, *o)
+		}
+	}
+	return res
+}
+
+// Positions returns a copy of the open positions of the account, sorted by symbol
+func (s *Server) Positions(accountID string) []broker.Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := []broker.Position{}
+	if a, ok := s.accounts[accountID]; ok {
+		res = positions(a)
+	}
+	return res
+}
+
+func positions(a *account) []broker.Position {
+	res := make([]broker.Position, 0, len(a.positions))
+	for _, p := range a.positions {
+		res = append(res, *p)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Symbol < res[j].Symbol })
+	return res
+}
+
+// reprice marks every position in symbol to price and fills the open orders the new price
+// crosses. Callers must hold s.mu.
+func (s *Server) reprice(symbol string, price float64) {
+	for _, a := range s.accounts {
+		if p, ok := a.positions[symbol]; ok {
+			mark(p, price)
+		}
+		for _, o := range a.orders {
+			if o.Symbol == symbol && !o.Closed() {
+				s.match(a, o, price)
+			}
+		}
+	}
+}
+
+// match fills o at price if its type and limits allow it. Callers must hold s.mu.
+func (s *Server) match(a *account, o *broker.Order, price float64) {
+	switch o.Type {
+	case "limit":
+		if (o.Side == broker.Buy && price > *o.LimitPrice) || (o.Side == broker.Sell && price < *o.LimitPrice) {
+			return
+		}
+	case "stop":
+		if (o.Side == broker.Buy && price < *o.StopPrice) || (o.Side == broker.Sell && price > *o.StopPrice) {
+			return
+		}
+	case "stop_limit":
+		if (o.Side == broker.Buy && (price < *o.StopPrice || price > *o.LimitPrice)) ||
+			(o.Side == broker.Sell && (price > *o.StopPrice || price < *o.LimitPrice)) {
+			return
+		}
+	}
+
+	qty := 0.0
+	if o.Qty != nil {
+		qty = *o.Qty
+	} else {
+		qty = math.Floor(*o.Notional/price*1e9) / 1e9
+	}
+	p, ok := a.positions[o.Symbol]
+	if o.Side == broker.Buy {
+		if qty*price > a.cash {
+			s.close(a, o, broker.OrderRejected)
+			return
+		}
+		if !ok {
+			p = &broker.Position{
+				AssetID:    s.assets[o.Symbol].ID,
+				Symbol:     o.Symbol,
+				Exchange:   s.assets[o.Symbol].Exchange,
+				AssetClass: "us_equity",
+				Side:       "long",
+			}
+			a.positions[o.Symbol] = p
+		}
+		p.CostBasis += qty * price
+		p.Qty += qty
+		p.AvgEntryPrice = p.CostBasis / p.Qty
+		a.cash -= qty * price
+	} else {
+		if !ok || p.Qty < qty {
+			s.close(a, o, broker.OrderRejected)
+			return
+		}
+		p.CostBasis -= qty * p.AvgEntryPrice
+		p.Qty -= qty
+		a.cash += qty * price
+		if p.Qty <= 0 {
+			delete(a.positions, o.Symbol)
+		}
+	}
+	p.QtyAvailable = p.Qty
+	mark(p, price)
+
+	now := s.Now()
+	o.FilledQty = qty
+	o.FilledAvgPrice = &price
+	o.FilledAt = &now
+	if o.Qty == nil {
+		o.Qty = &qty
+	}
+	s.close(a, o, broker.OrderFilled)
+}
+
+// add appends a newly submitted order to the orders of a. Callers must hold s.mu.
+func (s *Server) add(a *account, o *broker.Order) {
+	a.orders = append(a.orders, o)
+	s.trade(a, "new", o)
+}
+
+// close moves o to a final status. Callers must hold s.mu.
+func (s *Server) close(a *account, o *broker.Order, status string) {
+	now := s.Now()
+	o.Status = status
+	o.UpdatedAt = &now
+	switch status {
+	case broker.OrderCanceled:
+		o.CanceledAt = &now
+	case broker.OrderRejected:
+		o.FailedAt = &now
+	case broker.OrderReplaced:
+		o.ReplacedAt = &now
+	}
+	event := status
+	if status == broker.OrderFilled {
+		event = "fill"
+	}
+	s.trade(a, event, o)
+}
+
+func mark(p *broker.Position, price float64) {
+	p.CurrentPrice = price
+	p.LastdayPrice = price
+	p.MarketValue = p.Qty * price
+	p.UnrealizedPL = p.MarketValue - p.CostBasis
+	p.UnrealizedPLPC = pct(p.UnrealizedPL, p.CostBasis)
+}
+
+func (s *Server) listOrders(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	status := c.DefaultQuery("status", "open")
+	var symbols []string
+	if q := c.Query("symbols"); q != "" {
+		symbols = strings.Split(q, ",")
+	}
+	res := []broker.Order{}
+	for _, o := range a.orders {
+		if (status == "open" && o.Closed()) || (status == "closed" && !o.Closed()) {
+			continue
+		}
+		if len(symbols) > 0 && !contains(symbols, o.Symbol) {
+			continue
+		}
+		res = append(res, *o)
+	}
+	if c.DefaultQuery("direction", "desc") == "desc" {
+		for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+			res[i], res[j] = res[j], res[i]
+		}
+	}
+	if limit, _ := strconv.Atoi(c.Query("limit")); limit > 0 && limit < len(res) {
+		res = res[:limit]
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) createOrder(c *gin.Context) {
+	req := new(broker.OrderRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		abort(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	if msg := s.validate(req); msg != "" {
+		abort(c, http.StatusUnprocessableEntity, msg)
+		return
+	}
+	if a.blocked {
+		abort(c, http.StatusForbidden, "trading is blocked for this account")
+		return
+	}
+	for _, o := range a.orders {
+		if req.ClientOrderID != "" && o.ClientOrderID == req.ClientOrderID {
+			abort(c, http.StatusUnprocessableEntity, "client_order_id must be unique")
+			return
+		}
+	}
+
+	price, priced := s.prices[req.Symbol]
+	if req.Side == broker.Buy && priced {
+		if cost := s.cost(req, price); cost > a.cash {
+			abort(c, http.StatusForbidden, "insufficient buying power")
+			return
+		}
+	}
+	if req.Side == broker.Sell {
+		held := 0.0
+		if p, ok := a.positions[req.Symbol]; ok {
+			held = p.Qty
+		}
+		if req.Qty != nil && *req.Qty > held {
+			abort(c, http.StatusForbidden, "insufficient qty available for order (requested: "+
+				strconv.FormatFloat(*req.Qty, 'f', -1, 64)+", available: "+strconv.FormatFloat(held, 'f', -1, 64)+")")
+			return
+		}
+	}
+
+	o := s.newOrder(req)
+	s.add(a, o)
+	if priced {
+		s.match(a, o, price)
+	}
+	c.JSON(http.StatusOK, o)
+}
+
+// validate reports what is wrong with req, or an empty string. Callers must hold s.mu.
+func (s *Server) validate(req *broker.OrderRequest) string {
+	if req.Side != broker.Buy && req.Side != broker.Sell {
+		return "side must be buy or sell"
+	}
+	if (req.Qty == nil) == (req.Notional == nil) {
+		return "exactly one of qty or notional is required"
+	}
+	if (req.Qty != nil && *req.Qty <= 0) || (req.Notional != nil && *req.Notional <= 0) {
+		return "qty and notional must be greater than 0"
+	}
+	switch req.Type {
+	case "market":
+	case "limit":
+		if req.LimitPrice == nil {
+			return "limit_price is required"
+		}
+	case "stop":
+		if req.StopPrice == nil {
+			return "stop_price is required"
+		}
+	case "stop_limit":
+		if req.LimitPrice == nil || req.StopPrice == nil {
+			return "limit_price and stop_price are required"
+		}
+	default:
+		return "unsupported order type " + req.Type
+	}
+	if req.Notional != nil && req.Type != "market" {
+		return "notional orders must be market orders"
+	}
+	if asset, ok := s.assets[req.Symbol]; len(s.assets) > 0 && (!ok || !asset.Tradable) {
+		return "asset " + req.Symbol + " is not tradable"
+	}
+	return ""
+}
+
+// cost estimates the cash needed by a buy order at price
+func (s *Server) cost(req *broker.OrderRequest, price float64) float64 {
+	if req.Notional != nil {
+		return *req.Notional
+	}
+	if req.LimitPrice != nil {
+		price = *req.LimitPrice
+	}
+	return *req.Qty * price
+}
+
+func (s *Server) newOrder(req *broker.OrderRequest) *broker.Order {
+	now := s.Now()
+	o := &broker.Order{
+		ID:            s.id("order"),
+		ClientOrderID: req.ClientOrderID,
+		CreatedAt:     now,
+		UpdatedAt:     &now,
+		SubmittedAt:   &now,
+		AssetID:       s.assets[req.Symbol].ID,
+		Symbol:        req.Symbol,
+		AssetClass:    "us_equity",
+		Notional:      req.Notional,
+		Qty:           req.Qty,
+		OrderClass:    req.OrderClass,
+		Type:          req.Type,
+		Side:          req.Side,
+		TimeInForce:   req.TimeInForce,
+		LimitPrice:    req.LimitPrice,
+		StopPrice:     req.StopPrice,
+		TrailPrice:    req.TrailPrice,
+		TrailPercent:  req.TrailPercent,
+		Status:        "new",
+		ExtendedHours: req.ExtendedHours,
+	}
+	if o.ClientOrderID == "" {
+		o.ClientOrderID = s.id("client")
+	}
+	return o
+}
+
+// order returns the order of the request, writing a 404 when it does not exist.
+// Callers must hold s.mu.
+func (s *Server) order(c *gin.Context, a *account) *broker.Order {
+	for _, o := range a.orders {
+		if o.ID == c.Param("order_id") {
+			return o
+		}
+	}
+	abort(c, http.StatusNotFound, "order not found")
+	return nil
+}
+
+func (s *Server) getOrder(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.lookup(c); a != nil {
+		if o := s.order(c, a); o != nil {
+			c.JSON(http.StatusOK, o)
+		}
+	}
+}
+
+func (s *Server) replaceOrder(c *gin.Context) {
+	req := new(broker.ReplaceOrderRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		abort(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	old := s.order(c, a)
+	if old == nil {
+		return
+	}
+	if old.Closed() {
+		abort(c, http.StatusUnprocessableEntity, "order is not open")
+		return
+	}
+
+	o := *old
+	o.ID = s.id("order")
+	o.ClientOrderID = req.ClientOrderID
+	if o.ClientOrderID == "" {
+		o.ClientOrderID = s.id("client")
+	}
+	o.CreatedAt = s.Now()
+	o.Replaces = &old.ID
+	if req.Qty != nil {
+		o.Qty = req.Qty
+	}
+	if req.TimeInForce != "" {
+		o.TimeInForce = req.TimeInForce
+	}
+	if req.LimitPrice != nil {
+		o.LimitPrice = req.LimitPrice
+	}
+	if req.StopPrice != nil {
+		o.StopPrice = req.StopPrice
+	}
+	if req.Trail != nil {
+		o.TrailPrice = req.Trail
+	}
+	old.ReplacedBy = &o.ID
+	s.close(a, old, broker.OrderReplaced)
+	s.add(a, &o)
+	if price, ok := s.prices[o.Symbol]; ok {
+		s.match(a, &o, price)
+	}
+	c.JSON(http.StatusOK, o)
+}
+
+func (s *Server) cancelOrder(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	o := s.order(c, a)
+	if o == nil {
+		return
+	}
+	if o.Closed() {
+		abort(c, http.StatusUnprocessableEntity, "order is already in \""+o.Status+"\" state")
+		return
+	}
+	s.close(a, o, broker.OrderCanceled)
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) cancelAllOrders(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	res := []broker.CancelStatus{}
+	for _, o := range a.orders {
+		if !o.Closed() {
+			s.close(a, o, broker.OrderCanceled)
+			res = append(res, broker.CancelStatus{ID: o.ID, Status: http.StatusOK})
+		}
+	}
+	c.JSON(http.StatusMultiStatus, res)
+}
+
+func (s *Server) listPositions(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.lookup(c); a != nil {
+		c.JSON(http.StatusOK, positions(a))
+	}
+}
+
+func (s *Server) getPosition(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	p, ok := a.positions[c.Param("symbol")]
+	if !ok {
+		abort(c, http.StatusNotFound, "position does not exist")
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// liquidate submits and fills a market order selling the whole position in symbol.
+// Callers must hold s.mu.
+func (s *Server) liquidate(a *account, p *broker.Position) *broker.Order {
+	qty := p.Qty
+	o := s.newOrder(&broker.OrderRequest{
+		Symbol:      p.Symbol,
+		Qty:         &qty,
+		Side:        broker.Sell,
+		Type:        "market",
+		TimeInForce: "day",
+	})
+	s.add(a, o)
+	s.match(a, o, p.CurrentPrice)
+	return o
+}
+
+func (s *Server) closePosition(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	p, ok := a.positions[c.Param("symbol")]
+	if !ok {
+		abort(c, http.StatusNotFound, "position does not exist")
+		return
+	}
+	c.JSON(http.StatusOK, s.liquidate(a, p))
+}
+
+func (s *Server) closeAllPositions(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	res := []broker.CloseStatus{}
+	for _, p := range positions(a) {
+		o := s.liquidate(a, a.positions[p.Symbol])
+		res = append(res, broker.CloseStatus{Symbol: p.Symbol, Status: http.StatusOK, Body: o})
+	}
+	c.JSON(http.StatusMultiStatus, res)
+}
@@ -0,0 +1,203 @@
+// Package brokertest provides an in-process fake of the broker and market data APIs, for
+// tests that exercise broker backed handlers without network access.
+package brokertest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Token is the authorization header the fake expects on every request
+const Token = "Basic brokertest"
+
+// NewServer starts a new fake broker. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		Now:      time.Now,
+		accounts: map[string]*account{},
+		assets:   map[string]broker.Asset{},
+		prices:   map[string]float64{},
+		bars:     map[string][]broker.Bar{},
+		open:     true,
+		notify:   make(chan struct{}),
+		drop:     make(chan struct{}),
+		streams:  map[*streamConn]struct{}{},
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(s.record, s.authorize, s.inject)
+	s.routes(r)
+	s.Server = httptest.NewServer(r)
+	return s
+}
+
+// Server is a fake broker holding its state in memory. Market orders fill immediately at the
+// scripted price of their symbol, and open limit and stop orders fill as soon as SetPrice
+// moves the price through them.
+type Server struct {
+	*httptest.Server
+
+	// Now returns the current time, and can be replaced to make timestamps deterministic
+	Now func() time.Time
+
+	mu       sync.Mutex
+	seq      int
+	accounts map[string]*account
+	assets   map[string]broker.Asset
+	prices   map[string]float64
+	bars     map[string][]broker.Bar
+	open     bool
+	clock    *broker.Clock
+	failures []*failure
+	calls    []string
+	events   []event
+	notify   chan struct{}
+	drop     chan struct{}
+	streams  map[*streamConn]struct{}
+}
+
+type failure struct {
+	method string
+	path   string
+	status int
+	n      int
+}
+
+// Config returns a broker configuration pointing at the fake
+func (s *Server) Config() *config.BrokerConfig {
+	return &config.BrokerConfig{
+		APIBase:      s.URL,
+		DataBase:     s.URL,
+		StreamBase:   "ws" + strings.TrimPrefix(s.URL, "http") + "/stream",
+		Token:        Token,
+		Timeout:      5 * time.Second,
+		MaxRetries:   1,
+		RetryWaitMin: time.Millisecond,
+		RetryWaitMax: 10 * time.Millisecond,
+	}
+}
+
+// Broker returns a broker client talking to the fake
+func (s *Server) Broker() *broker.Broker {
+	return broker.NewBroker(s.Config())
+}
+
+// Fail makes the next n requests whose method and path match fail with status. An empty
+// method matches every method, and path matches every request path it prefixes.
+func (s *Server) Fail(method, path string, status, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &failure{method, path, status, n})
+}
+
+// Calls returns how many requests matching method and path prefix were received, including
+// the ones failed by Fail
+func (s *Server) Calls(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, c := range s.calls {
+		if strings.HasPrefix(c, method+" "+path) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) record(c *gin.Context) {
+	s.mu.Lock()
+	s.calls = append(s.calls, c.Request.Method+" "+c.Request.URL.Path)
+	s.mu.Unlock()
+}
+
+func (s *Server) authorize(c *gin.Context) {
+	if c.GetHeader("Authorization") != Token {
+		abort(c, http.StatusUnauthorized, "request is not authorized")
+	}
+}
+
+func (s *Server) inject(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.failures {
+		if (f.method == "" || f.method == c.Request.Method) && strings.HasPrefix(c.Request.URL.Path, f.path) {
+			if f.n--; f.n <= 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+			abort(c, f.status, "injected failure")
+			return
+		}
+	}
+}
+
+// abort writes an error in the broker's format, with a code derived from the status the way
+// the broker does it, e.g. 40410000 for a 404
+func abort(c *gin.Context, status int, msg string) {
+	c.AbortWithStatusJSON(status, gin.H{"code": status * 100000, "message": msg})
+}
+
+// id returns a new unique id with the given prefix. Callers must hold s.mu.
+func (s *Server) id(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%d", prefix, s.seq)
+}
+
+func (s *Server) routes(r *gin.Engine) {
+	acc := r.Group("/v1/accounts")
+	acc.POST("", s.createAccount)
+	acc.GET("/:account_id", s.getAccount)
+	acc.GET("/:account_id/transfers", s.listTransfers)
+	acc.POST("/:account_id/transfers", s.createTransfer)
+	acc.DELETE("/:account_id/transfers/:transfer_id", s.deleteTransfer)
+	acc.GET("/:account_id/ach_relationships", s.listACHRelationships)
+	acc.POST("/:account_id/ach_relationships", s.createACHRelationship)
+	acc.DELETE("/:account_id/ach_relationships/:relationship_id", s.deleteACHRelationship)
+
+	tr := r.Group("/v1/trading/accounts/:account_id")
+	tr.GET("/account", s.getTradingAccount)
+	tr.GET("/account/portfolio/history", s.getPortfolioHistory)
+	tr.GET("/orders", s.listOrders)
+	tr.POST("/orders", s.createOrder)
+	tr.DELETE("/orders", s.cancelAllOrders)
+	tr.GET("/orders/:order_id", s.getOrder)
+	tr.PATCH("/orders/:order_id", s.replaceOrder)
+	tr.DELETE("/orders/:order_id", s.cancelOrder)
+	tr.GET("/positions", s.listPositions)
+	tr.DELETE("/positions", s.closeAllPositions)
+	tr.GET("/positions/:symbol", s.getPosition)
+	tr.DELETE("/positions/:symbol", s.closePosition)
+	tr.GET("/watchlists", s.listWatchlists)
+	tr.POST("/watchlists", s.createWatchlist)
+	tr.GET("/watchlists/:watchlist_id", s.getWatchlist)
+	tr.PUT("/watchlists/:watchlist_id", s.updateWatchlist)
+	tr.POST("/watchlists/:watchlist_id", s.addAssetToWatchlist)
+	tr.DELETE("/watchlists/:watchlist_id", s.deleteWatchlist)
+	tr.DELETE("/watchlists/:watchlist_id/:symbol", s.removeAssetFromWatchlist)
+
+	r.GET("/v1/events/*stream", s.streamEvents)
+	r.GET("/stream", s.marketStream)
+
+	r.GET("/v1/assets", s.listAssets)
+	r.GET("/v1/assets/:symbol", s.getAsset)
+	r.GET("/v1/clock", s.getClock)
+	r.GET("/v1/calendar", s.getCalendar)
+
+	// the snapshots endpoint shares its path segment with the per symbol endpoints
+	st := r.Group("/v2/stocks/:symbol")
+	st.GET("", s.getSnapshots)
+	st.GET("/snapshot", s.getSnapshot)
+	st.GET("/bars", s.getBars)
+	st.GET("/trades", s.getTrades)
+	st.GET("/trades/latest", s.getLatestTrade)
+	st.GET("/quotes", s.getQuotes)
+	st.GET("/quotes/latest", s.getLatestQuote)
+}
@@ -0,0 +1,189 @@
+package brokertest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func f(v float64) *float64 {
+	return &v
+}
+
+func TestOrders(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.AddAccount("acc", 1000)
+	s.SetPrice("AAPL", 100)
+	b := s.Broker()
+	ctx := context.Background()
+
+	// market orders fill immediately at the scripted price
+	o, err := b.CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: f(3), Side: broker.Buy, Type: "market", TimeInForce: "day"})
+	assert.Nil(t, err)
+	assert.Equal(t, broker.OrderFilled, o.Status)
+	assert.Equal(t, 100.0, *o.FilledAvgPrice)
+	assert.Equal(t, 700.0, s.Cash("acc"))
+
+	// limit orders wait until the price crosses them
+	o, err = b.CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: f(2), Side: broker.Sell, Type: "limit", LimitPrice: f(110), TimeInForce: "gtc"})
+	assert.Nil(t, err)
+	assert.Equal(t, "new", o.Status)
+	s.SetPrice("AAPL", 105)
+	o, err = b.GetOrder(ctx, "acc", o.ID)
+	assert.Nil(t, err)
+	assert.False(t, o.Closed())
+	s.SetPrice("AAPL", 111)
+	o, err = b.GetOrder(ctx, "acc", o.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, broker.OrderFilled, o.Status)
+	assert.Equal(t, 922.0, s.Cash("acc"))
+
+	positions, err := b.ListPositions(ctx, "acc")
+	assert.Nil(t, err)
+	if assert.Len(t, positions, 1) {
+		assert.Equal(t, 1.0, positions[0].Qty)
+		assert.Equal(t, 100.0, positions[0].AvgEntryPrice)
+		assert.Equal(t, 11.0, positions[0].UnrealizedPL)
+	}
+
+	// orders the account cannot afford are refused
+	_, err = b.CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: f(100), Side: broker.Buy, Type: "market", TimeInForce: "day"})
+	if e, ok := err.(*broker.Error); assert.True(t, ok) {
+		assert.Equal(t, http.StatusForbidden, e.StatusCode)
+		assert.Equal(t, "insufficient buying power", e.Message)
+	}
+
+	closed, err := b.ClosePosition(ctx, "acc", "AAPL")
+	assert.Nil(t, err)
+	assert.Equal(t, broker.OrderFilled, closed.Status)
+	assert.Equal(t, 1033.0, s.Cash("acc"))
+	assert.Len(t, s.Positions("acc"), 0)
+	assert.Len(t, s.Orders("acc"), 3)
+}
+
+func TestTransfers(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.AddAccount("acc", 0)
+	bank := s.AddACHRelationship("acc", "Checking")
+	b := s.Broker()
+	ctx := context.Background()
+
+	tr, err := b.CreateTransfer(ctx, "acc", &broker.TransferRequest{TransferType: "ach", RelationshipID: bank, Amount: 250, Direction: broker.Incoming})
+	assert.Nil(t, err)
+	assert.Equal(t, "QUEUED", tr.Status)
+	assert.Equal(t, 0.0, s.Cash("acc"))
+
+	s.SettleTransfers("acc")
+	assert.Equal(t, 250.0, s.Cash("acc"))
+	assert.NotNil(t, b.DeleteTransfer(ctx, "acc", tr.ID))
+
+	relationships, err := b.ListACHRelationships(ctx, "acc", "APPROVED")
+	assert.Nil(t, err)
+	assert.Len(t, relationships, 1)
+	assert.Nil(t, b.DeleteACHRelationship(ctx, "acc", bank))
+	relationships, err = b.ListACHRelationships(ctx, "acc", "APPROVED")
+	assert.Nil(t, err)
+	assert.Len(t, relationships, 0)
+}
+
+func TestFail(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.SetPrice("AAPL", 100)
+	b := s.Broker()
+	ctx := context.Background()
+
+	// the client retries once, so a single failure is absorbed and two are not
+	s.Fail(http.MethodGet, "/v2/stocks/AAPL", http.StatusServiceUnavailable, 1)
+	_, err := b.GetSnapshot(ctx, "AAPL")
+	assert.Nil(t, err)
+
+	s.Fail("", "/v2/stocks", http.StatusServiceUnavailable, 2)
+	_, err = b.GetSnapshot(ctx, "AAPL")
+	if e, ok := err.(*broker.Error); assert.True(t, ok) {
+		assert.Equal(t, http.StatusServiceUnavailable, e.StatusCode)
+	}
+	assert.Equal(t, 4, s.Calls(http.MethodGet, "/v2/stocks/AAPL/snapshot"))
+
+	snapshots, err := b.GetSnapshots(ctx, []string{"AAPL", "MSFT"})
+	assert.Nil(t, err)
+	assert.Len(t, snapshots, 1)
+}
+
+func TestEvents(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.AddAccount("acc", 1000)
+	s.SetPrice("AAPL", 100)
+	b := s.Broker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan broker.Event, 10)
+	done := make(chan error, 1)
+	stream := func(sinceID string) {
+		go func() {
+			done <- b.StreamEvents(ctx, broker.TradeStream, sinceID, func(e broker.Event) error {
+				received <- e
+				return nil
+			})
+		}()
+	}
+	next := func() broker.Event {
+		select {
+		case e := <-received:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+			return broker.Event{}
+		}
+	}
+
+	// events emitted before resuming after an event are replayed
+	_, err := b.CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: f(1), Side: broker.Buy, Type: "market", TimeInForce: "day"})
+	assert.Nil(t, err)
+	stream("0")
+	first, second := next(), next()
+	var e broker.TradeEvent
+	assert.Nil(t, json.Unmarshal(first.Data, &e))
+	assert.Equal(t, "new", e.Event)
+	assert.Nil(t, json.Unmarshal(second.Data, &e))
+	assert.Equal(t, "fill", e.Event)
+	assert.Equal(t, broker.OrderFilled, e.Order.Status)
+	assert.Equal(t, 1.0, *e.PositionQty)
+
+	// dropped streams resume after the last event handled
+	s.DropStreams()
+	assert.Equal(t, broker.ErrStreamClosed, <-done)
+	o, err := b.CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: f(1), Side: broker.Sell, Type: "limit", LimitPrice: f(200), TimeInForce: "gtc"})
+	assert.Nil(t, err)
+	stream(second.ID)
+	assert.Nil(t, json.Unmarshal(next().Data, &e))
+	assert.Equal(t, o.ID, e.Order.ID)
+	assert.Nil(t, b.CancelOrder(ctx, "acc", o.ID))
+	assert.Nil(t, json.Unmarshal(next().Data, &e))
+	assert.Equal(t, "canceled", e.Event)
+
+	// other streams are not mixed in
+	bank := s.AddACHRelationship("acc", "Checking")
+	_, err = b.CreateTransfer(ctx, "acc", &broker.TransferRequest{TransferType: "ach", RelationshipID: bank, Amount: 50, Direction: broker.Incoming})
+	assert.Nil(t, err)
+	s.SettleTransfers("acc")
+	select {
+	case e := <-received:
+		t.Fatalf("unexpected event %s", e.Data)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
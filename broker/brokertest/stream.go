@@ -0,0 +1,134 @@
+package brokertest
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// streamConn is a client connection to the fake market data stream
+type streamConn struct {
+	conn   *websocket.Conn
+	mu     sync.Mutex
+	trades map[string]bool
+	quotes map[string]bool
+}
+
+// send writes msgs to the connection, ignoring clients that went away
+func (c *streamConn) send(msgs ...gin.H) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	c.conn.WriteJSON(msgs)
+}
+
+// StreamConnections returns the number of open market data stream connections
+func (s *Server) StreamConnections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+// StreamSubscriptions returns the symbols whose trades and quotes the market data stream
+// connections are subscribed to, sorted
+func (s *Server) StreamSubscriptions() (trades, quotes []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	trades, quotes = []string{}, []string{}
+	for c := range s.streams {
+		trades = append(trades, keys(c.trades)...)
+		quotes = append(quotes, keys(c.quotes)...)
+	}
+	sort.Strings(trades)
+	sort.Strings(quotes)
+	return trades, quotes
+}
+
+// DropMarketStreams disconnects every market data stream connection
+func (s *Server) DropMarketStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.streams {
+		c.conn.Close()
+	}
+}
+
+// broadcast sends a trade and a quote at price to the connections subscribed to symbol.
+// Callers must hold s.mu.
+func (s *Server) broadcast(symbol string, price float64) {
+	now := s.Now()
+	for c := range s.streams {
+		if c.trades[symbol] {
+			c.send(gin.H{"T": broker.StreamTrade, "S": symbol, "p": price, "s": 100, "t": now})
+		}
+		if c.quotes[symbol] {
+			c.send(gin.H{"T": broker.StreamQuote, "S": symbol, "bp": price, "bs": 1, "ap": price, "as": 1, "t": now})
+		}
+	}
+}
+
+func (s *Server) marketStream(c *gin.Context) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	sc := &streamConn{conn: conn, trades: map[string]bool{}, quotes: map[string]bool{}}
+	sc.send(gin.H{"T": broker.StreamSuccess, "msg": "connected"}, gin.H{"T": broker.StreamSuccess, "msg": "authenticated"})
+	s.mu.Lock()
+	s.streams[sc] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, sc)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		var req struct {
+			Action string   `json:"action"`
+			Trades []string `json:"trades"`
+			Quotes []string `json:"quotes"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		if req.Action != "subscribe" && req.Action != "unsubscribe" {
+			sc.send(gin.H{"T": broker.StreamError, "code": http.StatusBadRequest, "msg": "invalid syntax"})
+			continue
+		}
+		s.mu.Lock()
+		for _, symbol := range req.Trades {
+			sc.trades[symbol] = req.Action == "subscribe"
+		}
+		for _, symbol := range req.Quotes {
+			sc.quotes[symbol] = req.Action == "subscribe"
+		}
+		for _, subs := range []map[string]bool{sc.trades, sc.quotes} {
+			for symbol, on := range subs {
+				if !on {
+					delete(subs, symbol)
+				}
+			}
+		}
+		trades, quotes := keys(sc.trades), keys(sc.quotes)
+		s.mu.Unlock()
+		sc.send(gin.H{"T": broker.StreamSubscription, "trades": trades, "quotes": quotes})
+	}
+}
+
+func keys(m map[string]bool) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
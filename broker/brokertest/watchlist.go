@@ -0,0 +1,191 @@
+package brokertest
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/broker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// asset returns the known asset for symbol, or a minimal tradable and fractionable one.
+// Callers must hold s.mu.
+func (s *Server) asset(symbol string) broker.Asset {
+	if a, ok := s.assets[symbol]; ok {
+		return a
+	}
+	return broker.Asset{Symbol: symbol, Class: "us_equity", Status: "active", Tradable: true, Fractionable: true}
+}
+
+// watchlist returns the watchlist of the request, writing a 404 when it does not exist.
+// Callers must hold s.mu.
+func (s *Server) watchlist(c *gin.Context, a *account) *broker.Watchlist {
+	for _, w := range a.watchlists {
+		if w.ID == c.Param("watchlist_id") {
+			return w
+		}
+	}
+	abort(c, http.StatusNotFound, "watchlist not found")
+	return nil
+}
+
+func (s *Server) setSymbols(w *broker.Watchlist, symbols []string) {
+	w.Assets = []broker.Asset{}
+	for _, symbol := range symbols {
+		if !contains(w.Symbols(), symbol) {
+			w.Assets = append(w.Assets, s.asset(symbol))
+		}
+	}
+	w.UpdatedAt = s.Now()
+}
+
+func (s *Server) listWatchlists(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	// like the broker, the list omits the assets
+	res := []broker.Watchlist{}
+	for _, w := range a.watchlists {
+		l := *w
+		l.Assets = nil
+		res = append(res, l)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *Server) createWatchlist(c *gin.Context) {
+	req := new(broker.WatchlistRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		abort(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	if req.Name == "" {
+		abort(c, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	for _, w := range a.watchlists {
+		if w.Name == req.Name {
+			abort(c, http.StatusUnprocessableEntity, "watchlist name must be unique")
+			return
+		}
+	}
+	w := &broker.Watchlist{
+		ID:        s.id("watchlist"),
+		AccountID: a.ID,
+		Name:      req.Name,
+		CreatedAt: s.Now(),
+	}
+	s.setSymbols(w, req.Symbols)
+	a.watchlists = append(a.watchlists, w)
+	c.JSON(http.StatusOK, w)
+}
+
+func (s *Server) getWatchlist(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.lookup(c); a != nil {
+		if w := s.watchlist(c, a); w != nil {
+			c.JSON(http.StatusOK, w)
+		}
+	}
+}
+
+func (s *Server) updateWatchlist(c *gin.Context) {
+	req := new(broker.WatchlistRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		abort(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	w := s.watchlist(c, a)
+	if w == nil {
+		return
+	}
+	if req.Name != "" {
+		w.Name = req.Name
+	}
+	s.setSymbols(w, req.Symbols)
+	c.JSON(http.StatusOK, w)
+}
+
+func (s *Server) addAssetToWatchlist(c *gin.Context) {
+	req := new(struct {
+		Symbol string `json:"symbol"`
+	})
+	if err := c.ShouldBindJSON(req); err != nil || req.Symbol == "" {
+		abort(c, http.StatusUnprocessableEntity, "symbol is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	w := s.watchlist(c, a)
+	if w == nil {
+		return
+	}
+	if contains(w.Symbols(), req.Symbol) {
+		abort(c, http.StatusUnprocessableEntity, "asset is already in the watchlist")
+		return
+	}
+	s.setSymbols(w, append(w.Symbols(), req.Symbol))
+	c.JSON(http.StatusOK, w)
+}
+
+func (s *Server) removeAssetFromWatchlist(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	w := s.watchlist(c, a)
+	if w == nil {
+		return
+	}
+	symbols := []string{}
+	for _, symbol := range w.Symbols() {
+		if symbol != c.Param("symbol") {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == len(w.Assets) {
+		abort(c, http.StatusNotFound, "asset is not in the watchlist")
+		return
+	}
+	s.setSymbols(w, symbols)
+	c.JSON(http.StatusOK, w)
+}
+
+func (s *Server) deleteWatchlist(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.lookup(c)
+	if a == nil {
+		return
+	}
+	for i, w := range a.watchlists {
+		if w.ID == c.Param("watchlist_id") {
+			a.watchlists = append(a.watchlists[:i], a.watchlists[i+1:]...)
+			c.Status(http.StatusNoContent)
+			return
+		}
+	}
+	abort(c, http.StatusNotFound, "watchlist not found")
+}
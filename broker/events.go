@@ -0,0 +1,186 @@
+package broker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event streams of the broker
+const (
+	AccountStatusStream  = "accounts/status"
+	TradeStream          = "trades"
+	TransferStatusStream = "transfers/status"
+	JournalStatusStream  = "journals/status"
+)
+
+// EventStreams lists every event stream of the broker
+var EventStreams = []string{AccountStatusStream, TradeStream, TransferStatusStream, JournalStatusStream}
+
+// ErrStreamClosed is returned by StreamEvents when the broker ends the stream
+var ErrStreamClosed = errors.New("broker: event stream closed")
+
+// Event is a single server-sent event of one of the broker's event streams
+type Event struct {
+	Stream string
+	// ID identifies the event within its stream, and is passed back as sinceID to resume after it
+	ID   string
+	Data json.RawMessage
+}
+
+// AccountStatusEvent reports a change of the status of an account
+type AccountStatusEvent struct {
+	AccountID     string    `json:"account_id"`
+	AccountNumber string    `json:"account_number"`
+	StatusFrom    string    `json:"status_from"`
+	StatusTo      string    `json:"status_to"`
+	Reason        string    `json:"reason"`
+	At            time.Time `json:"at"`
+}
+
+// TradeEvent reports an update of an order, e.g. new, fill, partial_fill or canceled
+type TradeEvent struct {
+	AccountID   string    `json:"account_id"`
+	Event       string    `json:"event"`
+	ExecutionID string    `json:"execution_id,omitempty"`
+	Order       Order     `json:"order"`
+	Price       *float64  `json:"price,string,omitempty"`
+	Qty         *float64  `json:"qty,string,omitempty"`
+	PositionQty *float64  `json:"position_qty,string,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// TransferStatusEvent reports a change of the status of a transfer
+type TransferStatusEvent struct {
+	AccountID  string    `json:"account_id"`
+	TransferID string    `json:"transfer_id"`
+	StatusFrom string    `json:"status_from"`
+	StatusTo   string    `json:"status_to"`
+	Reason     string    `json:"reason,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// JournalStatusEvent reports a change of the status of a journal between two accounts
+type JournalStatusEvent struct {
+	JournalID  string    `json:"journal_id"`
+	EntryType  string    `json:"entry_type"`
+	StatusFrom string    `json:"status_from"`
+	StatusTo   string    `json:"status_to"`
+	Reason     string    `json:"reason,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// StreamEvents reads the events of stream that follow sinceID, or only new events when sinceID
+// is empty, and calls fn with each of them in order. It blocks until ctx is done, fn returns an
+// error or the connection ends, and returns that error. Callers resume after a disconnection by
+// calling it again with the ID of the last event they handled.
+func (b *Broker) StreamEvents(ctx context.Context, stream, sinceID string, fn func(Event) error) error {
+	u := strings.TrimRight(b.config.APIBase, "/") + "/v1/events/" + stream
+	if sinceID != "" {
+		u += "?" + url.Values{"since_id": {sinceID}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", b.config.Token)
+	req.Header.Add("Accept", "text/event-stream")
+	req.Header.Add("Cache-Control", "no-cache")
+
+	res, err := b.stream.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &Error{StatusCode: http.StatusBadGateway, Message: "Broker is unavailable. Try again later.", Err: err}
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		e := &Error{StatusCode: res.StatusCode}
+		_ = json.Unmarshal(body, e)
+		return e
+	}
+
+	err = readEvents(res.Body, stream, fn)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// readEvents parses a text/event-stream body, calling fn with every event it dispatches
+func readEvents(r io.Reader, stream string, fn func(Event) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	var id string
+	var data []byte
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if len(data) > 0 {
+				e := Event{Stream: stream, ID: id, Data: data}
+				if e.ID == "" {
+					e.ID = payloadID(data)
+				}
+				if err := fn(e); err != nil {
+					return err
+				}
+			}
+			id, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// comments keep the connection alive
+			continue
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return &Error{StatusCode: http.StatusBadGateway, Message: "Broker event stream failed.", Err: err}
+	}
+	return ErrStreamClosed
+}
+
+// payloadID returns the event ID carried in the payload of events sent without an id field
+func payloadID(data []byte) string {
+	var p struct {
+		EventID   json.RawMessage `json:"event_id"`
+		EventULID string          `json:"event_ulid"`
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return ""
+	}
+	if p.EventULID != "" {
+		return p.EventULID
+	}
+	raw := bytes.TrimSpace(p.EventID)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	if s, err := strconv.Unquote(string(raw)); err == nil {
+		return s
+	}
+	return string(raw)
+}
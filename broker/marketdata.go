@@ -0,0 +1,212 @@
+package broker
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Trade is a single market trade
+type Trade struct {
+	Timestamp  time.Time `json:"t"`
+	Exchange   string    `json:"x"`
+	Price      float64   `json:"p"`
+	Size       float64   `json:"s"`
+	Conditions []string  `json:"c,omitempty"`
+	ID         int64     `json:"i"`
+	Tape       string    `json:"z"`
+}
+
+// Quote is a single NBBO quote
+type Quote struct {
+	Timestamp   time.Time `json:"t"`
+	AskExchange string    `json:"ax"`
+	AskPrice    float64   `json:"ap"`
+	AskSize     float64   `json:"as"`
+	BidExchange string    `json:"bx"`
+	BidPrice    float64   `json:"bp"`
+	BidSize     float64   `json:"bs"`
+	Conditions  []string  `json:"c,omitempty"`
+	Tape        string    `json:"z"`
+}
+
+// Bar is an OHLCV aggregate over a timeframe
+type Bar struct {
+	Timestamp  time.Time `json:"t"`
+	Open       float64   `json:"o"`
+	High       float64   `json:"h"`
+	Low        float64   `json:"l"`
+	Close      float64   `json:"c"`
+	Volume     float64   `json:"v"`
+	TradeCount int64     `json:"n"`
+	VWAP       float64   `json:"vw"`
+}
+
+// Snapshot is the latest trade, quote and bars of a symbol
+type Snapshot struct {
+	Symbol       string `json:"symbol,omitempty"`
+	LatestTrade  *Trade `json:"latestTrade"`
+	LatestQuote  *Quote `json:"latestQuote"`
+	MinuteBar    *Bar   `json:"minuteBar"`
+	DailyBar     *Bar   `json:"dailyBar"`
+	PrevDailyBar *Bar   `json:"prevDailyBar"`
+}
+
+// MarketDataRequest filters and pages historical trades, quotes and bars
+type MarketDataRequest struct {
+	Start     string `form:"start"`
+	End       string `form:"end"`
+	Limit     int    `form:"limit"`
+	PageToken string `form:"page_token"`
+	Timeframe string `form:"timeframe"`
+}
+
+// BarsResponse is a page of historical bars
+type BarsResponse struct {
+	Symbol        string  `json:"symbol"`
+	Bars          []Bar   `json:"bars"`
+	NextPageToken *string `json:"next_page_token"`
+}
+
+// TradesResponse is a page of historical trades
+type TradesResponse struct {
+	Symbol        string  `json:"symbol"`
+	Trades        []Trade `json:"trades"`
+	NextPageToken *string `json:"next_page_token"`
+}
+
+// QuotesResponse is a page of historical quotes
+type QuotesResponse struct {
+	Symbol        string  `json:"symbol"`
+	Quotes        []Quote `json:"quotes"`
+	NextPageToken *string `json:"next_page_token"`
+}
+
+// LatestTradeResponse is the latest trade of a symbol
+type LatestTradeResponse struct {
+	Symbol string `json:"symbol"`
+	Trade  *Trade `json:"trade"`
+}
+
+// LatestQuoteResponse is the latest quote of a symbol
+type LatestQuoteResponse struct {
+	Symbol string `json:"symbol"`
+	Quote  *Quote `json:"quote"`
+}
+
+func (p *MarketDataRequest) query() url.Values {
+	q := url.Values{}
+	if p != nil {
+		setIf(q, "start", p.Start)
+		setIf(q, "end", p.End)
+		setIntIf(q, "limit", p.Limit)
+		setIf(q, "page_token", p.PageToken)
+		setIf(q, "timeframe", p.Timeframe)
+	}
+	return q
+}
+
+func stockPath(symbol string, parts ...string) string {
+	p := "/v2/stocks/" + url.PathEscape(symbol)
+	for _, part := range parts {
+		p += "/" + part
+	}
+	return p
+}
+
+// GetSnapshots returns the snapshots of symbols, keyed by symbol. Unknown symbols are omitted.
+func (b *Broker) GetSnapshots(ctx context.Context, symbols []string) (map[string]*Snapshot, error) {
+	res := map[string]*Snapshot{}
+	if len(symbols) == 0 {
+		return res, nil
+	}
+	r := b.data(http.MethodGet, "/v2/stocks/snapshots")
+	r.query = url.Values{"symbols": {strings.Join(symbols, ",")}}
+	if err := b.do(ctx, r, &res); err != nil {
+		return nil, err
+	}
+	for symbol, s := range res {
+		if s == nil {
+			delete(res, symbol)
+		}
+	}
+	return res, nil
+}
+
+// GetSnapshot returns the snapshot of a single symbol
+func (b *Broker) GetSnapshot(ctx context.Context, symbol string) (*Snapshot, error) {
+	res := new(Snapshot)
+	if err := b.do(ctx, b.data(http.MethodGet, stockPath(symbol, "snapshot")), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetBars returns a page of historical bars of a symbol
+func (b *Broker) GetBars(ctx context.Context, symbol string, p *MarketDataRequest) (*BarsResponse, error) {
+	r := b.data(http.MethodGet, stockPath(symbol, "bars"))
+	r.query = p.query()
+	res := new(BarsResponse)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListBars returns every historical bar of a symbol matching p, following page tokens
+func ListBars(ctx context.Context, s Service, symbol string, p MarketDataRequest) ([]Bar, error) {
+	var bars []Bar
+	for {
+		res, err := s.GetBars(ctx, symbol, &p)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, res.Bars...)
+		if res.NextPageToken == nil || *res.NextPageToken == "" {
+			return bars, nil
+		}
+		p.PageToken = *res.NextPageToken
+	}
+}
+
+// GetTrades returns a page of historical trades of a symbol
+func (b *Broker) GetTrades(ctx context.Context, symbol string, p *MarketDataRequest) (*TradesResponse, error) {
+	r := b.data(http.MethodGet, stockPath(symbol, "trades"))
+	r.query = p.query()
+	res := new(TradesResponse)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetQuotes returns a page of historical quotes of a symbol
+func (b *Broker) GetQuotes(ctx context.Context, symbol string, p *MarketDataRequest) (*QuotesResponse, error) {
+	r := b.data(http.MethodGet, stockPath(symbol, "quotes"))
+	r.query = p.query()
+	res := new(QuotesResponse)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetLatestTrade returns the latest trade of a symbol
+func (b *Broker) GetLatestTrade(ctx context.Context, symbol string) (*LatestTradeResponse, error) {
+	res := new(LatestTradeResponse)
+	if err := b.do(ctx, b.data(http.MethodGet, stockPath(symbol, "trades", "latest")), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetLatestQuote returns the latest quote of a symbol
+func (b *Broker) GetLatestQuote(ctx context.Context, symbol string) (*LatestQuoteResponse, error) {
+	res := new(LatestQuoteResponse)
+	if err := b.do(ctx, b.data(http.MethodGet, stockPath(symbol, "quotes", "latest")), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,271 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Order statuses that are final, i.e. the order will not change anymore
+const (
+	OrderFilled   = "filled"
+	OrderCanceled = "canceled"
+	OrderExpired  = "expired"
+	OrderRejected = "rejected"
+	OrderReplaced = "replaced"
+)
+
+// Order sides
+const (
+	Buy  = "buy"
+	Sell = "sell"
+)
+
+// Order is an order as returned by the broker
+type Order struct {
+	ID             string     `json:"id"`
+	ClientOrderID  string     `json:"client_order_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at"`
+	SubmittedAt    *time.Time `json:"submitted_at"`
+	FilledAt       *time.Time `json:"filled_at"`
+	ExpiredAt      *time.Time `json:"expired_at"`
+	CanceledAt     *time.Time `json:"canceled_at"`
+	FailedAt       *time.Time `json:"failed_at"`
+	ReplacedAt     *time.Time `json:"replaced_at"`
+	ReplacedBy     *string    `json:"replaced_by"`
+	Replaces       *string    `json:"replaces"`
+	AssetID        string     `json:"asset_id"`
+	Symbol         string     `json:"symbol"`
+	AssetClass     string     `json:"asset_class"`
+	Notional       *float64   `json:"notional,string"`
+	Qty            *float64   `json:"qty,string"`
+	FilledQty      float64    `json:"filled_qty,string"`
+	FilledAvgPrice *float64   `json:"filled_avg_price,string"`
+	OrderClass     string     `json:"order_class"`
+	Type           string     `json:"type"`
+	Side           string     `json:"side"`
+	TimeInForce    string     `json:"time_in_force"`
+	LimitPrice     *float64   `json:"limit_price,string"`
+	StopPrice      *float64   `json:"stop_price,string"`
+	TrailPrice     *float64   `json:"trail_price,string"`
+	TrailPercent   *float64   `json:"trail_percent,string"`
+	HWM            *float64   `json:"hwm,string"`
+	Status         string     `json:"status"`
+	ExtendedHours  bool       `json:"extended_hours"`
+	Legs           []Order    `json:"legs"`
+	Commission     float64    `json:"commission,string,omitempty"`
+}
+
+// Closed reports whether the order reached a final status
+func (o *Order) Closed() bool {
+	switch o.Status {
+	case OrderFilled, OrderCanceled, OrderExpired, OrderRejected, OrderReplaced:
+		return true
+	}
+	return false
+}
+
+// TakeProfit is the take profit leg of a bracket order
+type TakeProfit struct {
+	LimitPrice float64 `json:"limit_price,string"`
+}
+
+// StopLoss is the stop loss leg of a bracket order
+type StopLoss struct {
+	StopPrice  float64  `json:"stop_price,string"`
+	LimitPrice *float64 `json:"limit_price,string,omitempty"`
+}
+
+// OrderRequest places a new order. Decimal fields are sent to the broker as strings,
+// but are accepted from our clients as either strings or numbers.
+type OrderRequest struct {
+	Symbol        string      `json:"symbol" binding:"required"`
+	Qty           *float64    `json:"qty,string,omitempty"`
+	Notional      *float64    `json:"notional,string,omitempty"`
+	Side          string      `json:"side" binding:"required"`
+	Type          string      `json:"type" binding:"required"`
+	TimeInForce   string      `json:"time_in_force" binding:"required"`
+	LimitPrice    *float64    `json:"limit_price,string,omitempty"`
+	StopPrice     *float64    `json:"stop_price,string,omitempty"`
+	TrailPrice    *float64    `json:"trail_price,string,omitempty"`
+	TrailPercent  *float64    `json:"trail_percent,string,omitempty"`
+	ExtendedHours bool        `json:"extended_hours,omitempty"`
+	ClientOrderID string      `json:"client_order_id,omitempty"`
+	OrderClass    string      `json:"order_class,omitempty"`
+	TakeProfit    *TakeProfit `json:"take_profit,omitempty"`
+	StopLoss      *StopLoss   `json:"stop_loss,omitempty"`
+}
+
+// UnmarshalJSON accepts decimal fields as either JSON numbers or numeric strings
+func (o *OrderRequest) UnmarshalJSON(data []byte) error {
+	type alias OrderRequest
+	aux := struct {
+		*alias
+		Qty          json.Number `json:"qty"`
+		Notional     json.Number `json:"notional"`
+		LimitPrice   json.Number `json:"limit_price"`
+		StopPrice    json.Number `json:"stop_price"`
+		TrailPrice   json.Number `json:"trail_price"`
+		TrailPercent json.Number `json:"trail_percent"`
+	}{alias: (*alias)(o)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	for _, f := range []struct {
+		n json.Number
+		v **float64
+	}{
+		{aux.Qty, &o.Qty},
+		{aux.Notional, &o.Notional},
+		{aux.LimitPrice, &o.LimitPrice},
+		{aux.StopPrice, &o.StopPrice},
+		{aux.TrailPrice, &o.TrailPrice},
+		{aux.TrailPercent, &o.TrailPercent},
+	} {
+		if *f.v, err = numberPtr(f.n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceOrderRequest updates an open order
+type ReplaceOrderRequest struct {
+	Qty           *float64 `json:"qty,string,omitempty"`
+	TimeInForce   string   `json:"time_in_force,omitempty"`
+	LimitPrice    *float64 `json:"limit_price,string,omitempty"`
+	StopPrice     *float64 `json:"stop_price,string,omitempty"`
+	Trail         *float64 `json:"trail,string,omitempty"`
+	ClientOrderID string   `json:"client_order_id,omitempty"`
+}
+
+// UnmarshalJSON accepts decimal fields as either JSON numbers or numeric strings
+func (o *ReplaceOrderRequest) UnmarshalJSON(data []byte) error {
+	type alias ReplaceOrderRequest
+	aux := struct {
+		*alias
+		Qty        json.Number `json:"qty"`
+		LimitPrice json.Number `json:"limit_price"`
+		StopPrice  json.Number `json:"stop_price"`
+		Trail      json.Number `json:"trail"`
+	}{alias: (*alias)(o)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	for _, f := range []struct {
+		n json.Number
+		v **float64
+	}{
+		{aux.Qty, &o.Qty},
+		{aux.LimitPrice, &o.LimitPrice},
+		{aux.StopPrice, &o.StopPrice},
+		{aux.Trail, &o.Trail},
+	} {
+		if *f.v, err = numberPtr(f.n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func numberPtr(n json.Number) (*float64, error) {
+	if n == "" {
+		return nil, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ListOrdersRequest filters the orders returned by ListOrders
+type ListOrdersRequest struct {
+	Status    string   `form:"status"`
+	Limit     int      `form:"limit"`
+	After     string   `form:"after"`
+	Until     string   `form:"until"`
+	Direction string   `form:"direction"`
+	Nested    bool     `form:"nested"`
+	Symbols   []string `form:"symbols"`
+}
+
+// CancelStatus is the per order result of CancelAllOrders
+type CancelStatus struct {
+	ID     string `json:"id"`
+	Status int    `json:"status"`
+	Body   *Order `json:"body,omitempty"`
+}
+
+// ListOrders returns the orders of an account
+func (b *Broker) ListOrders(ctx context.Context, accountID string, p *ListOrdersRequest) ([]Order, error) {
+	r := b.api(http.MethodGet, tradingPath(accountID, "orders"))
+	r.query = url.Values{}
+	if p != nil {
+		setIf(r.query, "status", p.Status)
+		setIntIf(r.query, "limit", p.Limit)
+		setIf(r.query, "after", p.After)
+		setIf(r.query, "until", p.Until)
+		setIf(r.query, "direction", p.Direction)
+		if p.Nested {
+			r.query.Set("nested", "true")
+		}
+		setIf(r.query, "symbols", strings.Join(p.Symbols, ","))
+	}
+	res := []Order{}
+	if err := b.do(ctx, r, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CreateOrder places a new order
+func (b *Broker) CreateOrder(ctx context.Context, accountID string, o *OrderRequest) (*Order, error) {
+	r := b.api(http.MethodPost, tradingPath(accountID, "orders"))
+	r.body = o
+	res := new(Order)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetOrder returns a single order
+func (b *Broker) GetOrder(ctx context.Context, accountID, orderID string) (*Order, error) {
+	res := new(Order)
+	if err := b.do(ctx, b.api(http.MethodGet, tradingPath(accountID, "orders", orderID)), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReplaceOrder replaces an open order, returning the new order
+func (b *Broker) ReplaceOrder(ctx context.Context, accountID, orderID string, o *ReplaceOrderRequest) (*Order, error) {
+	r := b.api(http.MethodPatch, tradingPath(accountID, "orders", orderID))
+	r.body = o
+	res := new(Order)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CancelOrder requests cancellation of an open order
+func (b *Broker) CancelOrder(ctx context.Context, accountID, orderID string) error {
+	return b.do(ctx, b.api(http.MethodDelete, tradingPath(accountID, "orders", orderID)), nil)
+}
+
+// CancelAllOrders requests cancellation of every open order of an account
+func (b *Broker) CancelAllOrders(ctx context.Context, accountID string) ([]CancelStatus, error) {
+	res := []CancelStatus{}
+	if err := b.do(ctx, b.api(http.MethodDelete, tradingPath(accountID, "orders")), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package broker
+
+import (
+	"context"
+	"net/http"
+)
+
+// Position is an open position of an account
+type Position struct {
+	AssetID                string  `json:"asset_id"`
+	Symbol                 string  `json:"symbol"`
+	Exchange               string  `json:"exchange"`
+	AssetClass             string  `json:"asset_class"`
+	AssetMarginable        bool    `json:"asset_marginable"`
+	Qty                    float64 `json:"qty,string"`
+	QtyAvailable           float64 `json:"qty_available,string"`
+	AvgEntryPrice          float64 `json:"avg_entry_price,string"`
+	Side                   string  `json:"side"`
+	MarketValue            float64 `json:"market_value,string"`
+	CostBasis              float64 `json:"cost_basis,string"`
+	UnrealizedPL           float64 `json:"unrealized_pl,string"`
+	UnrealizedPLPC         float64 `json:"unrealized_plpc,string"`
+	UnrealizedIntradayPL   float64 `json:"unrealized_intraday_pl,string"`
+	UnrealizedIntradayPLPC float64 `json:"unrealized_intraday_plpc,string"`
+	CurrentPrice           float64 `json:"current_price,string"`
+	LastdayPrice           float64 `json:"lastday_price,string"`
+	ChangeToday            float64 `json:"change_today,string"`
+}
+
+// CloseStatus is the per position result of CloseAllPositions
+type CloseStatus struct {
+	Symbol string `json:"symbol"`
+	Status int    `json:"status"`
+	Body   *Order `json:"body,omitempty"`
+}
+
+// ListPositions returns the open positions of an account
+func (b *Broker) ListPositions(ctx context.Context, accountID string) ([]Position, error) {
+	res := []Position{}
+	if err := b.do(ctx, b.api(http.MethodGet, tradingPath(accountID, "positions")), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetPosition returns the open position of an account in symbol
+func (b *Broker) GetPosition(ctx context.Context, accountID, symbol string) (*Position, error) {
+	res := new(Position)
+	if err := b.do(ctx, b.api(http.MethodGet, tradingPath(accountID, "positions", symbol)), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ClosePosition liquidates the position in symbol, returning the closing order
+func (b *Broker) ClosePosition(ctx context.Context, accountID, symbol string) (*Order, error) {
+	res := new(Order)
+	if err := b.do(ctx, b.api(http.MethodDelete, tradingPath(accountID, "positions", symbol)), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CloseAllPositions liquidates every open position of an account
+func (b *Broker) CloseAllPositions(ctx context.Context, accountID string) ([]CloseStatus, error) {
+	res := []CloseStatus{}
+	if err := b.do(ctx, b.api(http.MethodDelete, tradingPath(accountID, "positions")), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,139 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Types of the messages of the market data stream
+const (
+	StreamTrade        = "t"
+	StreamQuote        = "q"
+	StreamSuccess      = "success"
+	StreamError        = "error"
+	StreamSubscription = "subscription"
+)
+
+// streamWriteWait bounds the time spent writing a single message to the market data stream
+const streamWriteWait = 10 * time.Second
+
+// StreamMessage is a single message of the market data stream. Trade or Quote is set for trade
+// and quote messages.
+type StreamMessage struct {
+	Type    string
+	Symbol  string
+	Trade   *Trade
+	Quote   *Quote
+	Code    int
+	Message string
+}
+
+// UnmarshalJSON decodes a message in the stream's format, where the fields of the trade or
+// quote are inlined next to the message type and symbol. Keys only differ by case, e.g. T and t,
+// so every key is given its own field to keep them from matching each other.
+func (m *StreamMessage) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Type    string          `json:"T"`
+		Symbol  string          `json:"S"`
+		Code    int             `json:"code"`
+		Message string          `json:"msg"`
+		Time    json.RawMessage `json:"t"`
+		Size    json.RawMessage `json:"s"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	*m = StreamMessage{Type: head.Type, Symbol: head.Symbol, Code: head.Code, Message: head.Message}
+	switch head.Type {
+	case StreamTrade:
+		t := struct {
+			Type   string `json:"T"`
+			Symbol string `json:"S"`
+			*Trade
+		}{Trade: new(Trade)}
+		m.Trade = t.Trade
+		return json.Unmarshal(data, &t)
+	case StreamQuote:
+		q := struct {
+			Type   string `json:"T"`
+			Symbol string `json:"S"`
+			*Quote
+		}{Quote: new(Quote)}
+		m.Quote = q.Quote
+		return json.Unmarshal(data, &q)
+	}
+	return nil
+}
+
+// streamAction is a subscription change sent to the market data stream
+type streamAction struct {
+	Action string   `json:"action"`
+	Trades []string `json:"trades,omitempty"`
+	Quotes []string `json:"quotes,omitempty"`
+}
+
+// MarketStream is a connection to the real-time market data stream. Read must be called from a
+// single goroutine, while Subscribe and Unsubscribe can be called concurrently with it.
+type MarketStream struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+// ConnectMarketData opens a connection to the real-time market data stream
+func (b *Broker) ConnectMarketData(ctx context.Context) (*MarketStream, error) {
+	h := http.Header{}
+	h.Add("Authorization", b.config.Token)
+	conn, res, err := websocket.DefaultDialer.DialContext(ctx, b.config.StreamBase, h)
+	if err != nil {
+		if res != nil && res.StatusCode != http.StatusSwitchingProtocols {
+			return nil, &Error{StatusCode: res.StatusCode, Message: "Market data stream refused the connection.", Err: err}
+		}
+		return nil, &Error{StatusCode: http.StatusBadGateway, Message: "Broker is unavailable. Try again later.", Err: err}
+	}
+	return &MarketStream{conn: conn}, nil
+}
+
+// Subscribe subscribes to the trades and quotes of the given symbols
+func (s *MarketStream) Subscribe(trades, quotes []string) error {
+	return s.write(&streamAction{Action: "subscribe", Trades: trades, Quotes: quotes})
+}
+
+// Unsubscribe unsubscribes from the trades and quotes of the given symbols
+func (s *MarketStream) Unsubscribe(trades, quotes []string) error {
+	return s.write(&streamAction{Action: "unsubscribe", Trades: trades, Quotes: quotes})
+}
+
+func (s *MarketStream) write(a *streamAction) error {
+	if len(a.Trades) == 0 && len(a.Quotes) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	return s.conn.WriteJSON(a)
+}
+
+// Read blocks until the next batch of messages arrives. Error messages of the stream are
+// returned as an *Error.
+func (s *MarketStream) Read() ([]StreamMessage, error) {
+	var msgs []StreamMessage
+	if err := s.conn.ReadJSON(&msgs); err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		if m.Type == StreamError {
+			return nil, &Error{StatusCode: http.StatusBadGateway, Code: m.Code, Message: m.Message}
+		}
+	}
+	return msgs, nil
+}
+
+// Close closes the connection, making a pending Read return
+func (s *MarketStream) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,128 @@
+package broker
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Transfer directions
+const (
+	Incoming = "INCOMING"
+	Outgoing = "OUTGOING"
+)
+
+// Transfer is a deposit into or withdrawal from an account
+type Transfer struct {
+	ID             string     `json:"id"`
+	RelationshipID string     `json:"relationship_id"`
+	AccountID      string     `json:"account_id"`
+	Type           string     `json:"type"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason,omitempty"`
+	Amount         float64    `json:"amount,string"`
+	Direction      string     `json:"direction"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// TransferRequest creates a new transfer
+type TransferRequest struct {
+	TransferType   string  `json:"transfer_type"`
+	RelationshipID string  `json:"relationship_id"`
+	Amount         float64 `json:"amount,string"`
+	Direction      string  `json:"direction"`
+}
+
+// ListTransfersRequest filters the transfers returned by ListTransfers
+type ListTransfersRequest struct {
+	Direction string
+	Limit     int
+	Offset    int
+}
+
+// ACHRelationship links a bank account to a brokerage account
+type ACHRelationship struct {
+	ID               string    `json:"id"`
+	AccountID        string    `json:"account_id"`
+	Status           string    `json:"status"`
+	AccountOwnerName string    `json:"account_owner_name"`
+	BankAccountType  string    `json:"bank_account_type"`
+	BankAccountNum   string    `json:"bank_account_number"`
+	BankRoutingNum   string    `json:"bank_routing_number"`
+	Nickname         string    `json:"nickname"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ACHRelationshipRequest links a new bank account
+type ACHRelationshipRequest struct {
+	AccountOwnerName  string `json:"account_owner_name"`
+	BankAccountType   string `json:"bank_account_type"`
+	BankAccountNumber string `json:"bank_account_number"`
+	BankRoutingNumber string `json:"bank_routing_number"`
+	Nickname          string `json:"nickname"`
+}
+
+// ListTransfers returns the transfers of an account
+func (b *Broker) ListTransfers(ctx context.Context, accountID string, p *ListTransfersRequest) ([]Transfer, error) {
+	r := b.api(http.MethodGet, accountPath(accountID, "transfers"))
+	r.query = url.Values{}
+	if p != nil {
+		setIf(r.query, "direction", p.Direction)
+		setIntIf(r.query, "limit", p.Limit)
+		setIntIf(r.query, "offset", p.Offset)
+	}
+	res := []Transfer{}
+	if err := b.do(ctx, r, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CreateTransfer requests a new transfer
+func (b *Broker) CreateTransfer(ctx context.Context, accountID string, t *TransferRequest) (*Transfer, error) {
+	r := b.api(http.MethodPost, accountPath(accountID, "transfers"))
+	r.body = t
+	res := new(Transfer)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteTransfer cancels a pending transfer
+func (b *Broker) DeleteTransfer(ctx context.Context, accountID, transferID string) error {
+	return b.do(ctx, b.api(http.MethodDelete, accountPath(accountID, "transfers", transferID)), nil)
+}
+
+// ListACHRelationships returns the bank accounts linked to an account, optionally filtered by status
+func (b *Broker) ListACHRelationships(ctx context.Context, accountID string, statuses ...string) ([]ACHRelationship, error) {
+	r := b.api(http.MethodGet, accountPath(accountID, "ach_relationships"))
+	r.query = url.Values{}
+	setIf(r.query, "statuses", strings.Join(statuses, ","))
+	res := []ACHRelationship{}
+	if err := b.do(ctx, r, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CreateACHRelationship links a bank account to an account
+func (b *Broker) CreateACHRelationship(ctx context.Context, accountID string, a *ACHRelationshipRequest) (*ACHRelationship, error) {
+	r := b.api(http.MethodPost, accountPath(accountID, "ach_relationships"))
+	r.body = a
+	res := new(ACHRelationship)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteACHRelationship unlinks a bank account
+func (b *Broker) DeleteACHRelationship(ctx context.Context, accountID, relationshipID string) error {
+	return b.do(ctx, b.api(http.MethodDelete, accountPath(accountID, "ach_relationships", relationshipID)), nil)
+}
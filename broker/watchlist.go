@@ -0,0 +1,97 @@
+package broker
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Watchlist is a named list of assets of an account
+type Watchlist struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"account_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Assets    []Asset   `json:"assets"`
+}
+
+// Symbols returns the symbols of the watchlisted assets
+func (w *Watchlist) Symbols() []string {
+	symbols := make([]string, 0, len(w.Assets))
+	for _, a := range w.Assets {
+		symbols = append(symbols, a.Symbol)
+	}
+	return symbols
+}
+
+// WatchlistRequest creates or updates a watchlist
+type WatchlistRequest struct {
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"`
+}
+
+// ListWatchlists returns the watchlists of an account. The broker omits the assets here.
+func (b *Broker) ListWatchlists(ctx context.Context, accountID string) ([]Watchlist, error) {
+	res := []Watchlist{}
+	if err := b.do(ctx, b.api(http.MethodGet, tradingPath(accountID, "watchlists")), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetWatchlist returns a single watchlist with its assets
+func (b *Broker) GetWatchlist(ctx context.Context, accountID, watchlistID string) (*Watchlist, error) {
+	res := new(Watchlist)
+	if err := b.do(ctx, b.api(http.MethodGet, tradingPath(accountID, "watchlists", watchlistID)), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CreateWatchlist creates a new watchlist
+func (b *Broker) CreateWatchlist(ctx context.Context, accountID string, w *WatchlistRequest) (*Watchlist, error) {
+	r := b.api(http.MethodPost, tradingPath(accountID, "watchlists"))
+	r.body = w
+	res := new(Watchlist)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpdateWatchlist replaces the name and assets of a watchlist
+func (b *Broker) UpdateWatchlist(ctx context.Context, accountID, watchlistID string, w *WatchlistRequest) (*Watchlist, error) {
+	r := b.api(http.MethodPut, tradingPath(accountID, "watchlists", watchlistID))
+	r.body = w
+	res := new(Watchlist)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// AddAssetToWatchlist appends symbol to a watchlist
+func (b *Broker) AddAssetToWatchlist(ctx context.Context, accountID, watchlistID, symbol string) (*Watchlist, error) {
+	r := b.api(http.MethodPost, tradingPath(accountID, "watchlists", watchlistID))
+	r.body = map[string]string{"symbol": symbol}
+	res := new(Watchlist)
+	if err := b.do(ctx, r, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RemoveAssetFromWatchlist removes symbol from a watchlist
+func (b *Broker) RemoveAssetFromWatchlist(ctx context.Context, accountID, watchlistID, symbol string) (*Watchlist, error) {
+	res := new(Watchlist)
+	if err := b.do(ctx, b.api(http.MethodDelete, tradingPath(accountID, "watchlists", watchlistID, symbol)), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteWatchlist deletes a watchlist
+func (b *Broker) DeleteWatchlist(ctx context.Context, accountID, watchlistID string) error {
+	return b.do(ctx, b.api(http.MethodDelete, tradingPath(accountID, "watchlists", watchlistID)), nil)
+}
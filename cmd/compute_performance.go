@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/repository"
+	"github.com/zcoriarty/Backend/repository/bars"
+	"github.com/zcoriarty/Backend/repository/performance"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// computePerformanceCmd represents the compute_performance command
+var computePerformanceCmd = &cobra.Command{
+	Use:   "compute_performance",
+	Short: "compute_performance recomputes algorithm and user performance from the trades ledger",
+	Long: `compute_performance recomputes algorithm and user performance, trade summaries and investments from the trades ledger.
+It runs once, or every --interval when one is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("compute_performance called")
+		db := config.GetConnection()
+		log, _ := zap.NewDevelopment()
+		defer log.Sync()
+
+		userRepo := repository.NewUserRepo(db, log)
+		performanceRepo := repository.NewPerformanceRepo(db, log)
+		brk := broker.NewBroker(config.GetBrokerConfig())
+		store := bars.NewStore(brk, repository.NewBarRepo(db, log), log)
+		svc := performance.NewPerformanceService(performanceRepo, repository.NewRBACService(userRepo), brk, store)
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval == 0 {
+			if err := svc.Recompute(context.Background()); err != nil {
+				log.Fatal(err.Error())
+			}
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt)
+		go func() {
+			<-stop
+			cancel()
+		}()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			if err := svc.Recompute(ctx); err != nil && ctx.Err() == nil {
+				log.Warn("compute_performance failed", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(computePerformanceCmd)
+	computePerformanceCmd.Flags().Duration("interval", 0, "recompute every interval instead of once, e.g. 15m")
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/mail"
+	"github.com/zcoriarty/Backend/mobile"
+	"github.com/zcoriarty/Backend/push"
+	"github.com/zcoriarty/Backend/repository"
+	"github.com/zcoriarty/Backend/repository/events"
+	"github.com/zcoriarty/Backend/repository/notifications"
+	"github.com/zcoriarty/Backend/repository/order"
+	"github.com/zcoriarty/Backend/repository/taxlots"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// consumeEventsCmd represents the consume_events command
+var consumeEventsCmd = &cobra.Command{
+	Use:   "consume_events",
+	Short: "consume_events keeps accounts, orders, transfers and rewards in sync with the broker's event streams",
+	Long: `consume_events follows the broker's account status, trade, transfer status and journal status event streams, updating
+the account status of users, the order ledger and its fills, the transfer ledger and rewards as events arrive, and notifies users of
+fills, completed transfers and rewards paid out. It resumes after the last
+event it handled. Run a single instance of it next to the API servers.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("consume_events called")
+		db := config.GetConnection()
+		log, _ := zap.NewDevelopment()
+		defer log.Sync()
+
+		userRepo := repository.NewUserRepo(db, log)
+		brk := broker.NewBroker(config.GetBrokerConfig())
+		orderRepo := repository.NewOrderRepo(db, log)
+		orders := order.NewOrderService(userRepo, orderRepo, brk)
+		lots := taxlots.NewTaxLotService(orderRepo, repository.NewTaxLotRepo(db, log))
+		notifier := notifications.NewNotificationService(userRepo, repository.NewNotificationRepo(db, log), notifications.NewChannels(
+			mail.NewMail(config.GetMailConfig(), config.GetSiteConfig()),
+			mobile.NewMobile(config.GetTwilioConfig()),
+			push.NewDevices(repository.NewDeviceRepo(db, log), push.NewSenders(config.GetPushConfig()), log),
+		))
+		consumer := events.NewConsumer(brk, repository.NewEventRepo(db, log), userRepo, repository.NewTransferRepo(db, log),
+			repository.NewUserRewardRepo(db, log), orders, lots, notifier, events.NewBus(), log)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt)
+		go func() {
+			<-stop
+			cancel()
+		}()
+		consumer.Run(ctx)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(consumeEventsCmd)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/mail"
+	"github.com/zcoriarty/Backend/mobile"
+	"github.com/zcoriarty/Backend/push"
+	"github.com/zcoriarty/Backend/repository"
+	"github.com/zcoriarty/Backend/repository/alerts"
+	"github.com/zcoriarty/Backend/repository/marketdata"
+	"github.com/zcoriarty/Backend/repository/notifications"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// runAlertsCmd represents the run_alerts command
+var runAlertsCmd = &cobra.Command{
+	Use:   "run_alerts",
+	Short: "run_alerts checks the active price alerts and delivers the ones that trigger",
+	Long: `run_alerts checks the active price alerts against the market and delivers the ones that trigger by email, SMS or push notification.
+Run a single instance of it next to the API servers.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("run_alerts called")
+		db := config.GetConnection()
+		log, _ := zap.NewDevelopment()
+		defer log.Sync()
+
+		// snapshots are requested in batches through the market data cache
+		brk := marketdata.NewCache(broker.NewBroker(config.GetBrokerConfig()), marketdata.NewMemoryStore(), config.GetMarketDataConfig(), log)
+		notifier := notifications.NewNotificationService(repository.NewUserRepo(db, log), repository.NewNotificationRepo(db, log), notifications.NewChannels(
+			mail.NewMail(config.GetMailConfig(), config.GetSiteConfig()),
+			mobile.NewMobile(config.GetTwilioConfig()),
+			push.NewDevices(repository.NewDeviceRepo(db, log), push.NewSenders(config.GetPushConfig()), log),
+		))
+		evaluator := alerts.NewEvaluator(repository.NewAlertRepo(db, log), brk, notifier, config.GetAlertsConfig(), log)
+		if once, _ := cmd.Flags().GetBool("once"); once {
+			if err := evaluator.RunOnce(context.Background()); err != nil {
+				log.Fatal(err.Error())
+			}
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt)
+		go func() {
+			<-stop
+			cancel()
+		}()
+		evaluator.Run(ctx)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runAlertsCmd)
+	runAlertsCmd.Flags().Bool("once", false, "check the active alerts once and exit")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/repository"
+	"github.com/zcoriarty/Backend/repository/algorithm"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// runAlgorithmsCmd represents the run_algorithms command
+var runAlgorithmsCmd = &cobra.Command{
+	Use:   "run_algorithms",
+	Short: "run_algorithms runs the active algorithms during market hours",
+	Long: `run_algorithms runs the active algorithms during market hours, placing their orders for every user with a budget in them.
+Run a single instance of it next to the API servers.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("run_algorithms called")
+		db := config.GetConnection()
+		log, _ := zap.NewDevelopment()
+		defer log.Sync()
+
+		userRepo := repository.NewUserRepo(db, log)
+		algorithmRepo := repository.NewAlgorithmRepo(db, log)
+		budgetRepo := repository.NewBudgetRepo(db, log)
+		brk := broker.NewBroker(config.GetBrokerConfig())
+		svc := algorithm.NewAlgorithmService(userRepo, algorithmRepo, budgetRepo, repository.NewRBACService(userRepo), brk)
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		engine := algorithm.NewEngine(svc, log, interval)
+		if once, _ := cmd.Flags().GetBool("once"); once {
+			if err := engine.RunOnce(context.Background()); err != nil {
+				log.Fatal(err.Error())
+			}
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt)
+		go func() {
+			<-stop
+			cancel()
+		}()
+		engine.Run(ctx)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runAlgorithmsCmd)
+	runAlgorithmsCmd.Flags().Duration("interval", time.Minute, "how often to check for algorithms that are due")
+	runAlgorithmsCmd.Flags().Bool("once", false, "run the algorithms that are due once and exit")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/repository"
+	"github.com/zcoriarty/Backend/repository/allocations"
+	"github.com/zcoriarty/Backend/repository/order"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// runRebalancerCmd represents the run_rebalancer command
+var runRebalancerCmd = &cobra.Command{
+	Use:   "run_rebalancer",
+	Short: "run_rebalancer rebalances the portfolios of the users who opted into automatic rebalancing",
+	Long: `run_rebalancer rebalances the portfolios of the users who opted into automatic rebalancing to their target allocation
+while the market is open, placing the orders through the pre-trade checks of the order service.
+Run a single instance of it next to the API servers.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("run_rebalancer called")
+		db := config.GetConnection()
+		log, _ := zap.NewDevelopment()
+		defer log.Sync()
+
+		userRepo := repository.NewUserRepo(db, log)
+		brk := broker.NewBroker(config.GetBrokerConfig())
+		orders := order.NewOrderService(userRepo, repository.NewOrderRepo(db, log), brk)
+		svc := allocations.NewAllocationService(userRepo, repository.NewAllocationRepo(db, log), repository.NewBudgetRepo(db, log), orders, brk)
+		scheduler := allocations.NewScheduler(svc, config.GetRebalanceConfig(), log)
+		if once, _ := cmd.Flags().GetBool("once"); once {
+			if err := scheduler.RunOnce(context.Background()); err != nil {
+				log.Fatal(err.Error())
+			}
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt)
+		go func() {
+			<-stop
+			cancel()
+		}()
+		scheduler.Run(ctx)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runRebalancerCmd)
+	runRebalancerCmd.Flags().Bool("once", false, "rebalance the allocations due once and exit")
+}
@@ -1,13 +1,11 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/zcoriarty/Backend/broker"
 	"github.com/zcoriarty/Backend/config"
 	"github.com/zcoriarty/Backend/model"
 	"github.com/zcoriarty/Backend/repository"
@@ -27,49 +25,28 @@ var syncAssetsCmd = &cobra.Command{
 		defer log.Sync()
 		assetRepo := repository.NewAssetRepo(db, log, secret.New())
 
-		client := &http.Client{}
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/assets", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		brk := broker.NewBroker(config.GetBrokerConfig())
+		assets, err := brk.ListAssets(context.Background(), nil)
 		if err != nil {
 			log.Fatal(err.Error())
 		}
-		// fmt.Printf("%v", string(responseData))
-
-		var responseObject []interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		for _, asset := range responseObject {
-			asset, _ := asset.(map[string]interface{})
 
+		for _, asset := range assets {
 			newAsset := new(model.Asset)
-			newAsset.ID = asset["id"].(string)
-			newAsset.Class = asset["class"].(string)
-			newAsset.Exchange = asset["exchange"].(string)
-			newAsset.Symbol = asset["symbol"].(string)
-			newAsset.Name = asset["name"].(string)
-			newAsset.Status = asset["status"].(string)
-			newAsset.Tradable = asset["tradable"].(bool)
-			newAsset.Marginable = asset["marginable"].(bool)
-			newAsset.Shortable = asset["shortable"].(bool)
-			newAsset.EasyToBorrow = asset["easy_to_borrow"].(bool)
-			newAsset.Fractionable = asset["fractionable"].(bool)
+			newAsset.ID = asset.ID
+			newAsset.Class = asset.Class
+			newAsset.Exchange = asset.Exchange
+			newAsset.Symbol = asset.Symbol
+			newAsset.Name = asset.Name
+			newAsset.Status = asset.Status
+			newAsset.Tradable = asset.Tradable
+			newAsset.Marginable = asset.Marginable
+			newAsset.Shortable = asset.Shortable
+			newAsset.EasyToBorrow = asset.EasyToBorrow
+			newAsset.Fractionable = asset.Fractionable
 
 			if _, err := assetRepo.CreateOrUpdate(newAsset); err != nil {
 				log.Fatal(err.Error())
-			} else {
-				// fmt.Println(asset)
 			}
 		}
 
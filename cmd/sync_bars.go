@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/repository"
+	"github.com/zcoriarty/Backend/repository/bars"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// syncBarsCmd represents the sync_bars command
+var syncBarsCmd = &cobra.Command{
+	Use:   "sync_bars",
+	Short: "sync_bars preloads the historical bars of a universe of symbols",
+	Long: `sync_bars backfills the historical bars of the given symbols, or of every active tradable asset
+when none are given, between --start and --end. Bars already stored are not fetched again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("sync_bars called")
+		db := config.GetConnection()
+		log, _ := zap.NewDevelopment()
+		defer log.Sync()
+
+		brk := broker.NewBroker(config.GetBrokerConfig())
+		store := bars.NewStore(brk, repository.NewBarRepo(db, log), log)
+		ctx := context.Background()
+
+		timeframe, _ := cmd.Flags().GetString("timeframe")
+		startFlag, _ := cmd.Flags().GetString("start")
+		endFlag, _ := cmd.Flags().GetString("end")
+		start := time.Now().AddDate(-1, 0, 0)
+		var end time.Time
+		var err error
+		if startFlag != "" {
+			if start, err = bars.ParseStart(startFlag); err != nil {
+				log.Fatal("invalid --start", zap.Error(err))
+			}
+		}
+		if endFlag != "" {
+			if end, err = bars.ParseEnd(endFlag); err != nil {
+				log.Fatal("invalid --end", zap.Error(err))
+			}
+		}
+
+		symbols, _ := cmd.Flags().GetStringSlice("symbols")
+		if len(symbols) == 0 {
+			assets, err := brk.ListAssets(ctx, &broker.ListAssetsRequest{Status: "active", AssetClass: "us_equity"})
+			if err != nil {
+				log.Fatal(err.Error())
+			}
+			for _, a := range assets {
+				if a.Tradable {
+					symbols = append(symbols, a.Symbol)
+				}
+			}
+		}
+
+		failed := 0
+		for i, symbol := range symbols {
+			symbol = strings.TrimSpace(symbol)
+			if err := store.Sync(ctx, symbol, timeframe, start, end); err != nil {
+				log.Warn("sync_bars failed", zap.String("symbol", symbol), zap.Error(err))
+				failed++
+				continue
+			}
+			if (i+1)%100 == 0 {
+				log.Info("sync_bars progress", zap.Int("synced", i+1), zap.Int("total", len(symbols)))
+			}
+		}
+		log.Info("sync_bars done", zap.Int("symbols", len(symbols)), zap.Int("failed", failed))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncBarsCmd)
+	syncBarsCmd.Flags().StringSlice("symbols", nil, "symbols to sync, every active tradable asset when empty")
+	syncBarsCmd.Flags().String("timeframe", bars.Day, "timeframe of the bars: 1Min, 5Min, 15Min, 1Hour or 1Day")
+	syncBarsCmd.Flags().String("start", "", "first day to sync, e.g. 2021-01-04, a year ago by default")
+	syncBarsCmd.Flags().String("end", "", "last day to sync, today by default")
+}
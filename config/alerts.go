@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+)
+
+// AlertsConfig persists the config for the price alerts evaluator
+type AlertsConfig struct {
+	// Interval is how often the active alerts are checked against the market
+	Interval time.Duration `env:"ALERTS_INTERVAL" envDefault:"15s"`
+	// RateLimit is the most alerts delivered to a user every RateWindow
+	RateLimit  int           `env:"ALERTS_RATE_LIMIT" envDefault:"10"`
+	RateWindow time.Duration `env:"ALERTS_RATE_WINDOW" envDefault:"1h"`
+}
+
+// GetAlertsConfig returns an AlertsConfig pointer with the correct Alerts Config values
+func GetAlertsConfig() *AlertsConfig {
+	c := AlertsConfig{}
+
+	_, b, _, _ := runtime.Caller(0)
+	d := path.Join(path.Dir(b))
+	projectRoot := filepath.Dir(d)
+	dotenvPath := path.Join(projectRoot, ".env")
+	_ = godotenv.Load(dotenvPath)
+
+	if err := env.Parse(&c); err != nil {
+		fmt.Printf("%+v\n", err)
+	}
+	return &c
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+)
+
+// BrokerConfig persists the config for our broker API client
+type BrokerConfig struct {
+	APIBase      string        `env:"BROKER_API_BASE"`
+	DataBase     string        `env:"BROKER_API_DATA_BASE"`
+	StreamBase   string        `env:"BROKER_STREAM_BASE"`
+	Token        string        `env:"BROKER_TOKEN"`
+	Timeout      time.Duration `env:"BROKER_TIMEOUT" envDefault:"15s"`
+	MaxRetries   int           `env:"BROKER_MAX_RETRIES" envDefault:"3"`
+	RetryWaitMin time.Duration `env:"BROKER_RETRY_WAIT_MIN" envDefault:"250ms"`
+	RetryWaitMax time.Duration `env:"BROKER_RETRY_WAIT_MAX" envDefault:"5s"`
+}
+
+// GetBrokerConfig returns a BrokerConfig pointer with the correct Broker Config values
+func GetBrokerConfig() *BrokerConfig {
+	c := BrokerConfig{}
+
+	_, b, _, _ := runtime.Caller(0)
+	d := path.Join(path.Dir(b))
+	projectRoot := filepath.Dir(d)
+	dotenvPath := path.Join(projectRoot, ".env")
+	_ = godotenv.Load(dotenvPath)
+
+	if err := env.Parse(&c); err != nil {
+		fmt.Printf("%+v\n", err)
+	}
+	return &c
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+)
+
+// MarketDataConfig persists the config for our market data cache
+type MarketDataConfig struct {
+	// Backend is where cached market data is kept, either memory or postgres
+	Backend     string        `env:"MARKET_DATA_CACHE" envDefault:"memory"`
+	SnapshotTTL time.Duration `env:"MARKET_DATA_SNAPSHOT_TTL" envDefault:"15s"`
+	QuoteTTL    time.Duration `env:"MARKET_DATA_QUOTE_TTL" envDefault:"5s"`
+	BarTTL      time.Duration `env:"MARKET_DATA_BAR_TTL" envDefault:"1m"`
+	// BatchSize is the most symbols requested from the broker in a single call
+	BatchSize int `env:"MARKET_DATA_BATCH_SIZE" envDefault:"200"`
+}
+
+// GetMarketDataConfig returns a MarketDataConfig pointer with the correct Market Data Config values
+func GetMarketDataConfig() *MarketDataConfig {
+	c := MarketDataConfig{}
+
+	_, b, _, _ := runtime.Caller(0)
+	d := path.Join(path.Dir(b))
+	projectRoot := filepath.Dir(d)
+	dotenvPath := path.Join(projectRoot, ".env")
+	_ = godotenv.Load(dotenvPath)
+
+	if err := env.Parse(&c); err != nil {
+		fmt.Printf("%+v\n", err)
+	}
+	return &c
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+)
+
+// MoversConfig persists the config for the market movers lists
+type MoversConfig struct {
+	// RefreshInterval is how often the lists are recomputed from snapshots
+	RefreshInterval time.Duration `env:"MOVERS_REFRESH_INTERVAL" envDefault:"1m"`
+	// MinPrice and MinVolume are the filters applied when a request gives none, keeping
+	// illiquid assets out of the lists
+	MinPrice  float64 `env:"MOVERS_MIN_PRICE" envDefault:"1"`
+	MinVolume float64 `env:"MOVERS_MIN_VOLUME" envDefault:"100000"`
+}
+
+// GetMoversConfig returns a MoversConfig pointer with the correct Movers Config values
+func GetMoversConfig() *MoversConfig {
+	c := MoversConfig{}
+
+	_, b, _, _ := runtime.Caller(0)
+	d := path.Join(path.Dir(b))
+	projectRoot := filepath.Dir(d)
+	dotenvPath := path.Join(projectRoot, ".env")
+	_ = godotenv.Load(dotenvPath)
+
+	if err := env.Parse(&c); err != nil {
+		fmt.Printf("%+v\n", err)
+	}
+	return &c
+}
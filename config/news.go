@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+)
+
+// NewsConfig persists the config for our news provider client
+type NewsConfig struct {
+	APIBase string        `env:"NEWS_API_BASE" envDefault:"https://api.polygon.io"`
+	APIKey  string        `env:"POLYGON_API_KEY"`
+	Timeout time.Duration `env:"NEWS_TIMEOUT" envDefault:"15s"`
+	// CacheTTL is how long the articles of a ticker are cached
+	CacheTTL time.Duration `env:"NEWS_CACHE_TTL" envDefault:"5m"`
+	// PerTicker is the number of latest articles fetched for each ticker
+	PerTicker int `env:"NEWS_PER_TICKER" envDefault:"100"`
+}
+
+// GetNewsConfig returns a NewsConfig pointer with the correct News Config values
+func GetNewsConfig() *NewsConfig {
+	c := NewsConfig{}
+
+	_, b, _, _ := runtime.Caller(0)
+	d := path.Join(path.Dir(b))
+	projectRoot := filepath.Dir(d)
+	dotenvPath := path.Join(projectRoot, ".env")
+	_ = godotenv.Load(dotenvPath)
+
+	if err := env.Parse(&c); err != nil {
+		fmt.Printf("%+v\n", err)
+	}
+	return &c
+}
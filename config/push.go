@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+)
+
+// PushConfig persists the config for our push notification providers
+type PushConfig struct {
+	FCMURL string `env:"FCM_URL" envDefault:"https://fcm.googleapis.com"`
+	// FCMCredentials is the JSON key of the Google service account sending notifications,
+	// which names the Firebase project they are sent from
+	FCMCredentials string `env:"FCM_CREDENTIALS"`
+	// APNSURL is https://api.sandbox.push.apple.com for development builds of the app
+	APNSURL   string `env:"APNS_URL" envDefault:"https://api.push.apple.com"`
+	APNSKeyID string `env:"APNS_KEY_ID"`
+	APNSTeam  string `env:"APNS_TEAM_ID"`
+	// APNSKey is the PEM encoded .p8 signing key of APNSKeyID
+	APNSKey string `env:"APNS_KEY"`
+	// APNSTopic is the bundle ID of the app
+	APNSTopic string        `env:"APNS_TOPIC"`
+	Timeout   time.Duration `env:"PUSH_TIMEOUT" envDefault:"10s"`
+}
+
+// GetPushConfig returns a PushConfig pointer with the correct Push Config values
+func GetPushConfig() *PushConfig {
+	c := PushConfig{}
+
+	_, b, _, _ := runtime.Caller(0)
+	d := path.Join(path.Dir(b))
+	projectRoot := filepath.Dir(d)
+	dotenvPath := path.Join(projectRoot, ".env")
+	_ = godotenv.Load(dotenvPath)
+
+	if err := env.Parse(&c); err != nil {
+		fmt.Printf("%+v\n", err)
+	}
+	return &c
+}
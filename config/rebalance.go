@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+)
+
+// RebalanceConfig persists the config for the scheduled rebalancing of allocations
+type RebalanceConfig struct {
+	// Interval is how often the allocations rebalanced on a schedule are checked for drift
+	Interval time.Duration `env:"REBALANCE_INTERVAL" envDefault:"1h"`
+	// MinInterval is the least time between two scheduled rebalances of an allocation, so
+	// that the orders of one fill before the next
+	MinInterval time.Duration `env:"REBALANCE_MIN_INTERVAL" envDefault:"24h"`
+}
+
+// GetRebalanceConfig returns a RebalanceConfig pointer with the correct Rebalance Config values
+func GetRebalanceConfig() *RebalanceConfig {
+	c := RebalanceConfig{}
+
+	_, b, _, _ := runtime.Caller(0)
+	d := path.Join(path.Dir(b))
+	projectRoot := filepath.Dir(d)
+	dotenvPath := path.Join(projectRoot, ".env")
+	_ = godotenv.Load(dotenvPath)
+
+	if err := env.Parse(&c); err != nil {
+		fmt.Printf("%+v\n", err)
+	}
+	return &c
+}
@@ -0,0 +1,68 @@
+package e2e_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *E2ETestSuite) TestAlgorithmBudget() {
+	t := suite.T()
+	ts := httptest.NewServer(suite.r)
+	defer ts.Close()
+	token := suite.login(ts)
+	user := suite.sign(ts, token)
+	suite.broker.SetCash(user.AccountID, 10000)
+	suite.broker.AddAsset(broker.Asset{Symbol: "MSFT", Tradable: true})
+	suite.broker.SetPrice("MSFT", 100)
+
+	var algorithm model.Algorithm
+	status := call(http.MethodPost, ts.URL+"/v1/algorithms", token, "application/json",
+		bytes.NewBufferString(`{"name":"Budget Test","strategy":"momentum","parameters":{"lookback_period":10}}`), &algorithm)
+	assert.Equal(t, http.StatusCreated, status)
+	base := fmt.Sprintf("%s/v1/algorithms/%d", ts.URL, algorithm.ID)
+
+	var limit model.InvestmentLimit
+	status = call(http.MethodPut, base+"/budget", token, "application/json", bytes.NewBufferString(`{"amount":500}`), &limit)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 500.0, limit.RemainingAmount)
+
+	order := func(body string) (int, *broker.Order) {
+		o := new(broker.Order)
+		return call(http.MethodPost, base+"/orders", token, "application/json", bytes.NewBufferString(body), o), o
+	}
+
+	// a filled buy moves its cost from remaining to invested
+	status, _ = order(`{"symbol":"MSFT","qty":"3","side":"buy","type":"market","time_in_force":"day"}`)
+	assert.Equal(t, http.StatusOK, status)
+	call(http.MethodGet, base+"/budget", token, "", nil, &limit)
+	assert.Equal(t, 300.0, limit.TotalInvested)
+	assert.Equal(t, 200.0, limit.RemainingAmount)
+
+	// orders the remaining budget cannot cover are refused
+	status, _ = order(`{"symbol":"MSFT","qty":"3","side":"buy","type":"market","time_in_force":"day"}`)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// open orders reserve budget until they are canceled
+	status, o := order(`{"symbol":"MSFT","qty":"2","side":"buy","type":"limit","limit_price":"90","time_in_force":"gtc"}`)
+	assert.Equal(t, http.StatusOK, status)
+	call(http.MethodGet, base+"/budget", token, "", nil, &limit)
+	assert.Equal(t, 180.0, limit.TotalReserved)
+	assert.Equal(t, 20.0, limit.RemainingAmount)
+
+	status = call(http.MethodDelete, base+"/orders/"+o.ID, token, "", nil, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	call(http.MethodGet, base+"/budget", token, "", nil, &limit)
+	assert.Equal(t, 0.0, limit.TotalReserved)
+	assert.Equal(t, 200.0, limit.RemainingAmount)
+
+	// budgets cannot drop below the amount invested
+	status = call(http.MethodPut, base+"/budget", token, "application/json", bytes.NewBufferString(`{"amount":100}`), nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,139 @@
+package e2e_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// login returns a bearer token for the superuser
+func (suite *E2ETestSuite) login(ts *httptest.Server) string {
+	b, _ := json.Marshal(&request.Credentials{
+		Email:    "superuser@example.org",
+		Password: "testpassword",
+	})
+	resp, err := http.Post(ts.URL+"/login", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var authToken model.AuthToken
+	if err := json.NewDecoder(resp.Body).Decode(&authToken); err != nil {
+		log.Fatal(err)
+	}
+	return "Bearer " + authToken.Token
+}
+
+// sign opens a brokerage account with the fake broker for the superuser
+func (suite *E2ETestSuite) sign(ts *httptest.Server, token string) *model.User {
+	user := new(model.User)
+	status := call(http.MethodPost, ts.URL+"/v1/account/sign", token, "", nil, user)
+	assert.Equal(suite.T(), http.StatusOK, status)
+	assert.NotEmpty(suite.T(), user.AccountID)
+	return user
+}
+
+// call makes an authenticated request and decodes the response into v, returning the status code
+func call(method, url, token, contentType string, body io.Reader, v interface{}) int {
+	req, _ := http.NewRequest(method, url, body)
+	req.Header.Set("Authorization", token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if v != nil && len(b) > 0 {
+		json.Unmarshal(b, v)
+	}
+	return resp.StatusCode
+}
+
+func (suite *E2ETestSuite) TestBroker() {
+	t := suite.T()
+	ts := httptest.NewServer(suite.r)
+	defer ts.Close()
+	token := suite.login(ts)
+
+	// open the brokerage account and fund it through a linked bank
+	user := suite.sign(ts, token)
+	bank := suite.broker.AddACHRelationship(user.AccountID, "Checking")
+	var banks []broker.ACHRelationship
+	status := call(http.MethodGet, ts.URL+"/v1/plaid/recipient_banks", token, "", nil, &banks)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, banks, 1)
+
+	form := url.Values{"amount": {"1000"}}
+	var transfer broker.Transfer
+	status = call(http.MethodPost, ts.URL+"/v1/transfer/bank/"+bank+"/deposit", token,
+		"application/x-www-form-urlencoded", strings.NewReader(form.Encode()), &transfer)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "QUEUED", transfer.Status)
+	suite.broker.SettleTransfers(user.AccountID)
+
+	var transfers []broker.Transfer
+	status = call(http.MethodGet, ts.URL+"/v1/transfer", token, "", nil, &transfers)
+	assert.Equal(t, http.StatusOK, status)
+	if assert.Len(t, transfers, 1) {
+		assert.Equal(t, "COMPLETE", transfers[0].Status)
+	}
+
+	// trade
+	suite.broker.AddAsset(broker.Asset{Symbol: "AAPL", Name: "Apple Inc. Common Stock", Exchange: "NASDAQ", Tradable: true})
+	suite.broker.SetPrice("AAPL", 150)
+	b, _ := json.Marshal(map[string]interface{}{
+		"symbol":        "AAPL",
+		"qty":           "2",
+		"side":          "buy",
+		"type":          "market",
+		"time_in_force": "day",
+	})
+	var order model.Order
+	status = call(http.MethodPost, ts.URL+"/v1/orders", token, "application/json", bytes.NewBuffer(b), &order)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, broker.OrderFilled, order.Status)
+	assert.Equal(t, 700.0, suite.broker.Cash(user.AccountID))
+
+	var positions []broker.Position
+	status = call(http.MethodGet, ts.URL+"/v1/positions", token, "", nil, &positions)
+	assert.Equal(t, http.StatusOK, status)
+	if assert.Len(t, positions, 1) {
+		assert.Equal(t, "AAPL", positions[0].Symbol)
+		assert.Equal(t, 2.0, positions[0].Qty)
+	}
+
+	// broker errors are passed through with their status
+	suite.broker.Fail(http.MethodGet, "/v1/trading/accounts/"+user.AccountID+"/positions", http.StatusInternalServerError, 2)
+	status = call(http.MethodGet, ts.URL+"/v1/positions", token, "", nil, nil)
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	var assets []struct {
+		Symbol string           `json:"symbol"`
+		Ticker *broker.Snapshot `json:"ticker"`
+	}
+	status = call(http.MethodGet, ts.URL+"/v1/assets/single/?tickers=AAPL", token, "", nil, &assets)
+	assert.Equal(t, http.StatusOK, status)
+	if assert.Len(t, assets, 1) && assert.NotNil(t, assets[0].Ticker) {
+		assert.Equal(t, 150.0, assets[0].Ticker.LatestTrade.Price)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/zcoriarty/Backend/broker/brokertest"
 	"github.com/zcoriarty/Backend/config"
 	"github.com/zcoriarty/Backend/e2e"
 	"github.com/zcoriarty/Backend/manager"
@@ -51,6 +52,7 @@ type E2ETestSuite struct {
 	r         *gin.Engine
 	v         *model.Verification
 	authToken model.AuthToken
+	broker    *brokertest.Server
 }
 
 // SetupSuite runs before all tests in this test suite
@@ -118,8 +120,11 @@ func (suite *E2ETestSuite) SetupSuite() {
 		},
 	}
 
+	// fake broker
+	suite.broker = brokertest.NewServer()
+
 	// setup routes
-	rs := route.NewServices(suite.db, log, jwt, m, mobile, r)
+	rs := route.NewServices(suite.db, log, jwt, m, mobile, &mock.Magic{}, suite.broker.Broker(), r)
 	rs.SetupV1Routes()
 
 	// we can now test our routes in an end-to-end fashion by making http calls
@@ -128,6 +133,7 @@ func (suite *E2ETestSuite) SetupSuite() {
 
 // TearDownSuite runs after all tests in this test suite
 func (suite *E2ETestSuite) TearDownSuite() {
+	suite.broker.Close()
 	if !isCI { // not in CI environment, so stop our embedded postgresql db
 		suite.postgres.Stop()
 	}
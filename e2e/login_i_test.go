@@ -2,7 +2,6 @@ package e2e_test
 
 import (
 	"bytes"
-	"fmt"
 	"encoding/json"
 	"io/ioutil"
 	"log"
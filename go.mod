@@ -15,6 +15,7 @@ require (
 	github.com/go-pg/pg/v9 v9.2.0
 	github.com/go-playground/validator/v10 v10.11.2 // indirect
 	github.com/go-resty/resty/v2 v2.7.0 // indirect
+	github.com/gorilla/websocket v1.4.2
 	github.com/joho/godotenv v1.3.0
 	github.com/lib/pq v1.10.2 // indirect
 	github.com/lithammer/shortuuid/v3 v3.0.6
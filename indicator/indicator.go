@@ -0,0 +1,247 @@
+// Package indicator computes technical indicators over bars. Every series is aligned to its
+// input, holding NaN until enough bars are available.
+package indicator
+
+import (
+	"math"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/market"
+)
+
+// Closes returns the closes of bars
+func Closes(bars []broker.Bar) []float64 {
+	res := make([]float64, len(bars))
+	for i, b := range bars {
+		res[i] = b.Close
+	}
+	return res
+}
+
+// Highs returns the highs of bars
+func Highs(bars []broker.Bar) []float64 {
+	res := make([]float64, len(bars))
+	for i, b := range bars {
+		res[i] = b.High
+	}
+	return res
+}
+
+// Lows returns the lows of bars
+func Lows(bars []broker.Bar) []float64 {
+	res := make([]float64, len(bars))
+	for i, b := range bars {
+		res[i] = b.Low
+	}
+	return res
+}
+
+// SMA is the simple moving average of the last n values
+func SMA(values []float64, n int) []float64 {
+	res := nans(len(values))
+	if n < 1 {
+		return res
+	}
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			res[i] = sum / float64(n)
+		}
+	}
+	return res
+}
+
+// EMA is the exponential moving average of values with a smoothing of 2/(n+1), seeded with the
+// simple average of the first n values. Leading NaNs, as in a series of another indicator, are
+// skipped.
+func EMA(values []float64, n int) []float64 {
+	res := nans(len(values))
+	if n < 1 {
+		return res
+	}
+	k := 2 / float64(n+1)
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if len(values)-start < n {
+		return res
+	}
+	sum := 0.0
+	for _, v := range values[start : start+n] {
+		sum += v
+	}
+	prev := sum / float64(n)
+	res[start+n-1] = prev
+	for i := start + n; i < len(values); i++ {
+		prev = values[i]*k + prev*(1-k)
+		res[i] = prev
+	}
+	return res
+}
+
+// RSI is the relative strength index of the last n changes of values, using Wilder's smoothing
+// of gains and losses
+func RSI(values []float64, n int) []float64 {
+	res := nans(len(values))
+	if n < 1 || len(values) <= n {
+		return res
+	}
+	gain, loss := 0.0, 0.0
+	for i := 1; i <= n; i++ {
+		if d := values[i] - values[i-1]; d > 0 {
+			gain += d
+		} else {
+			loss -= d
+		}
+	}
+	gain, loss = gain/float64(n), loss/float64(n)
+	res[n] = rsi(gain, loss)
+	for i := n + 1; i < len(values); i++ {
+		d := values[i] - values[i-1]
+		g, l := math.Max(d, 0), math.Max(-d, 0)
+		gain = (gain*float64(n-1) + g) / float64(n)
+		loss = (loss*float64(n-1) + l) / float64(n)
+		res[i] = rsi(gain, loss)
+	}
+	return res
+}
+
+func rsi(gain, loss float64) float64 {
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// ROC is the rate of change of values over the last n values, e.g. 0.05 for a 5% rise. It is
+// NaN after a zero value.
+func ROC(values []float64, n int) []float64 {
+	res := nans(len(values))
+	for i := n; n > 0 && i < len(values); i++ {
+		if values[i-n] != 0 {
+			res[i] = values[i]/values[i-n] - 1
+		}
+	}
+	return res
+}
+
+// Highest is the highest of the last n values
+func Highest(values []float64, n int) []float64 {
+	return extreme(values, n, math.Max)
+}
+
+// Lowest is the lowest of the last n values
+func Lowest(values []float64, n int) []float64 {
+	return extreme(values, n, math.Min)
+}
+
+func extreme(values []float64, n int, pick func(a, b float64) float64) []float64 {
+	res := nans(len(values))
+	for i := n - 1; n > 0 && i < len(values); i++ {
+		res[i] = values[i-n+1]
+		for _, v := range values[i-n+2 : i+1] {
+			res[i] = pick(res[i], v)
+		}
+	}
+	return res
+}
+
+// MACD is the difference between the fast and slow EMAs of values, with its signal line, the
+// EMA of the MACD, and their difference as histogram
+func MACD(values []float64, fast, slow, signal int) (macd, sig, hist []float64) {
+	f, s := EMA(values, fast), EMA(values, slow)
+	macd = nans(len(values))
+	for i := range values {
+		macd[i] = f[i] - s[i]
+	}
+	sig = EMA(macd, signal)
+	hist = nans(len(values))
+	for i := range values {
+		hist[i] = macd[i] - sig[i]
+	}
+	return macd, sig, hist
+}
+
+// Bollinger is the simple moving average of the last n values, and the bands k population
+// standard deviations above and below it
+func Bollinger(values []float64, n int, k float64) (middle, upper, lower []float64) {
+	middle = SMA(values, n)
+	upper, lower = nans(len(values)), nans(len(values))
+	for i := n - 1; n > 0 && i < len(values); i++ {
+		variance := 0.0
+		for _, v := range values[i-n+1 : i+1] {
+			variance += (v - middle[i]) * (v - middle[i])
+		}
+		d := k * math.Sqrt(variance/float64(n))
+		upper[i], lower[i] = middle[i]+d, middle[i]-d
+	}
+	return middle, upper, lower
+}
+
+// ATR is the average true range of the last n bars, using Wilder's smoothing. The true range
+// of the first bar is its range.
+func ATR(bars []broker.Bar, n int) []float64 {
+	res := nans(len(bars))
+	if n < 1 || len(bars) < n {
+		return res
+	}
+	tr := make([]float64, len(bars))
+	for i, b := range bars {
+		tr[i] = b.High - b.Low
+		if i > 0 {
+			prev := bars[i-1].Close
+			tr[i] = math.Max(tr[i], math.Max(math.Abs(b.High-prev), math.Abs(b.Low-prev)))
+		}
+	}
+	sum := 0.0
+	for _, v := range tr[:n] {
+		sum += v
+	}
+	prev := sum / float64(n)
+	res[n-1] = prev
+	for i := n; i < len(bars); i++ {
+		prev = (prev*float64(n-1) + tr[i]) / float64(n)
+		res[i] = prev
+	}
+	return res
+}
+
+// VWAP is the volume weighted average of the typical prices, (high+low+close)/3, of bars since
+// the start of their trading day
+func VWAP(bars []broker.Bar) []float64 {
+	res := nans(len(bars))
+	var value, volume float64
+	var day time.Time
+	for i, b := range bars {
+		t := b.Timestamp.In(market.Location)
+		if d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, market.Location); !d.Equal(day) {
+			day, value, volume = d, 0, 0
+		}
+		typical := (b.High + b.Low + b.Close) / 3
+		value += typical * b.Volume
+		volume += b.Volume
+		if volume > 0 {
+			res[i] = value / volume
+		} else {
+			res[i] = typical
+		}
+	}
+	return res
+}
+
+func nans(n int) []float64 {
+	res := make([]float64, n)
+	for i := range res {
+		res[i] = math.NaN()
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+package indicator_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/indicator"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// defined returns values with NaNs replaced by -1, so series compare with assert.Equal
+func defined(values []float64) []float64 {
+	res := make([]float64, len(values))
+	for i, v := range values {
+		res[i] = -1
+		if !math.IsNaN(v) {
+			res[i] = math.Round(v*1e4) / 1e4
+		}
+	}
+	return res
+}
+
+func TestAverages(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6}
+	assert.Equal(t, []float64{-1, -1, 2, 3, 4, 5}, defined(indicator.SMA(values, 3)))
+	assert.Equal(t, []float64{-1, -1, 2, 3, 4, 5}, defined(indicator.EMA(values, 3)))
+	assert.Equal(t, []float64{-1, -1, -1, -1, -1, -1}, defined(indicator.SMA(values, 7)))
+	assert.Equal(t, []float64{-1, -1, 2, 2.5, 3.25, 4.625}, defined(indicator.EMA([]float64{1, 2, 3, 3, 4, 6}, 3)))
+
+	// leading NaNs are skipped
+	assert.Equal(t, []float64{-1, -1, -1, 2.6667, 3.8333}, defined(indicator.EMA([]float64{math.NaN(), 2, 3, 3, 5}, 3)))
+}
+
+func TestRSI(t *testing.T) {
+	assert.Equal(t, []float64{-1, -1, 100, 100}, defined(indicator.RSI([]float64{1, 2, 3, 4}, 2)))
+	assert.Equal(t, []float64{-1, -1, 0}, defined(indicator.RSI([]float64{3, 2, 1}, 2)))
+	assert.Equal(t, []float64{-1, -1, 50, 25}, defined(indicator.RSI([]float64{1, 2, 1, 0}, 2)))
+}
+
+func TestRangeIndicators(t *testing.T) {
+	values := []float64{2, 4, 1, 3, 0.5, 5}
+	assert.Equal(t, []float64{-1, -1, -0.5, -0.25, -0.5, 0.6667}, defined(indicator.ROC(values, 2)))
+	assert.Equal(t, []float64{-1, -1}, defined(indicator.ROC([]float64{0, 1}, 1)))
+	assert.Equal(t, []float64{-1, -1, 4, 4, 3, 5}, defined(indicator.Highest(values, 3)))
+	assert.Equal(t, []float64{-1, -1, 1, 1, 0.5, 0.5}, defined(indicator.Lowest(values, 3)))
+	assert.Equal(t, values, indicator.Highest(values, 1))
+
+	bars := []broker.Bar{{High: 2, Low: 1}, {High: 3, Low: 0.5}}
+	assert.Equal(t, []float64{2, 3}, indicator.Highs(bars))
+	assert.Equal(t, []float64{1, 0.5}, indicator.Lows(bars))
+}
+
+func TestMACD(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7}
+	macd, signal, hist := indicator.MACD(values, 2, 3, 2)
+	assert.Equal(t, []float64{-1, -1, 0.5, 0.5, 0.5, 0.5, 0.5}, defined(macd))
+	assert.Equal(t, []float64{-1, -1, -1, 0.5, 0.5, 0.5, 0.5}, defined(signal))
+	assert.Equal(t, []float64{-1, -1, -1, 0, 0, 0, 0}, defined(hist))
+}
+
+func TestBollinger(t *testing.T) {
+	middle, upper, lower := indicator.Bollinger([]float64{1, 3, 1, 3}, 2, 2)
+	assert.Equal(t, []float64{-1, 2, 2, 2}, defined(middle))
+	assert.Equal(t, []float64{-1, 4, 4, 4}, defined(upper))
+	assert.Equal(t, []float64{-1, 0, 0, 0}, defined(lower))
+}
+
+func TestATRAndVWAP(t *testing.T) {
+	day := time.Date(2021, 3, 1, 15, 0, 0, 0, time.UTC)
+	bars := []broker.Bar{
+		{Timestamp: day, High: 11, Low: 9, Close: 10, Volume: 100},
+		{Timestamp: day.Add(time.Minute), High: 14, Low: 12, Close: 13, Volume: 300},
+		{Timestamp: day.Add(time.Minute * 2), High: 13, Low: 11, Close: 12, Volume: 0},
+		{Timestamp: day.AddDate(0, 0, 1), High: 21, Low: 19, Close: 20, Volume: 50},
+	}
+	// true ranges are 2, 4 (from the previous close), 2 and 9
+	assert.Equal(t, []float64{-1, 3, 2.5, 5.75}, defined(indicator.ATR(bars, 2)))
+	// VWAP restarts every trading day
+	assert.Equal(t, []float64{10, 12.25, 12.25, 20}, defined(indicator.VWAP(bars)))
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		spec string
+		want string
+		err  string
+	}{
+		{spec: "SMA", want: "sma:20"},
+		{spec: "sma:50", want: "sma:50"},
+		{spec: "macd:5", want: "macd:5:26:9"},
+		{spec: "bbands:10:1.5", want: "bbands:10:1.5"},
+		{spec: "vwap", want: "vwap"},
+		{spec: "kama", err: `unknown indicator "kama"`},
+		{spec: "rsi:14:2", err: "rsi takes at most 1 parameters"},
+		{spec: "ema:1.5", err: "the windows of ema must be whole numbers between 1 and 1000"},
+		{spec: "atr:x", err: `invalid parameter "x" of atr`},
+		{spec: "macd:26:12", err: "the fast window of macd must be shorter than the slow one"},
+		{spec: "bbands:20:0", err: "the deviations of bbands must be between 0 and 10"},
+	}
+	for _, tt := range cases {
+		spec, err := indicator.Parse(tt.spec)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err, tt.spec)
+			continue
+		}
+		assert.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.want, spec.String())
+	}
+
+	spec, _ := indicator.Parse("macd:2:3:2")
+	lines := spec.Compute([]broker.Bar{{Close: 1}, {Close: 2}, {Close: 3}})
+	assert.Len(t, lines, 3)
+	assert.Equal(t, []float64{-1, -1, 0.5}, defined(lines["macd"]))
+}
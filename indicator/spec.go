@@ -0,0 +1,107 @@
+package indicator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/zcoriarty/Backend/broker"
+)
+
+// maxWindow bounds the windows of the indicators
+const maxWindow = 1000
+
+// definition describes an indicator: its default parameters and how it is computed
+type definition struct {
+	defaults []float64
+	compute  func(bars []broker.Bar, p []float64) map[string][]float64
+}
+
+var definitions = map[string]definition{
+	"sma": {[]float64{20}, func(bars []broker.Bar, p []float64) map[string][]float64 {
+		return map[string][]float64{"value": SMA(Closes(bars), int(p[0]))}
+	}},
+	"ema": {[]float64{20}, func(bars []broker.Bar, p []float64) map[string][]float64 {
+		return map[string][]float64{"value": EMA(Closes(bars), int(p[0]))}
+	}},
+	"rsi": {[]float64{14}, func(bars []broker.Bar, p []float64) map[string][]float64 {
+		return map[string][]float64{"value": RSI(Closes(bars), int(p[0]))}
+	}},
+	"macd": {[]float64{12, 26, 9}, func(bars []broker.Bar, p []float64) map[string][]float64 {
+		macd, signal, hist := MACD(Closes(bars), int(p[0]), int(p[1]), int(p[2]))
+		return map[string][]float64{"macd": macd, "signal": signal, "histogram": hist}
+	}},
+	"bbands": {[]float64{20, 2}, func(bars []broker.Bar, p []float64) map[string][]float64 {
+		middle, upper, lower := Bollinger(Closes(bars), int(p[0]), p[1])
+		return map[string][]float64{"middle": middle, "upper": upper, "lower": lower}
+	}},
+	"atr": {[]float64{14}, func(bars []broker.Bar, p []float64) map[string][]float64 {
+		return map[string][]float64{"value": ATR(bars, int(p[0]))}
+	}},
+	"vwap": {nil, func(bars []broker.Bar, _ []float64) map[string][]float64 {
+		return map[string][]float64{"value": VWAP(bars)}
+	}},
+}
+
+// Spec is an indicator and its parameters, written as the name of the indicator followed by
+// its parameters, e.g. sma:50 or macd:12:26:9. Omitted parameters take their default value.
+//
+//	sma:window, ema:window, rsi:window, atr:window  default 20, 20, 14 and 14
+//	macd:fast:slow:signal                            default 12:26:9
+//	bbands:window:deviations                         default 20:2
+//	vwap
+type Spec struct {
+	Name   string
+	Params []float64
+}
+
+// Parse parses a spec
+func Parse(s string) (*Spec, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), ":")
+	d, ok := definitions[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown indicator %q", parts[0])
+	}
+	if len(parts)-1 > len(d.defaults) {
+		return nil, fmt.Errorf("%s takes at most %d parameters", parts[0], len(d.defaults))
+	}
+	spec := &Spec{Name: parts[0], Params: append([]float64(nil), d.defaults...)}
+	for i, part := range parts[1:] {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || math.IsNaN(v) {
+			return nil, fmt.Errorf("invalid parameter %q of %s", part, spec.Name)
+		}
+		spec.Params[i] = v
+	}
+	for i, v := range spec.Params {
+		if spec.Name == "bbands" && i == 1 {
+			if v <= 0 || v > 10 {
+				return nil, fmt.Errorf("the deviations of bbands must be between 0 and 10")
+			}
+			continue
+		}
+		if v != math.Trunc(v) || v < 1 || v > maxWindow {
+			return nil, fmt.Errorf("the windows of %s must be whole numbers between 1 and %d", spec.Name, maxWindow)
+		}
+	}
+	if spec.Name == "macd" && spec.Params[0] >= spec.Params[1] {
+		return nil, fmt.Errorf("the fast window of macd must be shorter than the slow one")
+	}
+	return spec, nil
+}
+
+// String returns the spec with every parameter, e.g. sma:20
+func (s *Spec) String() string {
+	parts := []string{s.Name}
+	for _, p := range s.Params {
+		parts = append(parts, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	return strings.Join(parts, ":")
+}
+
+// Compute returns the series of the indicator over bars, keyed by line, e.g. value, or macd,
+// signal and histogram
+func (s *Spec) Compute(bars []broker.Bar) map[string][]float64 {
+	return definitions[s.Name].compute(bars, s.Params)
+}
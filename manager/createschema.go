@@ -3,6 +3,8 @@ package manager
 import (
 	"log"
 
+	"github.com/zcoriarty/Backend/model"
+
 	"github.com/go-pg/pg/v9"
 	"github.com/go-pg/pg/v9/orm"
 )
@@ -18,5 +20,19 @@ func CreateSchema(db *pg.DB, models ...interface{}) {
 		if err != nil {
 			log.Fatal(err)
 		}
+		createIndexes(db, model)
+	}
+}
+
+// createIndexes creates the indexes of model, if it declares any
+func createIndexes(db *pg.DB, m interface{}) {
+	indexer, ok := m.(model.Indexer)
+	if !ok {
+		return
+	}
+	for _, statement := range indexer.Indexes() {
+		if _, err := db.Exec(statement); err != nil {
+			log.Fatal(err)
+		}
 	}
 }
@@ -38,6 +38,7 @@ func (m *Manager) CreateSchema(models ...interface{}) {
 		if err != nil {
 			log.Fatal(err)
 		}
+		createIndexes(m.db, model)
 		p := pluralize.NewClient()
 		modelName := GetType(model)
 		tableName := p.Plural(strings.ToLower(modelName))
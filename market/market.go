@@ -0,0 +1,15 @@
+// Package market holds the time zone of the US equity markets, which trading days and dates
+// are counted in.
+package market
+
+import "time"
+
+// Location is the time zone of the market, or UTC when the time zone database is missing
+var Location = load()
+
+func load() *time.Location {
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		return loc
+	}
+	return time.UTC
+}
@@ -65,6 +65,24 @@ func (m *Mobile) CheckCode(countryCode, mobile, code string) error {
 	return nil
 }
 
+// SendSMS sends a text message to the mobile number, given with its country code
+func (m *Mobile) SendSMS(to, body string) error {
+	apiURL := "https://api.twilio.com/2010-04-01/Accounts/" + m.config.Account + "/Messages.json"
+	data := url.Values{}
+	data.Set("To", to)
+	data.Set("From", m.config.From)
+	data.Set("Body", body)
+	resp, err := m.send(apiURL, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("twilio: sending a message returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func (m *Mobile) getTwilioVerifyURL() string {
 	return "https://verify.twilio.com/v2/Services/" + m.config.Verify + "/Verifications"
 }
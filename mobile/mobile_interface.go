@@ -4,4 +4,5 @@ package mobile
 type Service interface {
 	GenerateSMSToken(countryCode, mobile string) error
 	CheckCode(countryCode, mobile, code string) error
+	SendSMS(to, body string) error
 }
@@ -0,0 +1,77 @@
+package mockdb
+
+import (
+	"time"
+
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Alert database mock
+type Alert struct {
+	CreateFn      func(*model.Alert) (*model.Alert, error)
+	ViewFn        func(int) (*model.Alert, error)
+	ListFn        func(int) ([]model.Alert, error)
+	CountFn       func(int) (int, error)
+	UpdateFn      func(*model.Alert) (*model.Alert, error)
+	DeleteFn      func(*model.Alert) error
+	ListActiveFn  func() ([]model.Alert, error)
+	UpdateStateFn func(*model.Alert) error
+	CreateEventFn func(*model.AlertEvent) error
+	ListEventsFn  func(int, *model.Pagination) ([]model.AlertEvent, error)
+	CountSentFn   func(int, time.Time) (int, error)
+}
+
+// Create mock
+func (a *Alert) Create(alert *model.Alert) (*model.Alert, error) {
+	return a.CreateFn(alert)
+}
+
+// View mock
+func (a *Alert) View(id int) (*model.Alert, error) {
+	return a.ViewFn(id)
+}
+
+// List mock
+func (a *Alert) List(userID int) ([]model.Alert, error) {
+	return a.ListFn(userID)
+}
+
+// Count mock
+func (a *Alert) Count(userID int) (int, error) {
+	return a.CountFn(userID)
+}
+
+// Update mock
+func (a *Alert) Update(alert *model.Alert) (*model.Alert, error) {
+	return a.UpdateFn(alert)
+}
+
+// Delete mock
+func (a *Alert) Delete(alert *model.Alert) error {
+	return a.DeleteFn(alert)
+}
+
+// ListActive mock
+func (a *Alert) ListActive() ([]model.Alert, error) {
+	return a.ListActiveFn()
+}
+
+// UpdateState mock
+func (a *Alert) UpdateState(alert *model.Alert) error {
+	return a.UpdateStateFn(alert)
+}
+
+// CreateEvent mock
+func (a *Alert) CreateEvent(e *model.AlertEvent) error {
+	return a.CreateEventFn(e)
+}
+
+// ListEvents mock
+func (a *Alert) ListEvents(userID int, p *model.Pagination) ([]model.AlertEvent, error) {
+	return a.ListEventsFn(userID, p)
+}
+
+// CountSent mock
+func (a *Alert) CountSent(userID int, since time.Time) (int, error) {
+	return a.CountSentFn(userID, since)
+}
@@ -0,0 +1,52 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Algorithm database mock
+type Algorithm struct {
+	CreateFn func(*model.Algorithm) (*model.Algorithm, error)
+	ViewFn   func(int) (*model.Algorithm, error)
+	ListFn   func(string, *model.Pagination) ([]model.Algorithm, error)
+	UpdateFn func(*model.Algorithm) (*model.Algorithm, error)
+	DeleteFn func(*model.Algorithm) error
+
+	ListActiveFn func() ([]model.Algorithm, error)
+	UpdateRunFn  func(*model.Algorithm) error
+}
+
+// Create mock
+func (a *Algorithm) Create(algorithm *model.Algorithm) (*model.Algorithm, error) {
+	return a.CreateFn(algorithm)
+}
+
+// View mock
+func (a *Algorithm) View(id int) (*model.Algorithm, error) {
+	return a.ViewFn(id)
+}
+
+// List mock
+func (a *Algorithm) List(query string, p *model.Pagination) ([]model.Algorithm, error) {
+	return a.ListFn(query, p)
+}
+
+// Update mock
+func (a *Algorithm) Update(algorithm *model.Algorithm) (*model.Algorithm, error) {
+	return a.UpdateFn(algorithm)
+}
+
+// Delete mock
+func (a *Algorithm) Delete(algorithm *model.Algorithm) error {
+	return a.DeleteFn(algorithm)
+}
+
+// ListActive mock
+func (a *Algorithm) ListActive() ([]model.Algorithm, error) {
+	return a.ListActiveFn()
+}
+
+// UpdateRun mock
+func (a *Algorithm) UpdateRun(algorithm *model.Algorithm) error {
+	return a.UpdateRunFn(algorithm)
+}
@@ -0,0 +1,41 @@
+package mockdb
+
+import (
+	"time"
+
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Allocation database mock
+type Allocation struct {
+	ViewFn           func(int) (*model.Allocation, error)
+	SaveFn           func(*model.Allocation) error
+	DeleteFn         func(int) error
+	ListAutoFn       func() ([]model.Allocation, error)
+	MarkRebalancedFn func(int, time.Time) error
+}
+
+// View mock
+func (a *Allocation) View(userID int) (*model.Allocation, error) {
+	return a.ViewFn(userID)
+}
+
+// Save mock
+func (a *Allocation) Save(allocation *model.Allocation) error {
+	return a.SaveFn(allocation)
+}
+
+// Delete mock
+func (a *Allocation) Delete(userID int) error {
+	return a.DeleteFn(userID)
+}
+
+// ListAuto mock
+func (a *Allocation) ListAuto() ([]model.Allocation, error) {
+	return a.ListAutoFn()
+}
+
+// MarkRebalanced mock
+func (a *Allocation) MarkRebalanced(id int, at time.Time) error {
+	return a.MarkRebalancedFn(id, at)
+}
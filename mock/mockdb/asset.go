@@ -0,0 +1,33 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Asset database mock
+type Asset struct {
+	CreateOrUpdateFn func(*model.Asset) (*model.Asset, error)
+	UpdateAssetFn    func(*model.Asset) error
+	SearchFn         func(string) ([]model.Asset, error)
+	ListTradableFn   func() ([]model.Asset, error)
+}
+
+// CreateOrUpdate mock
+func (a *Asset) CreateOrUpdate(asset *model.Asset) (*model.Asset, error) {
+	return a.CreateOrUpdateFn(asset)
+}
+
+// UpdateAsset mock
+func (a *Asset) UpdateAsset(asset *model.Asset) error {
+	return a.UpdateAssetFn(asset)
+}
+
+// Search mock
+func (a *Asset) Search(query string) ([]model.Asset, error) {
+	return a.SearchFn(query)
+}
+
+// ListTradable mock
+func (a *Asset) ListTradable() ([]model.Asset, error) {
+	return a.ListTradableFn()
+}
@@ -0,0 +1,27 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Backtest database mock
+type Backtest struct {
+	CreateFn func(*model.Backtest) (*model.Backtest, error)
+	ViewFn   func(int) (*model.Backtest, error)
+	ListFn   func(int, *model.Pagination) ([]model.Backtest, error)
+}
+
+// Create mock
+func (b *Backtest) Create(backtest *model.Backtest) (*model.Backtest, error) {
+	return b.CreateFn(backtest)
+}
+
+// View mock
+func (b *Backtest) View(id int) (*model.Backtest, error) {
+	return b.ViewFn(id)
+}
+
+// List mock
+func (b *Backtest) List(userID int, p *model.Pagination) ([]model.Backtest, error) {
+	return b.ListFn(userID, p)
+}
@@ -0,0 +1,29 @@
+package mockdb
+
+import (
+	"time"
+
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Bar database mock
+type Bar struct {
+	ListFn   func(string, string, time.Time, time.Time) ([]model.Bar, error)
+	RangesFn func(string, string, time.Time, time.Time) ([]model.BarRange, error)
+	SaveFn   func(string, string, time.Time, time.Time, []model.Bar) error
+}
+
+// List mock
+func (b *Bar) List(symbol, timeframe string, start, end time.Time) ([]model.Bar, error) {
+	return b.ListFn(symbol, timeframe, start, end)
+}
+
+// Ranges mock
+func (b *Bar) Ranges(symbol, timeframe string, start, end time.Time) ([]model.BarRange, error) {
+	return b.RangesFn(symbol, timeframe, start, end)
+}
+
+// Save mock
+func (b *Bar) Save(symbol, timeframe string, start, end time.Time, bars []model.Bar) error {
+	return b.SaveFn(symbol, timeframe, start, end, bars)
+}
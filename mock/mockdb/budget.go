@@ -0,0 +1,66 @@
+package mockdb
+
+import (
+	"time"
+
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Budget database mock
+type Budget struct {
+	AllocateFn func(int, int, float64) (*model.InvestmentLimit, error)
+	LimitFn    func(int, int) (*model.InvestmentLimit, error)
+	LimitsFn   func(int) ([]model.InvestmentLimit, error)
+	ReserveFn  func(*model.AlgorithmOrder) error
+	SubmitFn   func(*model.AlgorithmOrder) error
+	SettleFn   func(*model.AlgorithmOrder, string, float64, float64, time.Time) error
+	OrdersFn   func(int, int, string) ([]model.AlgorithmOrder, error)
+
+	SubscribersFn func(int) ([]model.InvestmentLimit, error)
+	HoldingsFn    func(int, int) (map[string]float64, error)
+}
+
+// Allocate mock
+func (b *Budget) Allocate(userID, algorithmID int, amount float64) (*model.InvestmentLimit, error) {
+	return b.AllocateFn(userID, algorithmID, amount)
+}
+
+// Limit mock
+func (b *Budget) Limit(userID, algorithmID int) (*model.InvestmentLimit, error) {
+	return b.LimitFn(userID, algorithmID)
+}
+
+// Limits mock
+func (b *Budget) Limits(userID int) ([]model.InvestmentLimit, error) {
+	return b.LimitsFn(userID)
+}
+
+// Reserve mock
+func (b *Budget) Reserve(o *model.AlgorithmOrder) error {
+	return b.ReserveFn(o)
+}
+
+// Submit mock
+func (b *Budget) Submit(o *model.AlgorithmOrder) error {
+	return b.SubmitFn(o)
+}
+
+// Settle mock
+func (b *Budget) Settle(o *model.AlgorithmOrder, status string, qty, price float64, at time.Time) error {
+	return b.SettleFn(o, status, qty, price, at)
+}
+
+// Orders mock
+func (b *Budget) Orders(userID, algorithmID int, status string) ([]model.AlgorithmOrder, error) {
+	return b.OrdersFn(userID, algorithmID, status)
+}
+
+// Subscribers mock
+func (b *Budget) Subscribers(algorithmID int) ([]model.InvestmentLimit, error) {
+	return b.SubscribersFn(algorithmID)
+}
+
+// Holdings mock
+func (b *Budget) Holdings(userID, algorithmID int) (map[string]float64, error) {
+	return b.HoldingsFn(userID, algorithmID)
+}
@@ -0,0 +1,39 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Device database mock
+type Device struct {
+	RegisterFn      func(*model.Device) (*model.Device, error)
+	ViewFn          func(int) (*model.Device, error)
+	ListFn          func(int) ([]model.Device, error)
+	DeleteFn        func(*model.Device) error
+	DeleteByTokenFn func(string) error
+}
+
+// Register mock
+func (d *Device) Register(device *model.Device) (*model.Device, error) {
+	return d.RegisterFn(device)
+}
+
+// View mock
+func (d *Device) View(id int) (*model.Device, error) {
+	return d.ViewFn(id)
+}
+
+// List mock
+func (d *Device) List(userID int) ([]model.Device, error) {
+	return d.ListFn(userID)
+}
+
+// Delete mock
+func (d *Device) Delete(device *model.Device) error {
+	return d.DeleteFn(device)
+}
+
+// DeleteByToken mock
+func (d *Device) DeleteByToken(token string) error {
+	return d.DeleteByTokenFn(token)
+}
@@ -0,0 +1,17 @@
+package mockdb
+
+// Event database mock
+type Event struct {
+	CursorFn     func(string) (string, error)
+	SaveCursorFn func(string, string) error
+}
+
+// Cursor mock
+func (e *Event) Cursor(stream string) (string, error) {
+	return e.CursorFn(stream)
+}
+
+// SaveCursor mock
+func (e *Event) SaveCursor(stream, eventID string) error {
+	return e.SaveCursorFn(stream, eventID)
+}
@@ -0,0 +1,21 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// MarketData database mock
+type MarketData struct {
+	GetFn  func([]string) ([]model.MarketData, error)
+	SaveFn func([]model.MarketData) error
+}
+
+// Get mock
+func (m *MarketData) Get(keys []string) ([]model.MarketData, error) {
+	return m.GetFn(keys)
+}
+
+// Save mock
+func (m *MarketData) Save(entries []model.MarketData) error {
+	return m.SaveFn(entries)
+}
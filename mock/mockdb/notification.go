@@ -0,0 +1,51 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Notification database mock
+type Notification struct {
+	CreateFn         func(*model.Notification) error
+	ViewFn           func(int) (*model.Notification, error)
+	ListFn           func(int, bool, *model.Pagination) ([]model.Notification, error)
+	CountUnreadFn    func(int) (int, error)
+	UpdateReadFn     func(int, []int, bool) error
+	PreferencesFn    func(int) ([]model.NotificationPreference, error)
+	SavePreferenceFn func(*model.NotificationPreference) error
+}
+
+// Create mock
+func (n *Notification) Create(notification *model.Notification) error {
+	return n.CreateFn(notification)
+}
+
+// View mock
+func (n *Notification) View(id int) (*model.Notification, error) {
+	return n.ViewFn(id)
+}
+
+// List mock
+func (n *Notification) List(userID int, unread bool, p *model.Pagination) ([]model.Notification, error) {
+	return n.ListFn(userID, unread, p)
+}
+
+// CountUnread mock
+func (n *Notification) CountUnread(userID int) (int, error) {
+	return n.CountUnreadFn(userID)
+}
+
+// UpdateRead mock
+func (n *Notification) UpdateRead(userID int, ids []int, read bool) error {
+	return n.UpdateReadFn(userID, ids, read)
+}
+
+// Preferences mock
+func (n *Notification) Preferences(userID int) ([]model.NotificationPreference, error) {
+	return n.PreferencesFn(userID)
+}
+
+// SavePreference mock
+func (n *Notification) SavePreference(p *model.NotificationPreference) error {
+	return n.SavePreferenceFn(p)
+}
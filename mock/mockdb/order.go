@@ -0,0 +1,63 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Order database mock
+type Order struct {
+	ReserveFn func(*model.Order) (*model.Order, error)
+	CreateFn  func(*model.Order) error
+	UpdateFn  func(*model.Order) error
+	DeleteFn  func(*model.Order) error
+	ViewFn    func(int, string) (*model.Order, error)
+	FindFn    func(string) (*model.Order, error)
+	ListFn    func(int, *model.OrderFilter, *model.Pagination) ([]model.Order, error)
+	OpenFn    func(int) ([]model.Order, error)
+	CountFn   func(int) (int, error)
+}
+
+// Reserve mock
+func (o *Order) Reserve(order *model.Order) (*model.Order, error) {
+	return o.ReserveFn(order)
+}
+
+// Create mock
+func (o *Order) Create(order *model.Order) error {
+	return o.CreateFn(order)
+}
+
+// Update mock
+func (o *Order) Update(order *model.Order) error {
+	return o.UpdateFn(order)
+}
+
+// Delete mock
+func (o *Order) Delete(order *model.Order) error {
+	return o.DeleteFn(order)
+}
+
+// View mock
+func (o *Order) View(userID int, orderID string) (*model.Order, error) {
+	return o.ViewFn(userID, orderID)
+}
+
+// Find mock
+func (o *Order) Find(orderID string) (*model.Order, error) {
+	return o.FindFn(orderID)
+}
+
+// List mock
+func (o *Order) List(userID int, f *model.OrderFilter, p *model.Pagination) ([]model.Order, error) {
+	return o.ListFn(userID, f, p)
+}
+
+// Open mock
+func (o *Order) Open(userID int) ([]model.Order, error) {
+	return o.OpenFn(userID)
+}
+
+// Count mock
+func (o *Order) Count(userID int) (int, error) {
+	return o.CountFn(userID)
+}
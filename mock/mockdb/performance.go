@@ -0,0 +1,70 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Performance database mock
+type Performance struct {
+	TradesFn func() ([]model.Trade, error)
+	LimitsFn func() ([]model.InvestmentLimit, error)
+
+	AlgorithmTradesFn func(int) ([]model.Trade, error)
+	AlgorithmLimitsFn func(int) ([]model.InvestmentLimit, error)
+	SaveFn            func(*model.PerformanceSnapshot) error
+	LeaderboardFn     func(*model.Pagination) ([]model.AlgorithmPerformance, error)
+	AlgorithmFn       func(int) (*model.AlgorithmPerformance, error)
+	UsersFn           func(int) ([]model.UserPerformance, error)
+	SummariesFn       func(int) ([]model.TradeSummary, error)
+	InvestmentsFn     func(int) ([]model.Investment, error)
+}
+
+// Trades mock
+func (p *Performance) Trades() ([]model.Trade, error) {
+	return p.TradesFn()
+}
+
+// Limits mock
+func (p *Performance) Limits() ([]model.InvestmentLimit, error) {
+	return p.LimitsFn()
+}
+
+// AlgorithmTrades mock
+func (p *Performance) AlgorithmTrades(algorithmID int) ([]model.Trade, error) {
+	return p.AlgorithmTradesFn(algorithmID)
+}
+
+// AlgorithmLimits mock
+func (p *Performance) AlgorithmLimits(algorithmID int) ([]model.InvestmentLimit, error) {
+	return p.AlgorithmLimitsFn(algorithmID)
+}
+
+// Save mock
+func (p *Performance) Save(s *model.PerformanceSnapshot) error {
+	return p.SaveFn(s)
+}
+
+// Leaderboard mock
+func (p *Performance) Leaderboard(pg *model.Pagination) ([]model.AlgorithmPerformance, error) {
+	return p.LeaderboardFn(pg)
+}
+
+// Algorithm mock
+func (p *Performance) Algorithm(algorithmID int) (*model.AlgorithmPerformance, error) {
+	return p.AlgorithmFn(algorithmID)
+}
+
+// Users mock
+func (p *Performance) Users(userID int) ([]model.UserPerformance, error) {
+	return p.UsersFn(userID)
+}
+
+// Summaries mock
+func (p *Performance) Summaries(userID int) ([]model.TradeSummary, error) {
+	return p.SummariesFn(userID)
+}
+
+// Investments mock
+func (p *Performance) Investments(userID int) ([]model.Investment, error) {
+	return p.InvestmentsFn(userID)
+}
@@ -0,0 +1,33 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// TaxLot database mock
+type TaxLot struct {
+	RecordFillFn func(*model.Fill) error
+	FillsFn      func(int) ([]model.Fill, error)
+	SaveReliefFn func(*model.LotRelief) error
+	ReliefsFn    func(int) ([]model.LotRelief, error)
+}
+
+// RecordFill mock
+func (t *TaxLot) RecordFill(f *model.Fill) error {
+	return t.RecordFillFn(f)
+}
+
+// Fills mock
+func (t *TaxLot) Fills(userID int) ([]model.Fill, error) {
+	return t.FillsFn(userID)
+}
+
+// SaveRelief mock
+func (t *TaxLot) SaveRelief(l *model.LotRelief) error {
+	return t.SaveReliefFn(l)
+}
+
+// Reliefs mock
+func (t *TaxLot) Reliefs(userID int) ([]model.LotRelief, error) {
+	return t.ReliefsFn(userID)
+}
@@ -0,0 +1,33 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Transfer database mock
+type Transfer struct {
+	CreateFn       func(*model.Transfer) error
+	UpdateStatusFn func(string, string, string) error
+	FindFn         func(string) (*model.Transfer, error)
+	CompletedFn    func(int) ([]model.Transfer, error)
+}
+
+// Create mock
+func (t *Transfer) Create(transfer *model.Transfer) error {
+	return t.CreateFn(transfer)
+}
+
+// UpdateStatus mock
+func (t *Transfer) UpdateStatus(transferID, status, reason string) error {
+	return t.UpdateStatusFn(transferID, status, reason)
+}
+
+// Find mock
+func (t *Transfer) Find(transferID string) (*model.Transfer, error) {
+	return t.FindFn(transferID)
+}
+
+// Completed mock
+func (t *Transfer) Completed(userID int) ([]model.Transfer, error) {
+	return t.CompletedFn(userID)
+}
@@ -6,16 +6,18 @@ import (
 
 // User database mock
 type User struct {
-	ViewFn               func(int) (*model.User, error)
-	FindByReferralCodeFn func(string) (*model.ReferralCodeVerifyResponse, error)
-	FindByUsernameFn     func(string) (*model.User, error)
-	FindByEmailFn        func(string) (*model.User, error)
-	FindByMobileFn       func(string, string) (*model.User, error)
-	FindByTokenFn        func(string) (*model.User, error)
-	UpdateLoginFn        func(*model.User) error
-	ListFn               func(*model.ListQuery, *model.Pagination) ([]model.User, error)
-	DeleteFn             func(*model.User) error
-	UpdateFn             func(*model.User) (*model.User, error)
+	ViewFn                func(int) (*model.User, error)
+	FindByReferralCodeFn  func(string) (*model.ReferralCodeVerifyResponse, error)
+	FindByUsernameFn      func(string) (*model.User, error)
+	FindByEmailFn         func(string) (*model.User, error)
+	FindByMobileFn        func(string, string) (*model.User, error)
+	FindByTokenFn         func(string) (*model.User, error)
+	FindByAccountIDFn     func(string) (*model.User, error)
+	UpdateLoginFn         func(*model.User) error
+	UpdateAccountStatusFn func(string, string) error
+	ListFn                func(*model.ListQuery, *model.Pagination) ([]model.User, error)
+	DeleteFn              func(*model.User) error
+	UpdateFn              func(*model.User) (*model.User, error)
 }
 
 // View mock
@@ -48,11 +50,21 @@ func (u *User) FindByToken(token string) (*model.User, error) {
 	return u.FindByTokenFn(token)
 }
 
+// FindByAccountID mock
+func (u *User) FindByAccountID(accountID string) (*model.User, error) {
+	return u.FindByAccountIDFn(accountID)
+}
+
 // UpdateLogin mock
 func (u *User) UpdateLogin(usr *model.User) error {
 	return u.UpdateLoginFn(usr)
 }
 
+// UpdateAccountStatus mock
+func (u *User) UpdateAccountStatus(accountID, status string) error {
+	return u.UpdateAccountStatusFn(accountID, status)
+}
+
 // List mock
 func (u *User) List(lq *model.ListQuery, p *model.Pagination) ([]model.User, error) {
 	return u.ListFn(lq, p)
@@ -0,0 +1,21 @@
+package mockdb
+
+import (
+	"github.com/zcoriarty/Backend/model"
+)
+
+// UserReward database mock
+type UserReward struct {
+	UpdateJournalStatusFn func(string, string, string) error
+	FindByJournalIDFn     func(string) (*model.UserReward, error)
+}
+
+// UpdateJournalStatus mock
+func (r *UserReward) UpdateJournalStatus(journalID, status, reason string) error {
+	return r.UpdateJournalStatusFn(journalID, status, reason)
+}
+
+// FindByJournalID mock
+func (r *UserReward) FindByJournalID(journalID string) (*model.UserReward, error) {
+	return r.FindByJournalIDFn(journalID)
+}
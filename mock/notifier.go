@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Notifier mock
+type Notifier struct {
+	NotifyFn func(context.Context, *model.Notification, ...string) error
+}
+
+// Notify mock
+func (n *Notifier) Notify(ctx context.Context, notification *model.Notification, channels ...string) error {
+	return n.NotifyFn(ctx, notification, channels...)
+}
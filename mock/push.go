@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/zcoriarty/Backend/push"
+)
+
+// Push mock
+type Push struct {
+	SendFn func(string, *push.Message) error
+}
+
+// Send mock
+func (p *Push) Send(ctx context.Context, token string, m *push.Message) error {
+	return p.SendFn(token, m)
+}
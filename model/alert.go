@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Alert{})
+	Register(&AlertEvent{})
+}
+
+// Alert conditions
+const (
+	// AlertAbove triggers when the price crosses above the threshold
+	AlertAbove = "above"
+	// AlertBelow triggers when the price crosses below the threshold
+	AlertBelow = "below"
+	// AlertChangeUp triggers when the price is up threshold percent on the day
+	AlertChangeUp = "change_up"
+	// AlertChangeDown triggers when the price is down threshold percent on the day
+	AlertChangeDown = "change_down"
+)
+
+// Alert delivery channels
+const (
+	AlertEmail = "email"
+	AlertSMS   = "sms"
+	AlertPush  = "push"
+)
+
+// Statuses of the alert history
+const (
+	AlertSent        = "sent"
+	AlertFailed      = "failed"
+	AlertRateLimited = "rate_limited"
+)
+
+// Alert notifies a user through Channel when the market of Symbol meets Condition. An alert
+// triggers when its condition starts to hold: one-shot alerts are deactivated then, and
+// recurring ones trigger again once their condition stopped holding and holds again.
+type Alert struct {
+	Base
+	ID        int     `json:"id"`
+	UserID    int     `json:"user_id"`
+	Symbol    string  `json:"symbol"`
+	Condition string  `json:"condition"`
+	Threshold float64 `json:"threshold"`
+	Channel   string  `json:"channel"`
+	Recurring bool    `json:"recurring"`
+	Active    bool    `json:"active"`
+	// Triggered is whether the condition held when the alert was last checked
+	Triggered       bool       `json:"triggered"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+}
+
+// AlertEvent records an alert triggering and the outcome of its delivery
+type AlertEvent struct {
+	Base
+	ID            int     `json:"id"`
+	AlertID       int     `json:"alert_id"`
+	UserID        int     `json:"user_id"`
+	Symbol        string  `json:"symbol"`
+	Condition     string  `json:"condition"`
+	Threshold     float64 `json:"threshold"`
+	Channel       string  `json:"channel"`
+	Price         float64 `json:"price"`
+	ChangePercent float64 `json:"change_percent"`
+	Status        string  `json:"status"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// AlertRepo represents alert database interface (the repository)
+type AlertRepo interface {
+	Create(*Alert) (*Alert, error)
+	View(int) (*Alert, error)
+	List(int) ([]Alert, error)
+	Count(int) (int, error)
+	Update(*Alert) (*Alert, error)
+	Delete(*Alert) error
+	ListActive() ([]Alert, error)
+	UpdateState(*Alert) error
+	CreateEvent(*AlertEvent) error
+	ListEvents(int, *Pagination) ([]AlertEvent, error)
+	CountSent(int, time.Time) (int, error)
+}
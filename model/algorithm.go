@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Algorithm{})
+}
+
+// Algorithm is a trading algorithm users can invest in. It runs the registered strategy named
+// Strategy, configured with Parameters, on Symbols every Interval minutes of market hours.
+type Algorithm struct {
+	Base
+	ID          int                    `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Strategy    string                 `json:"strategy"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	Symbols     []string               `json:"symbols"`
+	Interval    int                    `json:"interval"`
+	// Active is the kill switch of the algorithm: halted algorithms place no orders
+	Active    bool       `json:"active"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+}
+
+// Due reports whether the algorithm should run again at now
+func (a *Algorithm) Due(now time.Time) bool {
+	return a.LastRunAt == nil || !now.Before(a.LastRunAt.Add(time.Duration(a.Interval)*time.Minute))
+}
+
+// AlgorithmRepo represents algorithm database interface (the repository)
+type AlgorithmRepo interface {
+	Create(*Algorithm) (*Algorithm, error)
+	View(int) (*Algorithm, error)
+	List(string, *Pagination) ([]Algorithm, error)
+	Update(*Algorithm) (*Algorithm, error)
+	Delete(*Algorithm) error
+	ListActive() ([]Algorithm, error)
+	UpdateRun(*Algorithm) error
+}
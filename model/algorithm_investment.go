@@ -0,0 +1,163 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&UserAlgorithmBudget{})
+	Register(&Investment{})
+	Register(&InvestmentLimit{})
+	Register(&AlgorithmOrder{})
+	Register(&Trade{})
+	Register(&TradeSummary{})
+	Register(&AlgorithmPerformance{})
+	Register(&UserPerformance{})
+}
+
+// UserAlgorithmBudget is the amount a user has allocated to an algorithm
+type UserAlgorithmBudget struct {
+	Base
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	AlgorithmID int        `json:"algorithm_id"`
+	Algorithm   *Algorithm `json:"algorithm,omitempty"`
+	Amount      float64    `json:"amount"`
+}
+
+// Investment is the current value of a user's position in an algorithm
+type Investment struct {
+	Base
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	AlgorithmID  int        `json:"algorithm_id"`
+	Algorithm    *Algorithm `json:"algorithm,omitempty"`
+	CurrentValue float64    `json:"current_value"`
+	StartedAt    time.Time  `json:"started_at"`
+}
+
+// InvestmentLimit caps how much of a user's money an algorithm may invest. Open buy orders
+// reserve part of the remaining amount until they are filled, canceled or rejected.
+type InvestmentLimit struct {
+	Base
+	ID              int        `json:"id"`
+	UserID          int        `json:"user_id"`
+	AlgorithmID     int        `json:"algorithm_id"`
+	Algorithm       *Algorithm `json:"algorithm,omitempty"`
+	TotalAllowed    float64    `json:"total_allowed"`
+	TotalInvested   float64    `json:"total_invested"`
+	TotalReserved   float64    `json:"total_reserved"`
+	RemainingAmount float64    `json:"remaining_amount"`
+}
+
+// Indexes covers the lookups of the investment limits of an algorithm
+func (l *InvestmentLimit) Indexes() []string {
+	return []string{
+		`CREATE INDEX IF NOT EXISTS investment_limits_algorithm_id_idx ON investment_limits (algorithm_id)`,
+	}
+}
+
+// Recalculate sets the remaining amount from the allowed, invested and reserved totals
+func (l *InvestmentLimit) Recalculate() {
+	l.RemainingAmount = l.TotalAllowed - l.TotalInvested - l.TotalReserved
+	if l.RemainingAmount < 0 {
+		l.RemainingAmount = 0
+	}
+}
+
+// Algorithm order statuses
+const (
+	AlgorithmOrderOpen     = "open"
+	AlgorithmOrderFilled   = "filled"
+	AlgorithmOrderCanceled = "canceled"
+	AlgorithmOrderRejected = "rejected"
+)
+
+// AlgorithmOrder is a broker order placed by an algorithm, holding the budget it reserved
+type AlgorithmOrder struct {
+	Base
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	AlgorithmID    int        `json:"algorithm_id"`
+	Algorithm      *Algorithm `json:"algorithm,omitempty"`
+	OrderID        string     `json:"order_id"`
+	Symbol         string     `json:"symbol"`
+	Side           string     `json:"side"`
+	Reserved       float64    `json:"reserved"`
+	FilledQty      float64    `json:"filled_qty"`
+	FilledAvgPrice float64    `json:"filled_avg_price"`
+	Status         string     `json:"status"`
+}
+
+// BudgetRepo represents algorithm budget database interface (the repository)
+type BudgetRepo interface {
+	Allocate(userID, algorithmID int, amount float64) (*InvestmentLimit, error)
+	Limit(userID, algorithmID int) (*InvestmentLimit, error)
+	Limits(userID int) ([]InvestmentLimit, error)
+	Reserve(*AlgorithmOrder) error
+	Submit(*AlgorithmOrder) error
+	Settle(o *AlgorithmOrder, status string, qty, price float64, at time.Time) error
+	Orders(userID, algorithmID int, status string) ([]AlgorithmOrder, error)
+	Subscribers(algorithmID int) ([]InvestmentLimit, error)
+	Holdings(userID, algorithmID int) (map[string]float64, error)
+}
+
+// Trade is a single execution made by an algorithm on behalf of a user
+type Trade struct {
+	Base
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	AlgorithmID    int        `json:"algorithm_id"`
+	Algorithm      *Algorithm `json:"algorithm,omitempty"`
+	Symbol         string     `json:"symbol"`
+	TradeType      string     `json:"trade_type"`
+	Amount         float64    `json:"amount"`
+	ExecutionPrice float64    `json:"execution_price"`
+	ExecutedAt     time.Time  `json:"executed_at"`
+}
+
+// Indexes covers the lookups of the trades of an algorithm, for all its users or for one
+func (t *Trade) Indexes() []string {
+	return []string{
+		`CREATE INDEX IF NOT EXISTS trades_algorithm_id_user_id_idx ON trades (algorithm_id, user_id)`,
+	}
+}
+
+// TradeSummary aggregates the trades of an algorithm for a user
+type TradeSummary struct {
+	Base
+	ID                int        `json:"id"`
+	UserID            int        `json:"user_id"`
+	AlgorithmID       int        `json:"algorithm_id"`
+	Algorithm         *Algorithm `json:"algorithm,omitempty"`
+	AvgExecutionPrice float64    `json:"avg_execution_price"`
+	TotalAmount       float64    `json:"total_amount"`
+	ProfitLoss        float64    `json:"profit_loss"`
+	TradesCount       int        `json:"trades_count"`
+}
+
+// AlgorithmPerformance holds the performance of an algorithm across all users. ProfitLoss is
+// realized and unrealized, PLPercentage is relative to the cost of every buy, and TotalReturn
+// is relative to the budgets users allocated to the algorithm.
+type AlgorithmPerformance struct {
+	Base
+	ID           int        `json:"id"`
+	AlgorithmID  int        `json:"algorithm_id"`
+	Algorithm    *Algorithm `json:"algorithm,omitempty"`
+	TotalReturn  float64    `json:"total_return"`
+	TotalTrades  int        `json:"total_trades"`
+	ProfitLoss   float64    `json:"profit_loss"`
+	PLPercentage float64    `json:"pl_percentage"`
+}
+
+// UserPerformance holds the performance of an algorithm for a single user, computed like
+// AlgorithmPerformance
+type UserPerformance struct {
+	Base
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	AlgorithmID  int        `json:"algorithm_id"`
+	Algorithm    *Algorithm `json:"algorithm,omitempty"`
+	TotalReturn  float64    `json:"total_return"`
+	TotalTrades  int        `json:"total_trades"`
+	ProfitLoss   float64    `json:"profit_loss"`
+	PLPercentage float64    `json:"pl_percentage"`
+}
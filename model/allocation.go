@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Allocation{})
+}
+
+// Defaults of the rebalancing bounds of an allocation: a drift of 5 percentage points and
+// trades of $1
+const (
+	DefaultAllocationThreshold = 0.05
+	DefaultMinTradeSize        = 1.0
+)
+
+// Allocation is the target allocation of the portfolio of a user. The weights not allocated to
+// a symbol are held in cash, e.g. 0.6 of VTI and 0.3 of BND hold 10% in cash.
+type Allocation struct {
+	Base
+	ID      int                `json:"id"`
+	UserID  int                `json:"user_id"`
+	Targets []AllocationTarget `json:"targets"`
+	// Threshold is the drift of the weight of a symbol from its target beyond which the
+	// symbol is rebalanced, e.g. 0.05 for 5 percentage points
+	Threshold float64 `json:"threshold"`
+	// MinTradeSize is the smallest order of a rebalance, in dollars
+	MinTradeSize float64 `json:"min_trade_size"`
+	// AutoRebalance rebalances the portfolio on a schedule
+	AutoRebalance bool `json:"auto_rebalance"`
+	// CloseUntargeted sells the positions without a target once they drift beyond the
+	// threshold. The positions held by algorithms are never traded.
+	CloseUntargeted  bool       `json:"close_untargeted"`
+	LastRebalancedAt *time.Time `json:"last_rebalanced_at,omitempty"`
+}
+
+// AllocationTarget is the weight of a symbol in an allocation
+type AllocationTarget struct {
+	Symbol string  `json:"symbol"`
+	Weight float64 `json:"weight"`
+}
+
+// AllocationRepo represents allocation database interface (the repository)
+type AllocationRepo interface {
+	View(userID int) (*Allocation, error)
+	Save(*Allocation) error
+	Delete(userID int) error
+	ListAuto() ([]Allocation, error)
+	MarkRebalanced(id int, at time.Time) error
+}
@@ -24,4 +24,5 @@ type AssetsRepo interface {
 	CreateOrUpdate(*Asset) (*Asset, error)
 	UpdateAsset(*Asset) error
 	Search(string) ([]Asset, error)
+	ListTradable() ([]Asset, error)
 }
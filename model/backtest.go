@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Backtest{})
+}
+
+// Backtest is a stored backtest run of a strategy over a symbol's history
+type Backtest struct {
+	Base
+	ID             int                    `json:"id"`
+	UserID         int                    `json:"user_id"`
+	Strategy       string                 `json:"strategy"`
+	Parameters     map[string]interface{} `json:"parameters"`
+	Symbol         string                 `json:"symbol"`
+	StartDate      time.Time              `json:"start_date"`
+	EndDate        time.Time              `json:"end_date"`
+	InitialCash    float64                `json:"initial_cash"`
+	Slippage       float64                `json:"slippage"`
+	Commission     float64                `json:"commission"`
+	CommissionRate float64                `json:"commission_rate"`
+	TotalReturn    float64                `json:"total_return"`
+	MaxDrawdown    float64                `json:"max_drawdown"`
+	Sharpe         float64                `json:"sharpe"`
+	WinRate        float64                `json:"win_rate"`
+	EquityCurve    []EquityPoint          `json:"equity_curve,omitempty"`
+	Trades         []BacktestTrade        `json:"trades,omitempty"`
+}
+
+// EquityPoint is the equity of a backtest at a point in time
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// BacktestTrade is a simulated fill of a backtest
+type BacktestTrade struct {
+	Time       time.Time `json:"time"`
+	Side       string    `json:"side"`
+	Qty        float64   `json:"qty"`
+	Price      float64   `json:"price"`
+	Commission float64   `json:"commission"`
+	ProfitLoss float64   `json:"profit_loss"`
+}
+
+// BacktestRepo represents backtest database interface (the repository)
+type BacktestRepo interface {
+	Create(*Backtest) (*Backtest, error)
+	View(int) (*Backtest, error)
+	List(int, *Pagination) ([]Backtest, error)
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Bar{})
+	Register(&BarRange{})
+}
+
+// Bar is a stored OHLCV aggregate of a symbol over a timeframe
+type Bar struct {
+	Base
+	ID         int       `json:"id"`
+	Symbol     string    `json:"symbol"`
+	Timeframe  string    `json:"timeframe"`
+	Timestamp  time.Time `json:"timestamp"`
+	Open       float64   `json:"open"`
+	High       float64   `json:"high"`
+	Low        float64   `json:"low"`
+	Close      float64   `json:"close"`
+	Volume     float64   `json:"volume"`
+	TradeCount int64     `json:"trade_count"`
+	VWAP       float64   `json:"vwap"`
+}
+
+// Indexes covers the lookups of the bars of a symbol and timeframe by time
+func (b *Bar) Indexes() []string {
+	return []string{
+		`CREATE INDEX IF NOT EXISTS bars_symbol_timeframe_timestamp_idx ON bars (symbol, timeframe, "timestamp")`,
+	}
+}
+
+// BarRange is a period, bounds included, the stored bars of a symbol and timeframe are
+// complete for. Periods without trades have no bars, so ranges tell them apart from the
+// periods that were never fetched.
+type BarRange struct {
+	Base
+	ID        int       `json:"id"`
+	Symbol    string    `json:"symbol"`
+	Timeframe string    `json:"timeframe"`
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+}
+
+// Indexes covers the lookups of the ranges of a symbol and timeframe by time
+func (r *BarRange) Indexes() []string {
+	return []string{
+		`CREATE INDEX IF NOT EXISTS bar_ranges_symbol_timeframe_start_at_idx ON bar_ranges (symbol, timeframe, start_at)`,
+	}
+}
+
+// BarRepo represents bars database interface (the repository)
+type BarRepo interface {
+	List(symbol, timeframe string, start, end time.Time) ([]Bar, error)
+	Ranges(symbol, timeframe string, start, end time.Time) ([]BarRange, error)
+	Save(symbol, timeframe string, start, end time.Time, bars []Bar) error
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Device{})
+}
+
+// Device platforms
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+// Device is a device of a user push notifications are sent to. A push token belongs to a
+// single device, and so to a single user.
+type Device struct {
+	Base
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Platform   string    `json:"platform"`
+	Token      string    `json:"token"`
+	AppVersion string    `json:"app_version"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// Indexes makes push tokens unique, so that a device registered twice at once is stored once
+func (d *Device) Indexes() []string {
+	return []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS devices_token_key ON devices (token)`,
+	}
+}
+
+// DeviceRepo represents device database interface (the repository)
+type DeviceRepo interface {
+	Register(*Device) (*Device, error)
+	View(int) (*Device, error)
+	List(int) ([]Device, error)
+	Delete(*Device) error
+	DeleteByToken(string) error
+}
@@ -0,0 +1,19 @@
+package model
+
+func init() {
+	Register(&EventCursor{})
+}
+
+// EventCursor is the position of the event consumer in one of the broker's event streams
+type EventCursor struct {
+	Base
+	ID          int    `json:"id"`
+	Stream      string `json:"stream"`
+	LastEventID string `json:"last_event_id"`
+}
+
+// EventRepo represents event cursor database interface (the repository)
+type EventRepo interface {
+	Cursor(stream string) (string, error)
+	SaveCursor(stream, eventID string) error
+}
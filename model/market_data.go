@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&MarketData{})
+}
+
+// MarketData is a cached market data response of the broker, such as the snapshot of a symbol
+type MarketData struct {
+	Base
+	ID        int       `json:"id"`
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MarketDataRepo represents market data cache database interface (the repository)
+type MarketDataRepo interface {
+	Get(keys []string) ([]MarketData, error)
+	Save(entries []MarketData) error
+}
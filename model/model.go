@@ -51,6 +51,12 @@ func (b *Base) Delete() {
 	b.DeletedAt = &t
 }
 
+// Indexer is implemented by models whose table needs indexes besides its primary key. Indexes
+// are created with their table, so their statements must be idempotent.
+type Indexer interface {
+	Indexes() []string
+}
+
 // Register is used for registering models
 func Register(m interface{}) {
 	Models = append(Models, m)
@@ -0,0 +1,104 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Notification{})
+	Register(&NotificationPreference{})
+}
+
+// Notification categories
+const (
+	NotifyTrades    = "trades"
+	NotifyTransfers = "transfers"
+	NotifyRewards   = "rewards"
+	NotifySecurity  = "security"
+	// NotifyAlerts is the category of price alerts, which are delivered on the channel of
+	// their alert rather than by preference
+	NotifyAlerts = "alerts"
+)
+
+// NotifyCategories lists the categories users set their channel preferences for
+var NotifyCategories = []string{NotifyTrades, NotifyTransfers, NotifyRewards, NotifySecurity}
+
+// Notification channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+	ChannelInApp = "in_app"
+)
+
+// Notification is a message to a user. Notifications delivered in the app are stored, and are
+// unread until the user reads them.
+type Notification struct {
+	Base
+	ID       int    `json:"id"`
+	UserID   int    `json:"user_id"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	// Data is handed to the app along with the notification
+	Data   map[string]string `json:"data,omitempty"`
+	ReadAt *time.Time        `json:"read_at"`
+}
+
+// NotificationPreference holds the channels a user receives the notifications of a category on
+type NotificationPreference struct {
+	Base
+	ID       int    `json:"-"`
+	UserID   int    `json:"-"`
+	Category string `json:"category"`
+	Email    bool   `json:"email"`
+	SMS      bool   `json:"sms"`
+	Push     bool   `json:"push"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Indexes keeps a single preference of a user per category
+func (p *NotificationPreference) Indexes() []string {
+	return []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS notification_preferences_user_id_category_key ON notification_preferences (user_id, category) WHERE deleted_at IS NULL`,
+	}
+}
+
+// Channels returns the channels p enables
+func (p *NotificationPreference) Channels() []string {
+	var res []string
+	if p.Email {
+		res = append(res, ChannelEmail)
+	}
+	if p.SMS {
+		res = append(res, ChannelSMS)
+	}
+	if p.Push {
+		res = append(res, ChannelPush)
+	}
+	if p.InApp {
+		res = append(res, ChannelInApp)
+	}
+	return res
+}
+
+// DefaultPreference returns the preference of a user for a category they did not set
+func DefaultPreference(userID int, category string) *NotificationPreference {
+	p := &NotificationPreference{UserID: userID, Category: category, Push: true, InApp: true}
+	switch category {
+	case NotifyTransfers:
+		p.Email = true
+	case NotifySecurity:
+		p.Email, p.SMS = true, true
+	}
+	return p
+}
+
+// NotificationRepo represents notification database interface (the repository)
+type NotificationRepo interface {
+	Create(*Notification) error
+	View(int) (*Notification, error)
+	List(userID int, unread bool, p *Pagination) ([]Notification, error)
+	CountUnread(int) (int, error)
+	UpdateRead(userID int, ids []int, read bool) error
+	Preferences(int) ([]NotificationPreference, error)
+	SavePreference(*NotificationPreference) error
+}
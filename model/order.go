@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Order{})
+}
+
+// Order statuses of the ledger. Other statuses are the broker's own.
+const (
+	// OrderPending marks an order reserved by its idempotency key that was not submitted yet
+	OrderPending = "pending"
+)
+
+// Order is a broker order placed by a user, as recorded in our ledger. Its status follows the
+// broker order's status.
+type Order struct {
+	Base
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	AccountID      string     `json:"account_id"`
+	OrderID        string     `json:"order_id"`
+	ClientOrderID  string     `json:"client_order_id"`
+	IdempotencyKey string     `json:"idempotency_key,omitempty"`
+	Replaces       string     `json:"replaces,omitempty"`
+	ReplacedBy     string     `json:"replaced_by,omitempty"`
+	Symbol         string     `json:"symbol"`
+	Side           string     `json:"side"`
+	Type           string     `json:"type"`
+	TimeInForce    string     `json:"time_in_force"`
+	Qty            *float64   `json:"qty"`
+	Notional       *float64   `json:"notional"`
+	LimitPrice     *float64   `json:"limit_price"`
+	StopPrice      *float64   `json:"stop_price"`
+	FilledQty      float64    `json:"filled_qty"`
+	FilledAvgPrice *float64   `json:"filled_avg_price"`
+	Status         string     `json:"status"`
+	SubmittedAt    *time.Time `json:"submitted_at"`
+	FilledAt       *time.Time `json:"filled_at"`
+	CanceledAt     *time.Time `json:"canceled_at"`
+}
+
+// Indexes makes broker order IDs and the idempotency keys of a user unique. Reservations have
+// neither a broker order ID nor, without a key, an idempotency key yet.
+func (o *Order) Indexes() []string {
+	return []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS orders_order_id_key ON orders (order_id) WHERE order_id <> ''`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS orders_user_id_idempotency_key_key ON orders (user_id, idempotency_key) WHERE idempotency_key <> ''`,
+	}
+}
+
+// ClosedOrderStatuses are the final statuses of broker orders
+var ClosedOrderStatuses = []string{"filled", "canceled", "expired", "rejected", "replaced"}
+
+// Closed reports whether the order reached a final status
+func (o *Order) Closed() bool {
+	for _, s := range ClosedOrderStatuses {
+		if o.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderFilter filters the orders of the ledger
+type OrderFilter struct {
+	// Status is open, closed, or empty for every order
+	Status  string
+	Symbols []string
+	Side    string
+	After   *time.Time
+	Until   *time.Time
+}
+
+// OrderRepo represents order ledger database interface (the repository)
+type OrderRepo interface {
+	Reserve(*Order) (*Order, error)
+	Create(*Order) error
+	Update(*Order) error
+	Delete(*Order) error
+	View(userID int, orderID string) (*Order, error)
+	Find(orderID string) (*Order, error)
+	List(userID int, f *OrderFilter, p *Pagination) ([]Order, error)
+	Open(userID int) ([]Order, error)
+	Count(userID int) (int, error)
+}
@@ -0,0 +1,32 @@
+package model
+
+// PerformanceSnapshot is a full recomputation of the performance tables from the trades ledger
+type PerformanceSnapshot struct {
+	Algorithms  []AlgorithmPerformance
+	Users       []UserPerformance
+	Summaries   []TradeSummary
+	Investments []Investment
+}
+
+// PerformanceBreakdown is a user's performance, trade summary and current investment in one
+// algorithm
+type PerformanceBreakdown struct {
+	Algorithm   *Algorithm       `json:"algorithm"`
+	Performance *UserPerformance `json:"performance"`
+	Summary     *TradeSummary    `json:"summary"`
+	Investment  *Investment      `json:"investment"`
+}
+
+// PerformanceRepo represents performance database interface (the repository)
+type PerformanceRepo interface {
+	Trades() ([]Trade, error)
+	Limits() ([]InvestmentLimit, error)
+	AlgorithmTrades(algorithmID int) ([]Trade, error)
+	AlgorithmLimits(algorithmID int) ([]InvestmentLimit, error)
+	Save(*PerformanceSnapshot) error
+	Leaderboard(*Pagination) ([]AlgorithmPerformance, error)
+	Algorithm(algorithmID int) (*AlgorithmPerformance, error)
+	Users(userID int) ([]UserPerformance, error)
+	Summaries(userID int) ([]TradeSummary, error)
+	Investments(userID int) ([]Investment, error)
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+func init() {
+	Register(&Fill{})
+	Register(&LotRelief{})
+}
+
+// Fill is an execution of an order of a user, as reported by the broker's trade events
+type Fill struct {
+	Base
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	OrderID     string    `json:"order_id"`
+	ExecutionID string    `json:"execution_id"`
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Qty         float64   `json:"qty"`
+	Price       float64   `json:"price"`
+	FilledAt    time.Time `json:"filled_at"`
+}
+
+// LotRelief is the relief method a user chose for a sell order, and the lots it relieves
+// first by specific lot identification
+type LotRelief struct {
+	Base
+	ID      int      `json:"-"`
+	UserID  int      `json:"-"`
+	OrderID string   `json:"order_id"`
+	Method  string   `json:"method"`
+	LotIDs  []string `json:"lot_ids,omitempty"`
+}
+
+// TaxLotRepo represents fill and lot relief database interface (the repository)
+type TaxLotRepo interface {
+	RecordFill(*Fill) error
+	Fills(userID int) ([]Fill, error)
+	SaveRelief(*LotRelief) error
+	Reliefs(userID int) ([]LotRelief, error)
+}
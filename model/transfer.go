@@ -0,0 +1,29 @@
+package model
+
+func init() {
+	Register(&Transfer{})
+}
+
+// Transfer is a broker transfer requested by a user, as recorded in our ledger. Its status
+// follows the broker's transfer status events.
+type Transfer struct {
+	Base
+	ID             int     `json:"id"`
+	UserID         int     `json:"user_id"`
+	AccountID      string  `json:"account_id"`
+	TransferID     string  `json:"transfer_id"`
+	RelationshipID string  `json:"relationship_id"`
+	Type           string  `json:"type"`
+	Direction      string  `json:"direction"`
+	Amount         float64 `json:"amount"`
+	Status         string  `json:"status"`
+	Reason         string  `json:"reason,omitempty"`
+}
+
+// TransferRepo represents transfer ledger database interface (the repository)
+type TransferRepo interface {
+	Create(*Transfer) error
+	UpdateStatus(transferID, status, reason string) error
+	Find(transferID string) (*Transfer, error)
+	Completed(userID int) ([]Transfer, error)
+}
@@ -62,6 +62,7 @@ type User struct {
 	ReferralCode                      string     `json:"referral_code"`
 	WatchlistID                       string     `json:"watchlist_id"`
 	PerAccountLimit                   float64    `json:"per_account_limit"`
+	MaxOrderSize                      float64    `json:"max_order_size"`
 }
 
 // ReferralCodeVerifyResponse
@@ -98,7 +99,9 @@ type UserRepo interface {
 	FindByEmail(string) (*User, error)
 	FindByMobile(string, string) (*User, error)
 	FindByToken(string) (*User, error)
+	FindByAccountID(string) (*User, error)
 	UpdateLogin(*User) error
+	UpdateAccountStatus(accountID, status string) error
 	List(*ListQuery, *Pagination) ([]User, error)
 	Update(*User) (*User, error)
 	Delete(*User) error
@@ -15,3 +15,9 @@ type UserReward struct {
 	RewardTransferStatus bool    `json:"reward_transfer_status"`
 	ErrorResponse        string  `json:"error_response"`
 }
+
+// UserRewardRepo represents user reward database interface (the repository)
+type UserRewardRepo interface {
+	UpdateJournalStatus(journalID, status, reason string) error
+	FindByJournalID(journalID string) (*UserReward, error)
+}
@@ -0,0 +1,499 @@
+// Package optimizer builds mean-variance efficient portfolios of a set of symbols from their
+// daily closes: the frontier of the long-only portfolios taking the least risk for their
+// expected return, the minimum variance portfolio and the portfolio with the highest Sharpe
+// ratio.
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/market"
+)
+
+const (
+	// minReturns is the number of common daily returns estimates need
+	minReturns = 20
+	// maxIterations bounds the iterations of a single descent
+	maxIterations = 5000
+	// maxUpdates bounds the updates of the multiplier of the expected return of a solve
+	maxUpdates = 50
+	// tolerance is the change of weights, relative to the largest weight, at which a descent
+	// stops, and the distance to its expected return, relative to the largest expected return,
+	// at which a solve stops
+	tolerance = 1e-9
+	// searches bounds the golden section search of the max Sharpe portfolio
+	searches = 40
+	// checkEvery is the number of iterations of a descent between checks of its context
+	checkEvery = 64
+)
+
+var (
+	// ErrHistory is returned when the symbols have too few days of history in common
+	ErrHistory = errors.New("optimizer: not enough common history")
+	// ErrInfeasible is returned when the max weight does not allow investing every dollar
+	ErrInfeasible = errors.New("optimizer: max weight too low to be fully invested")
+)
+
+// Estimates are the annualized expected returns of symbols and the covariance of their
+// returns, in the order of Symbols
+type Estimates struct {
+	Symbols    []string
+	Returns    []float64
+	Covariance [][]float64
+	// Days is the number of daily returns the estimates are computed from
+	Days int
+}
+
+// Constraints bound the portfolios of the frontier, which are always long-only and fully
+// invested
+type Constraints struct {
+	// MaxWeight is the largest weight of a symbol, no limit when 0
+	MaxWeight float64
+	// RiskFreeRate is the annual rate the Sharpe ratios are measured against
+	RiskFreeRate float64
+	// Points is the number of portfolios of the frontier, 20 when 0
+	Points int
+}
+
+// Weight is the fraction of a portfolio invested in a symbol
+type Weight struct {
+	Symbol string  `json:"symbol"`
+	Weight float64 `json:"weight"`
+}
+
+// Portfolio is a portfolio and its annualized expected return and volatility
+type Portfolio struct {
+	Weights    []Weight `json:"weights"`
+	Return     float64  `json:"return"`
+	Volatility float64  `json:"volatility"`
+	Sharpe     float64  `json:"sharpe"`
+}
+
+// Asset is the annualized expected return and volatility of a single symbol
+type Asset struct {
+	Symbol     string  `json:"symbol"`
+	Return     float64 `json:"return"`
+	Volatility float64 `json:"volatility"`
+}
+
+// Result is the efficient frontier of a set of symbols, from the minimum variance portfolio
+// to the portfolio with the highest expected return
+type Result struct {
+	Days        int         `json:"days"`
+	Assets      []Asset     `json:"assets"`
+	Frontier    []Portfolio `json:"frontier"`
+	MinVariance Portfolio   `json:"min_variance"`
+	MaxSharpe   Portfolio   `json:"max_sharpe"`
+}
+
+// Estimate estimates the expected returns and covariance of the daily returns of the symbols
+// of bars over the days all of them traded, annualized with periodsPerYear
+func Estimate(bars map[string][]broker.Bar, periodsPerYear float64) (*Estimates, error) {
+	symbols := make([]string, 0, len(bars))
+	for s := range bars {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+
+	closes := make([]map[time.Time]float64, len(symbols))
+	count := map[time.Time]int{}
+	for i, s := range symbols {
+		closes[i] = map[time.Time]float64{}
+		for _, b := range bars[s] {
+			d := day(b.Timestamp)
+			if _, ok := closes[i][d]; !ok && b.Close > 0 {
+				count[d]++
+			}
+			if b.Close > 0 {
+				closes[i][d] = b.Close
+			}
+		}
+	}
+	var days []time.Time
+	for d, n := range count {
+		if n == len(symbols) {
+			days = append(days, d)
+		}
+	}
+	if len(symbols) == 0 || len(days) <= minReturns {
+		return nil, ErrHistory
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	n, m := len(symbols), len(days)-1
+	returns := make([][]float64, n)
+	e := &Estimates{Symbols: symbols, Returns: make([]float64, n), Covariance: make([][]float64, n), Days: m}
+	for i := range symbols {
+		returns[i] = make([]float64, m)
+		for k := 0; k < m; k++ {
+			returns[i][k] = closes[i][days[k+1]]/closes[i][days[k]] - 1
+			e.Returns[i] += returns[i][k]
+		}
+		e.Returns[i] /= float64(m)
+	}
+	for i := range symbols {
+		e.Covariance[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			cov := 0.0
+			for k := 0; k < m; k++ {
+				cov += (returns[i][k] - e.Returns[i]) * (returns[j][k] - e.Returns[j])
+			}
+			cov = cov / float64(m-1) * periodsPerYear
+			e.Covariance[i][j], e.Covariance[j][i] = cov, cov
+		}
+	}
+	for i := range e.Returns {
+		e.Returns[i] *= periodsPerYear
+	}
+	return e, nil
+}
+
+// Optimize solves for the efficient frontier of e under c. The frontier portfolios are evenly
+// spaced by expected return, each the least variance portfolio of its return, and the max
+// Sharpe portfolio is searched along the frontier. It stops early with the error of ctx once
+// ctx is done.
+func Optimize(ctx context.Context, e *Estimates, c Constraints) (*Result, error) {
+	n := len(e.Symbols)
+	if n == 0 {
+		return nil, ErrHistory
+	}
+	limit := 1.0
+	if c.MaxWeight > 0 && c.MaxWeight < 1 {
+		limit = c.MaxWeight
+	}
+	if float64(n)*limit < 1-1e-9 {
+		return nil, ErrInfeasible
+	}
+	points := c.Points
+	if points <= 0 {
+		points = 20
+	}
+
+	s := newSolver(e, limit)
+	res := &Result{Days: e.Days, Assets: make([]Asset, n)}
+	for i, sym := range e.Symbols {
+		res.Assets[i] = Asset{Symbol: sym, Return: e.Returns[i], Volatility: math.Sqrt(math.Max(0, e.Covariance[i][i]))}
+	}
+
+	minVar, err := s.minimize(ctx, 0, 0, 0, s.start())
+	if err != nil {
+		return nil, err
+	}
+	low, high := s.ret(minVar), s.maxReturn()
+
+	type point struct {
+		r, lambda float64
+		w         []float64
+	}
+	frontier := []point{{low, 0, minVar}}
+	if high-low > 1e-12*math.Max(1, math.Abs(high)) {
+		for k := 1; k < points-1; k++ {
+			prev := frontier[len(frontier)-1]
+			r := low + (high-low)*float64(k)/float64(points-1)
+			w, lambda, err := s.atReturn(ctx, r, prev.w, prev.lambda)
+			if err != nil {
+				return nil, err
+			}
+			frontier = append(frontier, point{r, lambda, w})
+		}
+		top := s.top()
+		frontier = append(frontier, point{s.ret(top), frontier[len(frontier)-1].lambda, top})
+	}
+
+	res.Frontier = make([]Portfolio, len(frontier))
+	best := 0
+	for i, p := range frontier {
+		res.Frontier[i] = s.portfolio(p.w, c.RiskFreeRate)
+		if res.Frontier[i].Sharpe > res.Frontier[best].Sharpe {
+			best = i
+		}
+	}
+	res.MinVariance = res.Frontier[0]
+	res.MaxSharpe = res.Frontier[best]
+	if len(frontier) < 3 {
+		return res, nil
+	}
+
+	// the Sharpe ratio peaks once along the frontier, between the neighbours of the best point
+	lo, hi := frontier[best].r, frontier[best].r
+	if best > 0 {
+		lo = frontier[best-1].r
+	}
+	if best < len(frontier)-1 {
+		hi = frontier[best+1].r
+	}
+	w, lambda := frontier[best].w, frontier[best].lambda
+	sharpe := func(r float64) (float64, error) {
+		var err error
+		if w, lambda, err = s.atReturn(ctx, r, w, lambda); err != nil {
+			return 0, err
+		}
+		return s.portfolio(w, c.RiskFreeRate).Sharpe, nil
+	}
+	phi := (math.Sqrt(5) - 1) / 2
+	a, b := hi-phi*(hi-lo), lo+phi*(hi-lo)
+	fa, err := sharpe(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := sharpe(b)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < searches && hi-lo > 1e-9*(high-low); i++ {
+		if fa < fb {
+			lo, a, fa = a, b, fb
+			b = lo + phi*(hi-lo)
+			fb, err = sharpe(b)
+		} else {
+			hi, b, fb = b, a, fa
+			a = hi - phi*(hi-lo)
+			fa, err = sharpe(a)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := sharpe((lo + hi) / 2); err != nil {
+		return nil, err
+	}
+	if p := s.portfolio(w, c.RiskFreeRate); p.Sharpe > res.MaxSharpe.Sharpe {
+		res.MaxSharpe = p
+	}
+	return res, nil
+}
+
+// solver minimizes the variance w'Σw of the fully invested long-only weights below limit, for
+// a given expected return through an augmented Lagrangian of the return, with an accelerated
+// projected gradient descent restarted whenever its momentum stops helping
+type solver struct {
+	e     *Estimates
+	limit float64
+	// curvature is the largest eigenvalue of the Hessian 2Σ of the variance
+	curvature float64
+	// rho is the weight of the penalty on the distance to the expected return
+	rho float64
+}
+
+func newSolver(e *Estimates, limit float64) *solver {
+	// the largest eigenvalue of Σ by power iteration
+	n := len(e.Symbols)
+	v := make([]float64, n)
+	for i := range v {
+		v[i] = 1 / math.Sqrt(float64(n))
+	}
+	lambda := 0.0
+	for k := 0; k < 200; k++ {
+		next := mul(e.Covariance, v)
+		norm := 0.0
+		for _, x := range next {
+			norm += x * x
+		}
+		if norm = math.Sqrt(norm); norm == 0 {
+			break
+		}
+		for i := range next {
+			next[i] /= norm
+		}
+		v, lambda = next, norm
+	}
+	s := &solver{e: e, limit: limit, curvature: 2 * lambda * 1.01}
+	// the penalty doubles the curvature, which at least halves the distance to the return at
+	// every update of its multiplier
+	if mu := dot(e.Returns, e.Returns); mu > 0 {
+		s.rho = s.curvature / mu
+	}
+	return s
+}
+
+// start returns the equal weights
+func (s *solver) start() []float64 {
+	w := make([]float64, len(s.e.Symbols))
+	for i := range w {
+		w[i] = 1 / float64(len(w))
+	}
+	return w
+}
+
+// atReturn returns the least variance weights of expected return r and the multiplier of the
+// return, starting from the weights w and multiplier lambda of a nearby return
+func (s *solver) atReturn(ctx context.Context, r float64, w []float64, lambda float64) ([]float64, float64, error) {
+	scale := 1e-12
+	for _, m := range s.e.Returns {
+		scale = math.Max(scale, math.Abs(m))
+	}
+	for i := 0; i < maxUpdates; i++ {
+		var err error
+		if w, err = s.minimize(ctx, r, lambda, s.rho, w); err != nil {
+			return nil, 0, err
+		}
+		gap := r - s.ret(w)
+		if math.Abs(gap) <= tolerance*scale {
+			break
+		}
+		lambda += s.rho * gap
+	}
+	return w, lambda, nil
+}
+
+// minimize returns the weights minimizing w'Σw + λh + ρh²/2, where h = r - μ'w, starting from
+// w. A rho of 0 returns the minimum variance weights.
+func (s *solver) minimize(ctx context.Context, r, lambda, rho float64, w []float64) ([]float64, error) {
+	n := len(w)
+	step := 1 / (s.curvature + rho*dot(s.e.Returns, s.e.Returns) + 1e-12)
+	x := append([]float64(nil), w...)
+	y := append([]float64(nil), w...)
+	next := make([]float64, n)
+	k := 1.0
+	for i := 0; i < maxIterations; i++ {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		g := mul(s.e.Covariance, y)
+		pull := lambda + rho*(r-dot(s.e.Returns, y))
+		for j := range next {
+			next[j] = y[j] - step*(2*g[j]-pull*s.e.Returns[j])
+		}
+		s.project(next)
+		// the momentum is dropped when the step goes against it
+		restart := 0.0
+		for j := range next {
+			restart += (y[j] - next[j]) * (next[j] - x[j])
+		}
+		kn := (1 + math.Sqrt(1+4*k*k)) / 2
+		if restart > 0 {
+			k, kn = 1, 1
+		}
+		diff, size := 0.0, 0.0
+		for j := range next {
+			diff = math.Max(diff, math.Abs(next[j]-x[j]))
+			size = math.Max(size, math.Abs(next[j]))
+			y[j] = next[j] + (k-1)/kn*(next[j]-x[j])
+			x[j] = next[j]
+		}
+		k = kn
+		if diff <= tolerance*size {
+			break
+		}
+	}
+	return x, nil
+}
+
+// project projects v onto the weights adding up to 1, each between 0 and the limit. The sum of
+// the clamped v - shift falls linearly with shift between the breakpoints where a weight
+// reaches a bound, so the shift is interpolated between the breakpoints around a sum of 1.
+func (s *solver) project(v []float64) {
+	sum := func(shift float64) float64 {
+		res := 0.0
+		for _, x := range v {
+			res += math.Min(s.limit, math.Max(0, x-shift))
+		}
+		return res
+	}
+	points := make([]float64, 0, 2*len(v))
+	for _, x := range v {
+		points = append(points, x-s.limit, x)
+	}
+	sort.Float64s(points)
+	// the sum is at least 1 at the lowest breakpoint and 0 at the highest
+	lo, hi := 0, len(points)-1
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if sum(points[mid]) >= 1 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	shift := points[lo]
+	if a, b := sum(points[lo]), sum(points[hi]); a > b {
+		shift += (a - 1) / (a - b) * (points[hi] - points[lo])
+	}
+	for i, x := range v {
+		v[i] = math.Min(s.limit, math.Max(0, x-shift))
+	}
+}
+
+// top returns the weights with the highest expected return, investing up to the limit in the
+// symbols with the highest returns first, and the least volatile first among equal returns
+func (s *solver) top() []float64 {
+	order := make([]int, len(s.e.Symbols))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if s.e.Returns[i] != s.e.Returns[j] {
+			return s.e.Returns[i] > s.e.Returns[j]
+		}
+		return s.e.Covariance[i][i] < s.e.Covariance[j][j]
+	})
+	w := make([]float64, len(order))
+	left := 1.0
+	for _, i := range order {
+		w[i] = math.Min(s.limit, left)
+		if left -= w[i]; left <= 0 {
+			break
+		}
+	}
+	return w
+}
+
+// maxReturn returns the highest expected return
+func (s *solver) maxReturn() float64 {
+	return s.ret(s.top())
+}
+
+func (s *solver) ret(w []float64) float64 {
+	return dot(w, s.e.Returns)
+}
+
+func (s *solver) portfolio(w []float64, riskFree float64) Portfolio {
+	p := Portfolio{Weights: make([]Weight, len(w)), Return: s.ret(w)}
+	variance := 0.0
+	for i, x := range mul(s.e.Covariance, w) {
+		variance += w[i] * x
+	}
+	p.Volatility = math.Sqrt(math.Max(0, variance))
+	if p.Volatility > 0 {
+		p.Sharpe = (p.Return - riskFree) / p.Volatility
+	}
+	for i, x := range w {
+		if x < 1e-9 {
+			x = 0
+		}
+		p.Weights[i] = Weight{Symbol: s.e.Symbols[i], Weight: x}
+	}
+	return p
+}
+
+func dot(a, b []float64) float64 {
+	res := 0.0
+	for i, x := range a {
+		res += x * b[i]
+	}
+	return res
+}
+
+func mul(m [][]float64, v []float64) []float64 {
+	res := make([]float64, len(v))
+	for i := range m {
+		for j, x := range m[i] {
+			res[i] += x * v[j]
+		}
+	}
+	return res
+}
+
+// day returns the trading day of t
+func day(t time.Time) time.Time {
+	y, m, d := t.In(market.Location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,147 @@
+package optimizer_test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/optimizer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ny, _ = time.LoadLocation("America/New_York")
+
+func weights(p optimizer.Portfolio) map[string]float64 {
+	res := map[string]float64{}
+	for _, w := range p.Weights {
+		res[w.Symbol] = w.Weight
+	}
+	return res
+}
+
+func TestEstimate(t *testing.T) {
+	var a, b []broker.Bar
+	for d := 0; d < 30; d++ {
+		at := time.Date(2025, time.March, 1, 16, 0, 0, 0, ny).AddDate(0, 0, d)
+		a = append(a, broker.Bar{Timestamp: at, Close: 100 * math.Pow(1.01, float64(d))})
+		// B misses a day, which is left out of both
+		if d != 10 {
+			b = append(b, broker.Bar{Timestamp: at, Close: 50 + float64(d%2)})
+		}
+	}
+	e, err := optimizer.Estimate(map[string][]broker.Bar{"B": b, "A": a}, 252)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"A", "B"}, e.Symbols)
+	assert.Equal(t, 28, e.Days)
+	// the return across the missing day compounds two days
+	assert.InDelta(t, (27*0.01+0.0201)/28*252, e.Returns[0], 1e-9)
+	assert.True(t, e.Covariance[1][1] > 0)
+	assert.InDelta(t, e.Covariance[0][1], e.Covariance[1][0], 1e-15)
+
+	_, err = optimizer.Estimate(map[string][]broker.Bar{"A": a[:10], "B": b}, 252)
+	assert.Equal(t, optimizer.ErrHistory, err)
+}
+
+func TestOptimize(t *testing.T) {
+	// two uncorrelated assets
+	e := &optimizer.Estimates{
+		Symbols:    []string{"A", "B"},
+		Returns:    []float64{0.1, 0.05},
+		Covariance: [][]float64{{0.04, 0}, {0, 0.01}},
+		Days:       252,
+	}
+	res, err := optimizer.Optimize(context.Background(), e, optimizer.Constraints{Points: 10})
+	assert.Nil(t, err)
+	assert.Len(t, res.Frontier, 10)
+	assert.Equal(t, res.Frontier[0], res.MinVariance)
+	assert.InDelta(t, 0.2, weights(res.MinVariance)["A"], 1e-6)
+	assert.InDelta(t, math.Sqrt(0.008), res.MinVariance.Volatility, 1e-6)
+	// the tangency portfolio is proportional to Σ⁻¹μ
+	assert.InDelta(t, 1.0/3, weights(res.MaxSharpe)["A"], 1e-4)
+	assert.InDelta(t, 1, weights(res.Frontier[9])["A"], 1e-6)
+	for i, p := range res.Frontier {
+		if i > 0 {
+			assert.True(t, p.Return > res.Frontier[i-1].Return)
+			assert.True(t, p.Volatility > res.Frontier[i-1].Volatility)
+		}
+		assert.True(t, p.Sharpe <= res.MaxSharpe.Sharpe+1e-9)
+	}
+
+	// the max weight caps every portfolio
+	e.Symbols = append(e.Symbols, "C")
+	e.Returns = append(e.Returns, 0.02)
+	e.Covariance = [][]float64{{0.04, 0, 0}, {0, 0.01, 0}, {0, 0, 0.0025}}
+	res, err = optimizer.Optimize(context.Background(), e, optimizer.Constraints{MaxWeight: 0.4, RiskFreeRate: 0.01})
+	assert.Nil(t, err)
+	assert.Len(t, res.Frontier, 20)
+	for _, p := range append(res.Frontier, res.MaxSharpe) {
+		sum := 0.0
+		for _, w := range p.Weights {
+			assert.True(t, w.Weight >= 0 && w.Weight <= 0.4+1e-9)
+			sum += w.Weight
+		}
+		assert.InDelta(t, 1, sum, 1e-9)
+	}
+	assert.InDelta(t, 0.4, weights(res.MinVariance)["C"], 1e-6)
+	assert.InDelta(t, 0.4*0.1+0.4*0.05+0.2*0.02, res.Frontier[19].Return, 1e-6)
+
+	_, err = optimizer.Optimize(context.Background(), e, optimizer.Constraints{MaxWeight: 0.3})
+	assert.Equal(t, optimizer.ErrInfeasible, err)
+}
+
+// correlated returns the daily bars of n symbols over five years, moving with a common market
+// factor
+func correlated(n int) map[string][]broker.Bar {
+	rnd := rand.New(rand.NewSource(1))
+	bars := map[string][]broker.Bar{}
+	closes := make([]float64, n)
+	for i := range closes {
+		closes[i] = 100
+	}
+	start := time.Date(2020, time.January, 2, 16, 0, 0, 0, ny)
+	for d := 0; d < 5*252; d++ {
+		market := rnd.NormFloat64() * 0.01
+		for i := range closes {
+			closes[i] *= 1 + 0.0002*float64(i%7) + (0.5+float64(i)/float64(n))*market + rnd.NormFloat64()*0.005
+			sym := string(rune('A'+i/10)) + string(rune('A'+i%10))
+			bars[sym] = append(bars[sym], broker.Bar{Timestamp: start.AddDate(0, 0, d), Close: closes[i]})
+		}
+	}
+	return bars
+}
+
+func TestOptimizeBudget(t *testing.T) {
+	// the largest request: 20 correlated symbols over five years, with 25 points capped at 10%
+	e, err := optimizer.Estimate(correlated(20), 252)
+	if err != nil {
+		t.Fatal(err)
+	}
+	begin := time.Now()
+	res, err := optimizer.Optimize(context.Background(), e, optimizer.Constraints{MaxWeight: 0.1, Points: 25})
+	assert.Nil(t, err)
+	assert.True(t, time.Since(begin) < 2*time.Second, "took %s", time.Since(begin))
+	assert.Len(t, res.Frontier, 25)
+	for i, p := range res.Frontier {
+		sum := 0.0
+		for _, w := range p.Weights {
+			assert.True(t, w.Weight >= 0 && w.Weight <= 0.1+1e-9)
+			sum += w.Weight
+		}
+		assert.InDelta(t, 1, sum, 1e-9)
+		if i > 0 {
+			assert.True(t, p.Return > res.Frontier[i-1].Return)
+			assert.True(t, p.Volatility > res.Frontier[i-1].Volatility)
+		}
+		assert.True(t, p.Sharpe <= res.MaxSharpe.Sharpe+1e-9)
+	}
+
+	// and it stops once its context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = optimizer.Optimize(ctx, e, optimizer.Constraints{MaxWeight: 0.1, Points: 25})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,143 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zcoriarty/Backend/config"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// apnsTokenTTL is how long a provider token is reused. APNs rejects tokens older than an hour,
+// and ones refreshed more than every 20 minutes.
+const apnsTokenTTL = 50 * time.Minute
+
+// apnsInvalid lists the reasons APNs gives for tokens it no longer accepts
+var apnsInvalid = map[string]bool{"BadDeviceToken": true, "Unregistered": true, "DeviceTokenNotForTopic": true}
+
+// NewAPNs creates a new push service implementation for the Apple Push Notification service
+func NewAPNs(config *config.PushConfig) *APNs {
+	return &APNs{config: config, client: &http.Client{Timeout: config.Timeout}, now: time.Now}
+}
+
+// APNs provides a push service implementation, sending notifications to iOS devices through
+// the HTTP/2 API of the Apple Push Notification service, authenticated with provider tokens
+type APNs struct {
+	config *config.PushConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu       sync.Mutex
+	key      *ecdsa.PrivateKey
+	token    string
+	issuedAt time.Time
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type apnsAps struct {
+	Alert apnsAlert `json:"alert"`
+	Sound string    `json:"sound"`
+}
+
+type apnsResponse struct {
+	Reason string `json:"reason"`
+}
+
+// Send sends m to the device of token. The data of m is added to the payload next to aps.
+func (p *APNs) Send(ctx context.Context, token string, m *Message) error {
+	payload := map[string]interface{}{}
+	for k, v := range m.Data {
+		payload[k] = v
+	}
+	payload["aps"] = apnsAps{Alert: apnsAlert{Title: m.Title, Body: m.Body}, Sound: "default"}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	auth, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequest(http.MethodPost, p.config.APNSURL+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "bearer "+auth)
+	r.Header.Set("apns-topic", p.config.APNSTopic)
+	r.Header.Set("apns-push-type", "alert")
+	r.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(r.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var res apnsResponse
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	if resp.StatusCode == http.StatusGone || apnsInvalid[res.Reason] {
+		return ErrInvalidToken
+	}
+	return fmt.Errorf("apns: %d %s", resp.StatusCode, res.Reason)
+}
+
+// providerToken returns the JWT authenticating us to APNs, signed anew once it gets old
+func (p *APNs) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if p.token != "" && now.Sub(p.issuedAt) < apnsTokenTTL {
+		return p.token, nil
+	}
+	if p.key == nil {
+		key, err := parseAPNsKey(p.config.APNSKey)
+		if err != nil {
+			return "", err
+		}
+		p.key = key
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.config.APNSTeam,
+		"iat": now.Unix(),
+	})
+	t.Header["kid"] = p.config.APNSKeyID
+	signed, err := t.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	p.token, p.issuedAt = signed, now
+	return signed, nil
+}
+
+// parseAPNsKey parses the PKCS #8 .p8 key Apple issues for signing provider tokens
+func parseAPNsKey(s string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("apns: key must be PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ec, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("apns: key is not an ECDSA key")
+	}
+	return ec, nil
+}
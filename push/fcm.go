@@ -0,0 +1,194 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zcoriarty/Backend/config"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// fcmScope is the OAuth2 scope of the access tokens sending messages
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// fcmTokenMargin is how long before they expire access tokens are refreshed
+const fcmTokenMargin = 5 * time.Minute
+
+// fcmInvalid lists the error codes FCM returns for tokens it no longer accepts
+var fcmInvalid = map[string]bool{"UNREGISTERED": true, "SENDER_ID_MISMATCH": true}
+
+// NewFCM creates a new push service implementation for Firebase Cloud Messaging
+func NewFCM(config *config.PushConfig) *FCM {
+	return &FCM{config: config, client: &http.Client{Timeout: config.Timeout}, now: time.Now}
+}
+
+// FCM provides a push service implementation, sending notifications to Android devices
+// through the HTTP v1 API of Firebase Cloud Messaging, authenticated with the OAuth2 access
+// tokens of a service account
+type FCM struct {
+	config *config.PushConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	account   *fcmAccount
+	key       *rsa.PrivateKey
+	token     string
+	expiresAt time.Time
+}
+
+// fcmAccount is the JSON key of a Google service account
+type fcmAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmResponse struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+type fcmToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Send sends m to the device of token
+func (p *FCM) Send(ctx context.Context, token string, m *Message) error {
+	body, err := json.Marshal(fcmRequest{Message: fcmMessage{
+		Token:        token,
+		Notification: fcmNotification{Title: m.Title, Body: m.Body},
+		Data:         m.Data,
+	}})
+	if err != nil {
+		return err
+	}
+	account, auth, err := p.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequest(http.MethodPost, p.config.FCMURL+"/v1/projects/"+account.ProjectID+"/messages:send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+auth)
+	r.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(r.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var res fcmResponse
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrInvalidToken
+	}
+	for _, d := range res.Error.Details {
+		if fcmInvalid[d.ErrorCode] {
+			return ErrInvalidToken
+		}
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// the access token was revoked, so the next send gets a new one
+		p.mu.Lock()
+		p.token = ""
+		p.mu.Unlock()
+	}
+	return fmt.Errorf("fcm: %d %s", resp.StatusCode, res.Error.Status)
+}
+
+// accessToken returns the service account and an OAuth2 access token of it, exchanged anew
+// for a signed assertion once it is about to expire
+func (p *FCM) accessToken(ctx context.Context) (*fcmAccount, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if p.token != "" && now.Before(p.expiresAt) {
+		return p.account, p.token, nil
+	}
+	if p.account == nil {
+		account := new(fcmAccount)
+		if err := json.Unmarshal([]byte(p.config.FCMCredentials), account); err != nil {
+			return nil, "", fmt.Errorf("fcm: invalid service account key: %v", err)
+		}
+		if account.ProjectID == "" || account.ClientEmail == "" || account.TokenURI == "" {
+			return nil, "", errors.New("fcm: service account key misses its project, email or token URI")
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+		if err != nil {
+			return nil, "", err
+		}
+		p.account, p.key = account, key
+	}
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	r, err := http.NewRequest(http.MethodPost, p.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, "", err
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(r.WithContext(ctx))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fcm: getting an access token returned %d", resp.StatusCode)
+	}
+	t := new(fcmToken)
+	if err := json.NewDecoder(resp.Body).Decode(t); err != nil {
+		return nil, "", err
+	}
+	p.token = t.AccessToken
+	p.expiresAt = now.Add(time.Duration(t.ExpiresIn)*time.Second - fcmTokenMargin)
+	return p.account, p.token, nil
+}
@@ -0,0 +1,79 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/model"
+
+	"go.uber.org/zap"
+)
+
+// ErrNoDevice is returned when pushing to users without a registered device
+var ErrNoDevice = errors.New("push: no registered device")
+
+// NewSenders returns the push notification providers of every device platform
+func NewSenders(cfg *config.PushConfig) map[string]Service {
+	return map[string]Service{
+		model.PlatformIOS:     NewAPNs(cfg),
+		model.PlatformAndroid: NewFCM(cfg),
+	}
+}
+
+// NewDevices creates a new Devices pushing through senders, the providers of every platform
+func NewDevices(deviceRepo model.DeviceRepo, senders map[string]Service, log *zap.Logger) *Devices {
+	return &Devices{deviceRepo: deviceRepo, senders: senders, log: log}
+}
+
+// Devices pushes notifications to every registered device of users, and forgets the devices
+// whose tokens their provider no longer accepts
+type Devices struct {
+	deviceRepo model.DeviceRepo
+	senders    map[string]Service
+	log        *zap.Logger
+}
+
+// Send pushes m to every device of a user. It succeeds when at least one device received m,
+// and otherwise returns the first failure, or ErrNoDevice when the user has none left.
+func (d *Devices) Send(ctx context.Context, userID int, m *Message) error {
+	devices, err := d.deviceRepo.List(userID)
+	if err != nil {
+		return err
+	}
+	var first error
+	sent := false
+	for i := range devices {
+		err := d.send(ctx, &devices[i], m)
+		if err == ErrInvalidToken {
+			d.log.Info("push: pruning device", zap.Int("user_id", userID), zap.Int("device_id", devices[i].ID))
+			if err := d.deviceRepo.Delete(&devices[i]); err != nil {
+				d.log.Warn("push: pruning device failed", zap.Error(err))
+			}
+			continue
+		}
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		sent = true
+	}
+	if sent {
+		return nil
+	}
+	if first == nil {
+		return ErrNoDevice
+	}
+	return first
+}
+
+func (d *Devices) send(ctx context.Context, device *model.Device, m *Message) error {
+	s, ok := d.senders[device.Platform]
+	if !ok {
+		return fmt.Errorf("push: unknown platform %q", device.Platform)
+	}
+	return s.Send(ctx, device.Token, m)
+}
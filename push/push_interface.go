@@ -0,0 +1,23 @@
+package push
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is a push notification
+type Message struct {
+	Title string
+	Body  string
+	// Data is handed to the app along with the notification
+	Data map[string]string
+}
+
+// ErrInvalidToken is returned for tokens the provider no longer accepts, e.g. of apps
+// uninstalled. Their devices should be forgotten.
+var ErrInvalidToken = errors.New("push: invalid token")
+
+// Service is the interface to a push notification provider
+type Service interface {
+	Send(ctx context.Context, token string, m *Message) error
+}
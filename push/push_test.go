@@ -0,0 +1,196 @@
+package push_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/mock"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/push"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestDevicesSend(t *testing.T) {
+	devices := []model.Device{
+		{ID: 1, UserID: 1, Platform: model.PlatformIOS, Token: "stale"},
+		{ID: 2, UserID: 1, Platform: model.PlatformAndroid, Token: "phone"},
+		{ID: 3, UserID: 1, Platform: model.PlatformIOS, Token: "tablet"},
+	}
+	var deleted []int
+	deviceRepo := &mockdb.Device{
+		ListFn: func(userID int) ([]model.Device, error) {
+			var res []model.Device
+			for _, d := range devices {
+				if d.UserID == userID && !contains(deleted, d.ID) {
+					res = append(res, d)
+				}
+			}
+			return res, nil
+		},
+		DeleteFn: func(d *model.Device) error {
+			deleted = append(deleted, d.ID)
+			return nil
+		},
+	}
+	var sent []string
+	androidDown := false
+	sender := func(token string, m *push.Message) error {
+		if token == "stale" {
+			return push.ErrInvalidToken
+		}
+		if token == "phone" && androidDown {
+			return errors.New("unavailable")
+		}
+		sent = append(sent, token)
+		return nil
+	}
+	d := push.NewDevices(deviceRepo, map[string]push.Service{
+		model.PlatformIOS:     &mock.Push{SendFn: sender},
+		model.PlatformAndroid: &mock.Push{SendFn: sender},
+	}, zap.NewNop())
+	ctx := context.Background()
+
+	// every device is pushed to, and the ones of invalid tokens are forgotten
+	assert.Nil(t, d.Send(ctx, 1, &push.Message{Title: "Filled"}))
+	assert.Equal(t, []string{"phone", "tablet"}, sent)
+	assert.Equal(t, []int{1}, deleted)
+
+	// a failing device does not fail the others
+	sent = nil
+	androidDown = true
+	assert.Nil(t, d.Send(ctx, 1, &push.Message{Title: "Filled"}))
+	assert.Equal(t, []string{"tablet"}, sent)
+
+	devices = devices[1:2]
+	assert.EqualError(t, d.Send(ctx, 1, &push.Message{Title: "Filled"}), "unavailable")
+	assert.Equal(t, push.ErrNoDevice, d.Send(ctx, 2, &push.Message{Title: "Filled"}))
+}
+
+func TestAPNs(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var auth []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		assert.Equal(t, "com.pareto.app", r.Header.Get("apns-topic"))
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		assert.Equal(t, "42", payload["notification_id"])
+		switch strings.TrimPrefix(r.URL.Path, "/3/device/") {
+		case "good":
+		case "gone":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"reason":"BadDeviceToken"}`))
+		}
+	}))
+	defer ts.Close()
+
+	p := push.NewAPNs(&config.PushConfig{
+		APNSURL:   ts.URL,
+		APNSKeyID: "KEY",
+		APNSTeam:  "TEAM",
+		APNSKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		APNSTopic: "com.pareto.app",
+	})
+	m := &push.Message{Title: "AAPL", Body: "AAPL is above 150", Data: map[string]string{"notification_id": "42"}}
+	ctx := context.Background()
+	assert.Nil(t, p.Send(ctx, "good", m))
+	assert.Equal(t, push.ErrInvalidToken, p.Send(ctx, "gone", m))
+	assert.Equal(t, push.ErrInvalidToken, p.Send(ctx, "bad", m))
+
+	// the provider token is signed once and reused
+	assert.True(t, strings.HasPrefix(auth[0], "bearer "))
+	assert.Equal(t, auth[0], auth[2])
+}
+
+func TestFCM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+		assert.NotEmpty(t, r.FormValue("assertion"))
+		w.Write([]byte(`{"access_token":"access","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/v1/projects/pareto/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access", r.Header.Get("Authorization"))
+		var req struct {
+			Message struct {
+				Token string `json:"token"`
+			} `json:"message"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Message.Token {
+		case "good":
+			w.Write([]byte(`{"name":"projects/pareto/messages/1"}`))
+		case "gone":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"status":"NOT_FOUND"}}`))
+		case "unregistered":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":400,"status":"INVALID_ARGUMENT","details":[{"errorCode":"UNREGISTERED"}]}}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"code":503,"status":"UNAVAILABLE"}}`))
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	credentials, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "pareto",
+		"client_email": "push@pareto.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"token_uri":    ts.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := push.NewFCM(&config.PushConfig{FCMURL: ts.URL, FCMCredentials: string(credentials)})
+	ctx := context.Background()
+	assert.Nil(t, p.Send(ctx, "good", &push.Message{Title: "AAPL"}))
+	assert.Equal(t, push.ErrInvalidToken, p.Send(ctx, "gone", &push.Message{Title: "AAPL"}))
+	assert.Equal(t, push.ErrInvalidToken, p.Send(ctx, "unregistered", &push.Message{Title: "AAPL"}))
+	assert.EqualError(t, p.Send(ctx, "busy", &push.Message{Title: "AAPL"}), "fcm: 503 UNAVAILABLE")
+
+	// the access token is fetched once and reused
+	assert.Equal(t, 1, tokens)
+}
+
+func contains(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+// Package rebalance computes the orders returning a portfolio to a target allocation. Only the
+// holdings drifting beyond a threshold are traded, so small drifts do not cost trades.
+package rebalance
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/zcoriarty/Backend/broker"
+)
+
+// epsilon absorbs the rounding of fractional quantities and weights
+const epsilon = 1e-9
+
+// ErrWeights is returned when the target weights are negative or add up to more than 1
+var ErrWeights = errors.New("rebalance: target weights must be positive and add up to at most 1")
+
+// Holding is a position of the portfolio, valued at Price
+type Holding struct {
+	Symbol string
+	Qty    float64
+	Price  float64
+}
+
+// Target is the weight of a symbol in the target allocation. The weights not allocated to a
+// symbol are held in cash.
+type Target struct {
+	Symbol string  `json:"symbol"`
+	Weight float64 `json:"weight"`
+}
+
+// Config bounds the trades of a rebalance
+type Config struct {
+	// Threshold is the drift of the weight of a symbol from its target, e.g. 0.05 for 5
+	// percentage points, beyond which the symbol is traded back to its target
+	Threshold float64
+	// MinTrade is the smallest order placed, in dollars
+	MinTrade float64
+	// CloseUntargeted closes the holdings without a target once they drift beyond the
+	// threshold. They are left alone otherwise, still counting in the total.
+	CloseUntargeted bool
+}
+
+// Drift is the weight of a symbol in the portfolio against its target
+type Drift struct {
+	Symbol string  `json:"symbol"`
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
+	Target float64 `json:"target"`
+	// Drift is the weight above the target, negative below it
+	Drift float64 `json:"drift"`
+}
+
+// Order is an order of a rebalance. Sells are in shares, so that positions are closed
+// exactly, and buys are in dollars.
+type Order struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Qty      float64 `json:"qty,omitempty"`
+	Notional float64 `json:"notional,omitempty"`
+	// Value is the estimated value of the order
+	Value float64 `json:"value"`
+}
+
+// Plan is the drift of a portfolio and the orders returning it to its target allocation,
+// sells first
+type Plan struct {
+	Total      float64 `json:"total"`
+	Cash       float64 `json:"cash"`
+	CashWeight float64 `json:"cash_weight"`
+	CashTarget float64 `json:"cash_target"`
+	Drifts     []Drift `json:"drifts"`
+	Orders     []Order `json:"orders"`
+}
+
+// Compute plans the rebalance of holdings and cash to targets. Every target whose weight drifts
+// beyond cfg.Threshold is traded back to it. The holdings without a target are only traded,
+// closed, with cfg.CloseUntargeted. Buys are scaled down to the cash available after the sells,
+// and orders below cfg.MinTrade are left out.
+func Compute(holdings []Holding, cash float64, targets []Target, cfg Config) (*Plan, error) {
+	weights := map[string]float64{}
+	invested := 0.0
+	for _, t := range targets {
+		if t.Weight < 0 {
+			return nil, ErrWeights
+		}
+		weights[t.Symbol] += t.Weight
+		invested += t.Weight
+	}
+	if invested > 1+epsilon {
+		return nil, ErrWeights
+	}
+
+	held := map[string]Holding{}
+	p := &Plan{Cash: cash, Total: cash, CashTarget: math.Max(0, 1-invested), Drifts: []Drift{}, Orders: []Order{}}
+	for _, h := range holdings {
+		if h.Qty <= epsilon {
+			continue
+		}
+		c := held[h.Symbol]
+		c.Symbol, c.Qty, c.Price = h.Symbol, c.Qty+h.Qty, h.Price
+		held[h.Symbol] = c
+		p.Total += h.Qty * h.Price
+	}
+	if p.Total <= 0 {
+		return p, nil
+	}
+	p.CashWeight = cash / p.Total
+
+	symbols := make([]string, 0, len(weights)+len(held))
+	for s := range weights {
+		symbols = append(symbols, s)
+	}
+	for s := range held {
+		if _, ok := weights[s]; !ok {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+
+	var sells, buys []Order
+	available := cash
+	for _, s := range symbols {
+		h := held[s]
+		value := h.Qty * h.Price
+		d := Drift{Symbol: s, Value: value, Weight: value / p.Total, Target: weights[s]}
+		d.Drift = d.Weight - d.Target
+		p.Drifts = append(p.Drifts, d)
+		if _, ok := weights[s]; !ok && !cfg.CloseUntargeted {
+			continue
+		}
+		if math.Abs(d.Drift) <= cfg.Threshold+epsilon {
+			continue
+		}
+		delta := d.Target*p.Total - value
+		switch {
+		case delta < 0 && h.Price > 0:
+			qty := h.Qty
+			// positions without a target are closed, and the others keep their fraction
+			if d.Target > 0 {
+				qty = math.Min(h.Qty, math.Floor(-delta/h.Price*1e9)/1e9)
+			}
+			o := Order{Symbol: s, Side: broker.Sell, Qty: qty, Value: qty * h.Price}
+			if o.Value >= cfg.MinTrade && qty > epsilon {
+				sells = append(sells, o)
+				available += o.Value
+			}
+		case delta > 0:
+			buys = append(buys, Order{Symbol: s, Side: broker.Buy, Notional: delta, Value: delta})
+		}
+	}
+
+	wanted := 0.0
+	for _, o := range buys {
+		wanted += o.Notional
+	}
+	scale := 1.0
+	if wanted > available && wanted > 0 {
+		scale = math.Max(0, available) / wanted
+	}
+	p.Orders = append(p.Orders, sells...)
+	for _, o := range buys {
+		// notional orders are in cents
+		o.Notional = math.Floor(o.Notional*scale*100) / 100
+		o.Value = o.Notional
+		if o.Notional >= cfg.MinTrade && o.Notional > 0 {
+			p.Orders = append(p.Orders, o)
+		}
+	}
+	return p, nil
+}
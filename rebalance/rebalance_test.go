@@ -0,0 +1,85 @@
+package rebalance_test
+
+import (
+	"testing"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/rebalance"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var targets = []rebalance.Target{{Symbol: "VTI", Weight: 0.6}, {Symbol: "BND", Weight: 0.3}}
+
+func TestCompute(t *testing.T) {
+	// VTI ran up to 70% and BND fell to 20%, while cash is on target
+	holdings := []rebalance.Holding{{Symbol: "VTI", Qty: 35, Price: 200}, {Symbol: "BND", Qty: 25, Price: 80}}
+	p, err := rebalance.Compute(holdings, 1000, targets, rebalance.Config{Threshold: 0.05, MinTrade: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 10000.0, p.Total)
+	assert.InDelta(t, 0.1, p.CashWeight, 1e-9)
+	assert.InDelta(t, 0.1, p.CashTarget, 1e-9)
+	if assert.Len(t, p.Drifts, 2) {
+		assert.Equal(t, "BND", p.Drifts[0].Symbol)
+		assert.InDelta(t, -0.1, p.Drifts[0].Drift, 1e-9)
+		assert.Equal(t, "VTI", p.Drifts[1].Symbol)
+		assert.InDelta(t, 0.7, p.Drifts[1].Weight, 1e-9)
+		assert.InDelta(t, 0.1, p.Drifts[1].Drift, 1e-9)
+	}
+	assert.Equal(t, []rebalance.Order{
+		{Symbol: "VTI", Side: broker.Sell, Qty: 5, Value: 1000},
+		{Symbol: "BND", Side: broker.Buy, Notional: 1000, Value: 1000},
+	}, p.Orders)
+
+	// drifts within the threshold are not traded
+	p, err = rebalance.Compute(holdings, 1000, targets, rebalance.Config{Threshold: 0.1, MinTrade: 1})
+	assert.Nil(t, err)
+	assert.Empty(t, p.Orders)
+
+	// and neither are the orders below the minimum trade
+	p, err = rebalance.Compute(holdings, 1000, targets, rebalance.Config{Threshold: 0.05, MinTrade: 2000})
+	assert.Nil(t, err)
+	assert.Empty(t, p.Orders)
+}
+
+func TestComputeFunding(t *testing.T) {
+	// the position without a target is closed when opted in, and the sells fund the buys
+	holdings := []rebalance.Holding{{Symbol: "AAPL", Qty: 1.5, Price: 100}, {Symbol: "VTI", Qty: 10, Price: 100}}
+	p, err := rebalance.Compute(holdings, 0, targets, rebalance.Config{Threshold: 0.02, CloseUntargeted: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 1150.0, p.Total)
+	assert.Equal(t, []rebalance.Order{
+		{Symbol: "AAPL", Side: broker.Sell, Qty: 1.5, Value: 150},
+		{Symbol: "VTI", Side: broker.Sell, Qty: 3.1, Value: 310},
+		{Symbol: "BND", Side: broker.Buy, Notional: 345, Value: 345},
+	}, p.Orders)
+
+	// otherwise it is kept, and the buys are limited to the cash VTI frees
+	p, err = rebalance.Compute(holdings, 0, targets, rebalance.Config{Threshold: 0.02})
+	assert.Nil(t, err)
+	assert.Equal(t, 1150.0, p.Total)
+	assert.Equal(t, []rebalance.Order{
+		{Symbol: "VTI", Side: broker.Sell, Qty: 3.1, Value: 310},
+		{Symbol: "BND", Side: broker.Buy, Notional: 310, Value: 310},
+	}, p.Orders)
+
+	// AAPL is within the threshold, so it is kept even when opted in
+	p, err = rebalance.Compute(holdings, 0, targets, rebalance.Config{Threshold: 0.2, CloseUntargeted: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []rebalance.Order{
+		{Symbol: "VTI", Side: broker.Sell, Qty: 3.1, Value: 310},
+		{Symbol: "BND", Side: broker.Buy, Notional: 310, Value: 310},
+	}, p.Orders)
+}
+
+func TestComputeErrors(t *testing.T) {
+	_, err := rebalance.Compute(nil, 100, []rebalance.Target{{Symbol: "VTI", Weight: 0.8}, {Symbol: "BND", Weight: 0.3}}, rebalance.Config{})
+	assert.Equal(t, rebalance.ErrWeights, err)
+	_, err = rebalance.Compute(nil, 100, []rebalance.Target{{Symbol: "VTI", Weight: -0.1}}, rebalance.Config{})
+	assert.Equal(t, rebalance.ErrWeights, err)
+
+	// an empty portfolio has nothing to rebalance
+	p, err := rebalance.Compute(nil, 0, targets, rebalance.Config{})
+	assert.Nil(t, err)
+	assert.Empty(t, p.Orders)
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9/orm"
+	"go.uber.org/zap"
+)
+
+// NewAlertRepo returns a new AlertRepo instance
+func NewAlertRepo(db orm.DB, log *zap.Logger) *AlertRepo {
+	return &AlertRepo{db, log}
+}
+
+// AlertRepo is the client for our alert model and its history
+type AlertRepo struct {
+	db  orm.DB
+	log *zap.Logger
+}
+
+// Create creates a new alert
+func (a *AlertRepo) Create(alert *model.Alert) (*model.Alert, error) {
+	if err := a.db.Insert(alert); err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return alert, nil
+}
+
+// View returns single alert by ID
+func (a *AlertRepo) View(id int) (*model.Alert, error) {
+	alert := new(model.Alert)
+	err := a.db.Model(alert).Where("id = ?", id).Where(notDeleted).Select()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return nil, apperr.New(http.StatusNotFound, "Alert not found.")
+	}
+	return alert, nil
+}
+
+// List returns the alerts of a user, newest first
+func (a *AlertRepo) List(userID int) ([]model.Alert, error) {
+	var alerts []model.Alert
+	err := a.db.Model(&alerts).Where("user_id = ?", userID).Where(notDeleted).Order("id desc").Select()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return alerts, nil
+}
+
+// Count returns the number of alerts of a user
+func (a *AlertRepo) Count(userID int) (int, error) {
+	n, err := a.db.Model((*model.Alert)(nil)).Where("user_id = ?", userID).Where(notDeleted).Count()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return 0, apperr.DB
+	}
+	return n, nil
+}
+
+// Update updates an alert's condition, delivery and state
+func (a *AlertRepo) Update(alert *model.Alert) (*model.Alert, error) {
+	_, err := a.db.Model(alert).Column(
+		"symbol",
+		"condition",
+		"threshold",
+		"channel",
+		"recurring",
+		"active",
+		"triggered",
+		"updated_at",
+	).WherePK().Update()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return alert, nil
+}
+
+// Delete sets deleted_at for an alert
+func (a *AlertRepo) Delete(alert *model.Alert) error {
+	alert.Delete()
+	_, err := a.db.Model(alert).Column("deleted_at").WherePK().Update()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+	}
+	return err
+}
+
+// ListActive returns the active alerts of every user
+func (a *AlertRepo) ListActive() ([]model.Alert, error) {
+	var alerts []model.Alert
+	err := a.db.Model(&alerts).Where("active = ?", true).Where(notDeleted).Order("id asc").Select()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return alerts, nil
+}
+
+// UpdateState records whether an alert is active and triggered, and when it last triggered
+func (a *AlertRepo) UpdateState(alert *model.Alert) error {
+	_, err := a.db.Model(alert).Column("active", "triggered", "last_triggered_at").WherePK().Update()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return apperr.DB
+	}
+	return nil
+}
+
+// CreateEvent records an alert triggering in the history
+func (a *AlertRepo) CreateEvent(e *model.AlertEvent) error {
+	if err := a.db.Insert(e); err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return apperr.DB
+	}
+	return nil
+}
+
+// ListEvents returns the alert history of a user, newest first
+func (a *AlertRepo) ListEvents(userID int, p *model.Pagination) ([]model.AlertEvent, error) {
+	var events []model.AlertEvent
+	err := a.db.Model(&events).Where("user_id = ?", userID).Order("id desc").Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return events, nil
+}
+
+// CountSent returns the number of alerts delivered to a user since a time
+func (a *AlertRepo) CountSent(userID int, since time.Time) (int, error) {
+	n, err := a.db.Model((*model.AlertEvent)(nil)).Where("user_id = ?", userID).
+		Where("status = ?", model.AlertSent).Where("created_at >= ?", since).Count()
+	if err != nil {
+		a.log.Warn("AlertRepo Error", zap.Error(err))
+		return 0, apperr.DB
+	}
+	return n, nil
+}
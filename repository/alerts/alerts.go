@@ -0,0 +1,165 @@
+// Package alerts manages the price alerts of users and delivers them when the market meets
+// their conditions.
+package alerts
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/platform/structs"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxAlerts bounds the alerts of a single user
+	maxAlerts = 100
+	// maxChangePercent bounds the threshold of the day change conditions
+	maxChangePercent = 100.0
+)
+
+// NewAlertService creates a new alert application service
+func NewAlertService(userRepo model.UserRepo, alertRepo model.AlertRepo, deviceRepo model.DeviceRepo, brk broker.Service) *Service {
+	return &Service{
+		userRepo:   userRepo,
+		alertRepo:  alertRepo,
+		deviceRepo: deviceRepo,
+		broker:     brk,
+	}
+}
+
+// Service represents the alert application service
+type Service struct {
+	userRepo   model.UserRepo
+	alertRepo  model.AlertRepo
+	deviceRepo model.DeviceRepo
+	broker     broker.Service
+}
+
+// Create creates an active alert for the current user
+func (s *Service) Create(c *gin.Context, a *model.Alert) (*model.Alert, error) {
+	user, err := s.userRepo.View(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.alertRepo.Count(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxAlerts {
+		return nil, apperr.New(http.StatusBadRequest, "Too many alerts.")
+	}
+	a.UserID = user.ID
+	a.Active = true
+	if err := s.validate(c, user, a); err != nil {
+		return nil, err
+	}
+	return s.alertRepo.Create(a)
+}
+
+// List returns the alerts of the current user
+func (s *Service) List(c *gin.Context) ([]model.Alert, error) {
+	return s.alertRepo.List(c.GetInt("id"))
+}
+
+// View returns an alert of the current user
+func (s *Service) View(c *gin.Context, id int) (*model.Alert, error) {
+	a, err := s.alertRepo.View(id)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != c.GetInt("id") {
+		return nil, apperr.New(http.StatusNotFound, "Alert not found.")
+	}
+	return a, nil
+}
+
+// Update contains alert's information used for updating
+type Update struct {
+	ID        int
+	Symbol    *string
+	Condition *string
+	Threshold *float64
+	Channel   *string
+	Recurring *bool
+	Active    *bool
+}
+
+// Update updates an alert of the current user. The alert is checked again from scratch, so it
+// triggers if its new condition already holds.
+func (s *Service) Update(c *gin.Context, update *Update) (*model.Alert, error) {
+	a, err := s.View(c, update.ID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.View(a.UserID)
+	if err != nil {
+		return nil, err
+	}
+	structs.Merge(a, update)
+	a.Triggered = false
+	if err := s.validate(c, user, a); err != nil {
+		return nil, err
+	}
+	return s.alertRepo.Update(a)
+}
+
+// Delete deletes an alert of the current user
+func (s *Service) Delete(c *gin.Context, id int) error {
+	a, err := s.View(c, id)
+	if err != nil {
+		return err
+	}
+	return s.alertRepo.Delete(a)
+}
+
+// History returns the alerts delivered, or not, to the current user, newest first
+func (s *Service) History(c *gin.Context, p *model.Pagination) ([]model.AlertEvent, error) {
+	return s.alertRepo.ListEvents(c.GetInt("id"), p)
+}
+
+// validate normalizes the symbol of a, and checks that its condition is sound and that user
+// can be reached on its channel
+func (s *Service) validate(c *gin.Context, user *model.User, a *model.Alert) error {
+	a.Symbol = strings.ToUpper(strings.TrimSpace(a.Symbol))
+	switch a.Condition {
+	case model.AlertAbove, model.AlertBelow:
+		if a.Threshold <= 0 {
+			return apperr.New(http.StatusBadRequest, "Threshold must be a positive price.")
+		}
+	case model.AlertChangeUp, model.AlertChangeDown:
+		if a.Threshold <= 0 || a.Threshold > maxChangePercent {
+			return apperr.New(http.StatusBadRequest, "Threshold must be a percentage between 0 and 100.")
+		}
+	default:
+		return apperr.New(http.StatusBadRequest, "Unknown condition.")
+	}
+	switch a.Channel {
+	case model.AlertEmail:
+		if user.Email == "" {
+			return apperr.New(http.StatusBadRequest, "An email address is required for email alerts.")
+		}
+	case model.AlertSMS:
+		if user.Mobile == "" {
+			return apperr.New(http.StatusBadRequest, "A mobile number is required for SMS alerts.")
+		}
+	case model.AlertPush:
+		devices, err := s.deviceRepo.List(user.ID)
+		if err != nil {
+			return err
+		}
+		if len(devices) == 0 {
+			return apperr.New(http.StatusBadRequest, "A device is required for push alerts.")
+		}
+	default:
+		return apperr.New(http.StatusBadRequest, "Unknown channel.")
+	}
+	asset, err := s.broker.GetAsset(c.Request.Context(), a.Symbol)
+	if se, ok := err.(apperr.StatusError); ok && se.HTTPStatus() == http.StatusNotFound || err == nil && !asset.Tradable {
+		return apperr.New(http.StatusBadRequest, "Unknown symbol.")
+	}
+	return err
+}
@@ -0,0 +1,205 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/notifications"
+
+	"go.uber.org/zap"
+)
+
+// NewEvaluator creates the evaluator of the active alerts, delivering them through notifier on
+// their channel
+func NewEvaluator(alertRepo model.AlertRepo, brk broker.Service, notifier notifications.Notifier, cfg *config.AlertsConfig, log *zap.Logger) *Evaluator {
+	return &Evaluator{
+		alertRepo: alertRepo,
+		broker:    brk,
+		notifier:  notifier,
+		cfg:       cfg,
+		log:       log,
+		now:       time.Now,
+	}
+}
+
+// Evaluator checks the active alerts against the snapshots of their symbols and delivers the
+// ones that trigger, at most RateLimit of them to a user every RateWindow. Every alert that
+// triggers is recorded in the history, delivered or not.
+type Evaluator struct {
+	alertRepo model.AlertRepo
+	broker    broker.Service
+	notifier  notifications.Notifier
+	cfg       *config.AlertsConfig
+	log       *zap.Logger
+	now       func() time.Time
+}
+
+// Run checks the active alerts every interval until ctx is done
+func (e *Evaluator) Run(ctx context.Context) {
+	t := time.NewTicker(e.cfg.Interval)
+	defer t.Stop()
+	for {
+		if err := e.RunOnce(ctx); err != nil {
+			e.log.Warn("Evaluator Error", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce checks the active alerts once
+func (e *Evaluator) RunOnce(ctx context.Context) error {
+	alerts, err := e.alertRepo.ListActive()
+	if err != nil {
+		return err
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	var symbols []string
+	for _, a := range alerts {
+		if !seen[a.Symbol] {
+			seen[a.Symbol] = true
+			symbols = append(symbols, a.Symbol)
+		}
+	}
+	snapshots, err := e.broker.GetSnapshots(ctx, symbols)
+	if err != nil {
+		return err
+	}
+
+	now := e.now()
+	for i := range alerts {
+		a := &alerts[i]
+		price, change, ok := quote(snapshots[a.Symbol])
+		if !ok {
+			continue
+		}
+		holds := met(a, price, change)
+		if holds == a.Triggered {
+			continue
+		}
+		a.Triggered = holds
+		if holds {
+			a.LastTriggeredAt = &now
+			a.Active = a.Recurring
+		}
+		// the state is saved first, so an alert is never delivered twice
+		if err := e.alertRepo.UpdateState(a); err != nil {
+			e.log.Warn("Evaluator Error", zap.Int("alert", a.ID), zap.Error(err))
+			continue
+		}
+		if holds {
+			e.deliver(ctx, a, price, change, now)
+		}
+	}
+	return nil
+}
+
+// deliver sends a triggered alert to its user and records it in the history
+func (e *Evaluator) deliver(ctx context.Context, a *model.Alert, price, change float64, now time.Time) {
+	event := &model.AlertEvent{
+		AlertID:       a.ID,
+		UserID:        a.UserID,
+		Symbol:        a.Symbol,
+		Condition:     a.Condition,
+		Threshold:     a.Threshold,
+		Channel:       a.Channel,
+		Price:         price,
+		ChangePercent: change,
+		Status:        model.AlertSent,
+	}
+	if err := e.send(ctx, a, price, change, now); err == errRateLimited {
+		event.Status = model.AlertRateLimited
+	} else if err != nil {
+		e.log.Warn("Evaluator Error", zap.Int("alert", a.ID), zap.Error(err))
+		event.Status = model.AlertFailed
+		event.Error = err.Error()
+	}
+	if err := e.alertRepo.CreateEvent(event); err != nil {
+		e.log.Warn("Evaluator Error", zap.Int("alert", a.ID), zap.Error(err))
+	}
+}
+
+// errRateLimited is returned for the alerts of users who reached the rate limit
+var errRateLimited = errors.New("alerts: rate limited")
+
+// send sends a triggered alert on its channel, unless its user reached the rate limit
+func (e *Evaluator) send(ctx context.Context, a *model.Alert, price, change float64, now time.Time) error {
+	sent, err := e.alertRepo.CountSent(a.UserID, now.Add(-e.cfg.RateWindow))
+	if err != nil {
+		return err
+	}
+	if sent >= e.cfg.RateLimit {
+		return errRateLimited
+	}
+	title, body := message(a, price, change)
+	return e.notifier.Notify(ctx, &model.Notification{
+		UserID:   a.UserID,
+		Category: model.NotifyAlerts,
+		Title:    title,
+		Body:     body,
+		Data:     map[string]string{"type": "alert", "alert_id": strconv.Itoa(a.ID), "symbol": a.Symbol},
+	}, a.Channel)
+}
+
+// quote returns the last price of a snapshot and its change percent on the day
+func quote(s *broker.Snapshot) (price, change float64, ok bool) {
+	if s == nil {
+		return 0, 0, false
+	}
+	switch {
+	case s.LatestTrade != nil && s.LatestTrade.Price > 0:
+		price = s.LatestTrade.Price
+	case s.DailyBar != nil && s.DailyBar.Close > 0:
+		price = s.DailyBar.Close
+	default:
+		return 0, 0, false
+	}
+	if s.PrevDailyBar != nil && s.PrevDailyBar.Close > 0 {
+		change = (price - s.PrevDailyBar.Close) / s.PrevDailyBar.Close * 100
+	}
+	return price, change, true
+}
+
+// met reports whether the condition of a holds at price and change
+func met(a *model.Alert, price, change float64) bool {
+	switch a.Condition {
+	case model.AlertAbove:
+		return price >= a.Threshold
+	case model.AlertBelow:
+		return price <= a.Threshold
+	case model.AlertChangeUp:
+		return change >= a.Threshold
+	case model.AlertChangeDown:
+		return change <= -a.Threshold
+	}
+	return false
+}
+
+// message returns the title and body of a triggered alert
+func message(a *model.Alert, price, change float64) (title, body string) {
+	switch a.Condition {
+	case model.AlertAbove, model.AlertBelow:
+		title = fmt.Sprintf("%s is %s $%.2f", a.Symbol, a.Condition, a.Threshold)
+		body = fmt.Sprintf("%s is at $%.2f, %s your alert at $%.2f.", a.Symbol, price, a.Condition, a.Threshold)
+	default:
+		direction := "up"
+		if change < 0 {
+			direction, change = "down", -change
+		}
+		title = fmt.Sprintf("%s is %s %.2f%% today", a.Symbol, direction, change)
+		body = fmt.Sprintf("%s is at $%.2f, past your alert at %g%%.", a.Symbol, price, a.Threshold)
+	}
+	return title, body
+}
@@ -0,0 +1,133 @@
+package alerts_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/mock"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/alerts"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// book is an in-memory alerts table and history
+type book struct {
+	alerts []model.Alert
+	events []model.AlertEvent
+}
+
+func (b *book) repo() *mockdb.Alert {
+	return &mockdb.Alert{
+		ListActiveFn: func() ([]model.Alert, error) {
+			var res []model.Alert
+			for _, a := range b.alerts {
+				if a.Active {
+					res = append(res, a)
+				}
+			}
+			return res, nil
+		},
+		UpdateStateFn: func(a *model.Alert) error {
+			for i := range b.alerts {
+				if b.alerts[i].ID == a.ID {
+					b.alerts[i].Active, b.alerts[i].Triggered, b.alerts[i].LastTriggeredAt = a.Active, a.Triggered, a.LastTriggeredAt
+				}
+			}
+			return nil
+		},
+		CreateEventFn: func(e *model.AlertEvent) error {
+			b.events = append(b.events, *e)
+			return nil
+		},
+		CountSentFn: func(userID int, since time.Time) (int, error) {
+			n := 0
+			for _, e := range b.events {
+				if e.UserID == userID && e.Status == model.AlertSent {
+					n++
+				}
+			}
+			return n, nil
+		},
+	}
+}
+
+func (b *book) statuses() []string {
+	res := []string{}
+	for _, e := range b.events {
+		res = append(res, e.Status)
+	}
+	return res
+}
+
+func TestEvaluator(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.SetBars("AAPL", []broker.Bar{
+		{Timestamp: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), Close: 100},
+		{Timestamp: time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC), Close: 100},
+	})
+	s.SetPrice("AAPL", 100)
+
+	b := &book{alerts: []model.Alert{
+		{ID: 1, UserID: 1, Symbol: "AAPL", Condition: model.AlertAbove, Threshold: 110, Channel: model.AlertEmail, Active: true},
+		{ID: 2, UserID: 1, Symbol: "AAPL", Condition: model.AlertChangeDown, Threshold: 5, Channel: model.AlertSMS, Recurring: true, Active: true},
+		{ID: 3, UserID: 2, Symbol: "AAPL", Condition: model.AlertAbove, Threshold: 105, Channel: model.AlertPush, Recurring: true, Active: true},
+	}}
+	var sent []string
+	pushFails := true
+	notifier := &mock.Notifier{NotifyFn: func(ctx context.Context, n *model.Notification, channels ...string) error {
+		assert.Equal(t, model.NotifyAlerts, n.Category)
+		assert.Equal(t, "AAPL", n.Data["symbol"])
+		if channels[0] == model.AlertPush && pushFails {
+			return errors.New("unregistered")
+		}
+		text := n.Title
+		if channels[0] == model.AlertSMS {
+			text = n.Body
+		}
+		sent = append(sent, fmt.Sprintf("%s %d: %s", channels[0], n.UserID, text))
+		return nil
+	}}
+	cfg := &config.AlertsConfig{Interval: time.Minute, RateLimit: 2, RateWindow: time.Hour}
+	e := alerts.NewEvaluator(b.repo(), s.Broker(), notifier, cfg, zap.NewNop())
+	ctx := context.Background()
+
+	assert.Nil(t, e.RunOnce(ctx))
+	assert.Empty(t, sent)
+
+	// the one-shot alert triggers once, and a failed delivery is recorded
+	s.SetPrice("AAPL", 111)
+	assert.Nil(t, e.RunOnce(ctx))
+	assert.Equal(t, []string{"email 1: AAPL is above $110.00"}, sent)
+	assert.Equal(t, []string{model.AlertSent, model.AlertFailed}, b.statuses())
+	assert.Equal(t, "unregistered", b.events[1].Error)
+	assert.False(t, b.alerts[0].Active)
+	assert.True(t, b.alerts[2].Active)
+	assert.Nil(t, e.RunOnce(ctx))
+	assert.Len(t, b.events, 2)
+
+	// recurring alerts trigger again once their condition stopped holding
+	pushFails = false
+	s.SetPrice("AAPL", 94)
+	assert.Nil(t, e.RunOnce(ctx))
+	assert.Equal(t, "sms 1: AAPL is at $94.00, past your alert at 5%.", sent[1])
+	s.SetPrice("AAPL", 106)
+	assert.Nil(t, e.RunOnce(ctx))
+	assert.Equal(t, "push 2: AAPL is above $105.00", sent[2])
+
+	// users are rate limited
+	s.SetPrice("AAPL", 90)
+	assert.Nil(t, e.RunOnce(ctx))
+	assert.Len(t, sent, 3)
+	assert.Equal(t, []string{model.AlertSent, model.AlertFailed, model.AlertSent, model.AlertSent, model.AlertRateLimited}, b.statuses())
+	assert.Equal(t, -10.0, b.events[4].ChangePercent)
+}
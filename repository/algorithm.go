@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9/orm"
+	"go.uber.org/zap"
+)
+
+// NewAlgorithmRepo returns a new AlgorithmRepo instance
+func NewAlgorithmRepo(db orm.DB, log *zap.Logger) *AlgorithmRepo {
+	return &AlgorithmRepo{db, log}
+}
+
+// AlgorithmRepo is the client for our algorithm model
+type AlgorithmRepo struct {
+	db  orm.DB
+	log *zap.Logger
+}
+
+// Create creates a new algorithm, rejecting duplicate names
+func (a *AlgorithmRepo) Create(algorithm *model.Algorithm) (*model.Algorithm, error) {
+	n, err := a.db.Model((*model.Algorithm)(nil)).Where("name = ?", algorithm.Name).Where(notDeleted).Count()
+	if err != nil {
+		a.log.Warn("AlgorithmRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	if n != 0 {
+		return nil, apperr.New(http.StatusBadRequest, "Algorithm already exists.")
+	}
+	if err := a.db.Insert(algorithm); err != nil {
+		a.log.Warn("AlgorithmRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return algorithm, nil
+}
+
+// View returns single algorithm by ID
+func (a *AlgorithmRepo) View(id int) (*model.Algorithm, error) {
+	algorithm := new(model.Algorithm)
+	err := a.db.Model(algorithm).Where("id = ?", id).Where(notDeleted).Select()
+	if err != nil {
+		a.log.Warn("AlgorithmRepo Error", zap.Error(err))
+		return nil, apperr.New(http.StatusNotFound, "Algorithm not found.")
+	}
+	return algorithm, nil
+}
+
+// List returns the algorithms whose name or description matches query, ordered by name
+func (a *AlgorithmRepo) List(query string, p *model.Pagination) ([]model.Algorithm, error) {
+	var algorithms []model.Algorithm
+	q := a.db.Model(&algorithms).Where(notDeleted).Order("name asc").Limit(p.Limit).Offset(p.Offset)
+	if query != "" {
+		q.WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			return q.Where("name ILIKE ?", "%"+query+"%").WhereOr("description ILIKE ?", "%"+query+"%"), nil
+		})
+	}
+	if err := q.Select(); err != nil {
+		a.log.Warn("AlgorithmRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return algorithms, nil
+}
+
+// Update updates an algorithm's name, description, strategy, parameters, symbols, interval
+// and kill switch
+func (a *AlgorithmRepo) Update(algorithm *model.Algorithm) (*model.Algorithm, error) {
+	_, err := a.db.Model(algorithm).Column(
+		"name",
+		"description",
+		"strategy",
+		"parameters",
+		"symbols",
+		"interval",
+		"active",
+		"updated_at",
+	).WherePK().Update()
+	if err != nil {
+		a.log.Warn("AlgorithmRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return algorithm, nil
+}
+
+// Delete sets deleted_at for an algorithm
+func (a *AlgorithmRepo) Delete(algorithm *model.Algorithm) error {
+	algorithm.Delete()
+	_, err := a.db.Model(algorithm).Column("deleted_at").WherePK().Update()
+	if err != nil {
+		a.log.Warn("AlgorithmRepo Error", zap.Error(err))
+	}
+	return err
+}
+
+// ListActive returns the algorithms whose kill switch is off
+func (a *AlgorithmRepo) ListActive() ([]model.Algorithm, error) {
+	var algorithms []model.Algorithm
+	err := a.db.Model(&algorithms).Where("active = ?", true).Where(notDeleted).Order("id asc").Select()
+	if err != nil {
+		a.log.Warn("AlgorithmRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return algorithms, nil
+}
+
+// UpdateRun records when an algorithm last ran
+func (a *AlgorithmRepo) UpdateRun(algorithm *model.Algorithm) error {
+	_, err := a.db.Model(algorithm).Column("last_run_at").WherePK().Update()
+	if err != nil {
+		a.log.Warn("AlgorithmRepo Error", zap.Error(err))
+		return apperr.DB
+	}
+	return nil
+}
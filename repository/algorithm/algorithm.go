@@ -0,0 +1,167 @@
+package algorithm
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/platform/structs"
+	"github.com/zcoriarty/Backend/risk"
+	"github.com/zcoriarty/Backend/strategy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultInterval is the number of minutes between runs of an algorithm created without one
+const defaultInterval = 60
+
+// NewAlgorithmService creates a new algorithm application service
+func NewAlgorithmService(userRepo model.UserRepo, algorithmRepo model.AlgorithmRepo, budgetRepo model.BudgetRepo, rbac model.RBACService, brk broker.Service) *Service {
+	return &Service{
+		userRepo:      userRepo,
+		algorithmRepo: algorithmRepo,
+		budgetRepo:    budgetRepo,
+		rbac:          rbac,
+		broker:        brk,
+		risk:          risk.New(brk, risk.Default...),
+	}
+}
+
+// Service represents the algorithm application service
+type Service struct {
+	userRepo      model.UserRepo
+	algorithmRepo model.AlgorithmRepo
+	budgetRepo    model.BudgetRepo
+	rbac          model.RBACService
+	broker        broker.Service
+	risk          *risk.Checker
+}
+
+// Create creates a new algorithm. Only admins may create algorithms.
+func (s *Service) Create(c *gin.Context, a *model.Algorithm) (*model.Algorithm, error) {
+	if !s.rbac.EnforceRole(c, model.AdminRole) {
+		return nil, apperr.New(http.StatusForbidden, "Forbidden")
+	}
+	if err := validate(a); err != nil {
+		return nil, err
+	}
+	if a.Interval == 0 {
+		a.Interval = defaultInterval
+	}
+	a.Active = true
+	return s.algorithmRepo.Create(a)
+}
+
+// List returns list of algorithms matching query
+func (s *Service) List(c *gin.Context, query string, p *model.Pagination) ([]model.Algorithm, error) {
+	return s.algorithmRepo.List(query, p)
+}
+
+// View returns single algorithm
+func (s *Service) View(c *gin.Context, id int) (*model.Algorithm, error) {
+	return s.algorithmRepo.View(id)
+}
+
+// Update contains algorithm's information used for updating
+type Update struct {
+	ID          int
+	Name        *string
+	Description *string
+	Strategy    *string
+	Parameters  map[string]interface{} `structs:"-"`
+	Symbols     []string               `structs:"-"`
+	Interval    *int
+}
+
+// Update updates an algorithm. Only admins may update algorithms.
+func (s *Service) Update(c *gin.Context, update *Update) (*model.Algorithm, error) {
+	if !s.rbac.EnforceRole(c, model.AdminRole) {
+		return nil, apperr.New(http.StatusForbidden, "Forbidden")
+	}
+	a, err := s.algorithmRepo.View(update.ID)
+	if err != nil {
+		return nil, err
+	}
+	structs.Merge(a, update)
+	if update.Parameters != nil {
+		a.Parameters = update.Parameters
+	} else if update.Strategy != nil {
+		// the parameters of the previous strategy do not apply to the new one
+		a.Parameters = nil
+	}
+	if update.Symbols != nil {
+		a.Symbols = update.Symbols
+	}
+	if err := validate(a); err != nil {
+		return nil, err
+	}
+	return s.algorithmRepo.Update(a)
+}
+
+// Delete deletes an algorithm. Only admins may delete algorithms.
+func (s *Service) Delete(c *gin.Context, id int) error {
+	if !s.rbac.EnforceRole(c, model.AdminRole) {
+		return apperr.New(http.StatusForbidden, "Forbidden")
+	}
+	a, err := s.algorithmRepo.View(id)
+	if err != nil {
+		return err
+	}
+	return s.algorithmRepo.Delete(a)
+}
+
+// Halt trips the kill switch of an algorithm: it places no more orders and the open orders
+// it placed for every user are canceled. Only admins may halt algorithms.
+func (s *Service) Halt(c *gin.Context, id int) (*model.Algorithm, error) {
+	if !s.rbac.EnforceRole(c, model.AdminRole) {
+		return nil, apperr.New(http.StatusForbidden, "Forbidden")
+	}
+	a, err := s.algorithmRepo.View(id)
+	if err != nil {
+		return nil, err
+	}
+	a.Active = false
+	if a, err = s.algorithmRepo.Update(a); err != nil {
+		return nil, err
+	}
+	subscribers, err := s.budgetRepo.Subscribers(id)
+	if err != nil {
+		return nil, err
+	}
+	// one user's broker error must not leave the orders of the others open
+	var failed error
+	for _, l := range subscribers {
+		if err := s.cancelOpen(c.Request.Context(), l.UserID, id); err != nil && failed == nil {
+			failed = err
+		}
+	}
+	if failed != nil {
+		return nil, failed
+	}
+	return a, nil
+}
+
+// Resume turns the kill switch of an algorithm back off. Only admins may resume algorithms.
+func (s *Service) Resume(c *gin.Context, id int) (*model.Algorithm, error) {
+	if !s.rbac.EnforceRole(c, model.AdminRole) {
+		return nil, apperr.New(http.StatusForbidden, "Forbidden")
+	}
+	a, err := s.algorithmRepo.View(id)
+	if err != nil {
+		return nil, err
+	}
+	a.Active = true
+	return s.algorithmRepo.Update(a)
+}
+
+// validate checks the parameters of an algorithm against its strategy's schema and fills in
+// their defaults
+func validate(a *model.Algorithm) error {
+	params, err := strategy.Validate(a.Strategy, a.Parameters)
+	if err != nil {
+		return apperr.New(http.StatusBadRequest, err.Error())
+	}
+	a.Parameters = params.Map()
+	return nil
+}
@@ -0,0 +1,238 @@
+package algorithm
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Allocate sets the amount the current user allocates to an algorithm
+func (s *Service) Allocate(c *gin.Context, algorithmID int, amount float64) (*model.InvestmentLimit, error) {
+	if _, err := s.algorithmRepo.View(algorithmID); err != nil {
+		return nil, err
+	}
+	return s.budgetRepo.Allocate(c.GetInt("id"), algorithmID, amount)
+}
+
+// Budget returns the current user's budget in an algorithm, settling its closed orders first
+func (s *Service) Budget(c *gin.Context, algorithmID int) (*model.InvestmentLimit, error) {
+	user, err := s.userRepo.View(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	if user.AccountID != "" {
+		if err := s.sync(c.Request.Context(), user, algorithmID); err != nil {
+			return nil, err
+		}
+	}
+	return s.budgetRepo.Limit(user.ID, algorithmID)
+}
+
+// Budgets returns the current user's budgets in every algorithm
+func (s *Service) Budgets(c *gin.Context) ([]model.InvestmentLimit, error) {
+	return s.budgetRepo.Limits(c.GetInt("id"))
+}
+
+// Orders returns the orders an algorithm placed for the current user, settling the ones whose
+// broker order has closed
+func (s *Service) Orders(c *gin.Context, algorithmID int) ([]model.AlgorithmOrder, error) {
+	user, err := s.account(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sync(c.Request.Context(), user, algorithmID); err != nil {
+		return nil, err
+	}
+	return s.budgetRepo.Orders(user.ID, algorithmID, "")
+}
+
+// PlaceOrder submits an order on behalf of an algorithm. Buy orders reserve their estimated
+// cost from the remaining budget, and are refused when the budget cannot cover it.
+func (s *Service) PlaceOrder(c *gin.Context, algorithmID int, req *broker.OrderRequest) (*broker.Order, error) {
+	user, err := s.account(c)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.algorithmRepo.View(algorithmID)
+	if err != nil {
+		return nil, err
+	}
+	if !a.Active {
+		return nil, apperr.New(http.StatusForbidden, "Algorithm is halted.")
+	}
+	return s.place(c.Request.Context(), user, algorithmID, req)
+}
+
+// place submits an order of an algorithm for user, reserving its budget. The order must pass
+// the pre-trade checks first.
+func (s *Service) place(ctx context.Context, user *model.User, algorithmID int, req *broker.OrderRequest) (*broker.Order, error) {
+	if err := s.risk.Review(ctx, user.AccountID, user.MaxOrderSize, req); err != nil {
+		return nil, err
+	}
+
+	o := &model.AlgorithmOrder{
+		UserID:      user.ID,
+		AlgorithmID: algorithmID,
+		Symbol:      req.Symbol,
+		Side:        req.Side,
+	}
+	var err error
+	if req.Side == broker.Buy {
+		if o.Reserved, err = s.cost(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.budgetRepo.Reserve(o); err != nil {
+		return nil, err
+	}
+
+	order, err := s.broker.CreateOrder(ctx, user.AccountID, req)
+	if err != nil {
+		if serr := s.budgetRepo.Settle(o, model.AlgorithmOrderRejected, 0, 0, time.Time{}); serr != nil {
+			return nil, serr
+		}
+		return nil, err
+	}
+	o.OrderID = order.ID
+	if err := s.budgetRepo.Submit(o); err != nil {
+		return nil, err
+	}
+	return order, s.settle(o, order)
+}
+
+// CancelOrder cancels an open order of an algorithm, releasing the budget it reserved
+func (s *Service) CancelOrder(c *gin.Context, algorithmID int, orderID string) error {
+	user, err := s.account(c)
+	if err != nil {
+		return err
+	}
+	orders, err := s.budgetRepo.Orders(user.ID, algorithmID, model.AlgorithmOrderOpen)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		if orders[i].OrderID == orderID {
+			return s.cancel(c.Request.Context(), user, &orders[i])
+		}
+	}
+	return apperr.New(http.StatusNotFound, "Order not found.")
+}
+
+// cancelOpen cancels every open order an algorithm placed for a user
+func (s *Service) cancelOpen(ctx context.Context, userID, algorithmID int) error {
+	user, err := s.userRepo.View(userID)
+	if err != nil {
+		return err
+	}
+	orders, err := s.budgetRepo.Orders(userID, algorithmID, model.AlgorithmOrderOpen)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		if orders[i].OrderID == "" {
+			continue
+		}
+		if err := s.cancel(ctx, user, &orders[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancel cancels the broker order of o and settles it
+func (s *Service) cancel(ctx context.Context, user *model.User, o *model.AlgorithmOrder) error {
+	if err := s.broker.CancelOrder(ctx, user.AccountID, o.OrderID); err != nil {
+		return err
+	}
+	// the order may have been partially filled before it was canceled
+	order, err := s.broker.GetOrder(ctx, user.AccountID, o.OrderID)
+	if err != nil {
+		return err
+	}
+	return s.settle(o, order)
+}
+
+// sync settles the open orders of an algorithm whose broker order has closed
+func (s *Service) sync(ctx context.Context, user *model.User, algorithmID int) error {
+	orders, err := s.budgetRepo.Orders(user.ID, algorithmID, model.AlgorithmOrderOpen)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		if orders[i].OrderID == "" {
+			continue
+		}
+		order, err := s.broker.GetOrder(ctx, user.AccountID, orders[i].OrderID)
+		if err != nil {
+			return err
+		}
+		if err := s.settle(&orders[i], order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settle settles o with the outcome of its broker order once that order has closed, at the
+// time the broker filled it
+func (s *Service) settle(o *model.AlgorithmOrder, order *broker.Order) error {
+	if !order.Closed() {
+		return nil
+	}
+	status := model.AlgorithmOrderFilled
+	switch order.Status {
+	case broker.OrderCanceled, broker.OrderExpired, broker.OrderReplaced:
+		status = model.AlgorithmOrderCanceled
+	case broker.OrderRejected:
+		status = model.AlgorithmOrderRejected
+	}
+	price := 0.0
+	if order.FilledAvgPrice != nil {
+		price = *order.FilledAvgPrice
+	}
+	at := time.Time{}
+	if order.FilledAt != nil {
+		at = *order.FilledAt
+	}
+	return s.budgetRepo.Settle(o, status, order.FilledQty, price, at)
+}
+
+// cost estimates the cash a buy order needs: its notional, or its qty at the limit price or
+// the latest trade price
+func (s *Service) cost(ctx context.Context, req *broker.OrderRequest) (float64, error) {
+	if req.Notional != nil {
+		return *req.Notional, nil
+	}
+	if req.Qty == nil {
+		return 0, apperr.New(http.StatusBadRequest, "qty or notional is required.")
+	}
+	if req.LimitPrice != nil {
+		return *req.Qty * *req.LimitPrice, nil
+	}
+	trade, err := s.broker.GetLatestTrade(ctx, req.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	if trade.Trade == nil {
+		return 0, apperr.New(http.StatusBadRequest, "No price available for "+req.Symbol+".")
+	}
+	return *req.Qty * trade.Trade.Price, nil
+}
+
+// account returns the current user, who must have a brokerage account
+func (s *Service) account(c *gin.Context) (*model.User, error) {
+	user, err := s.userRepo.View(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	if user.AccountID == "" {
+		return nil, apperr.New(http.StatusBadRequest, "Account not found.")
+	}
+	return user, nil
+}
@@ -0,0 +1,228 @@
+package algorithm
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/strategy"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// closeBuffer is how long before the close of the session the engine stops placing orders,
+	// so market orders are not left unfilled at the close
+	closeBuffer = 5 * time.Minute
+	// history is the number of trading days of bars strategies are evaluated on
+	history = 250
+	// minNotional is the smallest order, in dollars, the engine places
+	minNotional = 1.0
+	// userTimeout bounds the time spent rebalancing a single user
+	userTimeout = 30 * time.Second
+)
+
+// NewEngine creates the engine running the algorithms of svc, waking up every interval
+func NewEngine(svc *Service, log *zap.Logger, interval time.Duration) *Engine {
+	return &Engine{svc: svc, log: log, interval: interval}
+}
+
+// Engine runs the active algorithms during market hours. A run evaluates the algorithm's
+// strategy on each of its symbols and rebalances the holdings of every user with a budget in
+// it towards the strategy's targets, within that user's budget.
+type Engine struct {
+	svc      *Service
+	log      *zap.Logger
+	interval time.Duration
+}
+
+// Run runs the algorithms that are due every interval until ctx is done
+func (e *Engine) Run(ctx context.Context) {
+	t := time.NewTicker(e.interval)
+	defer t.Stop()
+	for {
+		if err := e.RunOnce(ctx); err != nil {
+			e.log.Warn("Engine Error", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce runs the algorithms that are due, if the market is open
+func (e *Engine) RunOnce(ctx context.Context) error {
+	clock, err := e.svc.broker.GetClock(ctx)
+	if err != nil {
+		return err
+	}
+	if !clock.IsOpen || clock.NextClose.Sub(clock.Timestamp) < closeBuffer {
+		return nil
+	}
+	algorithms, err := e.svc.algorithmRepo.ListActive()
+	if err != nil {
+		return err
+	}
+
+	var start string
+	for i := range algorithms {
+		a := &algorithms[i]
+		if !a.Due(clock.Timestamp) || len(a.Symbols) == 0 {
+			continue
+		}
+		if start == "" {
+			if start, err = e.historyStart(ctx, clock.Timestamp); err != nil {
+				return err
+			}
+		}
+		if err := e.run(ctx, a, start); err != nil {
+			e.log.Warn("Engine Error", zap.Int("algorithm_id", a.ID), zap.Error(err))
+		}
+		ranAt := clock.Timestamp
+		a.LastRunAt = &ranAt
+		if err := e.svc.algorithmRepo.UpdateRun(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// historyStart returns the date of the trading day history trading days before now
+func (e *Engine) historyStart(ctx context.Context, now time.Time) (string, error) {
+	days, err := e.svc.broker.GetCalendar(ctx, now.AddDate(0, 0, -2*history).Format("2006-01-02"), now.Format("2006-01-02"))
+	if err != nil {
+		return "", err
+	}
+	if len(days) == 0 {
+		return now.AddDate(-1, 0, 0).Format("2006-01-02"), nil
+	}
+	if len(days) < history {
+		return days[0].Date, nil
+	}
+	return days[len(days)-history].Date, nil
+}
+
+// run evaluates an algorithm and rebalances every user with a budget in it. Users are
+// rebalanced one at a time, and a failure for one user does not stop the others.
+func (e *Engine) run(ctx context.Context, a *model.Algorithm, start string) error {
+	strat, err := strategy.New(a.Strategy, a.Parameters)
+	if err != nil {
+		return err
+	}
+	targets := map[string]float64{}
+	prices := map[string]float64{}
+	for _, symbol := range a.Symbols {
+		bars, err := broker.ListBars(ctx, e.svc.broker, symbol, broker.MarketDataRequest{
+			Start:     start,
+			Timeframe: "1Day",
+			Limit:     10000,
+		})
+		if err != nil {
+			e.log.Warn("Engine Error", zap.Int("algorithm_id", a.ID), zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		if len(bars) == 0 {
+			continue
+		}
+		prices[symbol] = bars[len(bars)-1].Close
+		if trade, err := e.svc.broker.GetLatestTrade(ctx, symbol); err == nil && trade.Trade != nil {
+			prices[symbol] = trade.Trade.Price
+		}
+		targets[symbol] = math.Max(0, math.Min(1, strat.Target(bars)))
+	}
+
+	subscribers, err := e.svc.budgetRepo.Subscribers(a.ID)
+	if err != nil {
+		return err
+	}
+	for _, l := range subscribers {
+		// the kill switch takes effect between users
+		current, err := e.svc.algorithmRepo.View(a.ID)
+		if err != nil {
+			return err
+		}
+		if !current.Active {
+			return nil
+		}
+		uctx, cancel := context.WithTimeout(ctx, userTimeout)
+		err = e.rebalance(uctx, a, l.UserID, targets, prices)
+		cancel()
+		if err != nil {
+			e.log.Warn("Engine Error", zap.Int("algorithm_id", a.ID), zap.Int("user_id", l.UserID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// rebalance moves a user's holdings in an algorithm towards its targets. The budget is split
+// evenly between the algorithm's symbols, and symbols with an open order are left alone until
+// that order closes.
+func (e *Engine) rebalance(ctx context.Context, a *model.Algorithm, userID int, targets, prices map[string]float64) error {
+	user, err := e.svc.userRepo.View(userID)
+	if err != nil {
+		return err
+	}
+	if user.AccountID == "" {
+		return nil
+	}
+	// settling the orders of previous runs records their fills as trades
+	if err := e.svc.sync(ctx, user, a.ID); err != nil {
+		return err
+	}
+	open, err := e.svc.budgetRepo.Orders(user.ID, a.ID, model.AlgorithmOrderOpen)
+	if err != nil {
+		return err
+	}
+	pending := map[string]bool{}
+	for _, o := range open {
+		pending[o.Symbol] = true
+	}
+	limit, err := e.svc.budgetRepo.Limit(user.ID, a.ID)
+	if err != nil {
+		return err
+	}
+	holdings, err := e.svc.budgetRepo.Holdings(user.ID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	slot := limit.TotalAllowed / float64(len(a.Symbols))
+	remaining := limit.RemainingAmount
+	for _, symbol := range a.Symbols {
+		target, ok := targets[symbol]
+		price, held := prices[symbol], holdings[symbol]
+		if !ok || pending[symbol] || price <= 0 {
+			continue
+		}
+		diff := target*slot - held*price
+
+		req := &broker.OrderRequest{Symbol: symbol, Type: "market", TimeInForce: "day"}
+		switch {
+		case diff >= minNotional:
+			notional := math.Floor(math.Min(diff, remaining)*100) / 100
+			if notional < minNotional {
+				continue
+			}
+			req.Side = broker.Buy
+			req.Notional = &notional
+			remaining -= notional
+		case diff <= -minNotional && held > 0:
+			qty := held
+			if target > 0 {
+				qty = math.Floor(math.Min(held, -diff/price)*1e9) / 1e9
+			}
+			req.Side = broker.Sell
+			req.Qty = &qty
+		default:
+			continue
+		}
+		if _, err := e.svc.place(ctx, user, a.ID, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
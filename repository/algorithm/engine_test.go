@@ -0,0 +1,186 @@
+package algorithm_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/algorithm"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestEngine(t *testing.T) {
+	now := time.Date(2023, 3, 1, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name      string
+		clock     broker.Clock
+		active    bool
+		lastRunAt *time.Time
+		holdings  map[string]float64
+		wantOrder map[string]string // account => side
+		wantRun   bool
+	}{
+		{
+			name:  "Market closed",
+			clock: broker.Clock{Timestamp: now, IsOpen: false, NextClose: now.Add(time.Hour)},
+		},
+		{
+			name:  "Too close to the close",
+			clock: broker.Clock{Timestamp: now, IsOpen: true, NextClose: now.Add(time.Minute)},
+		},
+		{
+			name:      "Not due",
+			clock:     broker.Clock{Timestamp: now, IsOpen: true, NextClose: now.Add(time.Hour)},
+			active:    true,
+			lastRunAt: func() *time.Time { t := now.Add(-10 * time.Minute); return &t }(),
+		},
+		{
+			// the kill switch was tripped after the algorithm was listed
+			name:    "Halted",
+			clock:   broker.Clock{Timestamp: now, IsOpen: true, NextClose: now.Add(time.Hour)},
+			wantRun: true,
+		},
+		{
+			name:      "Buys for every user with a budget",
+			clock:     broker.Clock{Timestamp: now, IsOpen: true, NextClose: now.Add(time.Hour)},
+			active:    true,
+			wantOrder: map[string]string{"acc2": broker.Buy, "acc3": broker.Buy},
+			wantRun:   true,
+		},
+		{
+			name:      "Sells above target",
+			clock:     broker.Clock{Timestamp: now, IsOpen: true, NextClose: now.Add(time.Hour)},
+			active:    true,
+			holdings:  map[string]float64{"AAPL": 10},
+			wantOrder: map[string]string{"acc2": broker.Buy, "acc3": broker.Sell},
+			wantRun:   true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := brokertest.NewServer()
+			defer brk.Close()
+			brk.SetClock(tt.clock)
+			brk.AddAccount("acc2", 10000)
+			brk.AddAccount("acc3", 10000)
+			brk.SetPrice("AAPL", 100)
+			brk.SetBars("AAPL", []broker.Bar{
+				{Timestamp: now.AddDate(0, 0, -2), Open: 95, Close: 98},
+				{Timestamp: now.AddDate(0, 0, -1), Open: 98, Close: 100},
+			})
+			if tt.holdings != nil {
+				// user 3 holds AAPL from an earlier run
+				brk.SetCash("acc3", 9000)
+				q := 10.0
+				if _, err := brk.Broker().CreateOrder(context.Background(), "acc3", &broker.OrderRequest{
+					Symbol: "AAPL", Qty: &q, Side: broker.Buy, Type: "market", TimeInForce: "day",
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var mu sync.Mutex
+			var ran bool
+			var settled []float64
+			userRepo := &mockdb.User{
+				ViewFn: func(id int) (*model.User, error) {
+					// user 1's account is unknown to the broker
+					return &model.User{ID: id, AccountID: map[int]string{1: "acc1", 2: "acc2", 3: "acc3"}[id]}, nil
+				},
+			}
+			alg := model.Algorithm{
+				ID:        1,
+				Strategy:  "buy_and_hold",
+				Symbols:   []string{"AAPL"},
+				Interval:  60,
+				Active:    true,
+				LastRunAt: tt.lastRunAt,
+			}
+			algorithmRepo := &mockdb.Algorithm{
+				ListActiveFn: func() ([]model.Algorithm, error) {
+					return []model.Algorithm{alg}, nil
+				},
+				ViewFn: func(int) (*model.Algorithm, error) {
+					a := alg
+					a.Active = tt.active
+					return &a, nil
+				},
+				UpdateRunFn: func(a *model.Algorithm) error {
+					assert.Equal(t, now, *a.LastRunAt)
+					ran = true
+					return nil
+				},
+			}
+			budgetRepo := &mockdb.Budget{
+				SubscribersFn: func(int) ([]model.InvestmentLimit, error) {
+					return []model.InvestmentLimit{{UserID: 1}, {UserID: 2}, {UserID: 3}}, nil
+				},
+				LimitFn: func(userID, algorithmID int) (*model.InvestmentLimit, error) {
+					if userID == 3 {
+						return &model.InvestmentLimit{TotalAllowed: 500, RemainingAmount: 500}, nil
+					}
+					return &model.InvestmentLimit{TotalAllowed: 1000, RemainingAmount: 1000}, nil
+				},
+				HoldingsFn: func(userID, algorithmID int) (map[string]float64, error) {
+					if userID == 3 && tt.holdings != nil {
+						return tt.holdings, nil
+					}
+					return map[string]float64{}, nil
+				},
+				OrdersFn: func(int, int, string) ([]model.AlgorithmOrder, error) {
+					return nil, nil
+				},
+				ReserveFn: func(*model.AlgorithmOrder) error { return nil },
+				SubmitFn:  func(*model.AlgorithmOrder) error { return nil },
+				SettleFn: func(o *model.AlgorithmOrder, status string, qty, price float64, at time.Time) error {
+					mu.Lock()
+					defer mu.Unlock()
+					assert.Equal(t, model.AlgorithmOrderFilled, status)
+					settled = append(settled, qty)
+					return nil
+				},
+			}
+			svc := algorithm.NewAlgorithmService(userRepo, algorithmRepo, budgetRepo, nil, brk.Broker())
+			engine := algorithm.NewEngine(svc, zap.NewNop(), time.Minute)
+			if err := engine.RunOnce(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tt.wantRun, ran)
+			for _, acc := range []string{"acc2", "acc3"} {
+				orders := brk.Orders(acc)
+				if tt.holdings != nil && acc == "acc3" {
+					orders = orders[1:]
+				}
+				side, ok := tt.wantOrder[acc]
+				if !ok {
+					assert.Empty(t, orders, acc)
+					continue
+				}
+				if assert.Len(t, orders, 1, acc) {
+					assert.Equal(t, side, orders[0].Side, acc)
+				}
+			}
+			assert.Len(t, settled, len(tt.wantOrder))
+		})
+	}
+}
+
+func TestEngineBrokerError(t *testing.T) {
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.Fail(http.MethodGet, "/v1/clock", http.StatusInternalServerError, 2)
+
+	svc := algorithm.NewAlgorithmService(nil, &mockdb.Algorithm{}, nil, nil, brk.Broker())
+	engine := algorithm.NewEngine(svc, zap.NewNop(), time.Minute)
+	assert.Error(t, engine.RunOnce(context.Background()))
+}
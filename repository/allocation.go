@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// NewAllocationRepo returns a new AllocationRepo instance
+func NewAllocationRepo(db *pg.DB, log *zap.Logger) *AllocationRepo {
+	return &AllocationRepo{db, log}
+}
+
+// AllocationRepo is the client for the target allocations of users, one per user
+type AllocationRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// View returns the allocation of a user
+func (r *AllocationRepo) View(userID int) (*model.Allocation, error) {
+	a := new(model.Allocation)
+	err := r.db.Model(a).Where("user_id = ?", userID).Select()
+	if err == pg.ErrNoRows {
+		return nil, apperr.New(http.StatusNotFound, "Allocation not found.")
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return a, nil
+}
+
+// Save creates or replaces the allocation of a user
+func (r *AllocationRepo) Save(a *model.Allocation) error {
+	res, err := r.db.Model(a).Column("targets", "threshold", "min_trade_size", "auto_rebalance", "close_untargeted", "updated_at").
+		Where("user_id = ?user_id").Returning("id, created_at, last_rebalanced_at").Update()
+	if err == nil && res.RowsAffected() == 0 {
+		err = r.db.Insert(a)
+	}
+	return r.error(err)
+}
+
+// Delete deletes the allocation of a user
+func (r *AllocationRepo) Delete(userID int) error {
+	res, err := r.db.Model((*model.Allocation)(nil)).Where("user_id = ?", userID).Delete()
+	if err == nil && res.RowsAffected() == 0 {
+		return apperr.New(http.StatusNotFound, "Allocation not found.")
+	}
+	return r.error(err)
+}
+
+// ListAuto returns the allocations rebalanced on a schedule
+func (r *AllocationRepo) ListAuto() ([]model.Allocation, error) {
+	var allocations []model.Allocation
+	err := r.db.Model(&allocations).Where("auto_rebalance").Order("id").Select()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return allocations, nil
+}
+
+// MarkRebalanced records the time an allocation was last rebalanced
+func (r *AllocationRepo) MarkRebalanced(id int, at time.Time) error {
+	_, err := r.db.Model((*model.Allocation)(nil)).Set("last_rebalanced_at = ?", at).Where("id = ?", id).Update()
+	return r.error(err)
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (r *AllocationRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apperr.APPError); ok {
+		return err
+	}
+	r.log.Warn("AllocationRepo Error", zap.Error(err))
+	return apperr.DB
+}
@@ -0,0 +1,237 @@
+// Package allocations keeps the target allocations of users and rebalances their portfolios
+// to them, through the order service and its pre-trade checks.
+package allocations
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/rebalance"
+	"github.com/zcoriarty/Backend/repository/order"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTargets bounds the number of symbols of an allocation
+const maxTargets = 20
+
+// NewAllocationService creates a new allocation application service
+func NewAllocationService(userRepo model.UserRepo, allocationRepo model.AllocationRepo, budgetRepo model.BudgetRepo, orders *order.Service, brk broker.Service) *Service {
+	return &Service{
+		userRepo:       userRepo,
+		allocationRepo: allocationRepo,
+		budgetRepo:     budgetRepo,
+		orders:         orders,
+		broker:         brk,
+		now:            time.Now,
+	}
+}
+
+// Service represents the allocation application service
+type Service struct {
+	userRepo       model.UserRepo
+	allocationRepo model.AllocationRepo
+	budgetRepo     model.BudgetRepo
+	orders         *order.Service
+	broker         broker.Service
+	now            func() time.Time
+}
+
+// View returns the allocation of the current user
+func (s *Service) View(c *gin.Context) (*model.Allocation, error) {
+	return s.allocationRepo.View(c.GetInt("id"))
+}
+
+// Save creates or replaces the allocation of the current user. Every symbol must be tradable
+// in fractions, as buys are placed in dollars.
+func (s *Service) Save(c *gin.Context, a *model.Allocation) (*model.Allocation, error) {
+	if len(a.Targets) == 0 || len(a.Targets) > maxTargets {
+		return nil, apperr.New(http.StatusBadRequest, "An allocation holds between 1 and 20 symbols.")
+	}
+	seen := map[string]bool{}
+	total := 0.0
+	for _, t := range a.Targets {
+		if seen[t.Symbol] {
+			return nil, apperr.New(http.StatusBadRequest, t.Symbol+" is allocated twice.")
+		}
+		seen[t.Symbol] = true
+		total += t.Weight
+	}
+	if total > 1+1e-9 {
+		return nil, apperr.New(http.StatusBadRequest, "Weights add up to more than 1.")
+	}
+	for _, t := range a.Targets {
+		asset, err := s.broker.GetAsset(c.Request.Context(), t.Symbol)
+		if se, ok := err.(apperr.StatusError); ok && se.HTTPStatus() == http.StatusNotFound || err == nil && !asset.Tradable {
+			return nil, apperr.New(http.StatusBadRequest, "Unknown symbol "+t.Symbol+".")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !asset.Fractionable {
+			return nil, apperr.New(http.StatusBadRequest, t.Symbol+" cannot be traded in fractions.")
+		}
+	}
+	a.UserID = c.GetInt("id")
+	if err := s.allocationRepo.Save(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Delete deletes the allocation of the current user
+func (s *Service) Delete(c *gin.Context) error {
+	return s.allocationRepo.Delete(c.GetInt("id"))
+}
+
+// Preview returns the orders rebalancing the portfolio of the current user to their
+// allocation, without placing them
+func (s *Service) Preview(c *gin.Context) (*rebalance.Plan, error) {
+	user, a, err := s.allocation(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.plan(c.Request.Context(), user, a)
+}
+
+// Submission is an order of a rebalance and the order placed for it, or the reason it was not
+type Submission struct {
+	rebalance.Order
+	Placed *model.Order `json:"placed,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// Result is the plan of a rebalance and its orders as submitted
+type Result struct {
+	Plan      *rebalance.Plan `json:"plan"`
+	Submitted []Submission    `json:"submitted"`
+}
+
+// Rebalance places the orders rebalancing the portfolio of the current user to their
+// allocation. Orders placed with an idempotency key are placed once, with the key suffixed
+// by their symbol.
+func (s *Service) Rebalance(c *gin.Context, key string) (*Result, error) {
+	user, a, err := s.allocation(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.rebalance(c.Request.Context(), user, a, key)
+}
+
+// rebalance places the orders of the plan of a, sells first so that they fund the buys. Orders
+// refused by the pre-trade checks are reported and do not stop the others.
+func (s *Service) rebalance(ctx context.Context, user *model.User, a *model.Allocation, key string) (*Result, error) {
+	plan, err := s.plan(ctx, user, a)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Plan: plan, Submitted: make([]Submission, len(plan.Orders))}
+	placed := false
+	for i, o := range plan.Orders {
+		req := &broker.OrderRequest{Symbol: o.Symbol, Side: o.Side, Type: "market", TimeInForce: "day"}
+		if o.Side == broker.Sell {
+			qty := o.Qty
+			req.Qty = &qty
+		} else {
+			notional := o.Notional
+			req.Notional = &notional
+		}
+		k := key
+		if k != "" {
+			k += ":" + o.Symbol
+		}
+		res.Submitted[i].Order = o
+		if res.Submitted[i].Placed, err = s.orders.Place(ctx, user, k, req); err != nil {
+			res.Submitted[i].Error = err.Error()
+			continue
+		}
+		placed = true
+	}
+	if placed {
+		now := s.now()
+		if err := s.allocationRepo.MarkRebalanced(a.ID, now); err != nil {
+			return nil, err
+		}
+		a.LastRebalancedAt = &now
+	}
+	return res, nil
+}
+
+// plan plans the rebalance of the long positions and cash of user to a. The shares held by the
+// algorithms the user invests in and the cash left in their budgets are theirs to trade, so
+// they are left out of the portfolio.
+func (s *Service) plan(ctx context.Context, user *model.User, a *model.Allocation) (*rebalance.Plan, error) {
+	account, err := s.broker.GetTradingAccount(ctx, user.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := s.broker.ListPositions(ctx, user.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	algorithms, budget, err := s.algorithms(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	holdings := make([]rebalance.Holding, 0, len(positions))
+	for _, p := range positions {
+		if qty := p.Qty - algorithms[p.Symbol]; qty > 0 {
+			holdings = append(holdings, rebalance.Holding{Symbol: p.Symbol, Qty: qty, Price: p.CurrentPrice})
+		}
+	}
+	targets := make([]rebalance.Target, len(a.Targets))
+	for i, t := range a.Targets {
+		targets[i] = rebalance.Target(t)
+	}
+	plan, err := rebalance.Compute(holdings, math.Max(0, account.Cash-budget), targets, rebalance.Config{
+		Threshold:       a.Threshold,
+		MinTrade:        a.MinTradeSize,
+		CloseUntargeted: a.CloseUntargeted,
+	})
+	if err == rebalance.ErrWeights {
+		return nil, apperr.New(http.StatusBadRequest, "Weights add up to more than 1.")
+	}
+	return plan, err
+}
+
+// algorithms returns the shares held by the algorithms userID invests in, by symbol, and the
+// cash of their budgets not invested yet, including the cash reserved by their open orders
+func (s *Service) algorithms(userID int) (map[string]float64, float64, error) {
+	limits, err := s.budgetRepo.Limits(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	held, budget := map[string]float64{}, 0.0
+	for _, l := range limits {
+		h, err := s.budgetRepo.Holdings(userID, l.AlgorithmID)
+		if err != nil {
+			return nil, 0, err
+		}
+		for sym, qty := range h {
+			held[sym] += qty
+		}
+		budget += math.Max(0, l.TotalAllowed-l.TotalInvested)
+	}
+	return held, budget, nil
+}
+
+// allocation returns the current user, who must have a brokerage account, and their allocation
+func (s *Service) allocation(c *gin.Context) (*model.User, *model.Allocation, error) {
+	user, err := s.userRepo.View(c.GetInt("id"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.AccountID == "" {
+		return nil, nil, apperr.New(http.StatusBadRequest, "Account not found.")
+	}
+	a, err := s.allocationRepo.View(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, a, nil
+}
@@ -0,0 +1,97 @@
+package allocations
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/market"
+	"github.com/zcoriarty/Backend/model"
+
+	"go.uber.org/zap"
+)
+
+// NewScheduler creates the scheduler rebalancing the allocations of svc that opted in
+func NewScheduler(svc *Service, cfg *config.RebalanceConfig, log *zap.Logger) *Scheduler {
+	return &Scheduler{
+		svc: svc,
+		cfg: cfg,
+		log: log,
+	}
+}
+
+// Scheduler rebalances the allocations that opted into automatic rebalancing while the market
+// is open, at most once every MinInterval each. Only the symbols that drifted beyond the
+// threshold of their allocation are traded, so checking often costs no trades.
+type Scheduler struct {
+	svc *Service
+	cfg *config.RebalanceConfig
+	log *zap.Logger
+}
+
+// Run rebalances the allocations every interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.cfg.Interval)
+	defer t.Stop()
+	for {
+		if err := s.RunOnce(ctx); err != nil {
+			s.log.Warn("Scheduler Error", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce rebalances the allocations due once. The failure of an allocation does not stop the
+// others.
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	clock, err := s.svc.broker.GetClock(ctx)
+	if err != nil {
+		return err
+	}
+	if !clock.IsOpen {
+		return nil
+	}
+	allocations, err := s.svc.allocationRepo.ListAuto()
+	if err != nil {
+		return err
+	}
+	now := s.svc.now()
+	for i := range allocations {
+		a := &allocations[i]
+		if a.LastRebalancedAt != nil && now.Sub(*a.LastRebalancedAt) < s.cfg.MinInterval {
+			continue
+		}
+		user, err := s.svc.userRepo.View(a.UserID)
+		if err != nil || user.AccountID == "" {
+			continue
+		}
+		res, err := s.svc.rebalance(ctx, user, a, runKey(a))
+		if err != nil {
+			s.log.Warn("Scheduler Error", zap.Int("allocation_id", a.ID), zap.Error(err))
+			continue
+		}
+		for _, sub := range res.Submitted {
+			if sub.Error != "" {
+				s.log.Info("Rebalance order refused", zap.Int("allocation_id", a.ID), zap.String("symbol", sub.Symbol), zap.String("error", sub.Error))
+			}
+		}
+	}
+	return nil
+}
+
+// runKey is the idempotency key of the next run of a, named after the rebalance it supersedes.
+// A run that fails before it is marked is retried under the same key, so its orders are placed
+// once even across restarts, and every run marked moves the next one to a new key whatever the
+// minimum interval.
+func runKey(a *model.Allocation) string {
+	last := "first"
+	if a.LastRebalancedAt != nil {
+		last = a.LastRebalancedAt.In(market.Location).Format("20060102T150405.000000")
+	}
+	return "rebalance:" + strconv.Itoa(a.ID) + ":" + last
+}
@@ -0,0 +1,84 @@
+package allocations_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/allocations"
+	"github.com/zcoriarty/Backend/repository/order"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestScheduler(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.AddAccount("acc1", 1000)
+	s.AddAccount("acc2", 1000)
+	s.AddAsset(broker.Asset{Symbol: "VTI", Tradable: true, Fractionable: true})
+	s.SetPrice("VTI", 100)
+	s.SetMarketOpen(false)
+
+	recently := time.Now().Add(-time.Hour)
+	list := []model.Allocation{
+		{ID: 1, UserID: 1, Targets: []model.AllocationTarget{{Symbol: "VTI", Weight: 0.5}}, Threshold: 0.05, MinTradeSize: 1, AutoRebalance: true},
+		{ID: 2, UserID: 2, Targets: []model.AllocationTarget{{Symbol: "VTI", Weight: 0.5}}, Threshold: 0.05, MinTradeSize: 1, AutoRebalance: true, LastRebalancedAt: &recently},
+	}
+	var rebalanced []int
+	allocationRepo := &mockdb.Allocation{
+		ListAutoFn: func() ([]model.Allocation, error) { return list, nil },
+		MarkRebalancedFn: func(id int, at time.Time) error {
+			rebalanced = append(rebalanced, id)
+			list[id-1].LastRebalancedAt = &at
+			return nil
+		},
+	}
+	userRepo := &mockdb.User{ViewFn: func(id int) (*model.User, error) {
+		return &model.User{ID: id, AccountID: map[int]string{1: "acc1", 2: "acc2"}[id]}, nil
+	}}
+	var keys []string
+	orderRepo := &mockdb.Order{
+		ReserveFn: func(o *model.Order) (*model.Order, error) {
+			keys = append(keys, o.IdempotencyKey)
+			o.ID = len(keys)
+			return nil, nil
+		},
+		UpdateFn: func(o *model.Order) error { return nil },
+	}
+	budgetRepo := &mockdb.Budget{LimitsFn: func(int) ([]model.InvestmentLimit, error) { return nil, nil }}
+	svc := allocations.NewAllocationService(userRepo, allocationRepo, budgetRepo, order.NewOrderService(userRepo, orderRepo, s.Broker()), s.Broker())
+	sc := allocations.NewScheduler(svc, &config.RebalanceConfig{Interval: time.Hour, MinInterval: 24 * time.Hour}, zap.NewNop())
+	ctx := context.Background()
+
+	// nothing trades while the market is closed
+	assert.Nil(t, sc.RunOnce(ctx))
+	assert.Empty(t, s.Orders("acc1"))
+
+	// allocations rebalanced within the minimum interval are skipped
+	s.SetMarketOpen(true)
+	assert.Nil(t, sc.RunOnce(ctx))
+	assert.Equal(t, []int{1}, rebalanced)
+	assert.Len(t, s.Orders("acc1"), 1)
+	assert.Empty(t, s.Orders("acc2"))
+	assert.Equal(t, []string{"rebalance:1:first:VTI"}, keys)
+	assert.InDelta(t, 500, s.Cash("acc1"), 1e-6)
+
+	assert.Nil(t, sc.RunOnce(ctx))
+	assert.Equal(t, []int{1}, rebalanced)
+
+	// the next run of an allocation, due within the same day, is keyed by the run it
+	// supersedes in market time
+	last := time.Date(2026, 3, 2, 2, 30, 0, 0, time.UTC)
+	list[0].LastRebalancedAt = &last
+	s.SetPrice("VTI", 200)
+	assert.Nil(t, sc.RunOnce(ctx))
+	assert.Equal(t, []int{1, 1}, rebalanced)
+	assert.Equal(t, "rebalance:1:20260301T213000.000000:VTI", keys[1])
+}
@@ -111,6 +111,17 @@ func (a *AssetRepo) Search(query string) ([]model.Asset, error) {
 	return assets, nil
 }
 
+// ListTradable returns the active, tradable assets ordered by symbol
+func (a *AssetRepo) ListTradable() ([]model.Asset, error) {
+	var assets []model.Asset
+	err := a.db.Model(&assets).Where("status = ?", "active").Where("tradable = TRUE").Order("symbol ASC").Select()
+	if err != nil {
+		a.log.Warn("AssetRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return assets, nil
+}
+
 func findAndDelete(s []model.Asset, item model.Asset) []model.Asset {
 	index := 0
 	for _, i := range s {
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9/orm"
+	"go.uber.org/zap"
+)
+
+// NewBacktestRepo returns a new BacktestRepo instance
+func NewBacktestRepo(db orm.DB, log *zap.Logger) *BacktestRepo {
+	return &BacktestRepo{db, log}
+}
+
+// BacktestRepo is the client for our backtest model
+type BacktestRepo struct {
+	db  orm.DB
+	log *zap.Logger
+}
+
+// Create stores a backtest run
+func (b *BacktestRepo) Create(backtest *model.Backtest) (*model.Backtest, error) {
+	if err := b.db.Insert(backtest); err != nil {
+		b.log.Warn("BacktestRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return backtest, nil
+}
+
+// View returns single backtest by ID, with its equity curve and trades
+func (b *BacktestRepo) View(id int) (*model.Backtest, error) {
+	backtest := new(model.Backtest)
+	err := b.db.Model(backtest).Where("id = ?", id).Where(notDeleted).Select()
+	if err != nil {
+		b.log.Warn("BacktestRepo Error", zap.Error(err))
+		return nil, apperr.New(http.StatusNotFound, "Backtest not found.")
+	}
+	return backtest, nil
+}
+
+// List returns the backtests of a user, newest first. The equity curve and trades are left out
+// so runs can be compared cheaply.
+func (b *BacktestRepo) List(userID int, p *model.Pagination) ([]model.Backtest, error) {
+	var backtests []model.Backtest
+	err := b.db.Model(&backtests).ExcludeColumn("equity_curve", "trades").
+		Where("user_id = ?", userID).Where(notDeleted).
+		Order("id desc").Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil {
+		b.log.Warn("BacktestRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return backtests, nil
+}
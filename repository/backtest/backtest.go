@@ -0,0 +1,118 @@
+package backtest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/backtest"
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/strategy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tradingDays annualizes the daily returns of backtests
+const tradingDays = 252
+
+// NewBacktestService creates a new backtest application service
+func NewBacktestService(backtestRepo model.BacktestRepo, brk broker.Service, bars benchmark.Bars) *Service {
+	return &Service{
+		backtestRepo: backtestRepo,
+		broker:       brk,
+		bars:         bars,
+	}
+}
+
+// Service represents the backtest application service
+type Service struct {
+	backtestRepo model.BacktestRepo
+	broker       broker.Service
+	bars         benchmark.Bars
+}
+
+// Run backtests a strategy over the daily bars of b.Symbol between b.StartDate and b.EndDate,
+// and stores the result for the current user
+func (s *Service) Run(c *gin.Context, b *model.Backtest) (*model.Backtest, error) {
+	if !b.EndDate.After(b.StartDate) {
+		return nil, apperr.New(http.StatusBadRequest, "end_date must be after start_date.")
+	}
+	params, err := strategy.Validate(b.Strategy, b.Parameters)
+	if err != nil {
+		return nil, apperr.New(http.StatusBadRequest, err.Error())
+	}
+	b.Parameters = params.Map()
+	strat, err := strategy.New(b.Strategy, b.Parameters)
+	if err != nil {
+		return nil, apperr.New(http.StatusBadRequest, err.Error())
+	}
+	bars, err := broker.ListBars(c.Request.Context(), s.broker, b.Symbol, broker.MarketDataRequest{
+		Start:     b.StartDate.Format(time.RFC3339),
+		End:       b.EndDate.Format(time.RFC3339),
+		Timeframe: "1Day",
+		Limit:     10000,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := backtest.Run(strat, bars, backtest.Config{
+		InitialCash:    b.InitialCash,
+		Slippage:       b.Slippage,
+		Commission:     b.Commission,
+		CommissionRate: b.CommissionRate,
+	})
+	if err == backtest.ErrNoBars {
+		return nil, apperr.New(http.StatusBadRequest, "No bars for "+b.Symbol+" in this period.")
+	}
+	if err != nil {
+		return nil, apperr.New(http.StatusBadRequest, err.Error())
+	}
+
+	b.UserID = c.GetInt("id")
+	b.TotalReturn = res.TotalReturn
+	b.MaxDrawdown = res.MaxDrawdown
+	b.Sharpe = res.Sharpe
+	b.WinRate = res.WinRate
+	b.EquityCurve = make([]model.EquityPoint, len(res.EquityCurve))
+	for i, p := range res.EquityCurve {
+		b.EquityCurve[i] = model.EquityPoint{Time: p.Time, Equity: p.Equity}
+	}
+	b.Trades = make([]model.BacktestTrade, len(res.Trades))
+	for i, t := range res.Trades {
+		b.Trades[i] = model.BacktestTrade(t)
+	}
+	return s.backtestRepo.Create(b)
+}
+
+// List returns the current user's backtests
+func (s *Service) List(c *gin.Context, p *model.Pagination) ([]model.Backtest, error) {
+	return s.backtestRepo.List(c.GetInt("id"), p)
+}
+
+// View returns a backtest of the current user
+func (s *Service) View(c *gin.Context, id int) (*model.Backtest, error) {
+	b, err := s.backtestRepo.View(id)
+	if err != nil {
+		return nil, err
+	}
+	if b.UserID != c.GetInt("id") {
+		return nil, apperr.New(http.StatusNotFound, "Backtest not found.")
+	}
+	return b, nil
+}
+
+// Benchmark compares the equity curve of a backtest with the benchmark symbol over the same
+// days
+func (s *Service) Benchmark(c *gin.Context, b *model.Backtest, symbol string) (*benchmark.Comparison, error) {
+	points := make([]benchmark.Point, len(b.EquityCurve))
+	for i, p := range b.EquityCurve {
+		points[i] = benchmark.Point{Day: p.Time, Value: p.Equity}
+	}
+	res, err := benchmark.Against(c.Request.Context(), s.bars, symbol, points, tradingDays)
+	if err == benchmark.ErrNoBars {
+		return nil, apperr.New(http.StatusBadRequest, "No bars for "+symbol+" in this period.")
+	}
+	return res, err
+}
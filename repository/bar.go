@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// barLock is the advisory lock class serializing the writes of the bars and ranges of a series,
+// keyed by the hash of its symbol and timeframe
+const barLock = 8005
+
+// NewBarRepo returns a new BarRepo instance
+func NewBarRepo(db *pg.DB, log *zap.Logger) *BarRepo {
+	return &BarRepo{db, log}
+}
+
+// BarRepo is the client for the historical bars store
+type BarRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// List returns the stored bars of symbol and timeframe between start and end, bounds included,
+// oldest first
+func (r *BarRepo) List(symbol, timeframe string, start, end time.Time) ([]model.Bar, error) {
+	var bars []model.Bar
+	err := r.db.Model(&bars).Where("symbol = ?", symbol).Where("timeframe = ?", timeframe).
+		Where(`"timestamp" >= ?`, start).Where(`"timestamp" <= ?`, end).OrderExpr(`"timestamp" ASC`).Select()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return bars, nil
+}
+
+// Ranges returns the ranges of symbol and timeframe overlapping start and end, earliest first
+func (r *BarRepo) Ranges(symbol, timeframe string, start, end time.Time) ([]model.BarRange, error) {
+	var ranges []model.BarRange
+	err := r.db.Model(&ranges).Where("symbol = ?", symbol).Where("timeframe = ?", timeframe).
+		Where("start_at <= ?", end).Where("end_at >= ?", start).Order("start_at asc").Select()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return ranges, nil
+}
+
+// Save replaces the bars of symbol and timeframe between start and end with bars, and records
+// that they are complete for this period, merging it with the ranges it overlaps
+func (r *BarRepo) Save(symbol, timeframe string, start, end time.Time, bars []model.Bar) error {
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?, hashtext(? || ':' || ?))", barLock, symbol, timeframe); err != nil {
+			return err
+		}
+		if _, err := tx.Model((*model.Bar)(nil)).Where("symbol = ?", symbol).Where("timeframe = ?", timeframe).
+			Where(`"timestamp" >= ?`, start).Where(`"timestamp" <= ?`, end).Delete(); err != nil {
+			return err
+		}
+		if len(bars) > 0 {
+			if _, err := tx.Model(&bars).Insert(); err != nil {
+				return err
+			}
+		}
+
+		var ranges []model.BarRange
+		if err := tx.Model(&ranges).Where("symbol = ?", symbol).Where("timeframe = ?", timeframe).
+			Where("start_at <= ?", end).Where("end_at >= ?", start).Select(); err != nil {
+			return err
+		}
+		merged := &model.BarRange{Symbol: symbol, Timeframe: timeframe, StartAt: start, EndAt: end}
+		for _, rg := range ranges {
+			if rg.StartAt.Before(merged.StartAt) {
+				merged.StartAt = rg.StartAt
+			}
+			if rg.EndAt.After(merged.EndAt) {
+				merged.EndAt = rg.EndAt
+			}
+			if _, err := tx.Model(&rg).WherePK().Delete(); err != nil {
+				return err
+			}
+		}
+		return tx.Insert(merged)
+	})
+	return r.error(err)
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (r *BarRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apperr.APPError); ok {
+		return err
+	}
+	r.log.Warn("BarRepo Error", zap.Error(err))
+	return apperr.DB
+}
@@ -0,0 +1,78 @@
+package bars
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/market"
+)
+
+// Timeframes served from the store
+const (
+	Minute         = "1Min"
+	FiveMinutes    = "5Min"
+	FifteenMinutes = "15Min"
+	Hour           = "1Hour"
+	Day            = "1Day"
+)
+
+// frames maps every timeframe served from the store to the stored timeframe it is built from
+var frames = map[string]string{
+	Minute:         Minute,
+	FiveMinutes:    Minute,
+	FifteenMinutes: Minute,
+	Hour:           Minute,
+	Day:            Day,
+}
+
+// Start returns the start of the bar of timeframe t falls in. Daily bars start at the midnight
+// of the market.
+func Start(t time.Time, timeframe string) time.Time {
+	switch timeframe {
+	case FiveMinutes:
+		return t.Truncate(5 * time.Minute)
+	case FifteenMinutes:
+		return t.Truncate(15 * time.Minute)
+	case Hour:
+		return t.Truncate(time.Hour)
+	case Day:
+		t = t.In(market.Location)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, market.Location)
+	}
+	return t.Truncate(time.Minute)
+}
+
+// Aggregate aggregates 1-minute bars, oldest first, into bars of timeframe. The VWAP of an
+// aggregate is weighted by the volume of its bars.
+func Aggregate(bars []broker.Bar, timeframe string) ([]broker.Bar, error) {
+	if _, ok := frames[timeframe]; !ok {
+		return nil, fmt.Errorf("unsupported timeframe %q", timeframe)
+	}
+	var res []broker.Bar
+	var value float64
+	for _, b := range bars {
+		start := Start(b.Timestamp, timeframe)
+		if len(res) == 0 || !res[len(res)-1].Timestamp.Equal(start) {
+			res = append(res, broker.Bar{Timestamp: start, Open: b.Open, High: b.High, Low: b.Low})
+			value = 0
+		}
+		agg := &res[len(res)-1]
+		if b.High > agg.High {
+			agg.High = b.High
+		}
+		if b.Low < agg.Low {
+			agg.Low = b.Low
+		}
+		agg.Close = b.Close
+		agg.Volume += b.Volume
+		agg.TradeCount += b.TradeCount
+		value += b.VWAP * b.Volume
+		if agg.Volume > 0 {
+			agg.VWAP = value / agg.Volume
+		} else {
+			agg.VWAP = b.Close
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,279 @@
+// Package bars stores historical bars, so that backtests and charts are served locally and see
+// the same bars every time.
+package bars
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/market"
+	"github.com/zcoriarty/Backend/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// pageSize is the number of bars returned by GetBars when no limit is given
+	pageSize = 1000
+	// maxPageSize bounds the number of bars returned by GetBars
+	maxPageSize = 10000
+	// fetchLimit is the page size used to backfill bars from the broker
+	fetchLimit = 10000
+)
+
+// NewStore returns a store of the bars of brk
+func NewStore(brk broker.Service, barRepo model.BarRepo, log *zap.Logger) *Store {
+	return &Store{
+		Service: brk,
+		barRepo: barRepo,
+		log:     log,
+		now:     time.Now,
+	}
+}
+
+// Store is a broker.Service serving the bars of a period from the database. The periods of
+// the request missing from the database are backfilled from the broker first. 1-minute and
+// daily bars are stored, and 5-minute, 15-minute and hourly bars are aggregated from the
+// 1-minute ones. Bars still in progress are fetched every time and never stored.
+//
+// Requests without a start or for other timeframes go to the broker, as does every other call.
+type Store struct {
+	broker.Service
+
+	barRepo model.BarRepo
+	log     *zap.Logger
+	now     func() time.Time
+}
+
+// GetBars returns a page of historical bars of a symbol. The page token of bars served from
+// the store is the offset of the page.
+func (s *Store) GetBars(ctx context.Context, symbol string, p *broker.MarketDataRequest) (*broker.BarsResponse, error) {
+	if p == nil || p.Start == "" || frames[p.Timeframe] == "" {
+		return s.Service.GetBars(ctx, symbol, p)
+	}
+	start, err := ParseStart(p.Start)
+	if err != nil {
+		return nil, apperr.New(http.StatusBadRequest, "Invalid start.")
+	}
+	var end time.Time
+	if p.End != "" {
+		if end, err = ParseEnd(p.End); err != nil {
+			return nil, apperr.New(http.StatusBadRequest, "Invalid end.")
+		}
+	}
+	offset := 0
+	if p.PageToken != "" {
+		if offset, err = strconv.Atoi(p.PageToken); err != nil || offset < 0 {
+			return nil, apperr.New(http.StatusBadRequest, "Invalid page_token.")
+		}
+	}
+	limit := p.Limit
+	if limit <= 0 {
+		limit = pageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	bars, err := s.List(ctx, symbol, p.Timeframe, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if offset > len(bars) {
+		offset = len(bars)
+	}
+	res := &broker.BarsResponse{Symbol: strings.ToUpper(symbol), Bars: bars[offset:]}
+	if len(res.Bars) > limit {
+		res.Bars = res.Bars[:limit]
+		next := strconv.Itoa(offset + limit)
+		res.NextPageToken = &next
+	}
+	return res, nil
+}
+
+// List returns the bars of symbol and timeframe between start and end, bounds included,
+// backfilling the periods missing from the database. A zero end is now.
+func (s *Store) List(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]broker.Bar, error) {
+	base := frames[timeframe]
+	if base == "" {
+		return nil, apperr.New(http.StatusBadRequest, "Unsupported timeframe.")
+	}
+	symbol = strings.ToUpper(symbol)
+	now := s.now()
+	if end.IsZero() || end.After(now) {
+		end = now
+	}
+	if end.Before(start) {
+		return []broker.Bar{}, nil
+	}
+
+	recent, err := s.sync(ctx, symbol, base, start, end)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.barRepo.List(symbol, base, start, end)
+	if err != nil {
+		return nil, err
+	}
+	bars := make([]broker.Bar, 0, len(stored)+len(recent))
+	for _, b := range stored {
+		bars = append(bars, toBar(b))
+	}
+	bars = append(bars, recent...)
+	if timeframe == base {
+		return bars, nil
+	}
+	return Aggregate(bars, timeframe)
+}
+
+// Sync backfills the bars of symbol and timeframe between start and end missing from the
+// database. A zero end is now.
+func (s *Store) Sync(ctx context.Context, symbol, timeframe string, start, end time.Time) error {
+	base := frames[timeframe]
+	if base == "" {
+		return apperr.New(http.StatusBadRequest, "Unsupported timeframe.")
+	}
+	now := s.now()
+	if end.IsZero() || end.After(now) {
+		end = now
+	}
+	if end.Before(start) {
+		return nil
+	}
+	_, err := s.sync(ctx, strings.ToUpper(symbol), base, start, end)
+	return err
+}
+
+// sync fetches the bars of the gaps between the stored ranges of symbol and timeframe, stores
+// the complete ones and returns the ones still in progress
+func (s *Store) sync(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]broker.Bar, error) {
+	// only the bars before the current one are complete and can be stored
+	cutoff := Start(s.now(), timeframe)
+	complete := end
+	if !end.Before(cutoff) {
+		complete = cutoff.Add(-time.Nanosecond)
+	}
+
+	if !complete.Before(start) {
+		ranges, err := s.barRepo.Ranges(symbol, timeframe, start, complete)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range gaps(ranges, start, complete) {
+			bars, err := s.fetch(ctx, symbol, timeframe, g[0], g[1])
+			if err != nil {
+				return nil, err
+			}
+			rows := make([]model.Bar, 0, len(bars))
+			for _, b := range bars {
+				if b.Timestamp.Before(g[0]) || b.Timestamp.After(g[1]) {
+					continue
+				}
+				rows = append(rows, fromBar(symbol, timeframe, b))
+			}
+			if err := s.barRepo.Save(symbol, timeframe, g[0], g[1], rows); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if end.Before(cutoff) {
+		return nil, nil
+	}
+	from := cutoff
+	if from.Before(start) {
+		from = start
+	}
+	return s.fetch(ctx, symbol, timeframe, from, end)
+}
+
+// fetch returns the bars of the broker between start and end
+func (s *Store) fetch(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]broker.Bar, error) {
+	bars, err := broker.ListBars(ctx, s.Service, symbol, broker.MarketDataRequest{
+		Start:     start.UTC().Format(time.RFC3339),
+		End:       end.UTC().Format(time.RFC3339),
+		Timeframe: timeframe,
+		Limit:     fetchLimit,
+	})
+	if err != nil {
+		s.log.Warn("Bars Error", zap.String("symbol", symbol), zap.String("timeframe", timeframe), zap.Error(err))
+		return nil, err
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Timestamp.Before(bars[j].Timestamp) })
+	return bars, nil
+}
+
+// gaps returns the periods between start and end, bounds included, that ranges do not cover
+func gaps(ranges []model.BarRange, start, end time.Time) [][2]time.Time {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].StartAt.Before(ranges[j].StartAt) })
+	var res [][2]time.Time
+	cur := start
+	for _, r := range ranges {
+		if r.StartAt.After(cur) {
+			res = append(res, [2]time.Time{cur, r.StartAt})
+		}
+		if r.EndAt.After(cur) {
+			cur = r.EndAt
+		}
+	}
+	if len(ranges) == 0 || cur.Before(end) {
+		res = append(res, [2]time.Time{cur, end})
+	}
+	return res
+}
+
+// ParseStart parses an RFC 3339 time or a date, which starts at the midnight of that day in
+// the market's time zone
+func ParseStart(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, market.Location)
+}
+
+// ParseEnd parses an RFC 3339 time or a date, which ends at the end of that day in the
+// market's time zone, so that the daily bar of the day is included
+func ParseEnd(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, market.Location)
+	if err != nil {
+		return t, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+func toBar(b model.Bar) broker.Bar {
+	return broker.Bar{
+		Timestamp:  b.Timestamp,
+		Open:       b.Open,
+		High:       b.High,
+		Low:        b.Low,
+		Close:      b.Close,
+		Volume:     b.Volume,
+		TradeCount: b.TradeCount,
+		VWAP:       b.VWAP,
+	}
+}
+
+func fromBar(symbol, timeframe string, b broker.Bar) model.Bar {
+	return model.Bar{
+		Symbol:     symbol,
+		Timeframe:  timeframe,
+		Timestamp:  b.Timestamp,
+		Open:       b.Open,
+		High:       b.High,
+		Low:        b.Low,
+		Close:      b.Close,
+		Volume:     b.Volume,
+		TradeCount: b.TradeCount,
+		VWAP:       b.VWAP,
+	}
+}
@@ -0,0 +1,204 @@
+package bars_test
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/bars"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// memoryBars is an in-memory bars table and its ranges
+type memoryBars struct {
+	sync.Mutex
+	bars   []model.Bar
+	ranges []model.BarRange
+}
+
+func (m *memoryBars) repo() *mockdb.Bar {
+	return &mockdb.Bar{
+		ListFn: func(symbol, timeframe string, start, end time.Time) ([]model.Bar, error) {
+			m.Lock()
+			defer m.Unlock()
+			var res []model.Bar
+			for _, b := range m.bars {
+				if b.Symbol == symbol && b.Timeframe == timeframe && !b.Timestamp.Before(start) && !b.Timestamp.After(end) {
+					res = append(res, b)
+				}
+			}
+			sort.Slice(res, func(i, j int) bool { return res[i].Timestamp.Before(res[j].Timestamp) })
+			return res, nil
+		},
+		RangesFn: func(symbol, timeframe string, start, end time.Time) ([]model.BarRange, error) {
+			m.Lock()
+			defer m.Unlock()
+			var res []model.BarRange
+			for _, r := range m.ranges {
+				if r.Symbol == symbol && r.Timeframe == timeframe && !r.StartAt.After(end) && !r.EndAt.Before(start) {
+					res = append(res, r)
+				}
+			}
+			return res, nil
+		},
+		SaveFn: func(symbol, timeframe string, start, end time.Time, bars []model.Bar) error {
+			m.Lock()
+			defer m.Unlock()
+			kept := bars
+			for _, b := range m.bars {
+				if b.Symbol != symbol || b.Timeframe != timeframe || b.Timestamp.Before(start) || b.Timestamp.After(end) {
+					kept = append(kept, b)
+				}
+			}
+			m.bars = kept
+			merged := model.BarRange{Symbol: symbol, Timeframe: timeframe, StartAt: start, EndAt: end}
+			var ranges []model.BarRange
+			for _, r := range m.ranges {
+				if r.Symbol != symbol || r.Timeframe != timeframe || r.StartAt.After(end) || r.EndAt.Before(start) {
+					ranges = append(ranges, r)
+					continue
+				}
+				if r.StartAt.Before(merged.StartAt) {
+					merged.StartAt = r.StartAt
+				}
+				if r.EndAt.After(merged.EndAt) {
+					merged.EndAt = r.EndAt
+				}
+			}
+			m.ranges = append(ranges, merged)
+			return nil
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	now := time.Now()
+	today := bars.Start(now, bars.Day)
+	var daily []broker.Bar
+	for i := 20; i >= 0; i-- {
+		daily = append(daily, broker.Bar{Timestamp: today.AddDate(0, 0, -i), Open: 1, High: 2, Low: 1, Close: float64(100 - i), Volume: 10})
+	}
+	s.SetBars("AAPL", daily)
+	m := &memoryBars{}
+	store := bars.NewStore(s.Broker(), m.repo(), zap.NewNop())
+	ctx := context.Background()
+	calls := func() int { return s.Calls(http.MethodGet, "/v2/stocks/AAPL/bars") }
+
+	res, err := store.List(ctx, "aapl", bars.Day, today.AddDate(0, 0, -15), today.AddDate(0, 0, -5))
+	assert.Nil(t, err)
+	assert.Len(t, res, 11)
+	assert.Equal(t, 85.0, res[0].Close)
+	assert.Equal(t, 1, calls())
+
+	// stored periods are served locally, and only the missing ones are fetched
+	res, err = store.List(ctx, "AAPL", bars.Day, today.AddDate(0, 0, -12), today.AddDate(0, 0, -8))
+	assert.Nil(t, err)
+	assert.Len(t, res, 5)
+	assert.Equal(t, 1, calls())
+	res, err = store.List(ctx, "AAPL", bars.Day, today.AddDate(0, 0, -20), today.AddDate(0, 0, -5))
+	assert.Nil(t, err)
+	assert.Len(t, res, 16)
+	assert.Equal(t, 2, calls())
+	m.Lock()
+	assert.Len(t, m.ranges, 1)
+	assert.Len(t, m.bars, 16)
+	m.Unlock()
+
+	// today's bar is still in progress, so it is fetched every time without being stored
+	for i := 0; i < 2; i++ {
+		res, err = store.List(ctx, "AAPL", bars.Day, today.AddDate(0, 0, -5), time.Time{})
+		assert.Nil(t, err)
+		assert.Len(t, res, 6)
+		assert.Equal(t, 100.0, res[5].Close)
+	}
+	assert.Equal(t, 5, calls())
+	m.Lock()
+	assert.Len(t, m.bars, 20)
+	m.Unlock()
+
+	// GetBars pages the stored bars
+	all, err := broker.ListBars(ctx, store, "AAPL", broker.MarketDataRequest{
+		Start:     today.AddDate(0, 0, -20).Format(time.RFC3339),
+		End:       today.AddDate(0, 0, -1).Format(time.RFC3339),
+		Timeframe: bars.Day,
+		Limit:     3,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, all, 20)
+	assert.Equal(t, 5, calls())
+}
+
+func TestDateBounds(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	end, err := bars.ParseEnd("2021-03-05")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2021, 3, 6, 0, 0, 0, 0, ny).Add(-time.Nanosecond), end)
+	start, err := bars.ParseStart("2021-03-05")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2021, 3, 5, 0, 0, 0, 0, ny), start)
+	_, err = bars.ParseEnd("03/05/2021")
+	assert.NotNil(t, err)
+
+	s := brokertest.NewServer()
+	defer s.Close()
+	today := bars.Start(time.Now(), bars.Day)
+	var daily []broker.Bar
+	for i := 10; i > 0; i-- {
+		daily = append(daily, broker.Bar{Timestamp: today.AddDate(0, 0, -i), Close: float64(100 - i)})
+	}
+	s.SetBars("AAPL", daily)
+	store := bars.NewStore(s.Broker(), (&memoryBars{}).repo(), zap.NewNop())
+
+	// the bar of the last day is included
+	res, err := store.GetBars(context.Background(), "AAPL", &broker.MarketDataRequest{
+		Start:     today.AddDate(0, 0, -8).Format("2006-01-02"),
+		End:       today.AddDate(0, 0, -4).Format("2006-01-02"),
+		Timeframe: bars.Day,
+	})
+	assert.Nil(t, err)
+	if assert.Len(t, res.Bars, 5) {
+		assert.Equal(t, 92.0, res.Bars[0].Close)
+		assert.Equal(t, 96.0, res.Bars[4].Close)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	start := time.Date(2021, 3, 1, 14, 30, 0, 0, time.UTC)
+	var minutes []broker.Bar
+	for i := 0; i < 12; i++ {
+		p := float64(100 + i)
+		minutes = append(minutes, broker.Bar{Timestamp: start.Add(time.Duration(i) * time.Minute), Open: p, High: p + 1, Low: p - 1, Close: p + 0.5, Volume: 10, TradeCount: 2, VWAP: p})
+	}
+
+	res, err := bars.Aggregate(minutes, bars.FiveMinutes)
+	assert.Nil(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, broker.Bar{Timestamp: start, Open: 100, High: 105, Low: 99, Close: 104.5, Volume: 50, TradeCount: 10, VWAP: 102}, res[0])
+	assert.Equal(t, start.Add(10*time.Minute), res[2].Timestamp)
+	assert.Equal(t, 20.0, res[2].Volume)
+
+	res, err = bars.Aggregate(minutes, bars.Hour)
+	assert.Nil(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, start.Truncate(time.Hour), res[0].Timestamp)
+	assert.Equal(t, 120.0, res[0].Volume)
+
+	res, err = bars.Aggregate(minutes, bars.Day)
+	assert.Nil(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, 111.5, res[0].Close)
+
+	_, err = bars.Aggregate(minutes, "2Min")
+	assert.NotNil(t, err)
+}
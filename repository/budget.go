@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// NewBudgetRepo returns a new BudgetRepo instance
+func NewBudgetRepo(db *pg.DB, log *zap.Logger) *BudgetRepo {
+	return &BudgetRepo{db, log}
+}
+
+// BudgetRepo is the client for the algorithm budget, investment limit and algorithm order
+// models. Every change to an investment limit runs in a transaction holding a row lock on it.
+type BudgetRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// Allocate sets the amount a user allocates to an algorithm, creating the budget on first
+// allocation. A budget cannot be lowered below what is already invested or reserved.
+func (b *BudgetRepo) Allocate(userID, algorithmID int, amount float64) (*model.InvestmentLimit, error) {
+	limit := new(model.InvestmentLimit)
+	err := b.db.RunInTransaction(func(tx *pg.Tx) error {
+		budget := new(model.UserAlgorithmBudget)
+		err := tx.Model(budget).Where("user_id = ? AND algorithm_id = ?", userID, algorithmID).For("UPDATE").Select()
+		switch err {
+		case nil:
+			budget.Amount = amount
+			if _, err := tx.Model(budget).Column("amount", "updated_at").WherePK().Update(); err != nil {
+				return err
+			}
+		case pg.ErrNoRows:
+			budget = &model.UserAlgorithmBudget{UserID: userID, AlgorithmID: algorithmID, Amount: amount}
+			if err := tx.Insert(budget); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		err = lockLimit(tx, limit, userID, algorithmID)
+		switch err {
+		case nil:
+			if amount < limit.TotalInvested+limit.TotalReserved {
+				return apperr.New(http.StatusBadRequest, "Budget cannot be lower than the amount already invested.")
+			}
+			limit.TotalAllowed = amount
+			limit.Recalculate()
+			return updateLimit(tx, limit)
+		case pg.ErrNoRows:
+			*limit = model.InvestmentLimit{UserID: userID, AlgorithmID: algorithmID, TotalAllowed: amount}
+			limit.Recalculate()
+			return tx.Insert(limit)
+		default:
+			return err
+		}
+	})
+	if err != nil {
+		return nil, b.error(err)
+	}
+	return limit, nil
+}
+
+// Limit returns the investment limit of a user in an algorithm
+func (b *BudgetRepo) Limit(userID, algorithmID int) (*model.InvestmentLimit, error) {
+	limit := new(model.InvestmentLimit)
+	err := b.db.Model(limit).Relation("Algorithm").
+		Where("investment_limit.user_id = ? AND investment_limit.algorithm_id = ?", userID, algorithmID).Select()
+	if err == pg.ErrNoRows {
+		return nil, apperr.New(http.StatusNotFound, "No budget allocated to this algorithm.")
+	}
+	if err != nil {
+		return nil, b.error(err)
+	}
+	return limit, nil
+}
+
+// Limits returns the investment limits of a user in every algorithm
+func (b *BudgetRepo) Limits(userID int) ([]model.InvestmentLimit, error) {
+	var limits []model.InvestmentLimit
+	err := b.db.Model(&limits).Relation("Algorithm").
+		Where("investment_limit.user_id = ?", userID).Order("investment_limit.id asc").Select()
+	if err != nil {
+		return nil, b.error(err)
+	}
+	return limits, nil
+}
+
+// Reserve reserves the budget an order needs and records the order as open
+func (b *BudgetRepo) Reserve(o *model.AlgorithmOrder) error {
+	err := b.db.RunInTransaction(func(tx *pg.Tx) error {
+		limit := new(model.InvestmentLimit)
+		if err := lockLimit(tx, limit, o.UserID, o.AlgorithmID); err != nil {
+			if err == pg.ErrNoRows {
+				return apperr.New(http.StatusForbidden, "No budget allocated to this algorithm.")
+			}
+			return err
+		}
+		if o.Reserved > limit.RemainingAmount {
+			return apperr.New(http.StatusForbidden, "Insufficient algorithm budget.")
+		}
+		limit.TotalReserved += o.Reserved
+		limit.Recalculate()
+		if err := updateLimit(tx, limit); err != nil {
+			return err
+		}
+		o.Status = model.AlgorithmOrderOpen
+		return tx.Insert(o)
+	})
+	return b.error(err)
+}
+
+// Submit records the broker order id of an open order
+func (b *BudgetRepo) Submit(o *model.AlgorithmOrder) error {
+	_, err := b.db.Model(o).Column("order_id", "updated_at").WherePK().Update()
+	return b.error(err)
+}
+
+// Settle closes an open order with the given status, releasing its reservation and adding
+// the filled qty at price to the amount invested. The trade of the fill is recorded as
+// executed at at, or now when it is zero. Settling a closed order does nothing.
+func (b *BudgetRepo) Settle(o *model.AlgorithmOrder, status string, qty, price float64, at time.Time) error {
+	err := b.db.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Model(o).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+		if o.Status != model.AlgorithmOrderOpen {
+			return nil
+		}
+
+		limit := new(model.InvestmentLimit)
+		if err := lockLimit(tx, limit, o.UserID, o.AlgorithmID); err != nil {
+			return err
+		}
+		cost := qty * price
+		limit.TotalReserved -= o.Reserved
+		if limit.TotalReserved < 0 {
+			limit.TotalReserved = 0
+		}
+		if o.Side == "sell" {
+			limit.TotalInvested -= cost
+			if limit.TotalInvested < 0 {
+				limit.TotalInvested = 0
+			}
+		} else {
+			limit.TotalInvested += cost
+		}
+		limit.Recalculate()
+		if err := updateLimit(tx, limit); err != nil {
+			return err
+		}
+
+		if qty > 0 {
+			if at.IsZero() {
+				at = time.Now()
+			}
+			trade := &model.Trade{
+				UserID:         o.UserID,
+				AlgorithmID:    o.AlgorithmID,
+				Symbol:         o.Symbol,
+				TradeType:      o.Side,
+				Amount:         qty,
+				ExecutionPrice: price,
+				ExecutedAt:     at,
+			}
+			if err := tx.Insert(trade); err != nil {
+				return err
+			}
+		}
+
+		o.Status = status
+		o.FilledQty = qty
+		o.FilledAvgPrice = price
+		_, err := tx.Model(o).Column("status", "filled_qty", "filled_avg_price", "updated_at").WherePK().Update()
+		return err
+	})
+	return b.error(err)
+}
+
+// Orders returns the orders of a user in an algorithm, newest first. An empty status returns
+// orders of every status.
+func (b *BudgetRepo) Orders(userID, algorithmID int, status string) ([]model.AlgorithmOrder, error) {
+	var orders []model.AlgorithmOrder
+	q := b.db.Model(&orders).Where("user_id = ? AND algorithm_id = ?", userID, algorithmID).Order("id desc")
+	if status != "" {
+		q.Where("status = ?", status)
+	}
+	if err := q.Select(); err != nil {
+		return nil, b.error(err)
+	}
+	return orders, nil
+}
+
+// Subscribers returns the investment limits of every user with a budget in an algorithm
+func (b *BudgetRepo) Subscribers(algorithmID int) ([]model.InvestmentLimit, error) {
+	var limits []model.InvestmentLimit
+	err := b.db.Model(&limits).Where("algorithm_id = ? AND total_allowed > 0", algorithmID).Order("user_id asc").Select()
+	if err != nil {
+		return nil, b.error(err)
+	}
+	return limits, nil
+}
+
+// Holdings returns the qty of each symbol an algorithm holds for a user, from its trades
+func (b *BudgetRepo) Holdings(userID, algorithmID int) (map[string]float64, error) {
+	var rows []struct {
+		Symbol string
+		Qty    float64
+	}
+	_, err := b.db.Query(&rows, `
+		SELECT symbol, SUM(CASE WHEN trade_type = 'sell' THEN -amount ELSE amount END) AS qty
+		FROM trades WHERE user_id = ? AND algorithm_id = ? AND deleted_at IS NULL
+		GROUP BY symbol`, userID, algorithmID)
+	if err != nil {
+		return nil, b.error(err)
+	}
+	res := make(map[string]float64, len(rows))
+	for _, r := range rows {
+		if r.Qty > 0 {
+			res[r.Symbol] = r.Qty
+		}
+	}
+	return res, nil
+}
+
+func lockLimit(tx *pg.Tx, limit *model.InvestmentLimit, userID, algorithmID int) error {
+	return tx.Model(limit).Where("user_id = ? AND algorithm_id = ?", userID, algorithmID).For("UPDATE").Select()
+}
+
+func updateLimit(tx *pg.Tx, limit *model.InvestmentLimit) error {
+	_, err := tx.Model(limit).Column(
+		"total_allowed",
+		"total_invested",
+		"total_reserved",
+		"remaining_amount",
+		"updated_at",
+	).WherePK().Update()
+	return err
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (b *BudgetRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apperr.APPError); ok {
+		return err
+	}
+	b.log.Warn("BudgetRepo Error", zap.Error(err))
+	return apperr.DB
+}
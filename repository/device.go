@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"go.uber.org/zap"
+)
+
+// NewDeviceRepo returns a new DeviceRepo instance
+func NewDeviceRepo(db orm.DB, log *zap.Logger) *DeviceRepo {
+	return &DeviceRepo{db, log}
+}
+
+// DeviceRepo is the client for our device model. Devices are deleted for good, as push tokens
+// are not kept once a user or a provider gave up on them.
+type DeviceRepo struct {
+	db  orm.DB
+	log *zap.Logger
+}
+
+// deviceColumns are the columns of a device updated when its token is registered again
+var deviceColumns = []string{"user_id", "platform", "app_version", "last_seen_at", "updated_at"}
+
+// Register creates a device, or updates the device of its token, which moves to its user
+func (r *DeviceRepo) Register(d *model.Device) (*model.Device, error) {
+	q := r.db.Model(d).OnConflict("(token) DO UPDATE")
+	for _, c := range deviceColumns {
+		q.Set("? = EXCLUDED.?", pg.Ident(c), pg.Ident(c))
+	}
+	_, err := q.Returning("*").Insert()
+	if err != nil {
+		r.log.Warn("DeviceRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return d, nil
+}
+
+// View returns single device by ID
+func (r *DeviceRepo) View(id int) (*model.Device, error) {
+	d := new(model.Device)
+	err := r.db.Model(d).Where("id = ?", id).Select()
+	if err != nil {
+		r.log.Warn("DeviceRepo Error", zap.Error(err))
+		return nil, apperr.New(http.StatusNotFound, "Device not found.")
+	}
+	return d, nil
+}
+
+// List returns the devices of a user, last seen first
+func (r *DeviceRepo) List(userID int) ([]model.Device, error) {
+	var devices []model.Device
+	err := r.db.Model(&devices).Where("user_id = ?", userID).Order("last_seen_at desc").Select()
+	if err != nil {
+		r.log.Warn("DeviceRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return devices, nil
+}
+
+// Delete deletes a device
+func (r *DeviceRepo) Delete(d *model.Device) error {
+	_, err := r.db.Model(d).WherePK().Delete()
+	if err != nil {
+		r.log.Warn("DeviceRepo Error", zap.Error(err))
+		return apperr.DB
+	}
+	return nil
+}
+
+// DeleteByToken deletes the device of a push token
+func (r *DeviceRepo) DeleteByToken(token string) error {
+	_, err := r.db.Model((*model.Device)(nil)).Where("token = ?", token).Delete()
+	if err != nil {
+		r.log.Warn("DeviceRepo Error", zap.Error(err))
+		return apperr.DB
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package devices manages the devices users receive push notifications on.
+package devices
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxDevices bounds the devices of a single user
+const maxDevices = 20
+
+// NewDeviceService creates a new device application service
+func NewDeviceService(deviceRepo model.DeviceRepo) *Service {
+	return &Service{deviceRepo: deviceRepo, now: time.Now}
+}
+
+// Service represents the device application service
+type Service struct {
+	deviceRepo model.DeviceRepo
+	now        func() time.Time
+}
+
+// Register registers a device of the current user, or refreshes it when its token is already
+// registered. Apps register on every launch, so that tokens rotated by the provider replace
+// the former ones and unused devices can be told apart.
+func (s *Service) Register(c *gin.Context, d *model.Device) (*model.Device, error) {
+	if d.Platform != model.PlatformIOS && d.Platform != model.PlatformAndroid {
+		return nil, apperr.New(http.StatusBadRequest, "Unknown platform.")
+	}
+	d.UserID = c.GetInt("id")
+	devices, err := s.deviceRepo.List(d.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) >= maxDevices && find(devices, d.Token) == nil {
+		return nil, apperr.New(http.StatusBadRequest, "Too many devices.")
+	}
+	d.LastSeenAt = s.now()
+	return s.deviceRepo.Register(d)
+}
+
+// List returns the devices of the current user
+func (s *Service) List(c *gin.Context) ([]model.Device, error) {
+	return s.deviceRepo.List(c.GetInt("id"))
+}
+
+// Unregister unregisters a device of the current user
+func (s *Service) Unregister(c *gin.Context, id int) error {
+	d, err := s.deviceRepo.View(id)
+	if err != nil {
+		return err
+	}
+	if d.UserID != c.GetInt("id") {
+		return apperr.New(http.StatusNotFound, "Device not found.")
+	}
+	return s.deviceRepo.Delete(d)
+}
+
+// UnregisterToken unregisters the device of the current user with a push token, e.g. when
+// they sign out of the app
+func (s *Service) UnregisterToken(c *gin.Context, token string) error {
+	devices, err := s.deviceRepo.List(c.GetInt("id"))
+	if err != nil {
+		return err
+	}
+	d := find(devices, token)
+	if d == nil {
+		return apperr.New(http.StatusNotFound, "Device not found.")
+	}
+	return s.deviceRepo.Delete(d)
+}
+
+func find(devices []model.Device, token string) *model.Device {
+	for i := range devices {
+		if devices[i].Token == token {
+			return &devices[i]
+		}
+	}
+	return nil
+}
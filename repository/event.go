@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// eventLock is the advisory lock key serializing the updates of the event cursors
+const eventLock = 8003
+
+// NewEventRepo returns a new EventRepo instance
+func NewEventRepo(db *pg.DB, log *zap.Logger) *EventRepo {
+	return &EventRepo{db, log}
+}
+
+// EventRepo is the client for the event cursor model
+type EventRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// Cursor returns the ID of the last event handled in stream, or an empty string when none was
+func (r *EventRepo) Cursor(stream string) (string, error) {
+	c := new(model.EventCursor)
+	err := r.db.Model(c).Where("stream = ?", stream).Select()
+	if err == pg.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", r.error(err)
+	}
+	return c.LastEventID, nil
+}
+
+// SaveCursor records eventID as the last event handled in stream
+func (r *EventRepo) SaveCursor(stream, eventID string) error {
+	return r.error(r.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", eventLock); err != nil {
+			return err
+		}
+		c := &model.EventCursor{Stream: stream, LastEventID: eventID}
+		res, err := tx.Model(c).Column("last_event_id", "updated_at").Where("stream = ?", stream).Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() > 0 {
+			return nil
+		}
+		return tx.Insert(c)
+	}))
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (r *EventRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apperr.APPError); ok {
+		return err
+	}
+	r.log.Warn("EventRepo Error", zap.Error(err))
+	return apperr.DB
+}
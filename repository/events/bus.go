@@ -0,0 +1,77 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Event is a broker event, decoded, as fanned out to the subscribers of a Bus
+type Event struct {
+	Stream string
+	ID     string
+	// AccountID is the brokerage account the event is about, empty for journal events
+	AccountID string
+	// Payload is a *broker.AccountStatusEvent, *broker.TradeEvent, *broker.TransferStatusEvent
+	// or *broker.JournalStatusEvent depending on Stream
+	Payload interface{}
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{subs: map[*Subscription]struct{}{}}
+}
+
+// Bus fans out the broker events to in-process subscribers. Publishing never blocks: events
+// are dropped for subscribers whose buffer is full.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscription receives the events published on a Bus until it is closed
+type Subscription struct {
+	// C delivers the events, and is closed by Close
+	C <-chan *Event
+
+	bus     *Bus
+	ch      chan *Event
+	dropped int64
+}
+
+// Subscribe returns a new subscription buffering up to size events
+func (b *Bus) Subscribe(size int) *Subscription {
+	ch := make(chan *Event, size)
+	s := &Subscription{C: ch, bus: b, ch: ch}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+// Publish delivers e to every subscription with room for it
+func (b *Bus) Publish(e *Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		select {
+		case s.ch <- e:
+		default:
+			atomic.AddInt64(&s.dropped, 1)
+		}
+	}
+}
+
+// Close unsubscribes s and closes its channel
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.ch)
+	}
+}
+
+// Dropped returns the number of events dropped because the subscription was full
+func (s *Subscription) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
@@ -0,0 +1,224 @@
+// Package events keeps our records in sync with the broker's event streams and fans the events
+// out to in-process subscribers.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/notifications"
+	"github.com/zcoriarty/Backend/repository/order"
+	"github.com/zcoriarty/Backend/repository/taxlots"
+
+	"go.uber.org/zap"
+)
+
+// maxRetryWait bounds the wait between two attempts to reconnect to a stream
+const maxRetryWait = time.Minute
+
+// NewConsumer creates a consumer of the broker's event streams, recording their events with
+// the given repositories, notifying users through notifier and publishing the events on bus
+func NewConsumer(brk broker.Service, eventRepo model.EventRepo, userRepo model.UserRepo, transferRepo model.TransferRepo,
+	rewardRepo model.UserRewardRepo, orders *order.Service, lots *taxlots.Service, notifier notifications.Notifier, bus *Bus, log *zap.Logger) *Consumer {
+	return &Consumer{
+		RetryWait:    time.Second,
+		streams:      broker.EventStreams,
+		broker:       brk,
+		eventRepo:    eventRepo,
+		userRepo:     userRepo,
+		transferRepo: transferRepo,
+		rewardRepo:   rewardRepo,
+		orders:       orders,
+		lots:         lots,
+		notifier:     notifier,
+		bus:          bus,
+		log:          log,
+	}
+}
+
+// NewRelay creates a consumer publishing the events of streams on bus without recording them,
+// for the API servers whose clients follow events live. It starts with the events that follow
+// its start, and only remembers its position in memory.
+func NewRelay(brk broker.Service, bus *Bus, log *zap.Logger, streams ...string) *Consumer {
+	return &Consumer{
+		RetryWait: time.Second,
+		streams:   streams,
+		cursors:   map[string]string{},
+		broker:    brk,
+		bus:       bus,
+		log:       log,
+	}
+}
+
+// Consumer follows the account status, trade, transfer status and journal status streams of
+// the broker. Each event updates the account status of its user, the order ledger and its
+// fills, the transfer ledger or the rewards, notifies its user of fills, completed transfers
+// and rewards paid out, and is then published on the bus. The ID of the last event handled in every
+// stream is persisted, so the consumer resumes where it stopped after a restart or a
+// disconnection.
+type Consumer struct {
+	// RetryWait is the wait before reconnecting to a stream the first time, doubled after
+	// every failed attempt
+	RetryWait time.Duration
+
+	streams []string
+	// cursors holds the position of relays, which have no eventRepo
+	mu      sync.Mutex
+	cursors map[string]string
+
+	broker       broker.Service
+	eventRepo    model.EventRepo
+	userRepo     model.UserRepo
+	transferRepo model.TransferRepo
+	rewardRepo   model.UserRewardRepo
+	orders       *order.Service
+	lots         *taxlots.Service
+	notifier     notifications.Notifier
+	bus          *Bus
+	log          *zap.Logger
+}
+
+// Run follows every event stream until ctx is done
+func (c *Consumer) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, stream := range c.streams {
+		wg.Add(1)
+		go func(stream string) {
+			defer wg.Done()
+			c.follow(ctx, stream)
+		}(stream)
+	}
+	wg.Wait()
+}
+
+// follow consumes stream from its persisted cursor, reconnecting with an exponential backoff
+// whenever the connection ends or an event cannot be handled
+func (c *Consumer) follow(ctx context.Context, stream string) {
+	wait := c.RetryWait
+	for {
+		sinceID, err := c.cursor(stream)
+		if err == nil {
+			err = c.broker.StreamEvents(ctx, stream, sinceID, func(e broker.Event) error {
+				wait = c.RetryWait
+				return c.Handle(e)
+			})
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		c.log.Warn("Consumer Error", zap.String("stream", stream), zap.Error(err))
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+// Handle records a single broker event, moves the cursor of its stream past it, notifies its
+// user and publishes it. Events that cannot be decoded are skipped.
+func (c *Consumer) Handle(e broker.Event) error {
+	ev, err := decode(e)
+	if err != nil {
+		c.log.Warn("Consumer Error", zap.String("stream", e.Stream), zap.String("event_id", e.ID), zap.Error(err))
+		return c.save(e)
+	}
+	if c.eventRepo != nil {
+		if err := c.apply(ev); err != nil {
+			return err
+		}
+	}
+	if err := c.save(e); err != nil {
+		return err
+	}
+	if c.eventRepo != nil {
+		// only once the cursor moved past the event, so a redelivery does not notify again
+		c.notify(ev)
+	}
+	c.bus.Publish(ev)
+	return nil
+}
+
+// apply records ev
+func (c *Consumer) apply(ev *Event) error {
+	switch p := ev.Payload.(type) {
+	case *broker.AccountStatusEvent:
+		return c.userRepo.UpdateAccountStatus(p.AccountID, p.StatusTo)
+	case *broker.TradeEvent:
+		if err := c.orders.Apply(&p.Order); err != nil {
+			return err
+		}
+		return c.lots.Record(p)
+	case *broker.TransferStatusEvent:
+		return c.transferRepo.UpdateStatus(p.TransferID, p.StatusTo, p.Reason)
+	case *broker.JournalStatusEvent:
+		return c.rewardRepo.UpdateJournalStatus(p.JournalID, p.StatusTo, p.Reason)
+	}
+	return nil
+}
+
+// cursor returns the ID of the last event handled in stream
+func (c *Consumer) cursor(stream string) (string, error) {
+	if c.eventRepo == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.cursors[stream], nil
+	}
+	return c.eventRepo.Cursor(stream)
+}
+
+// save persists the ID of e as the cursor of its stream
+func (c *Consumer) save(e broker.Event) error {
+	if e.ID == "" {
+		return nil
+	}
+	if c.eventRepo == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.cursors[e.Stream] = e.ID
+		return nil
+	}
+	return c.eventRepo.SaveCursor(e.Stream, e.ID)
+}
+
+// decode decodes the payload of e according to its stream
+func decode(e broker.Event) (*Event, error) {
+	ev := &Event{Stream: e.Stream, ID: e.ID}
+	switch e.Stream {
+	case broker.AccountStatusStream:
+		p := new(broker.AccountStatusEvent)
+		if err := json.Unmarshal(e.Data, p); err != nil {
+			return nil, err
+		}
+		ev.Payload, ev.AccountID = p, p.AccountID
+	case broker.TradeStream:
+		p := new(broker.TradeEvent)
+		if err := json.Unmarshal(e.Data, p); err != nil {
+			return nil, err
+		}
+		ev.Payload, ev.AccountID = p, p.AccountID
+	case broker.TransferStatusStream:
+		p := new(broker.TransferStatusEvent)
+		if err := json.Unmarshal(e.Data, p); err != nil {
+			return nil, err
+		}
+		ev.Payload, ev.AccountID = p, p.AccountID
+	case broker.JournalStatusStream:
+		p := new(broker.JournalStatusEvent)
+		if err := json.Unmarshal(e.Data, p); err != nil {
+			return nil, err
+		}
+		ev.Payload = p
+	}
+	return ev, nil
+}
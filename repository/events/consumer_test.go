@@ -0,0 +1,294 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/events"
+	"github.com/zcoriarty/Backend/repository/order"
+	"github.com/zcoriarty/Backend/repository/taxlots"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func f(v float64) *float64 {
+	return &v
+}
+
+// records holds what the consumer wrote through the mocked repositories
+type records struct {
+	sync.Mutex
+	cursors   map[string]string
+	statuses  map[string]string
+	orders    map[string]*model.Order
+	transfers map[string]string
+	journals  map[string]string
+	fills     []model.Fill
+	notified  []string
+	saves     int
+	// failSaves is the number of cursor saves failing next
+	failSaves int
+}
+
+func (r *records) get(fn func()) {
+	r.Lock()
+	defer r.Unlock()
+	fn()
+}
+
+func newConsumer(brk broker.Service, r *records, bus *events.Bus) *events.Consumer {
+	eventRepo := &mockdb.Event{
+		CursorFn: func(stream string) (string, error) {
+			r.Lock()
+			defer r.Unlock()
+			return r.cursors[stream], nil
+		},
+		SaveCursorFn: func(stream, id string) error {
+			r.Lock()
+			defer r.Unlock()
+			if r.failSaves > 0 {
+				r.failSaves--
+				return errors.New("database down")
+			}
+			r.cursors[stream] = id
+			r.saves++
+			return nil
+		},
+	}
+	userRepo := &mockdb.User{
+		UpdateAccountStatusFn: func(accountID, status string) error {
+			r.Lock()
+			defer r.Unlock()
+			r.statuses[accountID] = status
+			return nil
+		},
+		FindByAccountIDFn: func(accountID string) (*model.User, error) {
+			return &model.User{ID: 1, AccountID: accountID}, nil
+		},
+	}
+	orderRepo := &mockdb.Order{
+		FindFn: func(orderID string) (*model.Order, error) {
+			r.Lock()
+			defer r.Unlock()
+			if o, ok := r.orders[orderID]; ok {
+				cp := *o
+				return &cp, nil
+			}
+			return nil, apperr.New(http.StatusNotFound, "Order not found.")
+		},
+		UpdateFn: func(o *model.Order) error {
+			r.Lock()
+			defer r.Unlock()
+			r.orders[o.OrderID] = o
+			return nil
+		},
+	}
+	transferRepo := &mockdb.Transfer{
+		UpdateStatusFn: func(transferID, status, reason string) error {
+			r.Lock()
+			defer r.Unlock()
+			r.transfers[transferID] = status
+			return nil
+		},
+		FindFn: func(transferID string) (*model.Transfer, error) {
+			return &model.Transfer{UserID: 1, TransferID: transferID, Direction: broker.Incoming, Amount: 100}, nil
+		},
+	}
+	rewardRepo := &mockdb.UserReward{
+		UpdateJournalStatusFn: func(journalID, status, reason string) error {
+			r.Lock()
+			defer r.Unlock()
+			r.journals[journalID] = status
+			return nil
+		},
+		FindByJournalIDFn: func(journalID string) (*model.UserReward, error) {
+			if journalID != "reward" {
+				return nil, apperr.New(http.StatusNotFound, "Reward not found.")
+			}
+			return &model.UserReward{UserID: 1, JournalID: journalID, RewardValue: 25}, nil
+		},
+	}
+	notifier := &mock.Notifier{NotifyFn: func(ctx context.Context, n *model.Notification, channels ...string) error {
+		r.Lock()
+		defer r.Unlock()
+		r.notified = append(r.notified, fmt.Sprintf("%s %d: %s", n.Category, n.UserID, n.Body))
+		return nil
+	}}
+	taxLotRepo := &mockdb.TaxLot{RecordFillFn: func(f *model.Fill) error {
+		r.Lock()
+		defer r.Unlock()
+		r.fills = append(r.fills, *f)
+		return nil
+	}}
+	c := events.NewConsumer(brk, eventRepo, userRepo, transferRepo, rewardRepo, order.NewOrderService(userRepo, orderRepo, brk),
+		taxlots.NewTaxLotService(orderRepo, taxLotRepo), notifier, bus, zap.NewNop())
+	c.RetryWait = time.Millisecond
+	return c
+}
+
+// eventually waits for cond to hold
+func eventually(t *testing.T, r *records, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ok := false
+		r.get(func() { ok = cond() })
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.AddAccount("acc", 1000)
+	s.SetPrice("AAPL", 100)
+	bank := s.AddACHRelationship("acc", "Checking")
+	brk := s.Broker()
+
+	// every stream starts from the beginning, as if the consumer handled event 0 before
+	r := &records{
+		cursors:   map[string]string{},
+		statuses:  map[string]string{},
+		orders:    map[string]*model.Order{},
+		transfers: map[string]string{},
+		journals:  map[string]string{},
+	}
+	for _, stream := range broker.EventStreams {
+		r.cursors[stream] = "0"
+	}
+	bus := events.NewBus()
+	sub := bus.Subscribe(100)
+	defer sub.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		newConsumer(brk, r, bus).Run(ctx)
+		close(done)
+	}()
+
+	// an order placed through the ledger fills
+	o, err := brk.CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: f(2), Side: broker.Buy, Type: "limit", LimitPrice: f(90), TimeInForce: "gtc"})
+	assert.Nil(t, err)
+	r.get(func() {
+		r.orders[o.ID] = &model.Order{ID: 1, UserID: 1, AccountID: "acc", OrderID: o.ID, Status: "new"}
+	})
+	s.SetPrice("AAPL", 89)
+	eventually(t, r, func() bool { return r.orders[o.ID].Status == broker.OrderFilled && len(r.fills) > 0 })
+	r.get(func() {
+		assert.Equal(t, 2.0, r.orders[o.ID].FilledQty)
+		assert.Equal(t, 89.0, *r.orders[o.ID].FilledAvgPrice)
+		// the fill is recorded for its tax lot, but not the order's other events
+		assert.Len(t, r.fills, 1)
+		assert.Equal(t, model.Fill{UserID: 1, OrderID: o.ID, ExecutionID: r.fills[0].ExecutionID, Symbol: "AAPL",
+			Side: broker.Buy, Qty: 2, Price: 89, FilledAt: r.fills[0].FilledAt}, r.fills[0])
+	})
+
+	s.SetAccountStatus("acc", "ACTION_REQUIRED")
+	tr, err := brk.CreateTransfer(ctx, "acc", &broker.TransferRequest{TransferType: "ach", RelationshipID: bank, Amount: 100, Direction: broker.Incoming})
+	assert.Nil(t, err)
+	s.SettleTransfers("acc")
+	s.Emit(broker.JournalStatusStream, broker.JournalStatusEvent{JournalID: "journal", EntryType: "JNLC", StatusFrom: "queued", StatusTo: "executed"})
+	s.Emit(broker.JournalStatusStream, broker.JournalStatusEvent{JournalID: "reward", EntryType: "JNLC", StatusFrom: "queued", StatusTo: "executed"})
+	eventually(t, r, func() bool {
+		return r.statuses["acc"] == "ACTION_REQUIRED" && r.transfers[tr.ID] == "COMPLETE" && r.journals["reward"] == "executed"
+	})
+
+	// users are notified of fills, completed transfers and rewards paid out, but not of other
+	// journals
+	eventually(t, r, func() bool { return len(r.notified) == 3 })
+	r.get(func() {
+		assert.ElementsMatch(t, []string{
+			"trades 1: Your order to buy 2 AAPL filled at $89.00.",
+			"transfers 1: Your deposit of $100.00 is complete.",
+			"rewards 1: Your $25.00 reward was paid into your account.",
+		}, r.notified)
+	})
+
+	// the stream is resumed after a disconnection, without handling events twice
+	r.get(func() { r.saves = 0 })
+	s.DropStreams()
+	s.SetAccountStatus("acc", "ACTIVE")
+	eventually(t, r, func() bool { return r.statuses["acc"] == "ACTIVE" })
+	time.Sleep(50 * time.Millisecond)
+	r.get(func() { assert.Equal(t, 1, r.saves) })
+
+	// events are published once recorded
+	var published []string
+	for len(sub.C) > 0 {
+		e := <-sub.C
+		published = append(published, e.Stream)
+		if p, ok := e.Payload.(*broker.TradeEvent); ok {
+			assert.Equal(t, "acc", e.AccountID)
+			assert.Equal(t, o.ID, p.Order.ID)
+		}
+	}
+	assert.Equal(t, []string{"trades", "trades"}, published[:2])
+	assert.Len(t, published, 7)
+	assert.Equal(t, int64(0), sub.Dropped())
+
+	cancel()
+	<-done
+}
+
+func TestHandleCursorFailure(t *testing.T) {
+	r := &records{
+		cursors:   map[string]string{},
+		statuses:  map[string]string{},
+		orders:    map[string]*model.Order{},
+		transfers: map[string]string{},
+		journals:  map[string]string{},
+		failSaves: 1,
+	}
+	c := newConsumer(nil, r, events.NewBus())
+	data, err := json.Marshal(broker.TransferStatusEvent{AccountID: "acc", TransferID: "t1", StatusTo: "COMPLETE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := broker.Event{Stream: broker.TransferStatusStream, ID: "1", Data: data}
+
+	// an event whose cursor is not saved is redelivered, and its user notified once
+	assert.NotNil(t, c.Handle(e))
+	assert.Empty(t, r.notified)
+	assert.Nil(t, c.Handle(e))
+	assert.Len(t, r.notified, 1)
+	assert.Equal(t, "1", r.cursors[broker.TransferStatusStream])
+}
+
+func TestBus(t *testing.T) {
+	bus := events.NewBus()
+	slow := bus.Subscribe(1)
+	fast := bus.Subscribe(10)
+	for i := 0; i < 3; i++ {
+		bus.Publish(&events.Event{Stream: broker.TradeStream})
+	}
+	assert.Len(t, fast.C, 3)
+	assert.Len(t, slow.C, 1)
+	assert.Equal(t, int64(2), slow.Dropped())
+
+	slow.Close()
+	slow.Close()
+	bus.Publish(&events.Event{Stream: broker.TradeStream})
+	assert.Len(t, fast.C, 4)
+	_, open := <-slow.C
+	assert.True(t, open)
+	_, open = <-slow.C
+	assert.False(t, open)
+}
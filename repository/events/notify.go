@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+
+	"go.uber.org/zap"
+)
+
+// notify notifies the user of an event once it is recorded and its cursor saved: orders
+// filled, transfers completed and rewards paid out. Failures are logged and the event is not
+// handled again, so a notification may be missed but is not sent twice by a retry.
+func (c *Consumer) notify(ev *Event) {
+	if c.notifier == nil {
+		return
+	}
+	n, err := c.notification(ev)
+	if err == nil && n != nil {
+		err = c.notifier.Notify(context.Background(), n)
+	}
+	if err != nil {
+		c.log.Warn("Consumer Error", zap.String("stream", ev.Stream), zap.String("event_id", ev.ID), zap.Error(err))
+	}
+}
+
+// notification returns the notification of ev, or nil for events users are not notified of
+func (c *Consumer) notification(ev *Event) (*model.Notification, error) {
+	switch p := ev.Payload.(type) {
+	case *broker.TradeEvent:
+		if p.Event != "fill" {
+			return nil, nil
+		}
+		user, err := c.userRepo.FindByAccountID(p.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		o := p.Order
+		body := fmt.Sprintf("Your order to %s %s %s filled", o.Side, strconv.FormatFloat(o.FilledQty, 'f', -1, 64), o.Symbol)
+		if o.FilledAvgPrice != nil {
+			body += fmt.Sprintf(" at $%.2f", *o.FilledAvgPrice)
+		}
+		return &model.Notification{
+			UserID:   user.ID,
+			Category: model.NotifyTrades,
+			Title:    o.Symbol + " order filled",
+			Body:     body + ".",
+			Data:     map[string]string{"type": "order", "order_id": o.ID, "symbol": o.Symbol},
+		}, nil
+	case *broker.TransferStatusEvent:
+		if !strings.EqualFold(p.StatusTo, "COMPLETE") {
+			return nil, nil
+		}
+		t, err := c.transferRepo.Find(p.TransferID)
+		if err != nil {
+			return nil, err
+		}
+		kind := "withdrawal"
+		if t.Direction == broker.Incoming {
+			kind = "deposit"
+		}
+		return &model.Notification{
+			UserID:   t.UserID,
+			Category: model.NotifyTransfers,
+			Title:    strings.Title(kind) + " complete",
+			Body:     fmt.Sprintf("Your %s of $%.2f is complete.", kind, t.Amount),
+			Data:     map[string]string{"type": "transfer", "transfer_id": t.TransferID},
+		}, nil
+	case *broker.JournalStatusEvent:
+		if p.StatusTo != "executed" {
+			return nil, nil
+		}
+		r, err := c.rewardRepo.FindByJournalID(p.JournalID)
+		if e, ok := err.(*apperr.APPError); ok && e.Status == http.StatusNotFound {
+			// the journal does not pay out a reward
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &model.Notification{
+			UserID:   r.UserID,
+			Category: model.NotifyRewards,
+			Title:    "Reward paid",
+			Body:     fmt.Sprintf("Your $%.2f reward was paid into your account.", r.RewardValue),
+			Data:     map[string]string{"type": "reward", "journal_id": p.JournalID},
+		}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// marketDataLock is the advisory lock key serializing the writes to the market data cache
+const marketDataLock = 8004
+
+// NewMarketDataRepo returns a new MarketDataRepo instance
+func NewMarketDataRepo(db *pg.DB, log *zap.Logger) *MarketDataRepo {
+	return &MarketDataRepo{db, log}
+}
+
+// MarketDataRepo is the client for the market data cache
+type MarketDataRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// Get returns the unexpired entries of keys
+func (r *MarketDataRepo) Get(keys []string) ([]model.MarketData, error) {
+	var entries []model.MarketData
+	if len(keys) == 0 {
+		return entries, nil
+	}
+	err := r.db.Model(&entries).Where("key IN (?)", pg.In(keys)).Where("expires_at > now()").Select()
+	return entries, r.error(err)
+}
+
+// Save replaces the entries of the same keys, and drops the expired ones along the way
+func (r *MarketDataRepo) Save(entries []model.MarketData) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return r.error(r.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", marketDataLock); err != nil {
+			return err
+		}
+		if _, err := tx.Model((*model.MarketData)(nil)).Where("key IN (?)", pg.In(keys)).
+			WhereOr("expires_at <= now()").Delete(); err != nil {
+			return err
+		}
+		return tx.Insert(&entries)
+	}))
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (r *MarketDataRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apperr.APPError); ok {
+		return err
+	}
+	r.log.Warn("MarketDataRepo Error", zap.Error(err))
+	return apperr.DB
+}
@@ -0,0 +1,312 @@
+// Package marketdata caches the broker's market data, so that the snapshots, quotes and bars
+// many requests ask for at once are fetched from the broker a single time.
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+
+	"go.uber.org/zap"
+)
+
+// null is the cached value of the symbols the broker has no market data for
+var null = []byte("null")
+
+// Stats counts the lookups of the cache since it was created
+type Stats struct {
+	// Hits is the number of keys found in the store
+	Hits int64 `json:"hits"`
+	// Misses is the number of keys not found in the store
+	Misses int64 `json:"misses"`
+	// Coalesced is the number of misses served by an upstream call made for another request
+	Coalesced int64 `json:"coalesced"`
+	// Upstream is the number of calls made to the broker
+	Upstream int64 `json:"upstream"`
+	// Errors is the number of calls to the broker that failed
+	Errors int64 `json:"errors"`
+}
+
+// NewCache returns a cache of the market data of brk, keeping it in store
+func NewCache(brk broker.Service, store Store, cfg *config.MarketDataConfig, log *zap.Logger) *Cache {
+	return &Cache{
+		Service: brk,
+		store:   store,
+		config:  cfg,
+		log:     log,
+		calls:   map[string]*call{},
+	}
+}
+
+// Cache is a broker.Service serving snapshots, latest trades and quotes, and bars from a store
+// for a while before fetching them again. Concurrent requests for the same keys wait for a
+// single upstream call instead of making their own, and the symbols missing from a request
+// are fetched in batches of at most config.BatchSize. Every other call goes to the broker.
+type Cache struct {
+	broker.Service
+
+	store  Store
+	config *config.MarketDataConfig
+	log    *zap.Logger
+
+	mu    sync.Mutex
+	calls map[string]*call
+
+	stats Stats
+}
+
+// call is an upstream call in flight for a key
+type call struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// loader fetches the values of keys from the broker, omitting the unknown ones
+type loader func(ctx context.Context, keys []string) (map[string][]byte, error)
+
+// Stats returns the lookups of the cache so far
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:      atomic.LoadInt64(&c.stats.Hits),
+		Misses:    atomic.LoadInt64(&c.stats.Misses),
+		Coalesced: atomic.LoadInt64(&c.stats.Coalesced),
+		Upstream:  atomic.LoadInt64(&c.stats.Upstream),
+		Errors:    atomic.LoadInt64(&c.stats.Errors),
+	}
+}
+
+// GetSnapshots returns the snapshots of symbols, keyed by upper cased symbol. Unknown symbols
+// are omitted.
+func (c *Cache) GetSnapshots(ctx context.Context, symbols []string) (map[string]*broker.Snapshot, error) {
+	symbols = normalize(symbols)
+	values, err := c.fetch(ctx, keys("snapshot:", symbols), c.config.SnapshotTTL, c.config.BatchSize, c.loadSnapshots)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]*broker.Snapshot, len(symbols))
+	for _, symbol := range symbols {
+		snap := new(broker.Snapshot)
+		if decode(values["snapshot:"+symbol], snap) {
+			res[symbol] = snap
+		}
+	}
+	return res, nil
+}
+
+// GetSnapshot returns the snapshot of a single symbol
+func (c *Cache) GetSnapshot(ctx context.Context, symbol string) (*broker.Snapshot, error) {
+	snapshots, err := c.GetSnapshots(ctx, []string{symbol})
+	if err != nil {
+		return nil, err
+	}
+	if snap, ok := snapshots[strings.ToUpper(strings.TrimSpace(symbol))]; ok {
+		return snap, nil
+	}
+	// the broker tells why the symbol has no snapshot
+	return c.Service.GetSnapshot(ctx, symbol)
+}
+
+// GetLatestTrade returns the latest trade of a symbol
+func (c *Cache) GetLatestTrade(ctx context.Context, symbol string) (*broker.LatestTradeResponse, error) {
+	key := "trade:" + strings.ToUpper(symbol)
+	values, err := c.fetch(ctx, []string{key}, c.config.QuoteTTL, 1, func(ctx context.Context, _ []string) (map[string][]byte, error) {
+		return single(key)(c.Service.GetLatestTrade(ctx, symbol))
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := new(broker.LatestTradeResponse)
+	decode(values[key], res)
+	return res, nil
+}
+
+// GetLatestQuote returns the latest quote of a symbol
+func (c *Cache) GetLatestQuote(ctx context.Context, symbol string) (*broker.LatestQuoteResponse, error) {
+	key := "quote:" + strings.ToUpper(symbol)
+	values, err := c.fetch(ctx, []string{key}, c.config.QuoteTTL, 1, func(ctx context.Context, _ []string) (map[string][]byte, error) {
+		return single(key)(c.Service.GetLatestQuote(ctx, symbol))
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := new(broker.LatestQuoteResponse)
+	decode(values[key], res)
+	return res, nil
+}
+
+// GetBars returns a page of historical bars of a symbol
+func (c *Cache) GetBars(ctx context.Context, symbol string, p *broker.MarketDataRequest) (*broker.BarsResponse, error) {
+	if p == nil {
+		p = &broker.MarketDataRequest{}
+	}
+	key := strings.Join([]string{"bars:" + strings.ToUpper(symbol), p.Timeframe, p.Start, p.End, strconv.Itoa(p.Limit), p.PageToken}, ":")
+	values, err := c.fetch(ctx, []string{key}, c.config.BarTTL, 1, func(ctx context.Context, _ []string) (map[string][]byte, error) {
+		return single(key)(c.Service.GetBars(ctx, symbol, p))
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := new(broker.BarsResponse)
+	decode(values[key], res)
+	return res, nil
+}
+
+// loadSnapshots fetches the snapshots of the symbols of keys
+func (c *Cache) loadSnapshots(ctx context.Context, keys []string) (map[string][]byte, error) {
+	symbols := make([]string, 0, len(keys))
+	for _, key := range keys {
+		symbols = append(symbols, strings.TrimPrefix(key, "snapshot:"))
+	}
+	snapshots, err := c.Service.GetSnapshots(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]byte, len(snapshots))
+	for symbol, snap := range snapshots {
+		if data, err := json.Marshal(snap); err == nil {
+			res["snapshot:"+strings.ToUpper(symbol)] = data
+		}
+	}
+	return res, nil
+}
+
+// fetch returns the values of keys, reading them from the store or waiting for the upstream
+// calls in flight for them, and loading the others in batches of at most batch keys. Keys the
+// broker has nothing for are cached as null.
+//
+// Upstream calls are not bound to ctx, as other requests may be waiting for them, and are
+// bounded by the broker's timeout instead.
+func (c *Cache) fetch(ctx context.Context, keys []string, ttl time.Duration, batch int, load loader) (map[string][]byte, error) {
+	res, err := c.store.Get(keys)
+	if err != nil {
+		c.log.Warn("Cache Error", zap.Error(err))
+		res = map[string][]byte{}
+	}
+	atomic.AddInt64(&c.stats.Hits, int64(len(res)))
+	if len(res) == len(keys) {
+		return res, nil
+	}
+
+	waits := map[string]*call{}
+	var own []string
+	c.mu.Lock()
+	for _, key := range keys {
+		if _, ok := res[key]; ok {
+			continue
+		}
+		cl, ok := c.calls[key]
+		if !ok {
+			cl = &call{done: make(chan struct{})}
+			c.calls[key] = cl
+			own = append(own, key)
+		}
+		waits[key] = cl
+	}
+	c.mu.Unlock()
+	atomic.AddInt64(&c.stats.Misses, int64(len(waits)))
+	atomic.AddInt64(&c.stats.Coalesced, int64(len(waits)-len(own)))
+
+	if batch < 1 {
+		batch = 1
+	}
+	for start := 0; start < len(own); start += batch {
+		end := start + batch
+		if end > len(own) {
+			end = len(own)
+		}
+		go c.load(own[start:end], ttl, load)
+	}
+
+	for key, cl := range waits {
+		select {
+		case <-cl.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if cl.err != nil {
+			return nil, cl.err
+		}
+		res[key] = cl.value
+	}
+	return res, nil
+}
+
+// load makes a single upstream call for keys, stores its result and hands it to the requests
+// waiting for it
+func (c *Cache) load(keys []string, ttl time.Duration, load loader) {
+	atomic.AddInt64(&c.stats.Upstream, 1)
+	values, err := load(context.Background(), keys)
+	if err != nil {
+		atomic.AddInt64(&c.stats.Errors, 1)
+	} else {
+		for _, key := range keys {
+			if _, ok := values[key]; !ok {
+				values[key] = null
+			}
+		}
+		if err := c.store.Set(values, ttl); err != nil {
+			c.log.Warn("Cache Error", zap.Error(err))
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		cl := c.calls[key]
+		delete(c.calls, key)
+		cl.value, cl.err = values[key], err
+		close(cl.done)
+	}
+}
+
+// single returns a loader result holding the response of a call for a single key
+func single(key string) func(v interface{}, err error) (map[string][]byte, error) {
+	return func(v interface{}, err error) (map[string][]byte, error) {
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{key: data}, nil
+	}
+}
+
+// decode decodes a cached value into v, and reports whether it held any market data
+func decode(data []byte, v interface{}) bool {
+	if len(data) == 0 || string(data) == string(null) {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// normalize upper cases symbols and drops empty and duplicate ones
+func normalize(symbols []string) []string {
+	seen := map[string]bool{}
+	res := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" && !seen[s] {
+			seen[s] = true
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func keys(prefix string, symbols []string) []string {
+	res := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		res = append(res, prefix+s)
+	}
+	return res
+}
@@ -0,0 +1,170 @@
+package marketdata_test
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/marketdata"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newConfig(batch int) *config.MarketDataConfig {
+	return &config.MarketDataConfig{SnapshotTTL: time.Minute, QuoteTTL: time.Minute, BarTTL: time.Minute, BatchSize: batch}
+}
+
+// slowBroker blocks snapshot calls until release is closed, recording the symbols of each
+type slowBroker struct {
+	broker.Service
+	release chan struct{}
+	mu      sync.Mutex
+	calls   [][]string
+}
+
+func (b *slowBroker) GetSnapshots(ctx context.Context, symbols []string) (map[string]*broker.Snapshot, error) {
+	b.mu.Lock()
+	b.calls = append(b.calls, symbols)
+	b.mu.Unlock()
+	<-b.release
+	res := map[string]*broker.Snapshot{}
+	for _, s := range symbols {
+		if s != "UNKNOWN" {
+			res[s] = &broker.Snapshot{Symbol: s}
+		}
+	}
+	return res, nil
+}
+
+func TestCache(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.SetPrice("AAPL", 100)
+	s.SetPrice("MSFT", 200)
+	cache := marketdata.NewCache(s.Broker(), marketdata.NewMemoryStore(), newConfig(200), zap.NewNop())
+	ctx := context.Background()
+
+	snapshots, err := cache.GetSnapshots(ctx, []string{"AAPL", "msft", "NOPE"})
+	assert.Nil(t, err)
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, 200.0, snapshots["MSFT"].LatestTrade.Price)
+
+	// cached symbols, unknown ones included, are not fetched again
+	s.SetPrice("AAPL", 150)
+	snap, err := cache.GetSnapshot(ctx, "AAPL")
+	assert.Nil(t, err)
+	assert.Equal(t, 100.0, snap.LatestTrade.Price)
+	_, err = cache.GetSnapshots(ctx, []string{"NOPE", "MSFT"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, s.Calls(http.MethodGet, "/v2/stocks/snapshots"))
+	_, err = cache.GetSnapshot(ctx, "NOPE")
+	assert.True(t, broker.IsNotFound(err))
+
+	trade, err := cache.GetLatestTrade(ctx, "AAPL")
+	assert.Nil(t, err)
+	assert.Equal(t, 150.0, trade.Trade.Price)
+	_, err = cache.GetLatestTrade(ctx, "AAPL")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, s.Calls(http.MethodGet, "/v2/stocks/AAPL/trades/latest"))
+
+	// errors are not cached
+	_, err = cache.GetLatestQuote(ctx, "NOPE")
+	assert.NotNil(t, err)
+	_, err = cache.GetLatestQuote(ctx, "NOPE")
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, s.Calls(http.MethodGet, "/v2/stocks/NOPE/quotes/latest"))
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(4), stats.Upstream)
+	assert.Equal(t, int64(2), stats.Errors)
+}
+
+func TestCacheCoalescing(t *testing.T) {
+	brk := &slowBroker{release: make(chan struct{})}
+	cache := marketdata.NewCache(brk, marketdata.NewMemoryStore(), newConfig(2), zap.NewNop())
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	results := make([]map[string]*broker.Snapshot, 2)
+	get := func(i int, symbols ...string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := cache.GetSnapshots(ctx, symbols)
+			assert.Nil(t, err)
+			results[i] = res
+		}()
+	}
+	get(0, "AAPL", "MSFT", "TSLA", "UNKNOWN", "GOOG")
+	waitCalls(t, brk, 3)
+	get(1, "MSFT", "GOOG", "AMZN")
+	waitCalls(t, brk, 4)
+	close(brk.release)
+	wg.Wait()
+
+	// the first request is split in batches of 2, and the second one only fetches AMZN
+	var calls []string
+	for _, c := range brk.calls {
+		sort.Strings(c)
+		calls = append(calls, strings.Join(c, ","))
+	}
+	sort.Strings(calls)
+	assert.Equal(t, []string{"AAPL,MSFT", "AMZN", "GOOG", "TSLA,UNKNOWN"}, calls)
+	assert.Len(t, results[0], 4)
+	assert.Len(t, results[1], 3)
+
+	stats := cache.Stats()
+	assert.Equal(t, marketdata.Stats{Hits: 0, Misses: 8, Coalesced: 2, Upstream: 4}, stats)
+	_, err := cache.GetSnapshots(ctx, []string{"AAPL", "UNKNOWN"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), cache.Stats().Hits)
+}
+
+func waitCalls(t *testing.T, brk *slowBroker, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		brk.mu.Lock()
+		calls := len(brk.calls)
+		brk.mu.Unlock()
+		if calls == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d calls, want %d", calls, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDBStore(t *testing.T) {
+	var saved []model.MarketData
+	repo := &mockdb.MarketData{
+		GetFn: func(keys []string) ([]model.MarketData, error) {
+			return []model.MarketData{{Key: "snapshot:AAPL", Value: `{"symbol":"AAPL"}`}}, nil
+		},
+		SaveFn: func(entries []model.MarketData) error {
+			saved = entries
+			return nil
+		},
+	}
+	store := marketdata.NewDBStore(repo)
+	values, err := store.Get([]string{"snapshot:AAPL", "snapshot:MSFT"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"snapshot:AAPL": []byte(`{"symbol":"AAPL"}`)}, values)
+
+	assert.Nil(t, store.Set(map[string][]byte{"quote:AAPL": []byte("{}")}, time.Minute))
+	assert.Len(t, saved, 1)
+	assert.Equal(t, "quote:AAPL", saved[0].Key)
+	assert.Equal(t, "{}", saved[0].Value)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), saved[0].ExpiresAt, time.Second)
+}
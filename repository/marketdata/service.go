@@ -0,0 +1,33 @@
+package marketdata
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewMarketDataService creates a new market data application service
+func NewMarketDataService(cache *Cache, rbac model.RBACService) *Service {
+	return &Service{
+		cache: cache,
+		rbac:  rbac,
+	}
+}
+
+// Service represents the market data application service
+type Service struct {
+	cache *Cache
+	rbac  model.RBACService
+}
+
+// Stats returns the lookups of the market data cache. Only admins may see them.
+func (s *Service) Stats(c *gin.Context) (*Stats, error) {
+	if !s.rbac.EnforceRole(c, model.AdminRole) {
+		return nil, apperr.New(http.StatusForbidden, "Forbidden")
+	}
+	stats := s.cache.Stats()
+	return &stats, nil
+}
@@ -0,0 +1,103 @@
+package marketdata
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zcoriarty/Backend/model"
+)
+
+// sweepInterval is how often the memory store drops its expired entries
+const sweepInterval = time.Minute
+
+// Store keeps the cached market data, encoded as JSON, until it expires
+type Store interface {
+	// Get returns the unexpired values of keys, omitting the others
+	Get(keys []string) (map[string][]byte, error)
+	// Set stores values for ttl
+	Set(values map[string][]byte, ttl time.Duration) error
+}
+
+// NewMemoryStore returns a store keeping market data in memory, shared by nothing but the
+// current process
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}, swept: time.Now()}
+}
+
+// MemoryStore is a Store keeping market data in memory
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+	swept   time.Time
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// Get returns the unexpired values of keys
+func (s *MemoryStore) Get(keys []string) (map[string][]byte, error) {
+	now := time.Now()
+	res := map[string][]byte{}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range keys {
+		if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+			res[key] = e.value
+		}
+	}
+	return res, nil
+}
+
+// Set stores values for ttl, dropping the expired entries every sweepInterval
+func (s *MemoryStore) Set(values map[string][]byte, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.swept) > sweepInterval {
+		for key, e := range s.entries {
+			if !now.Before(e.expires) {
+				delete(s.entries, key)
+			}
+		}
+		s.swept = now
+	}
+	for key, value := range values {
+		s.entries[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+	}
+	return nil
+}
+
+// NewDBStore returns a store keeping market data in Postgres, shared by every API server
+func NewDBStore(repo model.MarketDataRepo) *DBStore {
+	return &DBStore{repo: repo}
+}
+
+// DBStore is a Store keeping market data in the database
+type DBStore struct {
+	repo model.MarketDataRepo
+}
+
+// Get returns the unexpired values of keys
+func (s *DBStore) Get(keys []string) (map[string][]byte, error) {
+	entries, err := s.repo.Get(keys)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		res[e.Key] = []byte(e.Value)
+	}
+	return res, nil
+}
+
+// Set stores values for ttl
+func (s *DBStore) Set(values map[string][]byte, ttl time.Duration) error {
+	expires := time.Now().Add(ttl)
+	entries := make([]model.MarketData, 0, len(values))
+	for key, value := range values {
+		entries = append(entries, model.MarketData{Key: key, Value: string(value), ExpiresAt: expires})
+	}
+	return s.repo.Save(entries)
+}
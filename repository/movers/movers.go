@@ -0,0 +1,256 @@
+// Package movers ranks the tradable assets of the assets table by their move of the day. The
+// lists are computed from snapshots on a schedule, so that they are served from memory.
+package movers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultLimit is the length of each list when a request gives none
+	defaultLimit = 20
+	// maxLimit bounds the length of each list
+	maxLimit = 100
+)
+
+// Mover is the move of an asset since the close of the previous trading day. RelativeVolume is
+// the volume of the day relative to the volume of the previous one.
+type Mover struct {
+	Symbol         string  `json:"symbol"`
+	Name           string  `json:"name"`
+	Exchange       string  `json:"exchange"`
+	Fractionable   bool    `json:"fractionable"`
+	Price          float64 `json:"price"`
+	PrevClose      float64 `json:"prev_close"`
+	Change         float64 `json:"change"`
+	ChangePercent  float64 `json:"change_percent"`
+	Volume         float64 `json:"volume"`
+	PrevVolume     float64 `json:"prev_volume"`
+	RelativeVolume float64 `json:"relative_volume"`
+}
+
+// Filter restricts the movers of the lists. MinPrice and MinVolume default to the ones of the
+// config when missing, and Fractionable keeps only fractionable assets when set.
+type Filter struct {
+	MinPrice     *float64 `form:"min_price"`
+	MinVolume    *float64 `form:"min_volume"`
+	Exchange     string   `form:"exchange"`
+	Fractionable bool     `form:"fractionable"`
+	Limit        int      `form:"limit"`
+}
+
+// Lists are the movers of the day: the biggest gainers and losers by change percent, the most
+// active by volume and the ones with the most unusual volume
+type Lists struct {
+	Gainers       []Mover   `json:"gainers"`
+	Losers        []Mover   `json:"losers"`
+	MostActive    []Mover   `json:"most_active"`
+	UnusualVolume []Mover   `json:"unusual_volume"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NewMoversService creates a new movers application service
+func NewMoversService(assetRepo model.AssetsRepo, brk broker.Service, cfg *config.MoversConfig, log *zap.Logger) *Service {
+	return &Service{
+		assetRepo: assetRepo,
+		broker:    brk,
+		cfg:       cfg,
+		log:       log,
+	}
+}
+
+// Service represents the movers application service
+type Service struct {
+	assetRepo model.AssetsRepo
+	broker    broker.Service
+	cfg       *config.MoversConfig
+	log       *zap.Logger
+
+	mu     sync.RWMutex
+	ranked *Lists // every mover of each list, unfiltered
+	closed bool   // whether the market was closed at the last refresh
+}
+
+// Run refreshes the lists every refresh interval until ctx is done
+func (s *Service) Run(ctx context.Context) {
+	t := time.NewTicker(s.cfg.RefreshInterval)
+	defer t.Stop()
+	for {
+		if err := s.Refresh(ctx); err != nil {
+			s.log.Warn("Movers Error", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Refresh recomputes the lists from the snapshots of the tradable assets. Snapshots do not move
+// while the market is closed, so the lists are only recomputed once until it opens again.
+func (s *Service) Refresh(ctx context.Context) error {
+	clock, err := s.broker.GetClock(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	done := s.ranked != nil && s.closed && !clock.IsOpen
+	s.mu.RUnlock()
+	if done {
+		return nil
+	}
+
+	assets, err := s.assetRepo.ListTradable()
+	if err != nil {
+		return err
+	}
+	symbols := make([]string, len(assets))
+	for i, a := range assets {
+		symbols[i] = a.Symbol
+	}
+	snapshots, err := s.broker.GetSnapshots(ctx, symbols)
+	if err != nil {
+		return err
+	}
+
+	var movers []Mover
+	for _, a := range assets {
+		if m, ok := move(a, snapshots[a.Symbol]); ok {
+			movers = append(movers, m)
+		}
+	}
+	ranked := rank(movers)
+	ranked.UpdatedAt = clock.Timestamp
+
+	s.mu.Lock()
+	s.ranked, s.closed = ranked, !clock.IsOpen
+	s.mu.Unlock()
+	return nil
+}
+
+// List returns the lists filtered by f
+func (s *Service) List(f Filter) (*Lists, error) {
+	s.mu.RLock()
+	ranked := s.ranked
+	s.mu.RUnlock()
+	if ranked == nil {
+		return nil, apperr.New(http.StatusServiceUnavailable, "Movers are not available yet.")
+	}
+
+	minPrice, minVolume := s.cfg.MinPrice, s.cfg.MinVolume
+	if f.MinPrice != nil {
+		minPrice = *f.MinPrice
+	}
+	if f.MinVolume != nil {
+		minVolume = *f.MinVolume
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	keep := func(list []Mover) []Mover {
+		res := []Mover{}
+		for _, m := range list {
+			if len(res) == limit {
+				break
+			}
+			if m.Price < minPrice || m.Volume < minVolume {
+				continue
+			}
+			if f.Exchange != "" && !strings.EqualFold(m.Exchange, f.Exchange) {
+				continue
+			}
+			if f.Fractionable && !m.Fractionable {
+				continue
+			}
+			res = append(res, m)
+		}
+		return res
+	}
+	return &Lists{
+		Gainers:       keep(ranked.Gainers),
+		Losers:        keep(ranked.Losers),
+		MostActive:    keep(ranked.MostActive),
+		UnusualVolume: keep(ranked.UnusualVolume),
+		UpdatedAt:     ranked.UpdatedAt,
+	}, nil
+}
+
+// move returns the move of asset from its snapshot, if it has traded on the last two days
+func move(asset model.Asset, snapshot *broker.Snapshot) (Mover, bool) {
+	if snapshot == nil || snapshot.DailyBar == nil || snapshot.PrevDailyBar == nil || snapshot.PrevDailyBar.Close <= 0 {
+		return Mover{}, false
+	}
+	price := snapshot.DailyBar.Close
+	if snapshot.LatestTrade != nil && snapshot.LatestTrade.Price > 0 {
+		price = snapshot.LatestTrade.Price
+	}
+	prev := snapshot.PrevDailyBar
+	m := Mover{
+		Symbol:        asset.Symbol,
+		Name:          asset.Name,
+		Exchange:      asset.Exchange,
+		Fractionable:  asset.Fractionable,
+		Price:         price,
+		PrevClose:     prev.Close,
+		Change:        price - prev.Close,
+		ChangePercent: (price - prev.Close) / prev.Close * 100,
+		Volume:        snapshot.DailyBar.Volume,
+		PrevVolume:    prev.Volume,
+	}
+	if prev.Volume > 0 {
+		m.RelativeVolume = m.Volume / prev.Volume
+	}
+	return m, true
+}
+
+// rank sorts movers into each list, ties broken by symbol
+func rank(movers []Mover) *Lists {
+	res := &Lists{}
+	for _, m := range movers {
+		if m.ChangePercent > 0 {
+			res.Gainers = append(res.Gainers, m)
+		}
+		if m.ChangePercent < 0 {
+			res.Losers = append(res.Losers, m)
+		}
+		if m.Volume > 0 {
+			res.MostActive = append(res.MostActive, m)
+		}
+		if m.RelativeVolume > 0 {
+			res.UnusualVolume = append(res.UnusualVolume, m)
+		}
+	}
+	by := func(list []Mover, less func(a, b Mover) bool) {
+		sort.Slice(list, func(i, j int) bool {
+			if less(list[i], list[j]) {
+				return true
+			}
+			if less(list[j], list[i]) {
+				return false
+			}
+			return list[i].Symbol < list[j].Symbol
+		})
+	}
+	by(res.Gainers, func(a, b Mover) bool { return a.ChangePercent > b.ChangePercent })
+	by(res.Losers, func(a, b Mover) bool { return a.ChangePercent < b.ChangePercent })
+	by(res.MostActive, func(a, b Mover) bool { return a.Volume > b.Volume })
+	by(res.UnusualVolume, func(a, b Mover) bool { return a.RelativeVolume > b.RelativeVolume })
+	return res
+}
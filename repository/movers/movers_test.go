@@ -0,0 +1,94 @@
+package movers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/movers"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func symbols(list []movers.Mover) []string {
+	res := []string{}
+	for _, m := range list {
+		res = append(res, m.Symbol)
+	}
+	return res
+}
+
+func TestMovers(t *testing.T) {
+	s := brokertest.NewServer()
+	defer s.Close()
+	s.SetMarketOpen(true)
+	day := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	set := func(symbol string, prevClose, prevVolume, price, volume float64) {
+		s.SetBars(symbol, []broker.Bar{
+			{Timestamp: day, Close: prevClose, Volume: prevVolume},
+			{Timestamp: day.AddDate(0, 0, 1), Close: price, Volume: volume},
+		})
+		s.SetPrice(symbol, price)
+	}
+	set("AAPL", 100, 2000000, 110, 1000000)
+	set("MSFT", 100, 1000000, 90, 3000000)
+	set("TSLA", 100, 100000, 105, 500000)
+	set("PENNY", 0.25, 1000000, 0.5, 5000000)
+
+	assets := []model.Asset{
+		{Symbol: "AAPL", Exchange: "NASDAQ", Fractionable: true},
+		{Symbol: "MSFT", Exchange: "NASDAQ", Fractionable: true},
+		{Symbol: "NOPE", Exchange: "NYSE"},
+		{Symbol: "PENNY", Exchange: "OTC"},
+		{Symbol: "TSLA", Exchange: "NASDAQ"},
+	}
+	assetRepo := &mockdb.Asset{ListTradableFn: func() ([]model.Asset, error) { return assets, nil }}
+	cfg := &config.MoversConfig{RefreshInterval: time.Minute, MinPrice: 1, MinVolume: 100000}
+	svc := movers.NewMoversService(assetRepo, s.Broker(), cfg, zap.NewNop())
+
+	_, err := svc.List(movers.Filter{})
+	assert.Equal(t, 503, err.(*apperr.APPError).Status)
+
+	assert.Nil(t, svc.Refresh(context.Background()))
+	lists, err := svc.List(movers.Filter{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AAPL", "TSLA"}, symbols(lists.Gainers))
+	assert.Equal(t, []string{"MSFT"}, symbols(lists.Losers))
+	assert.Equal(t, []string{"MSFT", "AAPL", "TSLA"}, symbols(lists.MostActive))
+	assert.Equal(t, []string{"TSLA", "MSFT", "AAPL"}, symbols(lists.UnusualVolume))
+	assert.InDelta(t, 10.0, lists.Gainers[0].ChangePercent, 1e-9)
+	assert.Equal(t, 5.0, lists.UnusualVolume[0].RelativeVolume)
+
+	// filters apply to every list
+	zero := 0.0
+	lists, _ = svc.List(movers.Filter{MinPrice: &zero, Limit: 2})
+	assert.Equal(t, []string{"PENNY", "AAPL"}, symbols(lists.Gainers))
+	assert.Equal(t, []string{"PENNY", "MSFT"}, symbols(lists.MostActive))
+	volume := 800000.0
+	lists, _ = svc.List(movers.Filter{MinVolume: &volume, Exchange: "nasdaq"})
+	assert.Equal(t, []string{"AAPL"}, symbols(lists.Gainers))
+	assert.Equal(t, []string{"MSFT", "AAPL"}, symbols(lists.UnusualVolume))
+	lists, _ = svc.List(movers.Filter{Fractionable: true})
+	assert.Equal(t, []string{"AAPL"}, symbols(lists.Gainers))
+	assert.Equal(t, []string{"MSFT", "AAPL"}, symbols(lists.MostActive))
+
+	// the lists are recomputed once after the close, then left alone until the open
+	s.SetMarketOpen(false)
+	set("AAPL", 100, 2000000, 120, 1000000)
+	assert.Nil(t, svc.Refresh(context.Background()))
+	set("AAPL", 100, 2000000, 130, 1000000)
+	assert.Nil(t, svc.Refresh(context.Background()))
+	lists, _ = svc.List(movers.Filter{})
+	assert.Equal(t, 120.0, lists.Gainers[0].Price)
+	s.SetMarketOpen(true)
+	assert.Nil(t, svc.Refresh(context.Background()))
+	lists, _ = svc.List(movers.Filter{})
+	assert.Equal(t, 130.0, lists.Gainers[0].Price)
+}
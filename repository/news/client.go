@@ -0,0 +1,122 @@
+package news
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/zcoriarty/Backend/config"
+)
+
+// maxPageSize is the most articles Polygon returns in a single page
+const maxPageSize = 1000
+
+// Article is a news article about one or more tickers
+type Article struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	Description  string    `json:"description"`
+	ArticleURL   string    `json:"article_url"`
+	AmpURL       string    `json:"amp_url"`
+	ImageURL     string    `json:"image_url"`
+	Keywords     []string  `json:"keywords"`
+	PublishedUTC time.Time `json:"published_utc"`
+	Publisher    Publisher `json:"publisher"`
+	Tickers      []string  `json:"tickers"`
+}
+
+// Publisher is the publisher of an article
+type Publisher struct {
+	Name        string `json:"name"`
+	HomepageURL string `json:"homepage_url"`
+	LogoURL     string `json:"logo_url"`
+	FaviconURL  string `json:"favicon_url"`
+}
+
+// NewClient returns a client of the Polygon news API
+func NewClient(cfg *config.NewsConfig) *Client {
+	return &Client{
+		base:      cfg.APIBase,
+		key:       cfg.APIKey,
+		perTicker: cfg.PerTicker,
+		http:      &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Client fetches the latest articles of a ticker from the Polygon news API
+type Client struct {
+	base      string
+	key       string
+	perTicker int
+	http      *http.Client
+}
+
+type page struct {
+	Status  string    `json:"status"`
+	Results []Article `json:"results"`
+	NextURL string    `json:"next_url"`
+}
+
+// Articles returns the latest articles about ticker, newest first, following the pages of the
+// API until perTicker articles are fetched
+func (c *Client) Articles(ctx context.Context, ticker string) ([]Article, error) {
+	q := url.Values{}
+	q.Set("ticker", ticker)
+	q.Set("order", "desc")
+	q.Set("sort", "published_utc")
+	limit := c.perTicker
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	q.Set("limit", strconv.Itoa(limit))
+	next := c.base + "/v2/reference/news?" + q.Encode()
+
+	res := []Article{}
+	for next != "" && len(res) < c.perTicker {
+		p, err := c.get(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p.Results...)
+		next = p.NextURL
+	}
+	if len(res) > c.perTicker {
+		res = res[:c.perTicker]
+	}
+	return res, nil
+}
+
+// get fetches a page of articles. The next page URLs of the API carry no key, so the key is
+// set on every request.
+func (c *Client) get(ctx context.Context, rawURL string) (*page, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("apiKey", c.key)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("news: %s returned %d", u.Path, resp.StatusCode)
+	}
+	p := new(page)
+	if err := json.NewDecoder(resp.Body).Decode(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
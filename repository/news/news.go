@@ -0,0 +1,294 @@
+// Package news aggregates the news articles of tickers into a single feed, newest first,
+// caching the latest articles of every ticker.
+package news
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/marketdata"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxTickers bounds the tickers of a single request, and of a feed
+	maxTickers = 50
+	// defaultLimit is the number of articles of a page when a request gives none
+	defaultLimit = 20
+	// maxLimit bounds the number of articles of a page
+	maxLimit = 100
+	// concurrency bounds the tickers fetched from the provider at the same time
+	concurrency = 8
+)
+
+// Item is an article with the requested tickers it is about
+type Item struct {
+	Article
+	RelevantTickers []string `json:"relevant_tickers"`
+}
+
+// Page is a page of articles, newest first. NextCursor is the cursor of the next page, empty on
+// the last one.
+type Page struct {
+	Articles   []Item `json:"articles"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewNewsService creates a new news application service, caching the articles of every ticker
+// in store
+func NewNewsService(userRepo model.UserRepo, brk broker.Service, client *Client, store marketdata.Store, cfg *config.NewsConfig, log *zap.Logger) *Service {
+	return &Service{
+		userRepo: userRepo,
+		broker:   brk,
+		client:   client,
+		store:    store,
+		cfg:      cfg,
+		log:      log,
+	}
+}
+
+// Service represents the news application service
+type Service struct {
+	userRepo model.UserRepo
+	broker   broker.Service
+	client   *Client
+	store    marketdata.Store
+	cfg      *config.NewsConfig
+	log      *zap.Logger
+}
+
+// List returns a page of the articles about tickers, starting after cursor
+func (s *Service) List(ctx context.Context, tickers []string, cursor string, limit int) (*Page, error) {
+	tickers = normalize(tickers)
+	if len(tickers) == 0 {
+		return nil, apperr.New(http.StatusBadRequest, "Tickers are required.")
+	}
+	if len(tickers) > maxTickers {
+		return nil, apperr.New(http.StatusBadRequest, "Too many tickers.")
+	}
+	return s.page(ctx, tickers, cursor, limit)
+}
+
+// Feed returns a page of the articles about the positions and watchlisted assets of the
+// current user, starting after cursor
+func (s *Service) Feed(c *gin.Context, cursor string, limit int) (*Page, error) {
+	user, err := s.userRepo.View(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	if user.AccountID == "" {
+		return nil, apperr.New(http.StatusBadRequest, "Account not found.")
+	}
+	ctx := c.Request.Context()
+	positions, err := s.broker.ListPositions(ctx, user.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	watchlists, err := s.broker.ListWatchlists(ctx, user.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	var tickers []string
+	for _, p := range positions {
+		tickers = append(tickers, p.Symbol)
+	}
+	// the list of watchlists omits their assets
+	for _, w := range watchlists {
+		full, err := s.broker.GetWatchlist(ctx, user.AccountID, w.ID)
+		if err != nil {
+			return nil, err
+		}
+		tickers = append(tickers, full.Symbols()...)
+	}
+	// positions come first, so they are kept when there are too many tickers
+	if tickers = normalize(tickers); len(tickers) > maxTickers {
+		tickers = tickers[:maxTickers]
+	}
+	if len(tickers) == 0 {
+		return &Page{Articles: []Item{}}, nil
+	}
+	return s.page(ctx, tickers, cursor, limit)
+}
+
+// page merges the articles of tickers, deduplicated by ID, and returns the ones after cursor
+func (s *Service) page(ctx context.Context, tickers []string, cursor string, limit int) (*Page, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	articles, err := s.fetch(ctx, tickers)
+	if err != nil {
+		return nil, err
+	}
+
+	requested := map[string]bool{}
+	for _, t := range tickers {
+		requested[t] = true
+	}
+	items := map[string]*Item{}
+	for _, t := range tickers {
+		for _, a := range articles[t] {
+			item, ok := items[a.ID]
+			if !ok {
+				item = &Item{Article: a}
+				items[a.ID] = item
+			}
+			item.RelevantTickers = append(item.RelevantTickers, t)
+		}
+	}
+	merged := make([]Item, 0, len(items))
+	for _, item := range items {
+		// an article may be about requested tickers it was not fetched for
+		for _, t := range item.Tickers {
+			if requested[strings.ToUpper(t)] {
+				item.RelevantTickers = append(item.RelevantTickers, t)
+			}
+		}
+		item.RelevantTickers = normalize(item.RelevantTickers)
+		sort.Strings(item.RelevantTickers)
+		merged = append(merged, *item)
+	}
+	sort.Slice(merged, func(i, j int) bool { return before(merged[i].Article, merged[j].Article) })
+
+	res := &Page{Articles: []Item{}}
+	for _, item := range merged {
+		if after != nil && !before(*after, item.Article) {
+			continue
+		}
+		if len(res.Articles) == limit {
+			res.NextCursor = encodeCursor(res.Articles[limit-1].Article)
+			break
+		}
+		res.Articles = append(res.Articles, item)
+	}
+	return res, nil
+}
+
+// fetch returns the articles of every ticker, from the store when cached. A ticker whose
+// articles cannot be fetched is left out, unless none of them can.
+func (s *Service) fetch(ctx context.Context, tickers []string) (map[string][]Article, error) {
+	keys := make([]string, len(tickers))
+	for i, t := range tickers {
+		keys[i] = "news:" + t
+	}
+	cached, err := s.store.Get(keys)
+	if err != nil {
+		s.log.Warn("News Error", zap.Error(err))
+		cached = map[string][]byte{}
+	}
+
+	res := map[string][]Article{}
+	var missing []string
+	for i, t := range tickers {
+		var articles []Article
+		if v, ok := cached[keys[i]]; ok && json.Unmarshal(v, &articles) == nil {
+			res[t] = articles
+			continue
+		}
+		missing = append(missing, t)
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	loaded := map[string][]byte{}
+	sem := make(chan struct{}, concurrency)
+	for _, t := range missing {
+		wg.Add(1)
+		go func(t string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			articles, err := s.client.Articles(ctx, t)
+			if err != nil {
+				s.log.Warn("News Error", zap.String("ticker", t), zap.Error(err))
+				return
+			}
+			v, _ := json.Marshal(articles)
+			mu.Lock()
+			res[t] = articles
+			loaded["news:"+t] = v
+			mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+
+	if len(res) == 0 {
+		return nil, apperr.New(http.StatusBadGateway, "Couldn't get news.")
+	}
+	if len(loaded) > 0 {
+		if err := s.store.Set(loaded, s.cfg.CacheTTL); err != nil {
+			s.log.Warn("News Error", zap.Error(err))
+		}
+	}
+	return res, nil
+}
+
+// before reports whether a comes before b in a page: newest first, then by descending ID
+func before(a, b Article) bool {
+	if !a.PublishedUTC.Equal(b.PublishedUTC) {
+		return a.PublishedUTC.After(b.PublishedUTC)
+	}
+	return a.ID > b.ID
+}
+
+// encodeCursor returns the cursor of the page after article
+func encodeCursor(a Article) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(a.PublishedUTC.UTC().Format(time.RFC3339Nano) + "|" + a.ID))
+}
+
+// decodeCursor returns the last article of the page before cursor, nil for the first page
+func decodeCursor(cursor string) (*Article, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	invalid := apperr.New(http.StatusBadRequest, "Invalid cursor.")
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	parts := strings.SplitN(string(b), "|", 2)
+	if len(parts) != 2 {
+		return nil, invalid
+	}
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, invalid
+	}
+	return &Article{ID: parts[1], PublishedUTC: t}, nil
+}
+
+// normalize upper cases tickers and drops the blank and repeated ones, keeping their order
+func normalize(tickers []string) []string {
+	seen := map[string]bool{}
+	res := []string{}
+	for _, t := range tickers {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		res = append(res, t)
+	}
+	return res
+}
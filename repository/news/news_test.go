@@ -0,0 +1,124 @@
+package news_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/config"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/marketdata"
+	"github.com/zcoriarty/Backend/repository/news"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// polygon serves the news of a few tickers, two articles per page
+type polygon struct {
+	sync.Mutex
+	articles map[string][]news.Article
+	calls    map[string]int
+}
+
+func (p *polygon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.Lock()
+	defer p.Unlock()
+	q := r.URL.Query()
+	ticker := q.Get("ticker")
+	p.calls[ticker]++
+	if q.Get("apiKey") != "key" || ticker == "FAIL" {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	articles := p.articles[ticker]
+	res := map[string]interface{}{"status": "OK"}
+	if q.Get("page") == "" && len(articles) > 2 {
+		res["next_url"] = "http://" + r.Host + "/v2/reference/news?ticker=" + ticker + "&page=2"
+		articles = articles[:2]
+	} else if q.Get("page") != "" {
+		articles = articles[2:]
+	}
+	res["results"] = articles
+	json.NewEncoder(w).Encode(res)
+}
+
+func TestNews(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2021, 3, 1, h, 0, 0, 0, time.UTC) }
+	shared := news.Article{ID: "a1", PublishedUTC: at(10), Tickers: []string{"AAPL", "MSFT"}}
+	p := &polygon{
+		articles: map[string][]news.Article{
+			"AAPL": {shared, {ID: "a2", PublishedUTC: at(9), Tickers: []string{"AAPL"}}, {ID: "a3", PublishedUTC: at(8), Tickers: []string{"AAPL"}}},
+			"MSFT": {shared, {ID: "a4", PublishedUTC: at(9), Tickers: []string{"MSFT"}}},
+			"TSLA": {{ID: "a5", PublishedUTC: at(12), Tickers: []string{"TSLA"}}},
+		},
+		calls: map[string]int{},
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAccount("acc", 10000)
+	brk.SetPrice("TSLA", 100)
+	ctx := context.Background()
+	qty := 1.0
+	_, err := brk.Broker().CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "TSLA", Qty: &qty, Side: broker.Buy, Type: "market", TimeInForce: "day"})
+	assert.Nil(t, err)
+	_, err = brk.Broker().CreateWatchlist(ctx, "acc", &broker.WatchlistRequest{Name: "tech", Symbols: []string{"MSFT", "TSLA"}})
+	assert.Nil(t, err)
+
+	cfg := &config.NewsConfig{APIBase: srv.URL, APIKey: "key", Timeout: time.Second, CacheTTL: time.Minute, PerTicker: 10}
+	userRepo := &mockdb.User{ViewFn: func(id int) (*model.User, error) { return &model.User{ID: id, AccountID: "acc"}, nil }}
+	svc := news.NewNewsService(userRepo, brk.Broker(), news.NewClient(cfg), marketdata.NewMemoryStore(), cfg, zap.NewNop())
+	ids := func(page *news.Page) []string {
+		res := []string{}
+		for _, a := range page.Articles {
+			res = append(res, a.ID)
+		}
+		return res
+	}
+
+	// articles are deduplicated, merged newest first and paged
+	page, err := svc.List(ctx, []string{"aapl", "msft", "fail"}, "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a1", "a4"}, ids(page))
+	assert.Equal(t, []string{"AAPL", "MSFT"}, page.Articles[0].RelevantTickers)
+	assert.NotEmpty(t, page.NextCursor)
+	page, err = svc.List(ctx, []string{"AAPL", "MSFT", "FAIL"}, page.NextCursor, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a2", "a3"}, ids(page))
+	assert.Empty(t, page.NextCursor)
+
+	// the articles of a ticker are cached, and its pages followed
+	p.Lock()
+	assert.Equal(t, 2, p.calls["AAPL"])
+	assert.Equal(t, 1, p.calls["MSFT"])
+	assert.Equal(t, 2, p.calls["FAIL"])
+	p.Unlock()
+
+	_, err = svc.List(ctx, []string{"FAIL"}, "", 0)
+	assert.Equal(t, http.StatusBadGateway, err.(*apperr.APPError).Status)
+	_, err = svc.List(ctx, []string{" "}, "", 0)
+	assert.Equal(t, http.StatusBadRequest, err.(*apperr.APPError).Status)
+	_, err = svc.List(ctx, []string{"AAPL"}, "nope", 0)
+	assert.Equal(t, http.StatusBadRequest, err.(*apperr.APPError).Status)
+
+	// the feed follows the positions and watchlists of the user
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("id", 1)
+	page, err = svc.Feed(c, "", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a5", "a1", "a4"}, ids(page))
+	assert.Equal(t, []string{"MSFT"}, page.Articles[1].RelevantTickers)
+}
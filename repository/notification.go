@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"go.uber.org/zap"
+)
+
+// NewNotificationRepo returns a new NotificationRepo instance
+func NewNotificationRepo(db orm.DB, log *zap.Logger) *NotificationRepo {
+	return &NotificationRepo{db, log}
+}
+
+// NotificationRepo is the client for our notification model and the preferences of users
+type NotificationRepo struct {
+	db  orm.DB
+	log *zap.Logger
+}
+
+// Create stores a notification
+func (r *NotificationRepo) Create(n *model.Notification) error {
+	if err := r.db.Insert(n); err != nil {
+		r.log.Warn("NotificationRepo Error", zap.Error(err))
+		return apperr.DB
+	}
+	return nil
+}
+
+// View returns single notification by ID
+func (r *NotificationRepo) View(id int) (*model.Notification, error) {
+	n := new(model.Notification)
+	err := r.db.Model(n).Where("id = ?", id).Where(notDeleted).Select()
+	if err != nil {
+		r.log.Warn("NotificationRepo Error", zap.Error(err))
+		return nil, apperr.New(http.StatusNotFound, "Notification not found.")
+	}
+	return n, nil
+}
+
+// List returns the notifications of a user, newest first, or only the unread ones
+func (r *NotificationRepo) List(userID int, unread bool, p *model.Pagination) ([]model.Notification, error) {
+	var notifications []model.Notification
+	q := r.db.Model(&notifications).Where("user_id = ?", userID).Where(notDeleted)
+	if unread {
+		q.Where("read_at is null")
+	}
+	err := q.Order("id desc").Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil {
+		r.log.Warn("NotificationRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return notifications, nil
+}
+
+// CountUnread returns the number of unread notifications of a user
+func (r *NotificationRepo) CountUnread(userID int) (int, error) {
+	n, err := r.db.Model((*model.Notification)(nil)).Where("user_id = ?", userID).
+		Where("read_at is null").Where(notDeleted).Count()
+	if err != nil {
+		r.log.Warn("NotificationRepo Error", zap.Error(err))
+		return 0, apperr.DB
+	}
+	return n, nil
+}
+
+// UpdateRead marks notifications of a user read or unread, all of them when ids is empty.
+// Notifications already read keep the time they were read at.
+func (r *NotificationRepo) UpdateRead(userID int, ids []int, read bool) error {
+	q := r.db.Model((*model.Notification)(nil)).Set("updated_at = now()").
+		Where("user_id = ?", userID).Where(notDeleted)
+	if read {
+		q.Set("read_at = now()").Where("read_at is null")
+	} else {
+		q.Set("read_at = null").Where("read_at is not null")
+	}
+	if len(ids) > 0 {
+		q.Where("id in (?)", pg.In(ids))
+	}
+	if _, err := q.Update(); err != nil {
+		r.log.Warn("NotificationRepo Error", zap.Error(err))
+		return apperr.DB
+	}
+	return nil
+}
+
+// Preferences returns the notification preferences a user set
+func (r *NotificationRepo) Preferences(userID int) ([]model.NotificationPreference, error) {
+	var prefs []model.NotificationPreference
+	err := r.db.Model(&prefs).Where("user_id = ?", userID).Where(notDeleted).Order("category asc").Select()
+	if err != nil {
+		r.log.Warn("NotificationRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return prefs, nil
+}
+
+// preferenceColumns are the columns of a preference updated when it is saved again
+var preferenceColumns = []string{"email", "sms", "push", "in_app", "updated_at"}
+
+// SavePreference creates or updates the preference of a user for a category
+func (r *NotificationRepo) SavePreference(p *model.NotificationPreference) error {
+	q := r.db.Model(p).OnConflict("(user_id, category) WHERE deleted_at IS NULL DO UPDATE")
+	for _, c := range preferenceColumns {
+		q.Set("? = EXCLUDED.?", pg.Ident(c), pg.Ident(c))
+	}
+	_, err := q.Returning("*").Insert()
+	if err != nil {
+		r.log.Warn("NotificationRepo Error", zap.Error(err))
+		return apperr.DB
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strconv"
+
+	"github.com/zcoriarty/Backend/mail"
+	"github.com/zcoriarty/Backend/mobile"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/push"
+)
+
+// ErrUnreachable is returned by channels for users without an address on the channel
+var ErrUnreachable = errors.New("notifications: user unreachable on channel")
+
+// Channel delivers notifications to users outside of the app
+type Channel interface {
+	Send(ctx context.Context, user *model.User, n *model.Notification) error
+}
+
+// NewChannels returns the email, SMS and push channels
+func NewChannels(mail mail.Service, mobile mobile.Service, devices *push.Devices) map[string]Channel {
+	return map[string]Channel{
+		model.ChannelEmail: &Email{mail},
+		model.ChannelSMS:   &SMS{mobile},
+		model.ChannelPush:  &Push{devices},
+	}
+}
+
+// Email delivers notifications by email
+type Email struct {
+	mail mail.Service
+}
+
+// Send sends n to the email address of user
+func (e *Email) Send(ctx context.Context, user *model.User, n *model.Notification) error {
+	if user.Email == "" {
+		return ErrUnreachable
+	}
+	return e.mail.SendWithDefaults(n.Title, user.Email, n.Body, "<p>"+html.EscapeString(n.Body)+"</p>")
+}
+
+// SMS delivers notifications by text message
+type SMS struct {
+	mobile mobile.Service
+}
+
+// Send texts the body of n to the mobile number of user
+func (s *SMS) Send(ctx context.Context, user *model.User, n *model.Notification) error {
+	if user.Mobile == "" {
+		return ErrUnreachable
+	}
+	return s.mobile.SendSMS(user.CountryCode+user.Mobile, n.Body)
+}
+
+// Push delivers notifications to the devices of users
+type Push struct {
+	devices *push.Devices
+}
+
+// Send pushes n to the devices of user, with its category and ID in its data
+func (p *Push) Send(ctx context.Context, user *model.User, n *model.Notification) error {
+	data := map[string]string{"category": n.Category}
+	if n.ID != 0 {
+		data["notification_id"] = strconv.Itoa(n.ID)
+	}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	err := p.devices.Send(ctx, user.ID, &push.Message{Title: n.Title, Body: n.Body, Data: data})
+	if err == push.ErrNoDevice {
+		return ErrUnreachable
+	}
+	return err
+}
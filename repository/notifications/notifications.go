@@ -0,0 +1,210 @@
+// Package notifications delivers notifications to users by email, SMS, push notification and
+// in the app, on the channels they chose for each category, and serves their in-app inbox.
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/platform/structs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	// Notify delivers n to its user on channels, or on the channels the user chose for its
+	// category when none is given
+	Notify(ctx context.Context, n *model.Notification, channels ...string) error
+}
+
+// NewNotificationService creates a new notification application service delivering
+// notifications on channels. In-app notifications are stored with notificationRepo.
+func NewNotificationService(userRepo model.UserRepo, notificationRepo model.NotificationRepo, channels map[string]Channel) *Service {
+	return &Service{
+		userRepo:         userRepo,
+		notificationRepo: notificationRepo,
+		channels:         channels,
+		now:              time.Now,
+	}
+}
+
+// Service represents the notification application service
+type Service struct {
+	userRepo         model.UserRepo
+	notificationRepo model.NotificationRepo
+	channels         map[string]Channel
+	now              func() time.Time
+}
+
+// Notify delivers n to its user. Every channel is attempted, and the first failure is
+// returned. Users without an address on a channel they chose, e.g. without a device, are
+// skipped on it.
+func (s *Service) Notify(ctx context.Context, n *model.Notification, channels ...string) error {
+	user, err := s.userRepo.View(n.UserID)
+	if err != nil {
+		return err
+	}
+	chosen := len(channels) == 0
+	if chosen {
+		pref, err := s.preference(n.UserID, n.Category)
+		if err != nil {
+			return err
+		}
+		channels = pref.Channels()
+	}
+
+	var first error
+	// the notification is stored first, so the other channels can refer to it
+	for _, ch := range channels {
+		if ch == model.ChannelInApp {
+			first = s.notificationRepo.Create(n)
+			break
+		}
+	}
+	for _, ch := range channels {
+		if ch == model.ChannelInApp {
+			continue
+		}
+		var err error
+		if c, ok := s.channels[ch]; ok {
+			err = c.Send(ctx, user, n)
+		} else {
+			err = fmt.Errorf("notifications: unknown channel %q", ch)
+		}
+		if err == ErrUnreachable && chosen {
+			continue
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// List returns a page of the in-app notifications of the current user, or of the unread ones,
+// and their number of unread notifications
+func (s *Service) List(c *gin.Context, unread bool, p *model.Pagination) ([]model.Notification, int, error) {
+	id := c.GetInt("id")
+	notifications, err := s.notificationRepo.List(id, unread, p)
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := s.notificationRepo.CountUnread(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, n, nil
+}
+
+// SetRead marks a notification of the current user read or unread
+func (s *Service) SetRead(c *gin.Context, id int, read bool) (*model.Notification, error) {
+	n, err := s.notificationRepo.View(id)
+	if err != nil {
+		return nil, err
+	}
+	if n.UserID != c.GetInt("id") {
+		return nil, apperr.New(http.StatusNotFound, "Notification not found.")
+	}
+	if read == (n.ReadAt != nil) {
+		return n, nil
+	}
+	if err := s.notificationRepo.UpdateRead(n.UserID, []int{n.ID}, read); err != nil {
+		return nil, err
+	}
+	n.ReadAt = nil
+	if read {
+		now := s.now()
+		n.ReadAt = &now
+	}
+	return n, nil
+}
+
+// SetReadAll marks notifications of the current user read or unread, all of them when ids is
+// empty, and returns their number of unread notifications
+func (s *Service) SetReadAll(c *gin.Context, ids []int, read bool) (int, error) {
+	id := c.GetInt("id")
+	if err := s.notificationRepo.UpdateRead(id, ids, read); err != nil {
+		return 0, err
+	}
+	return s.notificationRepo.CountUnread(id)
+}
+
+// Preferences returns the channels the current user receives every category on
+func (s *Service) Preferences(c *gin.Context) ([]model.NotificationPreference, error) {
+	id := c.GetInt("id")
+	stored, err := s.notificationRepo.Preferences(id)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.NotificationPreference, 0, len(model.NotifyCategories))
+	for _, category := range model.NotifyCategories {
+		res = append(res, *find(stored, id, category))
+	}
+	return res, nil
+}
+
+// PreferenceUpdate contains the channels of a category to turn on or off
+type PreferenceUpdate struct {
+	Category string
+	Email    *bool
+	SMS      *bool
+	Push     *bool
+	InApp    *bool
+}
+
+// UpdatePreferences updates the channels the current user receives categories on, and returns
+// their preferences
+func (s *Service) UpdatePreferences(c *gin.Context, updates []PreferenceUpdate) ([]model.NotificationPreference, error) {
+	for _, u := range updates {
+		if !known(u.Category) {
+			return nil, apperr.New(http.StatusBadRequest, "Unknown category.")
+		}
+	}
+	id := c.GetInt("id")
+	stored, err := s.notificationRepo.Preferences(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range updates {
+		p := find(stored, id, updates[i].Category)
+		structs.Merge(p, &updates[i])
+		if err := s.notificationRepo.SavePreference(p); err != nil {
+			return nil, err
+		}
+	}
+	return s.Preferences(c)
+}
+
+// preference returns the channels a user receives category on
+func (s *Service) preference(userID int, category string) (*model.NotificationPreference, error) {
+	stored, err := s.notificationRepo.Preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	return find(stored, userID, category), nil
+}
+
+// find returns the preference of category among the stored preferences of a user, or its
+// default
+func find(stored []model.NotificationPreference, userID int, category string) *model.NotificationPreference {
+	for i := range stored {
+		if stored[i].Category == category {
+			return &stored[i]
+		}
+	}
+	return model.DefaultPreference(userID, category)
+}
+
+func known(category string) bool {
+	for _, c := range model.NotifyCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zcoriarty/Backend/mock"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/push"
+	"github.com/zcoriarty/Backend/repository/notifications"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNotify(t *testing.T) {
+	users := map[int]*model.User{
+		1: {ID: 1, Email: "a@b.c", CountryCode: "+1", Mobile: "5550100"},
+		2: {ID: 2, Email: "d@e.f"},
+	}
+	userRepo := &mockdb.User{ViewFn: func(id int) (*model.User, error) { return users[id], nil }}
+	var stored []model.Notification
+	prefs := []model.NotificationPreference{{UserID: 1, Category: model.NotifyTrades, SMS: true, InApp: true}}
+	notificationRepo := &mockdb.Notification{
+		CreateFn: func(n *model.Notification) error {
+			n.ID = len(stored) + 1
+			stored = append(stored, *n)
+			return nil
+		},
+		PreferencesFn: func(userID int) ([]model.NotificationPreference, error) {
+			if userID == 1 {
+				return prefs, nil
+			}
+			return nil, nil
+		},
+	}
+	deviceRepo := &mockdb.Device{ListFn: func(userID int) ([]model.Device, error) {
+		if userID == 1 {
+			return []model.Device{{ID: 1, UserID: 1, Platform: model.PlatformIOS, Token: "device"}}, nil
+		}
+		return nil, nil
+	}}
+	var sent []string
+	pushFails := false
+	channels := notifications.NewChannels(
+		&mock.Mail{SendWithDefaultsFn: func(subject, to, content, html string) error {
+			sent = append(sent, "email "+to+": "+subject)
+			return nil
+		}},
+		&mock.Mobile{SendSMSFn: func(to, body string) error {
+			sent = append(sent, "sms "+to+": "+body)
+			return nil
+		}},
+		push.NewDevices(deviceRepo, map[string]push.Service{model.PlatformIOS: &mock.Push{SendFn: func(token string, m *push.Message) error {
+			if pushFails {
+				return errors.New("unavailable")
+			}
+			sent = append(sent, "push "+token+": "+m.Title+" "+m.Data["category"]+" "+m.Data["notification_id"])
+			return nil
+		}}}, zap.NewNop()),
+	)
+	svc := notifications.NewNotificationService(userRepo, notificationRepo, channels)
+	ctx := context.Background()
+
+	// the channels chosen for the category are used
+	assert.Nil(t, svc.Notify(ctx, &model.Notification{UserID: 1, Category: model.NotifyTrades, Title: "Filled", Body: "AAPL filled."}))
+	assert.Equal(t, []string{"sms +15550100: AAPL filled."}, sent)
+	assert.Len(t, stored, 1)
+
+	// and the defaults for the others, with the stored notification referred to by push
+	sent = nil
+	assert.Nil(t, svc.Notify(ctx, &model.Notification{UserID: 1, Category: model.NotifyTransfers, Title: "Deposit complete"}))
+	assert.Equal(t, []string{"email a@b.c: Deposit complete", "push device: Deposit complete transfers 2"}, sent)
+
+	// users are skipped on the channels they cannot be reached on
+	sent = nil
+	assert.Nil(t, svc.Notify(ctx, &model.Notification{UserID: 2, Category: model.NotifySecurity, Title: "New login"}))
+	assert.Equal(t, []string{"email d@e.f: New login"}, sent)
+	assert.Len(t, stored, 3)
+
+	// but not on the channels asked for, and failures are returned once every channel is tried
+	sent = nil
+	assert.Equal(t, notifications.ErrUnreachable, svc.Notify(ctx, &model.Notification{UserID: 2, Category: model.NotifyAlerts}, model.ChannelPush))
+	pushFails = true
+	err := svc.Notify(ctx, &model.Notification{UserID: 1, Category: model.NotifyAlerts, Title: "AAPL"}, model.ChannelPush, model.ChannelEmail)
+	assert.EqualError(t, err, "unavailable")
+	assert.Equal(t, []string{"email a@b.c: AAPL"}, sent)
+	assert.Len(t, stored, 3)
+}
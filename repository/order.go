@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// orderLock is the advisory lock key serializing the idempotency key reservations of a user
+const orderLock = 8002
+
+// NewOrderRepo returns a new OrderRepo instance
+func NewOrderRepo(db *pg.DB, log *zap.Logger) *OrderRepo {
+	return &OrderRepo{db, log}
+}
+
+// OrderRepo is the client for the order ledger
+type OrderRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// Reserve records o before it is submitted, unless the user already recorded an order with
+// the same idempotency key, in which case that order is returned and o is left alone
+func (r *OrderRepo) Reserve(o *model.Order) (*model.Order, error) {
+	var existing *model.Order
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", orderLock, o.UserID); err != nil {
+			return err
+		}
+		found := new(model.Order)
+		err := tx.Model(found).Where("user_id = ? AND idempotency_key = ?", o.UserID, o.IdempotencyKey).Select()
+		switch err {
+		case nil:
+			existing = found
+			return nil
+		case pg.ErrNoRows:
+			return tx.Insert(o)
+		default:
+			return err
+		}
+	})
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return existing, nil
+}
+
+// brokerColumns are the columns of an order that follow its broker order
+var brokerColumns = []string{
+	"client_order_id", "replaced_by", "type", "time_in_force", "qty", "notional", "limit_price", "stop_price",
+	"filled_qty", "filled_avg_price", "status", "submitted_at", "filled_at", "canceled_at", "updated_at",
+}
+
+// Create records an order. An order already recorded with the same broker order ID, e.g. by a
+// concurrent import, is updated with the state of o instead, and o is loaded from it.
+func (r *OrderRepo) Create(o *model.Order) error {
+	q := r.db.Model(o).OnConflict("(order_id) WHERE order_id <> '' DO UPDATE")
+	for _, c := range brokerColumns {
+		q.Set("? = EXCLUDED.?", pg.Ident(c), pg.Ident(c))
+	}
+	_, err := q.Returning("*").Insert()
+	return r.error(err)
+}
+
+// Update updates an order with the latest state of its broker order
+func (r *OrderRepo) Update(o *model.Order) error {
+	_, err := r.db.Model(o).WherePK().Update()
+	return r.error(err)
+}
+
+// Delete removes an order that could not be submitted, releasing its idempotency key
+func (r *OrderRepo) Delete(o *model.Order) error {
+	_, err := r.db.Model(o).WherePK().Delete()
+	return r.error(err)
+}
+
+// View returns an order of a user by its broker order ID
+func (r *OrderRepo) View(userID int, orderID string) (*model.Order, error) {
+	o := new(model.Order)
+	err := r.db.Model(o).Where("user_id = ? AND order_id = ?", userID, orderID).Select()
+	if err == pg.ErrNoRows {
+		return nil, apperr.New(http.StatusNotFound, "Order not found.")
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return o, nil
+}
+
+// Find returns an order by its broker order ID
+func (r *OrderRepo) Find(orderID string) (*model.Order, error) {
+	o := new(model.Order)
+	err := r.db.Model(o).Where("order_id = ?", orderID).Select()
+	if err == pg.ErrNoRows {
+		return nil, apperr.New(http.StatusNotFound, "Order not found.")
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return o, nil
+}
+
+// List returns the orders of a user matching f, newest first
+func (r *OrderRepo) List(userID int, f *model.OrderFilter, p *model.Pagination) ([]model.Order, error) {
+	var orders []model.Order
+	q := r.db.Model(&orders).Where("user_id = ?", userID).Where("status <> ?", model.OrderPending)
+	switch f.Status {
+	case "open":
+		q.Where("status NOT IN (?)", pg.In(model.ClosedOrderStatuses))
+	case "closed":
+		q.Where("status IN (?)", pg.In(model.ClosedOrderStatuses))
+	}
+	if len(f.Symbols) > 0 {
+		q.Where("symbol IN (?)", pg.In(f.Symbols))
+	}
+	if f.Side != "" {
+		q.Where("side = ?", f.Side)
+	}
+	if f.After != nil {
+		q.Where("created_at > ?", f.After)
+	}
+	if f.Until != nil {
+		q.Where("created_at < ?", f.Until)
+	}
+	err := q.Order("created_at desc", "id desc").Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return orders, nil
+}
+
+// Open returns the submitted orders of a user that have not reached a final status
+func (r *OrderRepo) Open(userID int) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.db.Model(&orders).Where("user_id = ?", userID).Where("order_id <> ''").
+		Where("status NOT IN (?)", pg.In(model.ClosedOrderStatuses)).
+		Order("id asc").Select()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return orders, nil
+}
+
+// Count returns the number of orders recorded for a user
+func (r *OrderRepo) Count(userID int) (int, error) {
+	n, err := r.db.Model((*model.Order)(nil)).Where("user_id = ?", userID).Count()
+	if err != nil {
+		return 0, r.error(err)
+	}
+	return n, nil
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (r *OrderRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apperr.APPError); ok {
+		return err
+	}
+	r.log.Warn("OrderRepo Error", zap.Error(err))
+	return apperr.DB
+}
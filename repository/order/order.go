@@ -0,0 +1,394 @@
+package order
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/risk"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// importLimit is the number of broker orders imported into the ledger of a user without any
+	importLimit = 500
+	// maxClientOrderID is the longest client order ID the broker accepts
+	maxClientOrderID = 128
+	// staleAfter is how long after its reservation an order still pending is assumed to have
+	// been left behind by a failed submission
+	staleAfter = time.Minute
+	// updateAttempts bounds the attempts to record an order the broker accepted
+	updateAttempts = 3
+)
+
+// NewOrderService creates a new order application service
+func NewOrderService(userRepo model.UserRepo, orderRepo model.OrderRepo, brk broker.Service) *Service {
+	return &Service{
+		userRepo:  userRepo,
+		orderRepo: orderRepo,
+		broker:    brk,
+		risk:      risk.New(brk, risk.Default...),
+		now:       time.Now,
+	}
+}
+
+// Service represents the order application service. Every order placed through it is
+// recorded in the order ledger, which follows the status of the broker orders.
+type Service struct {
+	userRepo  model.UserRepo
+	orderRepo model.OrderRepo
+	broker    broker.Service
+	risk      *risk.Checker
+	now       func() time.Time
+}
+
+// Create places an order for the current user once it passes the pre-trade checks. Orders
+// placed with an idempotency key are submitted once: placing another order with the same key
+// returns the first one.
+func (s *Service) Create(c *gin.Context, key string, req *broker.OrderRequest) (*model.Order, error) {
+	user, err := s.account(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.Place(c.Request.Context(), user, key, req)
+}
+
+// Place places an order for user, who must have a brokerage account, like Create
+func (s *Service) Place(ctx context.Context, user *model.User, key string, req *broker.OrderRequest) (*model.Order, error) {
+	o := &model.Order{
+		UserID:         user.ID,
+		AccountID:      user.AccountID,
+		IdempotencyKey: key,
+		Symbol:         req.Symbol,
+		Side:           req.Side,
+		Status:         model.OrderPending,
+	}
+	return s.submit(ctx, o, func() (*broker.Order, error) {
+		if err := s.risk.Review(ctx, user.AccountID, user.MaxOrderSize, req); err != nil {
+			return nil, err
+		}
+		if req.ClientOrderID == "" && len(key) <= maxClientOrderID {
+			// the broker refuses a second order with the same client order ID
+			req.ClientOrderID = key
+		}
+		return s.broker.CreateOrder(ctx, user.AccountID, req)
+	})
+}
+
+// Replace replaces an open order of the current user once the replacement passes the
+// pre-trade checks. The replaced order is marked as replaced by the new one.
+func (s *Service) Replace(c *gin.Context, key, orderID string, req *broker.ReplaceOrderRequest) (*model.Order, error) {
+	user, err := s.account(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx := c.Request.Context()
+	current, err := s.view(ctx, user, orderID)
+	if err != nil {
+		return nil, err
+	}
+	o := &model.Order{
+		UserID:         user.ID,
+		AccountID:      user.AccountID,
+		IdempotencyKey: key,
+		Replaces:       current.OrderID,
+		Symbol:         current.Symbol,
+		Side:           current.Side,
+		Status:         model.OrderPending,
+	}
+	o, err = s.submit(ctx, o, func() (*broker.Order, error) {
+		if err := s.risk.ReviewReplace(ctx, user.AccountID, orderID, user.MaxOrderSize, req); err != nil {
+			return nil, err
+		}
+		if req.ClientOrderID == "" && len(key) <= maxClientOrderID {
+			// like Place, so that a replacement left pending can be found at the broker
+			req.ClientOrderID = key
+		}
+		return s.broker.ReplaceOrder(ctx, user.AccountID, orderID, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if current.ReplacedBy == "" {
+		current.ReplacedBy = o.OrderID
+		current.Status = broker.OrderReplaced
+		if err := s.orderRepo.Update(current); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// submit records o and submits it with place, reserving its idempotency key first if it has one
+func (s *Service) submit(ctx context.Context, o *model.Order, place func() (*broker.Order, error)) (*model.Order, error) {
+	if o.IdempotencyKey != "" {
+		existing, err := s.orderRepo.Reserve(o)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			if existing, err = s.replay(ctx, existing, o); existing != nil || err != nil {
+				return existing, err
+			}
+			// the stale reservation was released, so the key is reserved again
+			if existing, err = s.orderRepo.Reserve(o); err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				return nil, apperr.New(http.StatusConflict, "An order with this Idempotency-Key is being submitted.")
+			}
+		}
+	}
+	order, err := place()
+	if err != nil {
+		if o.ID != 0 {
+			if derr := s.orderRepo.Delete(o); derr != nil {
+				return nil, derr
+			}
+		}
+		return nil, err
+	}
+	record(o, order)
+	if o.ID == 0 {
+		return o, s.orderRepo.Create(o)
+	}
+	// a reservation left pending would hold its key until it goes stale
+	for i := 1; ; i++ {
+		if err = s.orderRepo.Update(o); err == nil || i == updateAttempts {
+			return o, err
+		}
+	}
+}
+
+// replay returns the order recorded with the idempotency key of o. A stale reservation is
+// recovered from the broker order placed with the key as client order ID, or released when the
+// broker has none, in which case replay returns no order.
+func (s *Service) replay(ctx context.Context, existing, o *model.Order) (*model.Order, error) {
+	if existing.Symbol != o.Symbol || existing.Side != o.Side || existing.Replaces != o.Replaces {
+		return nil, apperr.New(http.StatusUnprocessableEntity, "Idempotency-Key was already used for another order.")
+	}
+	if existing.Status != model.OrderPending {
+		return existing, s.refresh(ctx, existing)
+	}
+	// without a client order ID, the broker order of a long key cannot be found
+	if s.now().Sub(existing.CreatedAt) < staleAfter || len(existing.IdempotencyKey) > maxClientOrderID {
+		return nil, apperr.New(http.StatusConflict, "An order with this Idempotency-Key is being submitted.")
+	}
+	order, err := s.placed(ctx, existing)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, s.orderRepo.Delete(existing)
+	}
+	record(existing, order)
+	return existing, s.orderRepo.Update(existing)
+}
+
+// placed returns the broker order placed for the reservation o, or nil if there is none
+func (s *Service) placed(ctx context.Context, o *model.Order) (*broker.Order, error) {
+	orders, err := s.broker.ListOrders(ctx, o.AccountID, &broker.ListOrdersRequest{
+		Status:    "all",
+		Limit:     importLimit,
+		After:     o.CreatedAt.Add(-staleAfter).Format(time.RFC3339),
+		Direction: "asc",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		if orders[i].ClientOrderID == o.IdempotencyKey {
+			return &orders[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// List returns the orders of the current user from the ledger, after bringing its open orders
+// up to date. Users without any recorded order get their broker orders imported first, so
+// orders placed before the ledger existed are listed too.
+func (s *Service) List(c *gin.Context, f *model.OrderFilter, p *model.Pagination) ([]model.Order, error) {
+	user, err := s.account(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx := c.Request.Context()
+	n, err := s.orderRepo.Count(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		if err := s.load(ctx, user); err != nil {
+			return nil, err
+		}
+	} else if err := s.Sync(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.List(user.ID, f, p)
+}
+
+// View returns an order of the current user, up to date with its broker order
+func (s *Service) View(c *gin.Context, orderID string) (*model.Order, error) {
+	user, err := s.account(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.view(c.Request.Context(), user, orderID)
+}
+
+// Cancel cancels an open order of the current user
+func (s *Service) Cancel(c *gin.Context, orderID string) error {
+	user, err := s.account(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request.Context()
+	if err := s.broker.CancelOrder(ctx, user.AccountID, orderID); err != nil {
+		return err
+	}
+	_, err = s.view(ctx, user, orderID)
+	return err
+}
+
+// CancelAll cancels every open order of the current user
+func (s *Service) CancelAll(c *gin.Context) ([]broker.CancelStatus, error) {
+	user, err := s.account(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx := c.Request.Context()
+	statuses, err := s.broker.CancelAllOrders(ctx, user.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return statuses, s.Sync(ctx, user)
+}
+
+// Sync updates the open orders of user in the ledger with the status of their broker order
+func (s *Service) Sync(ctx context.Context, user *model.User) error {
+	orders, err := s.orderRepo.Open(user.ID)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		if err := s.refresh(ctx, &orders[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply records the state of a broker order reported by a trade event. Orders missing from the
+// ledger are left alone, as they are either being submitted or are recorded when viewed.
+func (s *Service) Apply(order *broker.Order) error {
+	o, err := s.orderRepo.Find(order.ID)
+	if err != nil {
+		if e, ok := err.(*apperr.APPError); ok && e.Status == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+	if o.Closed() {
+		// events are delivered at least once, so a final status is never overwritten
+		return nil
+	}
+	record(o, order)
+	return s.orderRepo.Update(o)
+}
+
+// view returns the order of user with the status of its broker order. Orders missing from the
+// ledger are recorded from the broker.
+func (s *Service) view(ctx context.Context, user *model.User, orderID string) (*model.Order, error) {
+	o, err := s.orderRepo.View(user.ID, orderID)
+	if err != nil {
+		if e, ok := err.(*apperr.APPError); !ok || e.Status != http.StatusNotFound {
+			return nil, err
+		}
+		order, err := s.broker.GetOrder(ctx, user.AccountID, orderID)
+		if err != nil {
+			return nil, err
+		}
+		o = &model.Order{UserID: user.ID, AccountID: user.AccountID}
+		record(o, order)
+		return o, s.orderRepo.Create(o)
+	}
+	return o, s.refresh(ctx, o)
+}
+
+// refresh updates o with the status of its broker order
+func (s *Service) refresh(ctx context.Context, o *model.Order) error {
+	if o.Closed() {
+		return nil
+	}
+	order, err := s.broker.GetOrder(ctx, o.AccountID, o.OrderID)
+	if err != nil {
+		return err
+	}
+	record(o, order)
+	return s.orderRepo.Update(o)
+}
+
+// load imports the latest broker orders of user into the ledger
+func (s *Service) load(ctx context.Context, user *model.User) error {
+	orders, err := s.broker.ListOrders(ctx, user.AccountID, &broker.ListOrdersRequest{
+		Status:    "all",
+		Limit:     importLimit,
+		Direction: "desc",
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(orders) - 1; i >= 0; i-- {
+		o := &model.Order{UserID: user.ID, AccountID: user.AccountID}
+		record(o, &orders[i])
+		if err := s.orderRepo.Create(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// account returns the current user, who must have a brokerage account
+func (s *Service) account(c *gin.Context) (*model.User, error) {
+	user, err := s.userRepo.View(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	if user.AccountID == "" {
+		return nil, apperr.New(http.StatusBadRequest, "Account not found.")
+	}
+	return user, nil
+}
+
+// record copies the state of its broker order into o
+func record(o *model.Order, order *broker.Order) {
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = order.CreatedAt
+	}
+	o.OrderID = order.ID
+	o.ClientOrderID = order.ClientOrderID
+	if order.Replaces != nil {
+		o.Replaces = *order.Replaces
+	}
+	if order.ReplacedBy != nil {
+		o.ReplacedBy = *order.ReplacedBy
+	}
+	o.Symbol = order.Symbol
+	o.Side = order.Side
+	o.Type = order.Type
+	o.TimeInForce = order.TimeInForce
+	o.Qty = order.Qty
+	o.Notional = order.Notional
+	o.LimitPrice = order.LimitPrice
+	o.StopPrice = order.StopPrice
+	o.FilledQty = order.FilledQty
+	o.FilledAvgPrice = order.FilledAvgPrice
+	o.Status = order.Status
+	o.SubmittedAt = order.SubmittedAt
+	o.FilledAt = order.FilledAt
+	o.CanceledAt = order.CanceledAt
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// performanceLock is the advisory lock key serializing recomputations of the performance tables
+const performanceLock = 8001
+
+// NewPerformanceRepo returns a new PerformanceRepo instance
+func NewPerformanceRepo(db *pg.DB, log *zap.Logger) *PerformanceRepo {
+	return &PerformanceRepo{db, log}
+}
+
+// PerformanceRepo is the client for the algorithm performance, user performance, trade summary
+// and investment models, which are derived from the trades ledger
+type PerformanceRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// Trades returns every trade, oldest first
+func (p *PerformanceRepo) Trades() ([]model.Trade, error) {
+	var trades []model.Trade
+	err := p.db.Model(&trades).Where(notDeleted).Order("executed_at asc", "id asc").Select()
+	if err != nil {
+		return nil, p.error(err)
+	}
+	return trades, nil
+}
+
+// Limits returns the investment limits of every user in every algorithm
+func (p *PerformanceRepo) Limits() ([]model.InvestmentLimit, error) {
+	var limits []model.InvestmentLimit
+	if err := p.db.Model(&limits).Select(); err != nil {
+		return nil, p.error(err)
+	}
+	return limits, nil
+}
+
+// AlgorithmTrades returns the trades of an algorithm, oldest first
+func (p *PerformanceRepo) AlgorithmTrades(algorithmID int) ([]model.Trade, error) {
+	var trades []model.Trade
+	err := p.db.Model(&trades).Where("algorithm_id = ?", algorithmID).Where(notDeleted).
+		Order("executed_at asc", "id asc").Select()
+	if err != nil {
+		return nil, p.error(err)
+	}
+	return trades, nil
+}
+
+// AlgorithmLimits returns the investment limits of every user in an algorithm
+func (p *PerformanceRepo) AlgorithmLimits(algorithmID int) ([]model.InvestmentLimit, error) {
+	var limits []model.InvestmentLimit
+	if err := p.db.Model(&limits).Where("algorithm_id = ?", algorithmID).Select(); err != nil {
+		return nil, p.error(err)
+	}
+	return limits, nil
+}
+
+// Save replaces the content of the performance tables with s
+func (p *PerformanceRepo) Save(s *model.PerformanceSnapshot) error {
+	err := p.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", performanceLock); err != nil {
+			return err
+		}
+		tables := []interface{}{
+			(*model.AlgorithmPerformance)(nil),
+			(*model.UserPerformance)(nil),
+			(*model.TradeSummary)(nil),
+			(*model.Investment)(nil),
+		}
+		for _, t := range tables {
+			if _, err := tx.Model(t).Where("true").Delete(); err != nil {
+				return err
+			}
+		}
+		for _, rows := range []interface{}{&s.Algorithms, &s.Users, &s.Summaries, &s.Investments} {
+			if reflect.ValueOf(rows).Elem().Len() == 0 {
+				continue
+			}
+			if _, err := tx.Model(rows).Insert(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return p.error(err)
+}
+
+// Leaderboard returns the performance of every algorithm, best P/L percentage first
+func (p *PerformanceRepo) Leaderboard(page *model.Pagination) ([]model.AlgorithmPerformance, error) {
+	var res []model.AlgorithmPerformance
+	err := p.db.Model(&res).Relation("Algorithm").
+		Where("algorithm.deleted_at is null").
+		Order("algorithm_performance.pl_percentage desc", "algorithm_performance.algorithm_id asc").
+		Limit(page.Limit).Offset(page.Offset).Select()
+	if err != nil {
+		return nil, p.error(err)
+	}
+	return res, nil
+}
+
+// Algorithm returns the performance of an algorithm
+func (p *PerformanceRepo) Algorithm(algorithmID int) (*model.AlgorithmPerformance, error) {
+	res := new(model.AlgorithmPerformance)
+	err := p.db.Model(res).Relation("Algorithm").
+		Where("algorithm_performance.algorithm_id = ?", algorithmID).Select()
+	if err == pg.ErrNoRows {
+		return nil, apperr.New(http.StatusNotFound, "No performance for this algorithm yet.")
+	}
+	if err != nil {
+		return nil, p.error(err)
+	}
+	return res, nil
+}
+
+// Users returns the performance of every algorithm a user traded in
+func (p *PerformanceRepo) Users(userID int) ([]model.UserPerformance, error) {
+	var res []model.UserPerformance
+	err := p.db.Model(&res).Relation("Algorithm").
+		Where("user_performance.user_id = ?", userID).Order("user_performance.algorithm_id asc").Select()
+	if err != nil {
+		return nil, p.error(err)
+	}
+	return res, nil
+}
+
+// Summaries returns the trade summaries of a user in every algorithm
+func (p *PerformanceRepo) Summaries(userID int) ([]model.TradeSummary, error) {
+	var res []model.TradeSummary
+	err := p.db.Model(&res).Where("user_id = ?", userID).Order("algorithm_id asc").Select()
+	if err != nil {
+		return nil, p.error(err)
+	}
+	return res, nil
+}
+
+// Investments returns the current investments of a user in every algorithm
+func (p *PerformanceRepo) Investments(userID int) ([]model.Investment, error) {
+	var res []model.Investment
+	err := p.db.Model(&res).Where("user_id = ?", userID).Order("algorithm_id asc").Select()
+	if err != nil {
+		return nil, p.error(err)
+	}
+	return res, nil
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (p *PerformanceRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apperr.APPError); ok {
+		return err
+	}
+	p.log.Warn("PerformanceRepo Error", zap.Error(err))
+	return apperr.DB
+}
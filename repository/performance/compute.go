@@ -0,0 +1,162 @@
+package performance
+
+import (
+	"sort"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+)
+
+type key struct {
+	userID      int
+	algorithmID int
+}
+
+type position struct {
+	qty  float64
+	cost float64
+}
+
+// ledger accumulates the trades of an algorithm for a user
+type ledger struct {
+	positions map[string]*position
+	bought    float64 // cost of every buy
+	realized  float64 // profit realized by sells, against the average cost of the position
+	trades    int
+	volume    float64 // qty traded
+	notional  float64 // qty traded times execution price
+	started   time.Time
+	last      map[string]float64 // last execution price of each symbol
+}
+
+func (l *ledger) add(t model.Trade) {
+	if l.trades == 0 {
+		l.started = t.ExecutedAt
+	}
+	l.trades++
+	l.volume += t.Amount
+	l.notional += t.Amount * t.ExecutionPrice
+	l.last[t.Symbol] = t.ExecutionPrice
+
+	p, ok := l.positions[t.Symbol]
+	if !ok {
+		p = &position{}
+		l.positions[t.Symbol] = p
+	}
+	if t.TradeType == broker.Sell {
+		qty := t.Amount
+		if qty > p.qty {
+			qty = p.qty
+		}
+		if qty <= 0 {
+			return
+		}
+		avg := p.cost / p.qty
+		l.realized += (t.ExecutionPrice - avg) * qty
+		p.cost -= avg * qty
+		p.qty -= qty
+		return
+	}
+	p.qty += t.Amount
+	p.cost += t.Amount * t.ExecutionPrice
+	l.bought += t.Amount * t.ExecutionPrice
+}
+
+// value returns the current value of the open positions and their unrealized profit
+func (l *ledger) value(prices map[string]float64) (value, unrealized float64) {
+	for symbol, p := range l.positions {
+		price, ok := prices[symbol]
+		if !ok {
+			price = l.last[symbol]
+		}
+		value += p.qty * price
+		unrealized += p.qty*price - p.cost
+	}
+	return value, unrealized
+}
+
+// Compute derives the performance tables from trades, oldest first. Open positions are valued
+// at prices, or at their last execution price for symbols missing from prices, and returns are
+// relative to the budgets in limits.
+func Compute(trades []model.Trade, limits []model.InvestmentLimit, prices map[string]float64) *model.PerformanceSnapshot {
+	ledgers := map[key]*ledger{}
+	for _, t := range trades {
+		k := key{t.UserID, t.AlgorithmID}
+		l, ok := ledgers[k]
+		if !ok {
+			l = &ledger{positions: map[string]*position{}, last: map[string]float64{}}
+			ledgers[k] = l
+		}
+		l.add(t)
+	}
+	allowed := map[key]float64{}
+	for _, l := range limits {
+		allowed[key{l.UserID, l.AlgorithmID}] = l.TotalAllowed
+	}
+
+	keys := make([]key, 0, len(ledgers))
+	for k := range ledgers {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].algorithmID != keys[j].algorithmID {
+			return keys[i].algorithmID < keys[j].algorithmID
+		}
+		return keys[i].userID < keys[j].userID
+	})
+
+	s := &model.PerformanceSnapshot{}
+	algAllowed, algBought := map[int]float64{}, map[int]float64{}
+	for _, k := range keys {
+		l := ledgers[k]
+		value, unrealized := l.value(prices)
+		pl := l.realized + unrealized
+		s.Users = append(s.Users, model.UserPerformance{
+			UserID:       k.userID,
+			AlgorithmID:  k.algorithmID,
+			TotalReturn:  ratio(pl, allowed[k]),
+			TotalTrades:  l.trades,
+			ProfitLoss:   pl,
+			PLPercentage: ratio(pl, l.bought) * 100,
+		})
+		s.Summaries = append(s.Summaries, model.TradeSummary{
+			UserID:            k.userID,
+			AlgorithmID:       k.algorithmID,
+			AvgExecutionPrice: ratio(l.notional, l.volume),
+			TotalAmount:       l.volume,
+			ProfitLoss:        pl,
+			TradesCount:       l.trades,
+		})
+		s.Investments = append(s.Investments, model.Investment{
+			UserID:       k.userID,
+			AlgorithmID:  k.algorithmID,
+			CurrentValue: value,
+			StartedAt:    l.started,
+		})
+
+		n := len(s.Algorithms)
+		if n == 0 || s.Algorithms[n-1].AlgorithmID != k.algorithmID {
+			s.Algorithms = append(s.Algorithms, model.AlgorithmPerformance{AlgorithmID: k.algorithmID})
+			n++
+		}
+		a := &s.Algorithms[n-1]
+		a.TotalTrades += l.trades
+		a.ProfitLoss += pl
+		algAllowed[k.algorithmID] += allowed[k]
+		algBought[k.algorithmID] += l.bought
+	}
+	for i := range s.Algorithms {
+		a := &s.Algorithms[i]
+		a.TotalReturn = ratio(a.ProfitLoss, algAllowed[a.AlgorithmID])
+		a.PLPercentage = ratio(a.ProfitLoss, algBought[a.AlgorithmID]) * 100
+	}
+	return s
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
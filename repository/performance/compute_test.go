@@ -0,0 +1,49 @@
+package performance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/performance"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute(t *testing.T) {
+	t1 := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	trades := []model.Trade{
+		{UserID: 1, AlgorithmID: 1, Symbol: "AAPL", TradeType: "buy", Amount: 10, ExecutionPrice: 100, ExecutedAt: t1},
+		{UserID: 1, AlgorithmID: 1, Symbol: "AAPL", TradeType: "sell", Amount: 4, ExecutionPrice: 110, ExecutedAt: t1.Add(time.Hour)},
+		{UserID: 2, AlgorithmID: 1, Symbol: "MSFT", TradeType: "buy", Amount: 5, ExecutionPrice: 200, ExecutedAt: t1.Add(2 * time.Hour)},
+		// selling more than the algorithm holds realizes nothing
+		{UserID: 1, AlgorithmID: 2, Symbol: "AAPL", TradeType: "sell", Amount: 1, ExecutionPrice: 100, ExecutedAt: t1.Add(3 * time.Hour)},
+	}
+	limits := []model.InvestmentLimit{
+		{UserID: 1, AlgorithmID: 1, TotalAllowed: 2000},
+		{UserID: 2, AlgorithmID: 1, TotalAllowed: 1000},
+	}
+	// MSFT has no price and is valued at its last execution price
+	s := performance.Compute(trades, limits, map[string]float64{"AAPL": 120})
+
+	assert.Equal(t, []model.AlgorithmPerformance{
+		{AlgorithmID: 1, TotalReturn: 160.0 / 3000, TotalTrades: 3, ProfitLoss: 160, PLPercentage: 8},
+		{AlgorithmID: 2, TotalTrades: 1},
+	}, s.Algorithms)
+	assert.Equal(t, []model.UserPerformance{
+		{UserID: 1, AlgorithmID: 1, TotalReturn: 0.08, TotalTrades: 2, ProfitLoss: 160, PLPercentage: 16},
+		{UserID: 2, AlgorithmID: 1, TotalTrades: 1},
+		{UserID: 1, AlgorithmID: 2, TotalTrades: 1},
+	}, s.Users)
+	if assert.Len(t, s.Summaries, 3) {
+		assert.InDelta(t, 1440.0/14, s.Summaries[0].AvgExecutionPrice, 1e-9)
+		assert.Equal(t, 14.0, s.Summaries[0].TotalAmount)
+		assert.Equal(t, 160.0, s.Summaries[0].ProfitLoss)
+		assert.Equal(t, 2, s.Summaries[0].TradesCount)
+	}
+	assert.Equal(t, []model.Investment{
+		{UserID: 1, AlgorithmID: 1, CurrentValue: 720, StartedAt: t1},
+		{UserID: 2, AlgorithmID: 1, CurrentValue: 1000, StartedAt: t1.Add(2 * time.Hour)},
+		{UserID: 1, AlgorithmID: 2, StartedAt: t1.Add(3 * time.Hour)},
+	}, s.Investments)
+}
@@ -0,0 +1,175 @@
+package performance
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tradingDays annualizes the daily returns
+const tradingDays = 252
+
+// NewPerformanceService creates a new performance application service
+func NewPerformanceService(performanceRepo model.PerformanceRepo, rbac model.RBACService, brk broker.Service, bars benchmark.Bars) *Service {
+	return &Service{
+		performanceRepo: performanceRepo,
+		rbac:            rbac,
+		broker:          brk,
+		bars:            bars,
+	}
+}
+
+// Service represents the performance application service
+type Service struct {
+	performanceRepo model.PerformanceRepo
+	rbac            model.RBACService
+	broker          broker.Service
+	bars            benchmark.Bars
+}
+
+// Recompute rebuilds the performance tables from the trades ledger, valuing open positions at
+// the latest trade prices
+func (s *Service) Recompute(ctx context.Context) error {
+	trades, err := s.performanceRepo.Trades()
+	if err != nil {
+		return err
+	}
+	limits, err := s.performanceRepo.Limits()
+	if err != nil {
+		return err
+	}
+	symbols := map[string]bool{}
+	for _, t := range trades {
+		symbols[t.Symbol] = true
+	}
+	list := make([]string, 0, len(symbols))
+	for symbol := range symbols {
+		list = append(list, symbol)
+	}
+	sort.Strings(list)
+	snapshots, err := s.broker.GetSnapshots(ctx, list)
+	if err != nil {
+		return err
+	}
+	prices := map[string]float64{}
+	for symbol, snap := range snapshots {
+		if snap.LatestTrade != nil {
+			prices[symbol] = snap.LatestTrade.Price
+		}
+	}
+	return s.performanceRepo.Save(Compute(trades, limits, prices))
+}
+
+// Refresh recomputes the performance tables on demand. Only admins may refresh them.
+func (s *Service) Refresh(c *gin.Context) error {
+	if !s.rbac.EnforceRole(c, model.AdminRole) {
+		return apperr.New(http.StatusForbidden, "Forbidden")
+	}
+	return s.Recompute(c.Request.Context())
+}
+
+// Leaderboard returns the performance of every algorithm, best first
+func (s *Service) Leaderboard(c *gin.Context, p *model.Pagination) ([]model.AlgorithmPerformance, error) {
+	return s.performanceRepo.Leaderboard(p)
+}
+
+// Algorithm returns the performance of an algorithm across all users
+func (s *Service) Algorithm(c *gin.Context, algorithmID int) (*model.AlgorithmPerformance, error) {
+	return s.performanceRepo.Algorithm(algorithmID)
+}
+
+// Benchmark compares the daily performance of an algorithm across all users with the
+// benchmark symbol. Its returns are relative to the budgets of its users, like its total
+// return.
+func (s *Service) Benchmark(c *gin.Context, algorithmID int, symbol string) (*benchmark.Comparison, error) {
+	trades, err := s.performanceRepo.AlgorithmTrades(algorithmID)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := s.performanceRepo.AlgorithmLimits(algorithmID)
+	if err != nil {
+		return nil, err
+	}
+	var bought float64
+	for _, t := range trades {
+		if t.TradeType != broker.Sell {
+			bought += t.Amount * t.ExecutionPrice
+		}
+	}
+	if len(trades) == 0 {
+		return nil, apperr.New(http.StatusBadRequest, "The algorithm has no trades yet.")
+	}
+	capital := 0.0
+	for _, l := range limits {
+		capital += l.TotalAllowed
+	}
+	// without budgets, returns are relative to what was bought, like the P/L percentage
+	if capital == 0 {
+		capital = bought
+	}
+
+	ctx := c.Request.Context()
+	start, end := day(trades[0].ExecutedAt), time.Now()
+	closes := map[string][]broker.Bar{}
+	for _, t := range trades {
+		if _, ok := closes[t.Symbol]; ok {
+			continue
+		}
+		bars, err := s.bars.List(ctx, t.Symbol, "1Day", start, end)
+		if err != nil {
+			return nil, err
+		}
+		closes[t.Symbol] = bars
+	}
+	res, err := benchmark.Against(ctx, s.bars, symbol, Series(trades, closes, capital), tradingDays)
+	if err == benchmark.ErrNoBars {
+		return nil, apperr.New(http.StatusBadRequest, "No bars for "+symbol+" in this period.")
+	}
+	return res, err
+}
+
+// Breakdown returns the current user's performance in every algorithm they traded in
+func (s *Service) Breakdown(c *gin.Context) ([]model.PerformanceBreakdown, error) {
+	userID := c.GetInt("id")
+	performances, err := s.performanceRepo.Users(userID)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := s.performanceRepo.Summaries(userID)
+	if err != nil {
+		return nil, err
+	}
+	investments, err := s.performanceRepo.Investments(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bySummary := map[int]*model.TradeSummary{}
+	for i := range summaries {
+		bySummary[summaries[i].AlgorithmID] = &summaries[i]
+	}
+	byInvestment := map[int]*model.Investment{}
+	for i := range investments {
+		byInvestment[investments[i].AlgorithmID] = &investments[i]
+	}
+	res := make([]model.PerformanceBreakdown, len(performances))
+	for i := range performances {
+		p := &performances[i]
+		res[i] = model.PerformanceBreakdown{
+			Algorithm:   p.Algorithm,
+			Performance: p,
+			Summary:     bySummary[p.AlgorithmID],
+			Investment:  byInvestment[p.AlgorithmID],
+		}
+		p.Algorithm = nil
+	}
+	return res, nil
+}
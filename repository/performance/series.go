@@ -0,0 +1,71 @@
+package performance
+
+import (
+	"sort"
+	"time"
+
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/market"
+	"github.com/zcoriarty/Backend/model"
+)
+
+// Series values trades, oldest first, at the close of every day of closes from the day of the
+// first trade on. The value of a day is a growth index of capital, 1 plus the profit of the
+// trades so far over capital. closes holds the daily bars of every symbol traded, and
+// positions are valued at their last close, or at their last execution price before their
+// first bar.
+func Series(trades []model.Trade, closes map[string][]broker.Bar, capital float64) []benchmark.Point {
+	if len(trades) == 0 || capital <= 0 {
+		return nil
+	}
+	first := day(trades[0].ExecutedAt)
+	prices := map[time.Time]map[string]float64{}
+	var days []time.Time
+	for symbol, bars := range closes {
+		for _, b := range bars {
+			d := day(b.Timestamp)
+			if d.Before(first) {
+				continue
+			}
+			if prices[d] == nil {
+				prices[d] = map[string]float64{}
+				days = append(days, d)
+			}
+			prices[d][symbol] = b.Close
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	ledgers := map[int]*ledger{}
+	latest := map[string]float64{}
+	res := make([]benchmark.Point, 0, len(days))
+	next := 0
+	for _, d := range days {
+		for ; next < len(trades) && !day(trades[next].ExecutedAt).After(d); next++ {
+			t := trades[next]
+			l, ok := ledgers[t.UserID]
+			if !ok {
+				l = &ledger{positions: map[string]*position{}, last: map[string]float64{}}
+				ledgers[t.UserID] = l
+			}
+			l.add(t)
+		}
+		for symbol, price := range prices[d] {
+			latest[symbol] = price
+		}
+		pl := 0.0
+		for _, l := range ledgers {
+			_, unrealized := l.value(latest)
+			pl += l.realized + unrealized
+		}
+		res = append(res, benchmark.Point{Day: d, Value: 1 + pl/capital})
+	}
+	return res
+}
+
+// day returns the midnight starting the trading day of t
+func day(t time.Time) time.Time {
+	y, m, d := t.In(market.Location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, market.Location)
+}
@@ -0,0 +1,39 @@
+package performance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/performance"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeries(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	day := func(d int) time.Time { return time.Date(2023, 1, d, 0, 0, 0, 0, ny) }
+	trades := []model.Trade{
+		{UserID: 1, AlgorithmID: 1, Symbol: "AAPL", TradeType: "buy", Amount: 10, ExecutionPrice: 100, ExecutedAt: day(3).Add(10 * time.Hour)},
+		{UserID: 2, AlgorithmID: 1, Symbol: "MSFT", TradeType: "buy", Amount: 1, ExecutionPrice: 200, ExecutedAt: day(4).Add(10 * time.Hour)},
+		{UserID: 1, AlgorithmID: 1, Symbol: "AAPL", TradeType: "sell", Amount: 10, ExecutionPrice: 120, ExecutedAt: day(5).Add(10 * time.Hour)},
+	}
+	closes := map[string][]broker.Bar{
+		// the bar before the first trade is left out
+		"AAPL": {{Timestamp: day(2), Close: 90}, {Timestamp: day(3), Close: 105}, {Timestamp: day(4), Close: 110}, {Timestamp: day(5), Close: 130}},
+		// MSFT did not trade on the 5th
+		"MSFT": {{Timestamp: day(4), Close: 210}, {Timestamp: day(6), Close: 190}},
+	}
+	points := performance.Series(trades, closes, 1000)
+	days := make([]time.Time, len(points))
+	values := make([]float64, len(points))
+	for i, p := range points {
+		days[i], values[i] = p.Day, p.Value
+	}
+	assert.Equal(t, []time.Time{day(3), day(4), day(5), day(6)}, days)
+	assert.InDeltaSlice(t, []float64{1.05, 1.11, 1.21, 1.19}, values, 1e-9)
+
+	assert.Nil(t, performance.Series(trades, closes, 0))
+	assert.Nil(t, performance.Series(nil, closes, 1000))
+}
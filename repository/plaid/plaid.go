@@ -1,17 +1,15 @@
 package plaid
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"strings"
 
+	"github.com/zcoriarty/Backend/broker"
 	"github.com/zcoriarty/Backend/request"
 
 	"github.com/go-pg/pg/v9/orm"
@@ -22,9 +20,6 @@ import (
 	"github.com/plaid/plaid-go/plaid"
 )
 
-var baseURL string = os.Getenv("BROKER_API_BASE")
-var token string = os.Getenv("BROKER_TOKEN")
-
 var (
 	PLAID_CLIENT_ID     = os.Getenv("PLAID_CLIENT_ID")
 	PLAID_SECRET        = os.Getenv("PLAID_SECRET")
@@ -62,8 +57,8 @@ var client = func() *plaid.Client {
 }()
 
 // NewAuthService creates new auth service
-func NewPlaidService(userRepo model.UserRepo, accountRepo model.AccountRepo, jwt JWT, db orm.DB, log *zap.Logger) *Service {
-	return &Service{userRepo, accountRepo, jwt, db, log}
+func NewPlaidService(userRepo model.UserRepo, accountRepo model.AccountRepo, jwt JWT, db orm.DB, log *zap.Logger, brk broker.Service) *Service {
+	return &Service{userRepo, accountRepo, jwt, db, log, brk}
 }
 
 // Service represents the auth application service
@@ -73,6 +68,7 @@ type Service struct {
 	jwt         JWT
 	db          orm.DB
 	log         *zap.Logger
+	broker      broker.Service
 }
 
 // JWT represents jwt interface
@@ -104,7 +100,7 @@ func (s *Service) CreateLinkToken(c context.Context, accountID string, name stri
 	}, nil
 }
 
-func (s *Service) SetAccessToken(c context.Context, id int, accountID string, e *request.SetAccessToken) (interface{}, error) {
+func (s *Service) SetAccessToken(c context.Context, id int, accountID string, e *request.SetAccessToken) (*broker.ACHRelationship, error) {
 	response, err := client.ExchangePublicToken(e.PublicToken)
 	if err != nil {
 		return nil, err
@@ -163,35 +159,11 @@ func (s *Service) SetAccessToken(c context.Context, id int, accountID string, e
 		}
 	}
 
-	client := &http.Client{}
-	attachAccount, _ := json.Marshal(map[string]string{
-		"account_owner_name":  account_owner_name,
-		"bank_account_type":   bank_account_type,
-		"bank_account_number": bank_account_number,
-		"bank_routing_number": bank_routing_number,
-		"nickname":            bank_account_name,
+	return s.broker.CreateACHRelationship(c, accountID, &broker.ACHRelationshipRequest{
+		AccountOwnerName:  account_owner_name,
+		BankAccountType:   bank_account_type,
+		BankAccountNumber: bank_account_number,
+		BankRoutingNumber: bank_routing_number,
+		Nickname:          bank_account_name,
 	})
-	attachAccountBody := bytes.NewBuffer(attachAccount)
-
-	req, err := http.NewRequest("POST", baseURL+"/v1/accounts/"+accountID+"/ach_relationships", attachAccountBody)
-	if err != nil {
-		return nil, errors.New("Something went wrong. Try again later.")
-	}
-
-	req.Header.Add("Authorization", token)
-	cardBody, err := client.Do(req)
-
-	if err != nil {
-		return nil, errors.New("Something went wrong. Try again later.")
-	}
-
-	responseData, err := ioutil.ReadAll(cardBody.Body)
-	if err != nil {
-		return nil, errors.New("Something went wrong. Try again later.")
-	}
-
-	var responseObject interface{}
-	json.Unmarshal(responseData, &responseObject)
-
-	return responseObject, nil
 }
@@ -0,0 +1,113 @@
+// Package portfolio optimizes portfolios of a set of symbols from their stored daily bars, and
+// turns the optimized portfolios into target allocations.
+package portfolio
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/optimizer"
+	"github.com/zcoriarty/Backend/repository/allocations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Lookbacks the returns and covariance are estimated over
+const (
+	OneYear    = "1A"
+	ThreeYears = "3A"
+	FiveYears  = "5A"
+)
+
+// Portfolios of an optimization an allocation can be made of
+const (
+	MaxSharpe   = "max_sharpe"
+	MinVariance = "min_variance"
+)
+
+// tradingDays annualizes the daily returns
+const tradingDays = 252
+
+// years is the length of each lookback
+var years = map[string]int{OneYear: 1, ThreeYears: 3, FiveYears: 5}
+
+// NewPortfolioService creates a new portfolio application service
+func NewPortfolioService(bars benchmark.Bars, allocations *allocations.Service) *Service {
+	return &Service{
+		bars:        bars,
+		allocations: allocations,
+		now:         time.Now,
+	}
+}
+
+// Service represents the portfolio application service
+type Service struct {
+	bars        benchmark.Bars
+	allocations *allocations.Service
+	now         func() time.Time
+}
+
+// Optimize solves for the efficient frontier of symbols under c, from the daily bars of the
+// lookback
+func (s *Service) Optimize(c *gin.Context, symbols []string, lookback string, cons optimizer.Constraints) (*optimizer.Result, error) {
+	seen := map[string]bool{}
+	for _, sym := range symbols {
+		if seen[sym] {
+			return nil, apperr.New(http.StatusBadRequest, sym+" is listed twice.")
+		}
+		seen[sym] = true
+	}
+	if lookback == "" {
+		lookback = OneYear
+	}
+	end := s.now()
+	start := end.AddDate(-years[lookback], 0, 0)
+	daily := make(map[string][]broker.Bar, len(symbols))
+	for _, sym := range symbols {
+		bars, err := s.bars.List(c.Request.Context(), sym, "1Day", start, end)
+		if err != nil {
+			return nil, err
+		}
+		daily[sym] = bars
+	}
+	e, err := optimizer.Estimate(daily, tradingDays)
+	if err == optimizer.ErrHistory {
+		return nil, apperr.New(http.StatusBadRequest, "Not enough history in common for these symbols.")
+	}
+	if err != nil {
+		return nil, err
+	}
+	res, err := optimizer.Optimize(c.Request.Context(), e, cons)
+	if err == optimizer.ErrInfeasible {
+		return nil, apperr.New(http.StatusBadRequest, "max_weight is too low to invest in these symbols only.")
+	}
+	return res, err
+}
+
+// Allocate makes p the target allocation of the current user, keeping the rebalancing settings
+// of their current allocation if they have one. Weights are rounded down to basis points, and
+// the symbols left without weight are left out.
+func (s *Service) Allocate(c *gin.Context, p *optimizer.Portfolio) (*model.Allocation, error) {
+	a := &model.Allocation{
+		Threshold:    model.DefaultAllocationThreshold,
+		MinTradeSize: model.DefaultMinTradeSize,
+	}
+	current, err := s.allocations.View(c)
+	if err == nil {
+		a.Threshold, a.MinTradeSize, a.AutoRebalance = current.Threshold, current.MinTradeSize, current.AutoRebalance
+		a.CloseUntargeted = current.CloseUntargeted
+	} else if ae, ok := err.(*apperr.APPError); !ok || ae.Status != http.StatusNotFound {
+		return nil, err
+	}
+	for _, w := range p.Weights {
+		if weight := math.Floor(w.Weight*1e4+1e-6) / 1e4; weight > 0 {
+			a.Targets = append(a.Targets, model.AllocationTarget{Symbol: w.Symbol, Weight: weight})
+		}
+	}
+	return s.allocations.Save(c, a)
+}
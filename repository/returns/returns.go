@@ -0,0 +1,121 @@
+// Package returns serves the performance of the brokerage accounts of users, net of the
+// deposits and withdrawals of their transfer ledger.
+package returns
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/market"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/returns"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Periods performance is measured over
+const (
+	OneMonth    = "1M"
+	ThreeMonths = "3M"
+	SixMonths   = "6M"
+	YearToDate  = "YTD"
+	OneYear     = "1A"
+	ThreeYears  = "3A"
+	FiveYears   = "5A"
+)
+
+// tradingDays annualizes the daily returns
+const tradingDays = 252
+
+// NewReturnsService creates a new returns application service
+func NewReturnsService(userRepo model.UserRepo, transferRepo model.TransferRepo, brk broker.Service, bars benchmark.Bars) *Service {
+	return &Service{
+		userRepo:     userRepo,
+		transferRepo: transferRepo,
+		broker:       brk,
+		bars:         bars,
+		now:          time.Now,
+	}
+}
+
+// Service represents the returns application service
+type Service struct {
+	userRepo     model.UserRepo
+	transferRepo model.TransferRepo
+	broker       broker.Service
+	bars         benchmark.Bars
+	now          func() time.Time
+}
+
+// Performance measures the performance of the account of the current user over period, from
+// its daily equity and the transfers completed in the meantime
+func (s *Service) Performance(c *gin.Context, period string) (*returns.Metrics, error) {
+	user, err := s.userRepo.View(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	if user.AccountID == "" {
+		return nil, apperr.New(http.StatusBadRequest, "Account not found.")
+	}
+	p := &broker.PortfolioHistoryRequest{Period: period, Timeframe: "1D"}
+	if period == YearToDate {
+		p.Period = OneYear
+	}
+	history, err := s.broker.GetPortfolioHistory(c.Request.Context(), user.AccountID, p)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := s.transferRepo.Completed(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var since time.Time
+	if period == YearToDate {
+		since = time.Date(s.now().In(market.Location).Year(), time.January, 1, 0, 0, 0, 0, market.Location)
+	}
+	points := make([]returns.Point, 0, len(history.Timestamp))
+	for i, ts := range history.Timestamp {
+		if i >= len(history.Equity) {
+			break
+		}
+		// the equity of a day is its equity at the close, after the transfers of the day
+		y, m, d := time.Unix(ts, 0).In(market.Location).Date()
+		at := time.Date(y, m, d+1, 0, 0, 0, 0, market.Location)
+		if at.Before(since) {
+			continue
+		}
+		points = append(points, returns.Point{At: at, Equity: history.Equity[i]})
+	}
+	flows := make([]returns.Flow, len(transfers))
+	for i, t := range transfers {
+		amount := t.Amount
+		if t.Direction == broker.Outgoing {
+			amount = -amount
+		}
+		flows[i] = returns.Flow{At: t.UpdatedAt, Amount: amount}
+	}
+
+	res, err := returns.Compute(points, flows, tradingDays)
+	if err == returns.ErrNoHistory {
+		return nil, apperr.New(http.StatusBadRequest, "Not enough history for this period.")
+	}
+	return res, err
+}
+
+// Benchmark compares the time-weighted return of m, day by day, with the benchmark symbol
+func (s *Service) Benchmark(c *gin.Context, m *returns.Metrics, symbol string) (*benchmark.Comparison, error) {
+	points := make([]benchmark.Point, len(m.Series))
+	for i, p := range m.Series {
+		// the equity at midnight is the close of the day before
+		points[i] = benchmark.Point{Day: p.At.In(market.Location).AddDate(0, 0, -1), Value: 1 + p.Return}
+	}
+	res, err := benchmark.Against(c.Request.Context(), s.bars, symbol, points, tradingDays)
+	if err == benchmark.ErrNoBars {
+		return nil, apperr.New(http.StatusBadRequest, "No bars for "+symbol+" in this period.")
+	}
+	return res, err
+}
@@ -0,0 +1,500 @@
+// Package stream serves real-time market data and order updates to clients over WebSockets,
+// multiplexing a single upstream market data connection across all of them.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// sendBuffer is the number of messages queued for a client before market data is dropped
+	sendBuffer = 256
+	// maxDropped is the number of market data messages dropped in a row before a client is
+	// disconnected as too slow
+	maxDropped = 1024
+	// busBuffer is the number of broker events queued for the hub
+	busBuffer = 1024
+	// maxSymbols bounds the symbols a client subscribes to, trades and quotes together
+	maxSymbols = 200
+	// maxRequestSize bounds the size of a client request
+	maxRequestSize = 16 * 1024
+	// writeWait bounds the time spent writing a single message to a client
+	writeWait = 10 * time.Second
+	// pongWait is how long a client may stay silent, pings included, before it is disconnected
+	pongWait = 60 * time.Second
+	// pingPeriod is how often clients are pinged, which must be less than pongWait
+	pingPeriod = pongWait * 9 / 10
+	// maxRetryWait bounds the wait between two attempts to reconnect upstream
+	maxRetryWait = time.Minute
+)
+
+// Types of the messages sent to clients
+const (
+	TradeMessage        = "trade"
+	QuoteMessage        = "quote"
+	OrderMessage        = "order"
+	PositionMessage     = "position"
+	SubscriptionMessage = "subscription"
+	ErrorMessage        = "error"
+)
+
+// Request is a message sent by a client to change its subscriptions. Orders and Positions
+// subscribe to, or unsubscribe from, the updates of the client's own account when set.
+type Request struct {
+	Action    string   `json:"action"`
+	Trades    []string `json:"trades"`
+	Quotes    []string `json:"quotes"`
+	Orders    bool     `json:"orders"`
+	Positions bool     `json:"positions"`
+}
+
+// Message is a message sent to a client
+type Message struct {
+	Type         string        `json:"type"`
+	Symbol       string        `json:"symbol,omitempty"`
+	Trade        *broker.Trade `json:"trade,omitempty"`
+	Quote        *broker.Quote `json:"quote,omitempty"`
+	Event        string        `json:"event,omitempty"`
+	Order        *broker.Order `json:"order,omitempty"`
+	Position     *Position     `json:"position,omitempty"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Message      string        `json:"message,omitempty"`
+}
+
+// Position is the position of a client's account in a symbol after one of its orders filled
+type Position struct {
+	Symbol string   `json:"symbol"`
+	Qty    float64  `json:"qty"`
+	Price  *float64 `json:"price"`
+}
+
+// Subscription lists the subscriptions of a client
+type Subscription struct {
+	Trades    []string `json:"trades"`
+	Quotes    []string `json:"quotes"`
+	Orders    bool     `json:"orders"`
+	Positions bool     `json:"positions"`
+}
+
+// NewHub creates a new hub streaming the market data of brk and the broker events of bus
+func NewHub(brk broker.Service, userRepo model.UserRepo, bus *events.Bus, log *zap.Logger) *Hub {
+	return &Hub{
+		RetryWait: time.Second,
+		broker:    brk,
+		userRepo:  userRepo,
+		bus:       bus,
+		log:       log,
+		trades:    map[string]map[*Client]struct{}{},
+		quotes:    map[string]map[*Client]struct{}{},
+		accounts:  map[string]map[*Client]struct{}{},
+	}
+}
+
+// Hub relays a single upstream market data connection and the broker's trade events to the
+// connected clients. The clients subscribed to every symbol are tracked, and the upstream
+// connection is subscribed to a symbol while at least one client is.
+//
+// Clients that do not keep up get their market data dropped, as later trades and quotes
+// supersede it, and are disconnected when they fall too far behind or when one of their order
+// or position updates cannot be queued, so they reconnect and reload their state.
+type Hub struct {
+	// RetryWait is the wait before reconnecting upstream the first time, doubled after every
+	// failed attempt
+	RetryWait time.Duration
+
+	broker   broker.Service
+	userRepo model.UserRepo
+	bus      *events.Bus
+	log      *zap.Logger
+
+	mu       sync.Mutex
+	upstream *broker.MarketStream
+	trades   map[string]map[*Client]struct{}
+	quotes   map[string]map[*Client]struct{}
+	accounts map[string]map[*Client]struct{}
+}
+
+// Client is a client connection of the hub
+type Client struct {
+	accountID string
+	conn      *websocket.Conn
+	send      chan []byte
+	dropped   int64
+
+	// subscriptions are guarded by the hub's mutex
+	trades    map[string]bool
+	quotes    map[string]bool
+	orders    bool
+	positions bool
+
+	once   sync.Once
+	done   chan struct{}
+	code   int
+	reason string
+}
+
+// Client returns a new client for the current user
+func (h *Hub) Client(c *gin.Context) (*Client, error) {
+	user, err := h.userRepo.View(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		accountID: user.AccountID,
+		send:      make(chan []byte, sendBuffer),
+		trades:    map[string]bool{},
+		quotes:    map[string]bool{},
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Serve serves cl over conn until either side closes the connection
+func (h *Hub) Serve(cl *Client, conn *websocket.Conn) {
+	cl.conn = conn
+	if cl.accountID != "" {
+		h.mu.Lock()
+		add(h.accounts, cl.accountID, cl)
+		h.mu.Unlock()
+	}
+	go cl.write()
+	h.read(cl)
+
+	h.mu.Lock()
+	h.update(cl, &Request{Action: "unsubscribe", Trades: keys(cl.trades), Quotes: keys(cl.quotes)})
+	remove(h.accounts, cl.accountID, cl)
+	h.mu.Unlock()
+	cl.stop(websocket.CloseNormalClosure, "")
+}
+
+// read handles the requests of cl until its connection fails
+func (h *Hub) read(cl *Client) {
+	cl.conn.SetReadLimit(maxRequestSize)
+	cl.conn.SetReadDeadline(time.Now().Add(pongWait))
+	cl.conn.SetPongHandler(func(string) error {
+		return cl.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := cl.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		cl.conn.SetReadDeadline(time.Now().Add(pongWait))
+		req := new(Request)
+		if err := json.Unmarshal(data, req); err != nil {
+			cl.deliver(encode(&Message{Type: ErrorMessage, Message: "Invalid request."}), true)
+			continue
+		}
+		sub, err := h.subscribe(cl, req)
+		if err != nil {
+			cl.deliver(encode(&Message{Type: ErrorMessage, Message: err.Error()}), true)
+			continue
+		}
+		cl.deliver(encode(&Message{Type: SubscriptionMessage, Subscription: sub}), true)
+	}
+}
+
+// subscribe applies req to the subscriptions of cl and returns them
+func (h *Hub) subscribe(cl *Client, req *Request) (*Subscription, error) {
+	if req.Action != "subscribe" && req.Action != "unsubscribe" {
+		return nil, apperr.New(http.StatusBadRequest, "Action must be subscribe or unsubscribe.")
+	}
+	req.Trades, req.Quotes = normalize(req.Trades), normalize(req.Quotes)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if req.Action == "subscribe" && len(cl.trades)+len(cl.quotes)+len(req.Trades)+len(req.Quotes) > maxSymbols {
+		return nil, apperr.New(http.StatusBadRequest, "Too many symbols.")
+	}
+	h.update(cl, req)
+	return &Subscription{Trades: keys(cl.trades), Quotes: keys(cl.quotes), Orders: cl.orders, Positions: cl.positions}, nil
+}
+
+// update applies req to the subscriptions of cl, subscribing the upstream connection to the
+// symbols cl is the first client of and unsubscribing it from the symbols cl was the last
+// client of. Callers must hold h.mu.
+func (h *Hub) update(cl *Client, req *Request) {
+	on := req.Action == "subscribe"
+	var trades, quotes []string
+	for _, symbol := range req.Trades {
+		if toggle(h.trades, cl.trades, symbol, cl, on) {
+			trades = append(trades, symbol)
+		}
+	}
+	for _, symbol := range req.Quotes {
+		if toggle(h.quotes, cl.quotes, symbol, cl, on) {
+			quotes = append(quotes, symbol)
+		}
+	}
+	if req.Orders {
+		cl.orders = on
+	}
+	if req.Positions {
+		cl.positions = on
+	}
+	if h.upstream == nil {
+		// the subscriptions are made once connected
+		return
+	}
+	var err error
+	if on {
+		err = h.upstream.Subscribe(trades, quotes)
+	} else {
+		err = h.upstream.Unsubscribe(trades, quotes)
+	}
+	if err != nil {
+		// the read loop fails too, and the subscriptions are made again once reconnected
+		h.log.Warn("Hub Error", zap.Error(err))
+	}
+}
+
+// Run keeps the upstream market data connection open and relays the broker events of the bus
+// until ctx is done
+func (h *Hub) Run(ctx context.Context) {
+	sub := h.bus.Subscribe(busBuffer)
+	defer sub.Close()
+	go func() {
+		for e := range sub.C {
+			h.dispatch(e)
+		}
+	}()
+
+	wait := h.RetryWait
+	for {
+		connected, err := h.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		h.log.Warn("Hub Error", zap.Error(err))
+		if connected {
+			wait = h.RetryWait
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+// connect opens the upstream connection, subscribes it to the symbols clients are subscribed
+// to and relays its messages until it fails
+func (h *Hub) connect(ctx context.Context) (bool, error) {
+	ms, err := h.broker.ConnectMarketData(ctx)
+	if err != nil {
+		return false, err
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ms.Close()
+		case <-stop:
+		}
+	}()
+	defer ms.Close()
+
+	h.mu.Lock()
+	h.upstream = ms
+	err = ms.Subscribe(sorted(h.trades), sorted(h.quotes))
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.upstream = nil
+		h.mu.Unlock()
+	}()
+	if err != nil {
+		return true, err
+	}
+
+	for {
+		msgs, err := ms.Read()
+		if err != nil {
+			return true, err
+		}
+		for i := range msgs {
+			h.publish(&msgs[i])
+		}
+	}
+}
+
+// publish relays a trade or quote of the upstream connection to the clients subscribed to it
+func (h *Hub) publish(m *broker.StreamMessage) {
+	var clients map[string]map[*Client]struct{}
+	msg := &Message{Symbol: m.Symbol, Trade: m.Trade, Quote: m.Quote}
+	switch m.Type {
+	case broker.StreamTrade:
+		clients, msg.Type = h.trades, TradeMessage
+	case broker.StreamQuote:
+		clients, msg.Type = h.quotes, QuoteMessage
+	default:
+		return
+	}
+	data := encode(msg)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for cl := range clients[m.Symbol] {
+		cl.deliver(data, false)
+	}
+}
+
+// dispatch relays a trade event to the clients of its account subscribed to order or
+// position updates
+func (h *Hub) dispatch(e *events.Event) {
+	p, ok := e.Payload.(*broker.TradeEvent)
+	if !ok || e.AccountID == "" {
+		return
+	}
+	order := encode(&Message{Type: OrderMessage, Symbol: p.Order.Symbol, Event: p.Event, Order: &p.Order})
+	var position []byte
+	if (p.Event == "fill" || p.Event == "partial_fill") && p.PositionQty != nil {
+		position = encode(&Message{Type: PositionMessage, Symbol: p.Order.Symbol, Position: &Position{
+			Symbol: p.Order.Symbol,
+			Qty:    *p.PositionQty,
+			Price:  p.Price,
+		}})
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for cl := range h.accounts[e.AccountID] {
+		if cl.orders {
+			cl.deliver(order, true)
+		}
+		if cl.positions && position != nil {
+			cl.deliver(position, true)
+		}
+	}
+}
+
+// deliver queues data for cl without blocking. Market data that does not fit is dropped, while
+// clients missing critical messages or too much market data are disconnected.
+func (cl *Client) deliver(data []byte, critical bool) {
+	select {
+	case cl.send <- data:
+		atomic.StoreInt64(&cl.dropped, 0)
+	default:
+		if critical || atomic.AddInt64(&cl.dropped, 1) > maxDropped {
+			cl.stop(websocket.CloseTryAgainLater, "Client is too slow.")
+		}
+	}
+}
+
+// write writes the queued messages of cl and pings it until it is stopped
+func (cl *Client) write() {
+	t := time.NewTicker(pingPeriod)
+	defer t.Stop()
+	defer cl.conn.Close()
+	for {
+		select {
+		case data := <-cl.send:
+			cl.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := cl.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-t.C:
+			cl.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := cl.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-cl.done:
+			cl.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(cl.code, cl.reason), time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+// stop closes the connection of cl with code and reason
+func (cl *Client) stop(code int, reason string) {
+	cl.once.Do(func() {
+		cl.code, cl.reason = code, reason
+		close(cl.done)
+	})
+}
+
+// toggle subscribes or unsubscribes cl to symbol in subs and index, and reports whether symbol
+// gained its first or lost its last subscriber
+func toggle(index map[string]map[*Client]struct{}, subs map[string]bool, symbol string, cl *Client, on bool) bool {
+	if subs[symbol] == on {
+		return false
+	}
+	if on {
+		subs[symbol] = true
+		add(index, symbol, cl)
+		return len(index[symbol]) == 1
+	}
+	delete(subs, symbol)
+	remove(index, symbol, cl)
+	return len(index[symbol]) == 0
+}
+
+func add(index map[string]map[*Client]struct{}, key string, cl *Client) {
+	if index[key] == nil {
+		index[key] = map[*Client]struct{}{}
+	}
+	index[key][cl] = struct{}{}
+}
+
+func remove(index map[string]map[*Client]struct{}, key string, cl *Client) {
+	delete(index[key], cl)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+// normalize upper cases symbols and drops empty and duplicate ones
+func normalize(symbols []string) []string {
+	seen := map[string]bool{}
+	var res []string
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" && !seen[s] {
+			seen[s] = true
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func keys(m map[string]bool) []string {
+	res := []string{}
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sorted(index map[string]map[*Client]struct{}) []string {
+	res := []string{}
+	for k := range index {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func encode(m *Message) []byte {
+	data, _ := json.Marshal(m)
+	return data
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// NewTaxLotRepo returns a new TaxLotRepo instance
+func NewTaxLotRepo(db *pg.DB, log *zap.Logger) *TaxLotRepo {
+	return &TaxLotRepo{db, log}
+}
+
+// TaxLotRepo is the client for the fills of orders and the lot reliefs chosen for them
+type TaxLotRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// RecordFill records a fill once, as trade events are delivered at least once
+func (r *TaxLotRepo) RecordFill(f *model.Fill) error {
+	_, err := r.db.Model(f).Where("execution_id = ?execution_id").SelectOrInsert()
+	return r.error(err)
+}
+
+// Fills returns the fills of a user, oldest first
+func (r *TaxLotRepo) Fills(userID int) ([]model.Fill, error) {
+	var fills []model.Fill
+	err := r.db.Model(&fills).Where("user_id = ?", userID).Order("filled_at asc", "id asc").Select()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return fills, nil
+}
+
+// SaveRelief creates or replaces the lot relief of an order
+func (r *TaxLotRepo) SaveRelief(l *model.LotRelief) error {
+	res, err := r.db.Model(l).Column("method", "lot_ids", "updated_at").
+		Where("user_id = ?user_id AND order_id = ?order_id").Returning("id, created_at").Update()
+	if err == nil && res.RowsAffected() == 0 {
+		err = r.db.Insert(l)
+	}
+	return r.error(err)
+}
+
+// Reliefs returns the lot reliefs of a user
+func (r *TaxLotRepo) Reliefs(userID int) ([]model.LotRelief, error) {
+	var reliefs []model.LotRelief
+	err := r.db.Model(&reliefs).Where("user_id = ?", userID).Select()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return reliefs, nil
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (r *TaxLotRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	r.log.Warn("TaxLotRepo Error", zap.Error(err))
+	return apperr.DB
+}
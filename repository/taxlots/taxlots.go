@@ -0,0 +1,239 @@
+// Package taxlots serves the tax lots and realized gains of users, computed from the fills of
+// their orders.
+package taxlots
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/market"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/taxlot"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// reliefDeadline is how long after its fill the lots of a sell order can still be chosen.
+	// Lots are identified by settlement, a business day after the trade, and weekends are
+	// allowed for.
+	reliefDeadline = 72 * time.Hour
+	// epsilon absorbs the rounding of fractional quantities
+	epsilon = 1e-9
+)
+
+// NewTaxLotService creates a new tax lot application service
+func NewTaxLotService(orderRepo model.OrderRepo, taxLotRepo model.TaxLotRepo) *Service {
+	return &Service{
+		orderRepo:  orderRepo,
+		taxLotRepo: taxLotRepo,
+		now:        time.Now,
+	}
+}
+
+// Service represents the tax lot application service. Lots are computed from the fills the
+// broker's trade events report, and from the order ledger for the fills missing, e.g. of the
+// orders filled before fills were recorded.
+type Service struct {
+	orderRepo  model.OrderRepo
+	taxLotRepo model.TaxLotRepo
+	now        func() time.Time
+}
+
+// Record records the fill reported by a trade event. Fills of orders missing from the ledger
+// are left to the ledger.
+func (s *Service) Record(e *broker.TradeEvent) error {
+	if e.Event != "fill" && e.Event != "partial_fill" || e.Qty == nil || e.Price == nil {
+		return nil
+	}
+	o, err := s.orderRepo.Find(e.Order.ID)
+	if err != nil {
+		if ae, ok := err.(*apperr.APPError); ok && ae.Status == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+	id := e.ExecutionID
+	if id == "" {
+		id = e.Order.ID + "@" + e.At.Format(time.RFC3339Nano)
+	}
+	return s.taxLotRepo.RecordFill(&model.Fill{
+		UserID:      o.UserID,
+		OrderID:     e.Order.ID,
+		ExecutionID: id,
+		Symbol:      e.Order.Symbol,
+		Side:        e.Order.Side,
+		Qty:         *e.Qty,
+		Price:       *e.Price,
+		FilledAt:    e.At,
+	})
+}
+
+// Lots returns the open lots of the current user in symbol, or in every symbol when it is
+// empty
+func (s *Service) Lots(c *gin.Context, symbol string) ([]taxlot.Lot, error) {
+	b, err := s.book(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	return b.Lots(symbol), nil
+}
+
+// Summary totals realizations
+type Summary struct {
+	Proceeds       float64 `json:"proceeds"`
+	CostBasis      float64 `json:"cost_basis"`
+	WashDisallowed float64 `json:"wash_disallowed"`
+	Gain           float64 `json:"gain"`
+}
+
+func (s *Summary) add(r *taxlot.Realization) {
+	s.Proceeds += r.Proceeds
+	s.CostBasis += r.CostBasis
+	s.WashDisallowed += r.WashDisallowed
+	s.Gain += r.Gain
+}
+
+// Report is the realized gain and loss of a tax year
+type Report struct {
+	Year      int                  `json:"year"`
+	ShortTerm Summary              `json:"short_term"`
+	LongTerm  Summary              `json:"long_term"`
+	Total     Summary              `json:"total"`
+	Realized  []taxlot.Realization `json:"realized"`
+}
+
+// Realized returns the gains and losses the current user realized in the sales of a tax year
+func (s *Service) Realized(c *gin.Context, year int) (*Report, error) {
+	b, err := s.book(c.GetInt("id"))
+	if err != nil {
+		return nil, err
+	}
+	res := &Report{Year: year, Realized: []taxlot.Realization{}}
+	for _, r := range b.Realized() {
+		if r.SoldAt.In(market.Location).Year() != year {
+			continue
+		}
+		if r.LongTerm {
+			res.LongTerm.add(&r)
+		} else {
+			res.ShortTerm.add(&r)
+		}
+		res.Total.add(&r)
+		res.Realized = append(res.Realized, r)
+	}
+	return res, nil
+}
+
+// SetRelief chooses the lots relieved by a sell order of the current user, until shortly
+// after it is filled
+func (s *Service) SetRelief(c *gin.Context, l *model.LotRelief) (*model.LotRelief, error) {
+	if !known(l.Method) {
+		return nil, apperr.New(http.StatusBadRequest, "Unknown relief method.")
+	}
+	if l.Method == taxlot.SpecificLot && len(l.LotIDs) == 0 {
+		return nil, apperr.New(http.StatusBadRequest, "Lots are required for specific lot relief.")
+	}
+	if l.Method != taxlot.SpecificLot {
+		l.LotIDs = nil
+	}
+	l.UserID = c.GetInt("id")
+	o, err := s.orderRepo.View(l.UserID, l.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Side != broker.Sell {
+		return nil, apperr.New(http.StatusBadRequest, "Lots are only relieved by sell orders.")
+	}
+	if o.FilledAt != nil && s.now().Sub(*o.FilledAt) > reliefDeadline {
+		return nil, apperr.New(http.StatusBadRequest, "The lots of settled orders cannot be changed.")
+	}
+	if err := s.taxLotRepo.SaveRelief(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// book replays the fills of a user
+func (s *Service) book(userID int) (*taxlot.Book, error) {
+	fills, err := s.taxLotRepo.Fills(userID)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.orderRepo.List(userID, &model.OrderFilter{}, &model.Pagination{})
+	if err != nil {
+		return nil, err
+	}
+	reliefs, err := s.taxLotRepo.Reliefs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]taxlot.Fill, 0, len(fills))
+	recorded := map[string]filled{}
+	for _, f := range fills {
+		res = append(res, taxlot.Fill{
+			ID:      f.ExecutionID,
+			OrderID: f.OrderID,
+			Symbol:  f.Symbol,
+			Side:    f.Side,
+			Qty:     f.Qty,
+			Price:   f.Price,
+			At:      f.FilledAt,
+		})
+		r := recorded[f.OrderID]
+		recorded[f.OrderID] = filled{r.qty + f.Qty, r.notional + f.Qty*f.Price}
+	}
+	for _, o := range orders {
+		if f, ok := missing(&o, recorded[o.OrderID]); ok {
+			res = append(res, f)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].At.Before(res[j].At) })
+
+	byOrder := make(map[string]taxlot.Relief, len(reliefs))
+	for _, r := range reliefs {
+		byOrder[r.OrderID] = taxlot.Relief{Method: r.Method, LotIDs: r.LotIDs}
+	}
+	return taxlot.Compute(res, byOrder), nil
+}
+
+// filled totals the recorded fills of an order
+type filled struct {
+	qty      float64
+	notional float64
+}
+
+// missing returns the part of the fill of o recorded in the ledger that is missing from its
+// recorded fills
+func missing(o *model.Order, recorded filled) (taxlot.Fill, bool) {
+	qty := o.FilledQty - recorded.qty
+	if qty <= epsilon || o.FilledAvgPrice == nil {
+		return taxlot.Fill{}, false
+	}
+	at := o.UpdatedAt
+	if o.FilledAt != nil {
+		at = *o.FilledAt
+	}
+	return taxlot.Fill{
+		ID:      o.OrderID,
+		OrderID: o.OrderID,
+		Symbol:  o.Symbol,
+		Side:    o.Side,
+		Qty:     qty,
+		Price:   (o.FilledQty**o.FilledAvgPrice - recorded.notional) / qty,
+		At:      at,
+	}, true
+}
+
+func known(method string) bool {
+	for _, m := range taxlot.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
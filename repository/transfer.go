@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"go.uber.org/zap"
+)
+
+// NewTransferRepo returns a new TransferRepo instance
+func NewTransferRepo(db *pg.DB, log *zap.Logger) *TransferRepo {
+	return &TransferRepo{db, log}
+}
+
+// TransferRepo is the client for the transfer ledger
+type TransferRepo struct {
+	db  *pg.DB
+	log *zap.Logger
+}
+
+// Create records a transfer
+func (r *TransferRepo) Create(t *model.Transfer) error {
+	return r.error(r.db.Insert(t))
+}
+
+// UpdateStatus updates the status of a transfer by its broker transfer ID
+func (r *TransferRepo) UpdateStatus(transferID, status, reason string) error {
+	_, err := r.db.Model((*model.Transfer)(nil)).Set("status = ?", status).Set("reason = ?", reason).
+		Set("updated_at = now()").Where("transfer_id = ?", transferID).Update()
+	return r.error(err)
+}
+
+// Find returns a transfer by its broker transfer ID
+func (r *TransferRepo) Find(transferID string) (*model.Transfer, error) {
+	t := new(model.Transfer)
+	err := r.db.Model(t).Where("transfer_id = ?", transferID).Select()
+	if err == pg.ErrNoRows {
+		return nil, apperr.New(http.StatusNotFound, "Transfer not found.")
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+	return t, nil
+}
+
+// Completed returns the completed transfers of a user, in the order they completed
+func (r *TransferRepo) Completed(userID int) ([]model.Transfer, error) {
+	var transfers []model.Transfer
+	err := r.db.Model(&transfers).Where("user_id = ?", userID).Where("upper(status) = ?", "COMPLETE").
+		Order("updated_at").Select()
+	return transfers, r.error(err)
+}
+
+// error logs unexpected database errors and hides them behind apperr.DB
+func (r *TransferRepo) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apperr.APPError); ok {
+		return err
+	}
+	r.log.Warn("TransferRepo Error", zap.Error(err))
+	return apperr.DB
+}
@@ -2,25 +2,43 @@ package transfer
 
 import (
 	"github.com/go-pg/pg/v9/orm"
+	"github.com/zcoriarty/Backend/broker"
 	"github.com/zcoriarty/Backend/model"
 	"go.uber.org/zap"
 )
 
 // NewAuthService creates new auth service
-func NewTransferService(userRepo model.UserRepo, accountRepo model.AccountRepo, jwt JWT, db orm.DB, log *zap.Logger) *Service {
-	return &Service{userRepo, accountRepo, jwt, db, log}
+func NewTransferService(userRepo model.UserRepo, accountRepo model.AccountRepo, transferRepo model.TransferRepo, jwt JWT, db orm.DB, log *zap.Logger) *Service {
+	return &Service{userRepo, accountRepo, transferRepo, jwt, db, log}
 }
 
 // Service represents the auth application service
 type Service struct {
-	userRepo    model.UserRepo
-	accountRepo model.AccountRepo
-	jwt         JWT
-	db          orm.DB
-	log         *zap.Logger
+	userRepo     model.UserRepo
+	accountRepo  model.AccountRepo
+	transferRepo model.TransferRepo
+	jwt          JWT
+	db           orm.DB
+	log          *zap.Logger
 }
 
 // JWT represents jwt interface
 type JWT interface {
 	GenerateToken(*model.User) (string, string, error)
 }
+
+// Record records a transfer requested by user in the transfer ledger, whose status then follows
+// the broker's transfer status events
+func (s *Service) Record(user *model.User, t *broker.Transfer) error {
+	return s.transferRepo.Create(&model.Transfer{
+		UserID:         user.ID,
+		AccountID:      t.AccountID,
+		TransferID:     t.ID,
+		RelationshipID: t.RelationshipID,
+		Type:           t.Type,
+		Direction:      t.Direction,
+		Amount:         t.Amount,
+		Status:         t.Status,
+		Reason:         t.Reason,
+	})
+}
@@ -79,6 +79,20 @@ func (u *UserRepo) FindByEmail(email string) (*model.User, error) {
 	return user, nil
 }
 
+// FindByAccountID queries for a single user by broker account ID
+func (u *UserRepo) FindByAccountID(accountID string) (*model.User, error) {
+	user := new(model.User)
+	sql := `SELECT "user".*, "role"."id" AS "role__id", "role"."access_level" AS "role__access_level", "role"."name" AS "role__name" 
+	FROM "users" AS "user" LEFT JOIN "roles" AS "role" ON "role"."id" = "user"."role_id" 
+	WHERE ("user"."account_id" = ? and deleted_at is null)`
+	_, err := u.db.QueryOne(user, sql, accountID)
+	if err != nil {
+		u.log.Warn("UserRepo Error", zap.String("Error:", err.Error()))
+		return nil, apperr.New(http.StatusNotFound, "User not found.")
+	}
+	return user, nil
+}
+
 // FindByMobile queries for a single user by mobile (and country code)
 func (u *UserRepo) FindByMobile(countryCode, mobile string) (*model.User, error) {
 	user := new(model.User)
@@ -117,6 +131,16 @@ func (u *UserRepo) UpdateLogin(user *model.User) error {
 	return err
 }
 
+// UpdateAccountStatus updates the account status of the user owning a brokerage account
+func (u *UserRepo) UpdateAccountStatus(accountID, status string) error {
+	_, err := u.db.Model((*model.User)(nil)).Set("account_status = ?", status).Set("updated_at = now()").
+		Where("account_id = ?", accountID).Where(notDeleted).Update()
+	if err != nil {
+		u.log.Warn("UserRepo Error", zap.Error(err))
+	}
+	return err
+}
+
 // List returns list of all users retreivable for the current user, depending on role
 func (u *UserRepo) List(qp *model.ListQuery, p *model.Pagination) ([]model.User, error) {
 	var users []model.User
@@ -175,6 +199,7 @@ func (u *UserRepo) Update(user *model.User) (*model.User, error) {
 		"avatar",
 		"referred_by",
 		"watchlist_id",
+		"max_order_size",
 		"active",
 		"verified",
 		"updated_at",
@@ -47,15 +47,16 @@ func (s *Service) View(c *gin.Context, id int) (*model.User, error) {
 
 // Update contains user's information used for updating
 type Update struct {
-	ID        int
-	FirstName *string
-	LastName  *string
-	Mobile    *string
-	Phone     *string
-	Address   *string
+	ID           int
+	FirstName    *string
+	LastName     *string
+	Mobile       *string
+	Phone        *string
+	Address      *string
+	MaxOrderSize *float64
 }
 
-// Update updates user's contact information
+// Update updates user's contact information and trading limits
 func (s *Service) Update(c *gin.Context, update *Update) (*model.User, error) {
 	if !s.rbac.EnforceUser(c, update.ID) {
 		return nil, apperr.New(http.StatusForbidden, "Forbidden")
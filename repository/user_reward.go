@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"go.uber.org/zap"
+)
+
+// journalRejected lists the final journal statuses of journals that did not go through
+var journalRejected = []string{"rejected", "canceled", "refused", "deleted"}
+
+// NewUserRewardRepo returns a new UserRewardRepo instance
+func NewUserRewardRepo(db orm.DB, log *zap.Logger) *UserRewardRepo {
+	return &UserRewardRepo{db, log}
+}
+
+// UserRewardRepo is the client for the user reward model
+type UserRewardRepo struct {
+	db  orm.DB
+	log *zap.Logger
+}
+
+// UpdateJournalStatus records the status of the journal paying out a reward. Rewards are
+// transferred once their journal is executed.
+func (r *UserRewardRepo) UpdateJournalStatus(journalID, status, reason string) error {
+	q := r.db.Model((*model.UserReward)(nil)).Set("reward_transfer_status = ?", status == "executed").
+		Set("updated_at = now()").Where("journal_id = ?", journalID)
+	for _, s := range journalRejected {
+		if s == status {
+			if reason == "" {
+				reason = "Journal " + status + "."
+			}
+			q.Set("error_response = ?", reason)
+		}
+	}
+	_, err := q.Update()
+	if err != nil {
+		r.log.Warn("UserRewardRepo Error", zap.Error(err))
+	}
+	return err
+}
+
+// FindByJournalID returns the reward paid out by a journal
+func (r *UserRewardRepo) FindByJournalID(journalID string) (*model.UserReward, error) {
+	reward := new(model.UserReward)
+	err := r.db.Model(reward).Where("journal_id = ?", journalID).Where(notDeleted).Select()
+	if err == pg.ErrNoRows {
+		return nil, apperr.New(http.StatusNotFound, "Reward not found.")
+	}
+	if err != nil {
+		r.log.Warn("UserRewardRepo Error", zap.Error(err))
+		return nil, apperr.DB
+	}
+	return reward, nil
+}
@@ -0,0 +1,52 @@
+package request
+
+import (
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertCreate contains alert creation data from json request
+type AlertCreate struct {
+	Symbol    string  `json:"symbol" binding:"required"`
+	Condition string  `json:"condition" binding:"required"`
+	Threshold float64 `json:"threshold" binding:"required"`
+	Channel   string  `json:"channel" binding:"required"`
+	Recurring bool    `json:"recurring"`
+}
+
+// CreateAlert validates alert creation request
+func CreateAlert(c *gin.Context) (*AlertCreate, error) {
+	var a AlertCreate
+	if err := c.ShouldBindJSON(&a); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &a, nil
+}
+
+// AlertUpdate contains alert update data from json request
+type AlertUpdate struct {
+	ID        int      `json:"-"`
+	Symbol    *string  `json:"symbol,omitempty"`
+	Condition *string  `json:"condition,omitempty"`
+	Threshold *float64 `json:"threshold,omitempty"`
+	Channel   *string  `json:"channel,omitempty"`
+	Recurring *bool    `json:"recurring,omitempty"`
+	Active    *bool    `json:"active,omitempty"`
+}
+
+// UpdateAlert validates alert update request
+func UpdateAlert(c *gin.Context) (*AlertUpdate, error) {
+	var a AlertUpdate
+	id, err := ID(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ShouldBindJSON(&a); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	a.ID = id
+	return &a, nil
+}
@@ -0,0 +1,68 @@
+package request
+
+import (
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlgorithmCreate contains algorithm creation data from json request
+type AlgorithmCreate struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	Strategy    string                 `json:"strategy" binding:"required"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	Symbols     []string               `json:"symbols"`
+	Interval    int                    `json:"interval" binding:"omitempty,min=1"`
+}
+
+// CreateAlgorithm validates algorithm creation request
+func CreateAlgorithm(c *gin.Context) (*AlgorithmCreate, error) {
+	var a AlgorithmCreate
+	if err := c.ShouldBindJSON(&a); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &a, nil
+}
+
+// AlgorithmUpdate contains algorithm update data from json request
+type AlgorithmUpdate struct {
+	ID          int                    `json:"-"`
+	Name        *string                `json:"name,omitempty" binding:"omitempty,min=1"`
+	Description *string                `json:"description,omitempty"`
+	Strategy    *string                `json:"strategy,omitempty" binding:"omitempty,min=1"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Symbols     []string               `json:"symbols,omitempty"`
+	Interval    *int                   `json:"interval,omitempty" binding:"omitempty,min=1"`
+}
+
+// UpdateAlgorithm validates algorithm update request
+func UpdateAlgorithm(c *gin.Context) (*AlgorithmUpdate, error) {
+	var a AlgorithmUpdate
+	id, err := ID(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ShouldBindJSON(&a); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	a.ID = id
+	return &a, nil
+}
+
+// AlgorithmBudget contains the amount a user allocates to an algorithm
+type AlgorithmBudget struct {
+	Amount float64 `json:"amount" binding:"min=0"`
+}
+
+// BudgetAlgorithm validates algorithm budget request
+func BudgetAlgorithm(c *gin.Context) (*AlgorithmBudget, error) {
+	var b AlgorithmBudget
+	if err := c.ShouldBindJSON(&b); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &b, nil
+}
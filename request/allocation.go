@@ -0,0 +1,48 @@
+package request
+
+import (
+	"strings"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllocationTarget contains the weight of a symbol in an allocation
+type AllocationTarget struct {
+	Symbol string  `json:"symbol" binding:"required"`
+	Weight float64 `json:"weight" binding:"gt=0,lte=1"`
+}
+
+// AllocationSave contains allocation data from json request. The drift threshold defaults to
+// 5 percentage points and the minimum trade to $1.
+type AllocationSave struct {
+	Targets       []AllocationTarget `json:"targets" binding:"required,dive"`
+	Threshold     *float64           `json:"threshold" binding:"omitempty,gte=0,lte=1"`
+	MinTradeSize  *float64           `json:"min_trade_size" binding:"omitempty,gte=0"`
+	AutoRebalance bool               `json:"auto_rebalance"`
+	// CloseUntargeted opts into selling the positions without a target
+	CloseUntargeted bool `json:"close_untargeted"`
+}
+
+// SaveAllocation validates allocation request
+func SaveAllocation(c *gin.Context) (*AllocationSave, error) {
+	var a AllocationSave
+	if err := c.ShouldBindJSON(&a); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	for i := range a.Targets {
+		a.Targets[i].Symbol = strings.ToUpper(strings.TrimSpace(a.Targets[i].Symbol))
+	}
+	if a.Threshold == nil {
+		v := model.DefaultAllocationThreshold
+		a.Threshold = &v
+	}
+	if a.MinTradeSize == nil {
+		v := model.DefaultMinTradeSize
+		a.MinTradeSize = &v
+	}
+	return &a, nil
+}
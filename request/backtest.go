@@ -0,0 +1,44 @@
+package request
+
+import (
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BacktestCreate contains backtest run data from json request. Dates are formatted 2006-01-02.
+type BacktestCreate struct {
+	Strategy       string                 `json:"strategy" binding:"required"`
+	Parameters     map[string]interface{} `json:"parameters"`
+	Symbol         string                 `json:"symbol" binding:"required"`
+	StartDate      string                 `json:"start_date" binding:"required"`
+	EndDate        string                 `json:"end_date" binding:"required"`
+	InitialCash    float64                `json:"initial_cash" binding:"required,gt=0"`
+	Slippage       float64                `json:"slippage" binding:"min=0,max=1"`
+	Commission     float64                `json:"commission" binding:"min=0"`
+	CommissionRate float64                `json:"commission_rate" binding:"min=0,max=1"`
+
+	Start time.Time `json:"-"`
+	End   time.Time `json:"-"`
+}
+
+// CreateBacktest validates backtest run request
+func CreateBacktest(c *gin.Context) (*BacktestCreate, error) {
+	var b BacktestCreate
+	if err := c.ShouldBindJSON(&b); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	var err error
+	if b.Start, err = time.Parse("2006-01-02", b.StartDate); err != nil {
+		apperr.Response(c, apperr.BadRequest)
+		return nil, err
+	}
+	if b.End, err = time.Parse("2006-01-02", b.EndDate); err != nil {
+		apperr.Response(c, apperr.BadRequest)
+		return nil, err
+	}
+	return &b, nil
+}
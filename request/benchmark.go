@@ -0,0 +1,25 @@
+package request
+
+import (
+	"strings"
+
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Benchmark contains the symbol a performance is compared with, e.g. SPY, if any
+type Benchmark struct {
+	Symbol string `form:"benchmark" binding:"omitempty,max=10"`
+}
+
+// CompareBenchmark validates benchmark request
+func CompareBenchmark(c *gin.Context) (*Benchmark, error) {
+	var r Benchmark
+	if err := c.ShouldBindQuery(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	r.Symbol = strings.ToUpper(strings.TrimSpace(r.Symbol))
+	return &r, nil
+}
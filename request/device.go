@@ -0,0 +1,39 @@
+package request
+
+import (
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceRegister contains device registration data from json request
+type DeviceRegister struct {
+	Platform   string `json:"platform" binding:"required"`
+	Token      string `json:"token" binding:"required"`
+	AppVersion string `json:"app_version"`
+}
+
+// RegisterDevice validates device registration request
+func RegisterDevice(c *gin.Context) (*DeviceRegister, error) {
+	var r DeviceRegister
+	if err := c.ShouldBindJSON(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &r, nil
+}
+
+// DeviceUnregister contains the push token of the device to unregister from json request
+type DeviceUnregister struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// UnregisterDevice validates device unregistration request
+func UnregisterDevice(c *gin.Context) (*DeviceUnregister, error) {
+	var r DeviceUnregister
+	if err := c.ShouldBindJSON(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,84 @@
+package request
+
+import (
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationList contains the filters of a notification list request
+type NotificationList struct {
+	Unread bool `form:"unread"`
+}
+
+// ListNotifications validates notification list request
+func ListNotifications(c *gin.Context) (*NotificationList, error) {
+	var r NotificationList
+	if err := c.ShouldBindQuery(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &r, nil
+}
+
+// NotificationUpdate contains the read state of a notification from json request
+type NotificationUpdate struct {
+	ID   int   `json:"-"`
+	Read *bool `json:"read" binding:"required"`
+}
+
+// UpdateNotification validates notification update request
+func UpdateNotification(c *gin.Context) (*NotificationUpdate, error) {
+	var r NotificationUpdate
+	id, err := ID(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ShouldBindJSON(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	r.ID = id
+	return &r, nil
+}
+
+// NotificationsUpdate contains the read state of several notifications from json request.
+// Every notification is updated when IDs is empty.
+type NotificationsUpdate struct {
+	IDs  []int `json:"ids"`
+	Read *bool `json:"read" binding:"required"`
+}
+
+// UpdateNotifications validates notifications update request
+func UpdateNotifications(c *gin.Context) (*NotificationsUpdate, error) {
+	var r NotificationsUpdate
+	if err := c.ShouldBindJSON(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &r, nil
+}
+
+// NotificationPreference contains the channels of a category to turn on or off
+type NotificationPreference struct {
+	Category string `json:"category" binding:"required"`
+	Email    *bool  `json:"email,omitempty"`
+	SMS      *bool  `json:"sms,omitempty"`
+	Push     *bool  `json:"push,omitempty"`
+	InApp    *bool  `json:"in_app,omitempty"`
+}
+
+// NotificationPreferencesUpdate contains notification preferences from json request
+type NotificationPreferencesUpdate struct {
+	Preferences []NotificationPreference `json:"preferences" binding:"required,dive"`
+}
+
+// UpdateNotificationPreferences validates notification preferences update request
+func UpdateNotificationPreferences(c *gin.Context) (*NotificationPreferencesUpdate, error) {
+	var r NotificationPreferencesUpdate
+	if err := c.ShouldBindJSON(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &r, nil
+}
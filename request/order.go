@@ -0,0 +1,55 @@
+package request
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderList contains the filters of an order list request. Status defaults to open, as it does
+// at the broker. Symbols are comma separated, and after and until are RFC 3339 timestamps.
+type OrderList struct {
+	Status  string `form:"status" binding:"omitempty,oneof=open closed all"`
+	Symbols string `form:"symbols"`
+	Side    string `form:"side" binding:"omitempty,oneof=buy sell"`
+	After   string `form:"after"`
+	Until   string `form:"until"`
+}
+
+// ListOrders validates order list request
+func ListOrders(c *gin.Context) (*model.OrderFilter, error) {
+	var r OrderList
+	if err := c.ShouldBindQuery(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	f := &model.OrderFilter{Status: r.Status, Side: r.Side}
+	switch r.Status {
+	case "":
+		f.Status = "open"
+	case "all":
+		f.Status = ""
+	}
+	if r.Symbols != "" {
+		f.Symbols = strings.Split(strings.ToUpper(r.Symbols), ",")
+	}
+	for _, t := range []struct {
+		s string
+		v **time.Time
+	}{{r.After, &f.After}, {r.Until, &f.Until}} {
+		if t.s == "" {
+			continue
+		}
+		v, err := time.Parse(time.RFC3339, t.s)
+		if err != nil {
+			apperr.Response(c, apperr.BadRequest)
+			return nil, err
+		}
+		*t.v = &v
+	}
+	return f, nil
+}
@@ -0,0 +1,49 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFrontierCost bounds the symbols times the points of the frontier of an optimization,
+// which its cost grows with
+const maxFrontierCost = 500
+
+// Optimize contains the symbols of a portfolio optimization from json request and its
+// constraints. Returns are estimated over a year and the frontier has 20 points by default,
+// up to 500 points across symbols, e.g. 25 points of 20 symbols. Allocate makes the
+// max_sharpe or min_variance portfolio the target allocation of the user.
+type Optimize struct {
+	Symbols      []string `json:"symbols" binding:"required,min=2,max=20"`
+	Lookback     string   `json:"lookback" binding:"omitempty,oneof=1A 3A 5A"`
+	MaxWeight    float64  `json:"max_weight" binding:"omitempty,gt=0,lte=1"`
+	RiskFreeRate float64  `json:"risk_free_rate" binding:"omitempty,gte=0,lt=1"`
+	Points       int      `json:"points" binding:"omitempty,min=2,max=50"`
+	Allocate     string   `json:"allocate" binding:"omitempty,oneof=max_sharpe min_variance"`
+}
+
+// OptimizePortfolio validates portfolio optimization request
+func OptimizePortfolio(c *gin.Context) (*Optimize, error) {
+	var r Optimize
+	if err := c.ShouldBindJSON(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	for i := range r.Symbols {
+		r.Symbols[i] = strings.ToUpper(strings.TrimSpace(r.Symbols[i]))
+	}
+	if r.Points == 0 {
+		r.Points = 20
+	}
+	if len(r.Symbols)*r.Points > maxFrontierCost {
+		err := apperr.New(http.StatusBadRequest, "Too many points for these symbols, at most "+strconv.Itoa(maxFrontierCost/len(r.Symbols))+".")
+		apperr.Response(c, err)
+		return nil, err
+	}
+	return &r, nil
+}
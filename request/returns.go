@@ -0,0 +1,30 @@
+package request
+
+import (
+	"strings"
+
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Performance contains the period of an account performance request, a month by default,
+// and the symbol it is compared with, if any
+type Performance struct {
+	Period    string `form:"period" binding:"omitempty,oneof=1M 3M 6M YTD 1A 3A 5A"`
+	Benchmark string `form:"benchmark" binding:"omitempty,max=10"`
+}
+
+// AccountPerformance validates account performance request
+func AccountPerformance(c *gin.Context) (*Performance, error) {
+	var r Performance
+	if err := c.ShouldBindQuery(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	r.Benchmark = strings.ToUpper(strings.TrimSpace(r.Benchmark))
+	if r.Period == "" {
+		r.Period = "1M"
+	}
+	return &r, nil
+}
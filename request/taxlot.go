@@ -0,0 +1,63 @@
+package request
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaxLotList contains the symbol of a tax lot list request
+type TaxLotList struct {
+	Symbol string `form:"symbol"`
+}
+
+// ListTaxLots validates tax lot list request
+func ListTaxLots(c *gin.Context) (*TaxLotList, error) {
+	var r TaxLotList
+	if err := c.ShouldBindQuery(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	r.Symbol = strings.ToUpper(strings.TrimSpace(r.Symbol))
+	return &r, nil
+}
+
+// RealizedGains contains the tax year of a realized gain and loss report request, the current
+// year by default
+type RealizedGains struct {
+	Year int `form:"year" binding:"omitempty,min=1900,max=9999"`
+}
+
+// ReportRealizedGains validates realized gain and loss report request
+func ReportRealizedGains(c *gin.Context) (*RealizedGains, error) {
+	var r RealizedGains
+	if err := c.ShouldBindQuery(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	if r.Year == 0 {
+		r.Year = time.Now().Year()
+	}
+	return &r, nil
+}
+
+// LotRelief contains the lots relieved by a sell order from json request
+type LotRelief struct {
+	OrderID string   `json:"-"`
+	Method  string   `json:"method" binding:"required"`
+	LotIDs  []string `json:"lot_ids"`
+}
+
+// SetLotRelief validates lot relief request
+func SetLotRelief(c *gin.Context) (*LotRelief, error) {
+	var r LotRelief
+	if err := c.ShouldBindJSON(&r); err != nil {
+		apperr.Response(c, err)
+		return nil, err
+	}
+	r.OrderID = c.Param("order_id")
+	return &r, nil
+}
@@ -0,0 +1,186 @@
+// Package returns measures the performance of an account from its equity series and the cash
+// flows in and out of it, so that deposits and withdrawals are not mistaken for gains.
+package returns
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/zcoriarty/Backend/backtest"
+)
+
+// year is the length of a year IRRs are annualized over
+const year = 365.25 * 24 * time.Hour
+
+// ErrNoHistory is returned for equity series of less than two points
+var ErrNoHistory = errors.New("returns: not enough history")
+
+// Point is the equity of an account at a time
+type Point struct {
+	At     time.Time
+	Equity float64
+}
+
+// Flow is a cash flow into the account, positive for deposits and negative for withdrawals
+type Flow struct {
+	At     time.Time
+	Amount float64
+}
+
+// SeriesPoint is the equity of an account and its time-weighted return since the start of
+// the series
+type SeriesPoint struct {
+	At     time.Time `json:"at"`
+	Equity float64   `json:"equity"`
+	Return float64   `json:"return"`
+}
+
+// Metrics measures the performance of an account over a period. Returns are fractions, e.g.
+// 0.05 for 5%.
+type Metrics struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	StartEquity float64   `json:"start_equity"`
+	EndEquity   float64   `json:"end_equity"`
+	NetDeposits float64   `json:"net_deposits"`
+	// Gain is the change of equity that deposits and withdrawals do not account for
+	Gain float64 `json:"gain"`
+	// TimeWeightedReturn compounds the returns between cash flows, measuring the investments
+	// regardless of when money came in or out
+	TimeWeightedReturn           float64 `json:"time_weighted_return"`
+	AnnualizedTimeWeightedReturn float64 `json:"annualized_time_weighted_return"`
+	// MoneyWeightedReturn is the internal rate of return of the cash flows over the period,
+	// measuring the investor's return. It is missing when no rate solves the cash flows.
+	MoneyWeightedReturn           *float64 `json:"money_weighted_return"`
+	AnnualizedMoneyWeightedReturn *float64 `json:"annualized_money_weighted_return"`
+	// Volatility is the annualized standard deviation of the periodic returns
+	Volatility  float64       `json:"volatility"`
+	MaxDrawdown float64       `json:"max_drawdown"`
+	Sharpe      float64       `json:"sharpe"`
+	Series      []SeriesPoint `json:"series"`
+}
+
+// Compute measures the performance of an account from its equity at points and the flows in
+// and out of it. The flows between two points are attributed to the period ending at the
+// second one, whose equity includes them, and flows outside of the points are ignored.
+// periodsPerYear annualizes the volatility and Sharpe ratio of the periodic returns.
+func Compute(points []Point, flows []Flow, periodsPerYear float64) (*Metrics, error) {
+	if len(points) < 2 {
+		return nil, ErrNoHistory
+	}
+	points = sorted(points)
+	first, last := points[0], points[len(points)-1]
+	periodFlows := make([]float64, len(points))
+	var inside []Flow
+	for _, f := range flows {
+		if !f.At.After(first.At) || f.At.After(last.At) {
+			continue
+		}
+		i := sort.Search(len(points), func(i int) bool { return !points[i].At.Before(f.At) })
+		periodFlows[i] += f.Amount
+		inside = append(inside, f)
+	}
+
+	m := &Metrics{
+		Start:       first.At,
+		End:         last.At,
+		StartEquity: first.Equity,
+		EndEquity:   last.Equity,
+		Series:      make([]SeriesPoint, len(points)),
+	}
+	for _, f := range periodFlows {
+		m.NetDeposits += f
+	}
+	m.Gain = m.EndEquity - m.StartEquity - m.NetDeposits
+
+	growth := make([]float64, len(points))
+	growth[0] = 1
+	m.Series[0] = SeriesPoint{At: first.At, Equity: first.Equity}
+	var periodic []float64
+	for i := 1; i < len(points); i++ {
+		growth[i] = growth[i-1]
+		if prev := points[i-1].Equity; prev > 0 {
+			r := (points[i].Equity-periodFlows[i])/prev - 1
+			growth[i] *= 1 + r
+			periodic = append(periodic, r)
+		}
+		m.Series[i] = SeriesPoint{At: points[i].At, Equity: points[i].Equity, Return: growth[i] - 1}
+	}
+	m.TimeWeightedReturn = growth[len(growth)-1] - 1
+	years := float64(last.At.Sub(first.At)) / float64(year)
+	m.AnnualizedTimeWeightedReturn = annualize(m.TimeWeightedReturn, years)
+	if irr, ok := IRR(first, last, inside); ok {
+		period := math.Pow(1+irr, years) - 1
+		annual := annualize(period, years)
+		m.MoneyWeightedReturn = &period
+		m.AnnualizedMoneyWeightedReturn = &annual
+	}
+	m.Volatility = stddev(periodic) * math.Sqrt(periodsPerYear)
+	m.MaxDrawdown = backtest.MaxDrawdown(growth)
+	m.Sharpe = backtest.Sharpe(periodic, periodsPerYear)
+	return m, nil
+}
+
+// IRR returns the annual rate at which investing the equity of first and flows yields the
+// equity of last, and whether there is one
+func IRR(first, last Point, flows []Flow) (float64, bool) {
+	npv := func(rate float64) float64 {
+		discount := func(t time.Time) float64 {
+			return math.Pow(1+rate, -float64(t.Sub(first.At))/float64(year))
+		}
+		v := -first.Equity + last.Equity*discount(last.At)
+		for _, f := range flows {
+			v -= f.Amount * discount(f.At)
+		}
+		return v
+	}
+	lo, hi := -0.9999, 1.0
+	for npv(lo)*npv(hi) > 0 {
+		if hi *= 2; hi > 1e6 {
+			return 0, false
+		}
+	}
+	for i := 0; i < 200 && hi-lo > 1e-10; i++ {
+		mid := (lo + hi) / 2
+		if npv(lo)*npv(mid) <= 0 {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return (lo + hi) / 2, true
+}
+
+// annualize converts the return r over years into a yearly return. Returns over less than a
+// year are not extrapolated.
+func annualize(r, years float64) float64 {
+	if years < 1 || r <= -1 {
+		return r
+	}
+	return math.Pow(1+r, 1/years) - 1
+}
+
+func stddev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)-1))
+}
+
+func sorted(points []Point) []Point {
+	res := make([]Point, len(points))
+	copy(res, points)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].At.Before(res[j].At) })
+	return res
+}
@@ -0,0 +1,82 @@
+package returns_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/returns"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2025, 1, 2, 21, 0, 0, 0, time.UTC)
+
+func series(equity ...float64) []returns.Point {
+	res := make([]returns.Point, len(equity))
+	for i, e := range equity {
+		res[i] = returns.Point{At: start.AddDate(0, 0, i), Equity: e}
+	}
+	return res
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
+
+func TestCompute(t *testing.T) {
+	// a deposit of 1000 on the second day is not a gain
+	points := series(1000, 2100, 2205, 2100)
+	flows := []returns.Flow{{At: start.Add(20 * time.Hour), Amount: 1000}}
+	m, err := returns.Compute(points, flows, 252)
+	assert.Nil(t, err)
+	assert.Equal(t, 1000.0, m.NetDeposits)
+	assert.Equal(t, 100.0, round(m.Gain))
+	// 10%, then 5%, then -4.76%
+	assert.Equal(t, 0.1, round(m.TimeWeightedReturn))
+	assert.Equal(t, []float64{0, 0.1, 0.155, 0.1}, []float64{
+		round(m.Series[0].Return), round(m.Series[1].Return), round(m.Series[2].Return), round(m.Series[3].Return),
+	})
+	assert.Equal(t, 0.0476, round(m.MaxDrawdown))
+	assert.True(t, m.Volatility > 0)
+	assert.True(t, m.Sharpe > 0)
+
+	// the money-weighted return weighs the period after the deposit more
+	assert.NotNil(t, m.MoneyWeightedReturn)
+	assert.True(t, *m.MoneyWeightedReturn > 0 && *m.MoneyWeightedReturn < m.TimeWeightedReturn)
+	assert.Equal(t, *m.MoneyWeightedReturn, *m.AnnualizedMoneyWeightedReturn)
+
+	// flows outside of the series are left out
+	m, err = returns.Compute(points, append(flows, returns.Flow{At: start.AddDate(0, 1, 0), Amount: 50}), 252)
+	assert.Nil(t, err)
+	assert.Equal(t, 1000.0, m.NetDeposits)
+
+	_, err = returns.Compute(points[:1], nil, 252)
+	assert.Equal(t, returns.ErrNoHistory, err)
+}
+
+func TestIRR(t *testing.T) {
+	// 1000 growing to 1100 in a year returns 10% whatever the way
+	first := returns.Point{At: start, Equity: 1000}
+	last := returns.Point{At: start.Add(365*24*time.Hour + 6*time.Hour), Equity: 1100}
+	irr, ok := returns.IRR(first, last, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 0.1, round(irr))
+
+	// a withdrawal halfway returns its money earlier
+	last.Equity = 0
+	irr, ok = returns.IRR(first, last, []returns.Flow{{At: start.AddDate(0, 6, 0), Amount: -1100}})
+	assert.True(t, ok)
+	assert.True(t, irr > 0.2)
+
+	// nothing invested has no rate
+	_, ok = returns.IRR(returns.Point{At: start}, returns.Point{At: last.At, Equity: 10}, nil)
+	assert.False(t, ok)
+
+	// and over years, returns are annualized
+	m, err := returns.Compute([]returns.Point{first, {At: start.Add(2*365*24*time.Hour + 12*time.Hour), Equity: 1210}}, nil, 252)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.21, round(m.TimeWeightedReturn))
+	assert.Equal(t, 0.1, round(m.AnnualizedTimeWeightedReturn))
+	assert.Equal(t, 0.1, round(*m.AnnualizedMoneyWeightedReturn))
+}
@@ -0,0 +1,296 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+)
+
+const (
+	// MaxDaytrades is the number of day trades in five business days after which an account
+	// under the pattern day trader equity minimum is refused further orders
+	MaxDaytrades = 3
+	// PDTEquity is the equity above which the pattern day trader rule does not apply
+	PDTEquity = 25000.0
+)
+
+// Reason codes of rejected orders
+const (
+	AccountBlocked          = "account_blocked"
+	TradingBlocked          = "trading_blocked"
+	PatternDayTrader        = "pattern_day_trader"
+	InsufficientBuyingPower = "insufficient_buying_power"
+	AssetNotTradable        = "asset_not_tradable"
+	AssetNotFractionable    = "asset_not_fractionable"
+	MaxOrderSize            = "max_order_size"
+)
+
+// Reason explains why a check rejected an order
+type Reason struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Rejection is returned when an order fails one or more checks. It is written to clients
+// with every reason.
+type Rejection struct {
+	Reasons []Reason
+}
+
+// Error returns the messages of every reason
+func (r *Rejection) Error() string {
+	msgs := make([]string, len(r.Reasons))
+	for i, reason := range r.Reasons {
+		msgs[i] = reason.Message
+	}
+	return strings.Join(msgs, " ")
+}
+
+// HTTPStatus returns the status rejected orders are answered with
+func (r *Rejection) HTTPStatus() int {
+	return http.StatusUnprocessableEntity
+}
+
+// Body returns the response body of the rejection
+func (r *Rejection) Body() interface{} {
+	return map[string]interface{}{
+		"message": "Order rejected by pre-trade checks.",
+		"reasons": r.Reasons,
+	}
+}
+
+// Has reports whether the rejection has a reason with code
+func (r *Rejection) Has(code string) bool {
+	for _, reason := range r.Reasons {
+		if reason.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Order is an order under review, along with the account placing it and the asset it trades
+type Order struct {
+	Symbol   string
+	Side     string
+	Qty      *float64
+	Notional *float64
+	// Price is the estimated execution price: the limit price, or the latest trade price
+	Price float64
+	// Held is the buying power already held by the order being replaced, if any
+	Held float64
+	// MaxOrderSize is the largest notional the user allows for a single order, 0 for no limit
+	MaxOrderSize float64
+	Account      *broker.TradingAccount
+	Asset        *broker.Asset
+}
+
+// Estimate returns the estimated notional of the order
+func (o *Order) Estimate() float64 {
+	if o.Notional != nil {
+		return *o.Notional
+	}
+	if o.Qty != nil {
+		return *o.Qty * o.Price
+	}
+	return 0
+}
+
+// Fractional reports whether the order trades a fraction of a share
+func (o *Order) Fractional() bool {
+	if o.Notional != nil {
+		return true
+	}
+	return o.Qty != nil && *o.Qty != math.Trunc(*o.Qty)
+}
+
+// Check reviews an order, returning why it is rejected or nil
+type Check func(o *Order) *Reason
+
+// Default is the chain of checks run before any order is forwarded to the broker
+var Default = []Check{
+	CheckAccount,
+	CheckTrading,
+	CheckDaytrades,
+	CheckBuyingPower,
+	CheckTradable,
+	CheckFractionable,
+	CheckMaxOrderSize,
+}
+
+// CheckAccount rejects orders of blocked accounts
+func CheckAccount(o *Order) *Reason {
+	if o.Account.AccountBlocked {
+		return &Reason{AccountBlocked, "Account is blocked."}
+	}
+	return nil
+}
+
+// CheckTrading rejects orders of accounts whose trading is blocked or suspended
+func CheckTrading(o *Order) *Reason {
+	if o.Account.TradingBlocked || o.Account.TradeSuspendedByUser {
+		return &Reason{TradingBlocked, "Trading is blocked for this account."}
+	}
+	return nil
+}
+
+// CheckDaytrades rejects orders of accounts that reached the pattern day trader limit
+// without the equity to be exempt from it
+func CheckDaytrades(o *Order) *Reason {
+	if o.Account.DaytradeCount >= MaxDaytrades && o.Account.Equity < PDTEquity {
+		return &Reason{PatternDayTrader, "Too many day trades."}
+	}
+	return nil
+}
+
+// CheckBuyingPower rejects buy orders whose estimated notional exceeds the buying power
+func CheckBuyingPower(o *Order) *Reason {
+	if o.Side != broker.Buy {
+		return nil
+	}
+	if need := o.Estimate() - o.Held; need > o.Account.BuyingPower {
+		return &Reason{InsufficientBuyingPower, fmt.Sprintf("Insufficient buying power: the order needs %.2f, %.2f is available.", need, o.Account.BuyingPower)}
+	}
+	return nil
+}
+
+// CheckTradable rejects orders for inactive or untradable assets
+func CheckTradable(o *Order) *Reason {
+	if !o.Asset.Tradable || o.Asset.Status != "active" {
+		return &Reason{AssetNotTradable, o.Symbol + " is not tradable."}
+	}
+	return nil
+}
+
+// CheckFractionable rejects fractional and notional orders for assets that cannot be traded
+// in fractions
+func CheckFractionable(o *Order) *Reason {
+	if o.Fractional() && !o.Asset.Fractionable {
+		return &Reason{AssetNotFractionable, o.Symbol + " cannot be traded in fractions."}
+	}
+	return nil
+}
+
+// CheckMaxOrderSize rejects orders whose estimated notional exceeds the user's limit
+func CheckMaxOrderSize(o *Order) *Reason {
+	if o.MaxOrderSize > 0 && o.Estimate() > o.MaxOrderSize {
+		return &Reason{MaxOrderSize, fmt.Sprintf("Order exceeds the maximum order size of %.2f.", o.MaxOrderSize)}
+	}
+	return nil
+}
+
+// New creates a checker running checks against the accounts and assets of brk
+func New(brk broker.Service, checks ...Check) *Checker {
+	return &Checker{broker: brk, checks: checks}
+}
+
+// Checker runs a chain of checks against orders before they are forwarded to the broker
+type Checker struct {
+	broker broker.Service
+	checks []Check
+}
+
+// Run runs every check against o and returns a Rejection with the reasons of all the checks
+// that failed, or nil
+func (c *Checker) Run(o *Order) error {
+	var reasons []Reason
+	for _, check := range c.checks {
+		if r := check(o); r != nil {
+			reasons = append(reasons, *r)
+		}
+	}
+	if len(reasons) > 0 {
+		return &Rejection{Reasons: reasons}
+	}
+	return nil
+}
+
+// Review checks an order an account is about to place. maxOrderSize is the user's limit on
+// the notional of a single order, 0 for no limit.
+func (c *Checker) Review(ctx context.Context, accountID string, maxOrderSize float64, req *broker.OrderRequest) error {
+	if req.Qty == nil && req.Notional == nil {
+		return apperr.New(http.StatusBadRequest, "qty or notional is required.")
+	}
+	o := &Order{
+		Symbol:       req.Symbol,
+		Side:         req.Side,
+		Qty:          req.Qty,
+		Notional:     req.Notional,
+		MaxOrderSize: maxOrderSize,
+	}
+	return c.review(ctx, accountID, req.LimitPrice, o)
+}
+
+// ReviewReplace checks the order replacing an open order of an account
+func (c *Checker) ReviewReplace(ctx context.Context, accountID, orderID string, maxOrderSize float64, req *broker.ReplaceOrderRequest) error {
+	current, err := c.broker.GetOrder(ctx, accountID, orderID)
+	if err != nil {
+		return err
+	}
+	o := &Order{
+		Symbol:       current.Symbol,
+		Side:         current.Side,
+		Qty:          current.Qty,
+		Notional:     current.Notional,
+		MaxOrderSize: maxOrderSize,
+	}
+	if req.Qty != nil {
+		o.Qty, o.Notional = req.Qty, nil
+	}
+	limit := current.LimitPrice
+	if req.LimitPrice != nil {
+		limit = req.LimitPrice
+	}
+	if current.Side == broker.Buy {
+		// the replaced order releases the buying power its unfilled part holds
+		held := &Order{Notional: current.Notional}
+		if current.Qty != nil {
+			unfilled := *current.Qty - current.FilledQty
+			held.Qty, held.Notional = &unfilled, nil
+			if held.Price, err = c.price(ctx, current.Symbol, current.LimitPrice); err != nil {
+				return err
+			}
+		}
+		o.Held = held.Estimate()
+	}
+	return c.review(ctx, accountID, limit, o)
+}
+
+func (c *Checker) review(ctx context.Context, accountID string, limit *float64, o *Order) error {
+	var err error
+	if o.Account, err = c.broker.GetTradingAccount(ctx, accountID); err != nil {
+		return err
+	}
+	if o.Asset, err = c.broker.GetAsset(ctx, o.Symbol); err != nil {
+		if broker.IsNotFound(err) {
+			return &Rejection{Reasons: []Reason{{AssetNotTradable, o.Symbol + " is not tradable."}}}
+		}
+		return err
+	}
+	if o.Notional == nil {
+		if o.Price, err = c.price(ctx, o.Symbol, limit); err != nil {
+			return err
+		}
+	}
+	return c.Run(o)
+}
+
+// price returns the limit price, or the latest trade price of symbol
+func (c *Checker) price(ctx context.Context, symbol string, limit *float64) (float64, error) {
+	if limit != nil {
+		return *limit, nil
+	}
+	trade, err := c.broker.GetLatestTrade(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if trade.Trade == nil {
+		return 0, apperr.New(http.StatusBadRequest, "No price available for "+symbol+".")
+	}
+	return trade.Trade.Price, nil
+}
@@ -0,0 +1,173 @@
+package risk_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/risk"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func f(v float64) *float64 {
+	return &v
+}
+
+func TestChecks(t *testing.T) {
+	order := func(edit func(o *risk.Order)) *risk.Order {
+		o := &risk.Order{
+			Symbol:  "AAPL",
+			Side:    broker.Buy,
+			Qty:     f(2),
+			Price:   100,
+			Account: &broker.TradingAccount{BuyingPower: 1000, Equity: 1000},
+			Asset:   &broker.Asset{Symbol: "AAPL", Status: "active", Tradable: true, Fractionable: true},
+		}
+		if edit != nil {
+			edit(o)
+		}
+		return o
+	}
+	cases := []struct {
+		name  string
+		order *risk.Order
+		want  []string
+	}{
+		{
+			name:  "Pass",
+			order: order(nil),
+		},
+		{
+			name:  "Account blocked",
+			order: order(func(o *risk.Order) { o.Account.AccountBlocked = true }),
+			want:  []string{risk.AccountBlocked},
+		},
+		{
+			name:  "Trading suspended by user",
+			order: order(func(o *risk.Order) { o.Account.TradeSuspendedByUser = true }),
+			want:  []string{risk.TradingBlocked},
+		},
+		{
+			name:  "Pattern day trader",
+			order: order(func(o *risk.Order) { o.Account.DaytradeCount = 3 }),
+			want:  []string{risk.PatternDayTrader},
+		},
+		{
+			name: "Pattern day trader above the equity minimum",
+			order: order(func(o *risk.Order) {
+				o.Account.DaytradeCount = 3
+				o.Account.Equity = 30000
+			}),
+		},
+		{
+			name:  "Insufficient buying power",
+			order: order(func(o *risk.Order) { o.Qty = f(11) }),
+			want:  []string{risk.InsufficientBuyingPower},
+		},
+		{
+			name: "Sells need no buying power",
+			order: order(func(o *risk.Order) {
+				o.Side = broker.Sell
+				o.Qty = f(11)
+			}),
+		},
+		{
+			name: "Replaced order releases its buying power",
+			order: order(func(o *risk.Order) {
+				o.Qty = f(11)
+				o.Held = 200
+			}),
+		},
+		{
+			name:  "Asset not tradable",
+			order: order(func(o *risk.Order) { o.Asset.Status = "inactive" }),
+			want:  []string{risk.AssetNotTradable},
+		},
+		{
+			name: "Asset not fractionable",
+			order: order(func(o *risk.Order) {
+				o.Asset.Fractionable = false
+				o.Qty, o.Notional = nil, f(50)
+			}),
+			want: []string{risk.AssetNotFractionable},
+		},
+		{
+			name:  "Max order size",
+			order: order(func(o *risk.Order) { o.MaxOrderSize = 150 }),
+			want:  []string{risk.MaxOrderSize},
+		},
+		{
+			name: "Every failed check is reported",
+			order: order(func(o *risk.Order) {
+				o.Account.TradingBlocked = true
+				o.Asset.Fractionable = false
+				o.Qty = f(10.5)
+			}),
+			want: []string{risk.TradingBlocked, risk.InsufficientBuyingPower, risk.AssetNotFractionable},
+		},
+	}
+
+	checker := risk.New(nil, risk.Default...)
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checker.Run(tt.order)
+			if tt.want == nil {
+				assert.Nil(t, err)
+				return
+			}
+			rejection, ok := err.(*risk.Rejection)
+			if !assert.True(t, ok, "want a rejection, got %v", err) {
+				return
+			}
+			var codes []string
+			for _, r := range rejection.Reasons {
+				codes = append(codes, r.Code)
+			}
+			assert.Equal(t, tt.want, codes)
+		})
+	}
+}
+
+func TestReview(t *testing.T) {
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAccount("acc", 1000)
+	brk.SetPrice("AAPL", 100)
+	brk.AddAsset(broker.Asset{Symbol: "AAPL", Tradable: true})
+	checker := risk.New(brk.Broker(), risk.Default...)
+	ctx := context.Background()
+
+	// the latest trade price estimates market orders
+	err := checker.Review(ctx, "acc", 0, &broker.OrderRequest{Symbol: "AAPL", Qty: f(11), Side: broker.Buy, Type: "market"})
+	assert.True(t, err.(*risk.Rejection).Has(risk.InsufficientBuyingPower))
+	// and the limit price limit orders
+	assert.Nil(t, checker.Review(ctx, "acc", 0, &broker.OrderRequest{Symbol: "AAPL", Qty: f(11), Side: broker.Buy, Type: "limit", LimitPrice: f(90)}))
+
+	err = checker.Review(ctx, "acc", 0, &broker.OrderRequest{Symbol: "AAPL", Notional: f(10), Side: broker.Buy, Type: "market"})
+	assert.True(t, err.(*risk.Rejection).Has(risk.AssetNotFractionable))
+	err = checker.Review(ctx, "acc", 0, &broker.OrderRequest{Symbol: "MSFT", Qty: f(1), Side: broker.Buy, Type: "market"})
+	assert.True(t, err.(*risk.Rejection).Has(risk.AssetNotTradable))
+	err = checker.Review(ctx, "acc", 0, &broker.OrderRequest{Symbol: "AAPL", Side: broker.Buy, Type: "market"})
+	assert.Equal(t, http.StatusBadRequest, err.(*apperr.APPError).Status)
+
+	brk.SetTradingBlocked("acc", true)
+	err = checker.Review(ctx, "acc", 0, &broker.OrderRequest{Symbol: "AAPL", Qty: f(1), Side: broker.Buy, Type: "market"})
+	assert.True(t, err.(*risk.Rejection).Has(risk.TradingBlocked))
+	brk.SetTradingBlocked("acc", false)
+
+	// replacing an order only needs the buying power it does not already hold
+	o, err := brk.Broker().CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: f(5), Side: broker.Buy, Type: "limit", LimitPrice: f(90), TimeInForce: "day"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	brk.SetCash("acc", 600)
+	assert.Nil(t, checker.ReviewReplace(ctx, "acc", o.ID, 0, &broker.ReplaceOrderRequest{Qty: f(10)}))
+	err = checker.ReviewReplace(ctx, "acc", o.ID, 0, &broker.ReplaceOrderRequest{Qty: f(12)})
+	assert.True(t, err.(*risk.Rejection).Has(risk.InsufficientBuyingPower))
+	err = checker.ReviewReplace(ctx, "acc", o.ID, 500, &broker.ReplaceOrderRequest{LimitPrice: f(110)})
+	assert.True(t, err.(*risk.Rejection).Has(risk.MaxOrderSize))
+}
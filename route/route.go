@@ -1,18 +1,39 @@
 package route
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/config"
 	"github.com/zcoriarty/Backend/docs"
 	"github.com/zcoriarty/Backend/magic"
 	"github.com/zcoriarty/Backend/mail"
 	mw "github.com/zcoriarty/Backend/middleware"
 	"github.com/zcoriarty/Backend/mobile"
+	"github.com/zcoriarty/Backend/push"
 	"github.com/zcoriarty/Backend/repository"
 	"github.com/zcoriarty/Backend/repository/account"
+	"github.com/zcoriarty/Backend/repository/alerts"
+	"github.com/zcoriarty/Backend/repository/algorithm"
+	"github.com/zcoriarty/Backend/repository/allocations"
 	assets "github.com/zcoriarty/Backend/repository/assets"
 	"github.com/zcoriarty/Backend/repository/auth"
+	"github.com/zcoriarty/Backend/repository/backtest"
+	"github.com/zcoriarty/Backend/repository/bars"
+	"github.com/zcoriarty/Backend/repository/devices"
+	"github.com/zcoriarty/Backend/repository/events"
+	"github.com/zcoriarty/Backend/repository/marketdata"
+	"github.com/zcoriarty/Backend/repository/movers"
+	"github.com/zcoriarty/Backend/repository/news"
+	"github.com/zcoriarty/Backend/repository/notifications"
+	"github.com/zcoriarty/Backend/repository/order"
+	"github.com/zcoriarty/Backend/repository/performance"
 	"github.com/zcoriarty/Backend/repository/plaid"
+	"github.com/zcoriarty/Backend/repository/portfolio"
+	"github.com/zcoriarty/Backend/repository/returns"
+	"github.com/zcoriarty/Backend/repository/stream"
+	"github.com/zcoriarty/Backend/repository/taxlots"
 	"github.com/zcoriarty/Backend/repository/transfer"
 	"github.com/zcoriarty/Backend/repository/user"
 	"github.com/zcoriarty/Backend/secret"
@@ -26,8 +47,8 @@ import (
 )
 
 // NewServices creates a new router services
-func NewServices(DB *pg.DB, Log *zap.Logger, JWT *mw.JWT, Mail mail.Service, Mobile mobile.Service, Magic magic.Service, R *gin.Engine) *Services {
-	return &Services{DB, Log, JWT, Mail, Mobile, Magic, R}
+func NewServices(DB *pg.DB, Log *zap.Logger, JWT *mw.JWT, Mail mail.Service, Mobile mobile.Service, Magic magic.Service, Broker broker.Service, R *gin.Engine) *Services {
+	return &Services{DB, Log, JWT, Mail, Mobile, Magic, Broker, R}
 }
 
 // Services lets us bind specific services when setting up routes
@@ -38,6 +59,7 @@ type Services struct {
 	Mail   mail.Service
 	Mobile mobile.Service
 	Magic  magic.Service
+	Broker broker.Service
 	R      *gin.Engine
 }
 
@@ -47,8 +69,29 @@ func (s *Services) SetupV1Routes() {
 	userRepo := repository.NewUserRepo(s.DB, s.Log)
 	accountRepo := repository.NewAccountRepo(s.DB, s.Log, secret.New())
 	assetRepo := repository.NewAssetRepo(s.DB, s.Log, secret.New())
+	algorithmRepo := repository.NewAlgorithmRepo(s.DB, s.Log)
+	budgetRepo := repository.NewBudgetRepo(s.DB, s.Log)
+	backtestRepo := repository.NewBacktestRepo(s.DB, s.Log)
+	performanceRepo := repository.NewPerformanceRepo(s.DB, s.Log)
+	orderRepo := repository.NewOrderRepo(s.DB, s.Log)
+	transferRepo := repository.NewTransferRepo(s.DB, s.Log)
+	alertRepo := repository.NewAlertRepo(s.DB, s.Log)
+	notificationRepo := repository.NewNotificationRepo(s.DB, s.Log)
+	deviceRepo := repository.NewDeviceRepo(s.DB, s.Log)
+	taxLotRepo := repository.NewTaxLotRepo(s.DB, s.Log)
+	allocationRepo := repository.NewAllocationRepo(s.DB, s.Log)
 	rbac := repository.NewRBACService(userRepo)
 
+	// market data is served from a cache shared by every service, in front of the bars store
+	barStore := bars.NewStore(s.Broker, repository.NewBarRepo(s.DB, s.Log), s.Log)
+	mdConfig := config.GetMarketDataConfig()
+	var mdStore marketdata.Store = marketdata.NewMemoryStore()
+	if mdConfig.Backend == "postgres" {
+		mdStore = marketdata.NewDBStore(repository.NewMarketDataRepo(s.DB, s.Log))
+	}
+	cache := marketdata.NewCache(barStore, mdStore, mdConfig, s.Log)
+	brk := broker.Service(cache)
+
 	// s.R.Use(cors.New(cors.Config{
 	// 	AllowAllOrigins:  true,
 	// 	AllowMethods:     []string{"GET", "PUT", "DELETE", "PATCH", "POST", "OPTIONS"},
@@ -65,9 +108,34 @@ func (s *Services) SetupV1Routes() {
 	authService := auth.NewAuthService(userRepo, accountRepo, s.JWT, s.Mail, s.Mobile, s.Magic)
 	accountService := account.NewAccountService(userRepo, accountRepo, rbac, secret.New())
 	userService := user.NewUserService(userRepo, authService, rbac)
-	plaidService := plaid.NewPlaidService(userRepo, accountRepo, s.JWT, s.DB, s.Log)
-	transferService := transfer.NewTransferService(userRepo, accountRepo, s.JWT, s.DB, s.Log)
+	plaidService := plaid.NewPlaidService(userRepo, accountRepo, s.JWT, s.DB, s.Log, brk)
+	transferService := transfer.NewTransferService(userRepo, accountRepo, transferRepo, s.JWT, s.DB, s.Log)
 	assetsService := assets.NewAssetsService(userRepo, accountRepo, assetRepo, s.JWT, s.DB, s.Log)
+	algorithmService := algorithm.NewAlgorithmService(userRepo, algorithmRepo, budgetRepo, rbac, brk)
+	backtestService := backtest.NewBacktestService(backtestRepo, brk, barStore)
+	performanceService := performance.NewPerformanceService(performanceRepo, rbac, brk, barStore)
+	orderService := order.NewOrderService(userRepo, orderRepo, brk)
+	marketDataService := marketdata.NewMarketDataService(cache, rbac)
+	moversService := movers.NewMoversService(assetRepo, brk, config.GetMoversConfig(), s.Log)
+	alertService := alerts.NewAlertService(userRepo, alertRepo, deviceRepo, brk)
+	notificationService := notifications.NewNotificationService(userRepo, notificationRepo,
+		notifications.NewChannels(s.Mail, s.Mobile, push.NewDevices(deviceRepo, push.NewSenders(config.GetPushConfig()), s.Log)))
+	deviceService := devices.NewDeviceService(deviceRepo)
+	taxLotService := taxlots.NewTaxLotService(orderRepo, taxLotRepo)
+	returnsService := returns.NewReturnsService(userRepo, transferRepo, brk, barStore)
+	allocationService := allocations.NewAllocationService(userRepo, allocationRepo, budgetRepo, orderService, brk)
+	portfolioService := portfolio.NewPortfolioService(barStore, allocationService)
+	newsConfig := config.GetNewsConfig()
+	newsService := news.NewNewsService(userRepo, brk, news.NewClient(newsConfig), mdStore, newsConfig, s.Log)
+
+	// real-time streams, relaying the broker's market data and trade events to every client
+	bus := events.NewBus()
+	hub := stream.NewHub(brk, userRepo, bus, s.Log)
+	go hub.Run(context.Background())
+	go events.NewRelay(brk, bus, s.Log, broker.TradeStream).Run(context.Background())
+
+	// market movers are recomputed on a schedule and served from memory
+	go moversService.Run(context.Background())
 
 	// no prefix, no jwt
 	service.AuthRouter(authService, s.R)
@@ -75,11 +143,27 @@ func (s *Services) SetupV1Routes() {
 	// prefixed with /v1 and protected by jwt
 	v1Router := s.R.Group("/v1")
 	v1Router.Use(s.JWT.MWFunc())
-	service.AccountRouter(accountService, s.DB, v1Router)
-	service.PlaidRouter(plaidService, accountService, v1Router)
-	service.TransferRouter(transferService, accountService, v1Router)
-	service.AssetsRouter(assetsService, accountService, v1Router)
+	service.AccountRouter(accountService, s.DB, brk, v1Router)
+	service.PlaidRouter(plaidService, accountService, brk, v1Router)
+	service.TransferRouter(transferService, accountService, brk, v1Router)
+	service.AssetsRouter(assetsService, accountService, brk, v1Router)
 	service.UserRouter(userService, v1Router)
+	service.AlgorithmRouter(algorithmService, v1Router)
+	service.BacktestRouter(backtestService, v1Router)
+	service.StrategyRouter(v1Router)
+	service.PerformanceRouter(performanceService, v1Router)
+	service.OrderRouter(orderService, v1Router)
+	service.StreamRouter(hub, v1Router)
+	service.MarketDataRouter(marketDataService, v1Router)
+	service.MoversRouter(moversService, v1Router)
+	service.NewsRouter(newsService, v1Router)
+	service.AlertRouter(alertService, v1Router)
+	service.NotificationRouter(notificationService, v1Router)
+	service.DeviceRouter(deviceService, v1Router)
+	service.TaxLotRouter(taxLotService, v1Router)
+	service.ReturnsRouter(returnsService, v1Router)
+	service.AllocationRouter(allocationService, v1Router)
+	service.PortfolioRouter(portfolioService, v1Router)
 
 	// Routes for static files
 	s.R.StaticFS("/file", http.Dir("public"))
@@ -3,6 +3,7 @@ package server
 import (
 	"os"
 
+	"github.com/zcoriarty/Backend/broker"
 	"github.com/zcoriarty/Backend/config"
 	"github.com/zcoriarty/Backend/mail"
 	mw "github.com/zcoriarty/Backend/middleware"
@@ -49,6 +50,7 @@ func (server *Server) Run(env string) error {
 	jwt := mw.NewJWT(j)
 	m := mail.NewMail(config.GetMailConfig(), config.GetSiteConfig())
 	mobile := mobile.NewMobile(config.GetTwilioConfig())
+	brk := broker.NewBroker(config.GetBrokerConfig())
 	db := config.GetConnection()
 	log, _ := zap.NewDevelopment()
 	defer log.Sync()
@@ -60,6 +62,7 @@ func (server *Server) Run(env string) error {
 		JWT:    jwt,
 		Mail:   m,
 		Mobile: mobile,
+		Broker: brk,
 		R:      r}
 	rsDefault.SetupV1Routes()
 
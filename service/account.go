@@ -1,16 +1,13 @@
 package service
 
 import (
-	"bytes"
 	"encoding/csv"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -18,6 +15,8 @@ import (
 	"time"
 
 	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/indicator"
 	"github.com/zcoriarty/Backend/model"
 	"github.com/zcoriarty/Backend/repository/account"
 	"github.com/zcoriarty/Backend/request"
@@ -26,20 +25,21 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-pg/pg/v9/orm"
 	shortuuid "github.com/lithammer/shortuuid/v3"
-	"go.uber.org/zap"
 )
 
 // AccountService represents the account http service
 type AccountService struct {
-	svc *account.Service
-	db  orm.DB
+	svc    *account.Service
+	db     orm.DB
+	broker broker.Service
 }
 
 // AccountRouter sets up all the controller functions to our router
-func AccountRouter(svc *account.Service, db orm.DB, r *gin.RouterGroup) {
+func AccountRouter(svc *account.Service, db orm.DB, brk broker.Service, r *gin.RouterGroup) {
 	a := AccountService{
-		svc: svc,
-		db:  db,
+		svc:    svc,
+		db:     db,
+		broker: brk,
 	}
 	pr := r.Group("/profile")
 	pr.GET("", a.profile)
@@ -72,14 +72,6 @@ func AccountRouter(svc *account.Service, db orm.DB, r *gin.RouterGroup) {
 	ar.POST("", a.create)
 	ar.PATCH("/:id/password", a.changePassword)
 
-	ac := r.Group("/orders")
-	ac.GET("", a.getOrders)
-	ac.POST("", a.createOrder)
-	ac.GET("/:order_id", a.getOrderDetails)
-	ac.PATCH("/:order_id", a.replaceOrder)
-	ac.DELETE("", a.cancelAllOrders)
-	ac.DELETE("/:order_id", a.cancelOrder)
-
 	pz := r.Group("/positions")
 	pz.GET("", a.getPositions)
 	pz.GET("/:symbol", a.getOneOpenPosition)
@@ -94,8 +86,7 @@ func AccountRouter(svc *account.Service, db orm.DB, r *gin.RouterGroup) {
 	mrk.GET("/stocks/:symbol/quotes", a.getMarketQuotesBySymbol)
 	mrk.GET("/stocks/:symbol/quotes/latest", a.getMarketLatestQuoteBySymbol)
 	mrk.GET("/stocks/:symbol/bars", a.getMarketBarsBySymbol)
-	mrk.GET("/stocks/top-movers", a.getMarketTopMovers)
-	mrk.GET("/stocks/news", a.getMarketNews)
+	mrk.GET("/stocks/:symbol/indicators", a.getMarketIndicatorsBySymbol)
 
 	// testing: /v1/trading/accounts/b020a0d5-afab-4749-9a14-6662eb0aa63b/watchlists
 	watchlist := r.Group("/watchlist")
@@ -489,66 +480,9 @@ func (a *AccountService) getReferralUrl(c *gin.Context) {
 	})
 }
 
-type BrokerContact struct {
-	Email   string   `json:"email_address"`
-	Phone   string   `json:"phone_number"`
-	Address []string `json:"street_address"`
-	City    string   `json:"city"`
-	State   string   `json:"state"`
-	Country string   `json:"country"`
-}
-type BrokerIdentity struct {
-	FirstName             string   `json:"given_name"`
-	LastName              string   `json:"family_name"`
-	DateOfBirth           string   `json:"date_of_birth"`
-	TaxID                 string   `json:"tax_id"`
-	TaxIDType             string   `json:"tax_id_type"`
-	CountryOfCitizenship  string   `json:"country_of_citizenship"`
-	CountryOfBirth        string   `json:"country_of_birth"`
-	CountryOfTaxResidence string   `json:"country_of_tax_residence"`
-	FundingSource         []string `json:"funding_source"`
-}
-type BrokerDisclosures struct {
-	IsControlPerson             bool `json:"is_control_person"`
-	IsAffiliatedExchangeOrFinra bool `json:"is_affiliated_exchange_or_finra"`
-	IsPoliticallyExposed        bool `json:"is_politically_exposed"`
-	ImmediateFamilyExposed      bool `json:"immediate_family_exposed"`
-}
-type BrokerAgreement struct {
-	Agreement string `json:"agreement"`
-	SignedAt  string `json:"signed_at"`
-	IPAddress string `json:"ip_address"`
-}
-
-// type BrokerDocument struct {
-// 	DocumentType    string `json:"document_type"`
-// 	DocumentSubType string `json:"document_sub_type"`
-// 	Content         string `json:"content"`
-// 	MimeType        string `json:"mime_type"`
-// }
-// type BrokerTrustedContact struct {
-// 	Code            string `json:"code"`
-// 	Url             string `json:"url"`
-// 	ReferredSignups int    `json:"referred_signups"`
-// }
-type BrokerAccount struct {
-	Contact     BrokerContact     `json:"contact"`
-	Identity    BrokerIdentity    `json:"identity"`
-	Disclosures BrokerDisclosures `json:"disclosures"`
-	Agreements  []BrokerAgreement `json:"agreements"`
-}
-type BrokerAccountResponse struct {
-	AccountNumber string `json:"account_number"`
-	CreatedAt     string `json:"created_at"`
-	Currency      string `json:"currency"`
-	ID            string `json:"id"`
-	LastEquity    string `json:"last_equity"`
-	Status        string `json:"status"`
-}
-
-func getBrokerAccount(u *model.User) BrokerAccount {
-	account := BrokerAccount{
-		Contact: BrokerContact{
+func getBrokerAccount(u *model.User) *broker.AccountRequest {
+	account := &broker.AccountRequest{
+		Contact: broker.Contact{
 			Email:   u.Email,
 			Phone:   u.Mobile,
 			Address: []string{u.Address},
@@ -556,7 +490,7 @@ func getBrokerAccount(u *model.User) BrokerAccount {
 			State:   u.State,
 			Country: "USA",
 		},
-		Identity: BrokerIdentity{
+		Identity: broker.Identity{
 			FirstName:             u.FirstName,
 			LastName:              u.LastName,
 			DateOfBirth:           u.DOB,
@@ -567,13 +501,13 @@ func getBrokerAccount(u *model.User) BrokerAccount {
 			CountryOfTaxResidence: "USA",
 			FundingSource:         strings.Split(u.FundingSource, ","),
 		},
-		Disclosures: BrokerDisclosures{
+		Disclosures: broker.Disclosures{
 			IsControlPerson:             false,
 			IsAffiliatedExchangeOrFinra: false,
 			IsPoliticallyExposed:        false,
 			ImmediateFamilyExposed:      false,
 		},
-		Agreements: []BrokerAgreement{
+		Agreements: []broker.Agreement{
 			{
 				Agreement: "margin_agreement",
 				SignedAt:  time.Now().Format(time.RFC3339),
@@ -596,59 +530,30 @@ func getBrokerAccount(u *model.User) BrokerAccount {
 }
 
 func (a *AccountService) sign(c *gin.Context) {
-
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil {
-		brokerAccount := getBrokerAccount(user)
-		requestBytes, _ := json.Marshal(brokerAccount)
-		fmt.Println(string(requestBytes))
-
-		client := &http.Client{}
-		req, err := http.NewRequest("POST", os.Getenv("BROKER_API_BASE")+"/v1/accounts", bytes.NewReader(requestBytes))
+		brokerAccount, err := a.broker.CreateAccount(c.Request.Context(), getBrokerAccount(user))
 		if err != nil {
-			fmt.Print(err.Error())
+			apperr.Response(c, err)
+			return
 		}
 
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
+		reqUser := request.Update{
+			ID:              user.ID,
+			AccountID:       &brokerAccount.ID,
+			AccountCurrency: &brokerAccount.Currency,
+			AccountNumber:   &brokerAccount.AccountNumber,
+			AccountStatus:   &brokerAccount.Status,
 		}
 
-		responseData, err := ioutil.ReadAll(response.Body)
+		user2, err := a.svc.UpdateProfile(c, &reqUser)
 		if err != nil {
-			log.Fatal(err)
-		}
-
-		fmt.Println(string(responseData))
-		if strings.Contains(string(responseData), "account_number") {
-			brokerResponse := BrokerAccountResponse{}
-			json.Unmarshal(responseData, &brokerResponse)
-
-			reqUser := request.Update{
-				ID:              user.ID,
-				AccountID:       &brokerResponse.ID,
-				AccountCurrency: &brokerResponse.Currency,
-				AccountNumber:   &brokerResponse.AccountNumber,
-				AccountStatus:   &brokerResponse.Status,
-			}
-
-			user2, err := a.svc.UpdateProfile(c, &reqUser)
-			if err != nil {
-				apperr.Response(c, err)
-				return
-			}
-			c.JSON(http.StatusOK, user2)
-			return
-
-		} else {
-			var brokerResponse interface{}
-			json.Unmarshal(responseData, &brokerResponse)
-			c.JSON(response.StatusCode, brokerResponse)
+			apperr.Response(c, err)
 			return
 		}
+		c.JSON(http.StatusOK, user2)
+		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
 		"message": "Couldn't sign the account.",
@@ -656,771 +561,235 @@ func (a *AccountService) sign(c *gin.Context) {
 }
 
 func (a *AccountService) clock(c *gin.Context) {
-
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/clock", nil)
-	if err != nil {
-		fmt.Print(err.Error())
-	}
-
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-	response, err := client.Do(req)
-
-	if err != nil {
-		fmt.Print(err.Error())
-	}
-
-	responseData, err := ioutil.ReadAll(response.Body)
+	clock, err := a.broker.GetClock(c.Request.Context())
 	if err != nil {
-		log.Fatal(err)
+		apperr.Response(c, err)
+		return
 	}
-
-	var responseObject interface{}
-	json.Unmarshal(responseData, &responseObject)
-
-	c.JSON(response.StatusCode, responseObject)
+	c.JSON(http.StatusOK, clock)
 }
 
-func (a *AccountService) getOrders(c *gin.Context) {
+func (a *AccountService) portfolioHistory(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/orders", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
+		p := new(broker.PortfolioHistoryRequest)
+		if err := c.ShouldBindQuery(p); err != nil {
+			apperr.Response(c, err)
+			return
 		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		history, err := a.broker.GetPortfolioHistory(c.Request.Context(), user.AccountID, p)
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, history)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
-		"message": "Couldn't fetch orders",
+		"message": "Couldn't get profile history.",
 	})
 }
 
-type Account struct {
-	ID                        string  `json:"id"`
-	AccountNumber             string  `json:"account_number"`
-	Status                    string  `json:"status"`
-	CryptoStatus              string  `json:"crypto_status"`
-	Currency                  string  `json:"currency"`
-	BuyingPower               float64 `json:"buying_power"`
-	RegtBuyingPower           float64 `json:"regt_buying_power"`
-	DaytradingBuyingPower     float64 `json:"daytrading_buying_power"`
-	EffectiveBuyingPower      float64 `json:"effective_buying_power"`
-	NonMarginableBuyingPower  float64 `json:"non_marginable_buying_power"`
-	BodDtbp                   float64 `json:"bod_dtbp"`
-	Cash                      float64 `json:"cash"`
-	CashWithdrawable          float64 `json:"cash_withdrawable"`
-	CashTransferable          float64 `json:"cash_transferable"`
-	AccruedFees               float64 `json:"accrued_fees"`
-	PendingTransferOut        float64 `json:"pending_transfer_out"`
-	PendingTransferIn         float64 `json:"pending_transfer_in"`
-	PortfolioValue            float64 `json:"portfolio_value"`
-	PatternDayTrader          bool    `json:"pattern_day_trader"`
-	TradingBlocked            bool    `json:"trading_blocked"`
-	TransfersBlocked          bool    `json:"transfers_blocked"`
-	AccountBlocked            bool    `json:"account_blocked"`
-	CreatedAt                 string  `json:"created_at"`
-	TradeSuspendedByUser      bool    `json:"trade_suspended_by_user"`
-	Multiplier                string  `json:"multiplier"`
-	ShortingEnabled           bool    `json:"shorting_enabled"`
-	Equity                    float64 `json:"equity"`
-	LastEquity                float64 `json:"last_equity"`
-	LongMarketValue           float64 `json:"long_market_value"`
-	ShortMarketValue          float64 `json:"short_market_value"`
-	PositionMarketValue       float64 `json:"position_market_value"`
-	InitialMargin             float64 `json:"initial_margin"`
-	MaintenanceMargin         float64 `json:"maintenance_margin"`
-	LastMaintenanceMargin     float64 `json:"last_maintenance_margin"`
-	Sma                       float64 `json:"sma"`
-	DaytradeCount             int     `json:"daytrade_count"`
-	BalanceAsof               string  `json:"balance_asof"`
-	PreviousClose             string  `json:"previous_close"`
-	LastLongMarketValue       float64 `json:"last_long_market_value"`
-	LastShortMarketValue      float64 `json:"last_short_market_value"`
-	LastCash                  float64 `json:"last_cash"`
-	LastInitialMargin         float64 `json:"last_initial_margin"`
-	LastRegtBuyingPower       float64 `json:"last_regt_buying_power"`
-	LastDaytradingBuyingPower float64 `json:"last_daytrading_buying_power"`
-	LastBuyingPower           float64 `json:"last_buying_power"`
-	LastDaytradeCount         int     `json:"last_daytrade_count"`
-	ClearingBroker            string  `json:"clearing_broker"`
-}
-
-type BacktestRequest struct {
-	Strategy  string `json:"strategy"`
-	StartDate string `json:"start_date"`
-	EndDate   string `json:"end_date"`
-	Symbol    string `json:"symbol"`
-}
-
-func getHistoricalData(symbol string, start, end time.Time) ([]byte, error) {
+func (a *AccountService) tradingProfile(c *gin.Context) {
+	id, _ := c.Get("id")
+	user := a.svc.GetProfile(c, id.(int))
 
-	apiKey := strings.TrimSpace(os.Getenv("POLYGON_API_KEY"))
-	baseURL := strings.TrimSpace(os.Getenv("POLYGON_API_BASE"))
-	if apiKey == "" || baseURL == "" {
-		log.Fatal("Missing API key or base URL")
+	if user == nil || user.AccountID == "" {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
+		return
 	}
 
-	client := &http.Client{}
-
-	req, err := http.NewRequest("GET", baseURL+"/aggs/ticker/"+symbol+"/range/"+start.Format("20060102")+"/"+end.Format("20060102")+"/day", nil)
+	account, err := a.broker.GetTradingAccount(c.Request.Context(), user.AccountID)
 	if err != nil {
-		return nil, err
+		apperr.Response(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, account)
+}
+
+func (a *AccountService) stats(c *gin.Context) {
+	id, _ := c.Get("id")
 
-	// Set API key in the request header
-	req.Header.Set("X-API-KEY", apiKey)
+	peopleInvited, _ := a.db.Model(&model.UserReward{}).Where(`referred_by = ?`, id.(int)).Count()
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get historical data: %s", resp.Status)
-	}
+	referralReward := new(model.UserReward)
+	referreReward := new(model.UserReward)
+	_, err := a.db.Model((*model.UserReward)(nil)).QueryOne(referralReward, `
+		SELECT SUM(reward_value) reward_value from user_rewards where referred_by = ? AND reward_transfer_status = ?;`, id, true)
 
-	data, err := ioutil.ReadAll(resp.Body)
+	_, err1 := a.db.Model((*model.UserReward)(nil)).QueryOne(referreReward, `
+	SELECT SUM(reward_value) reward_value from user_rewards where user_id = ? AND reward_transfer_status = ?;`, id, true)
+	var totalReward float32 = 0
 	if err != nil {
-		return nil, err
+		totalReward = 0
 	}
 
-	return data, nil
-}
-
-/*
-Cases to cover:
-DONE BY ALPACA:
-1. if selling, check that the # to sell is <= the # of shares each user owns
-
-DONE BY PARETO:
-1. if buying, total cost should be <= the amount the user has invested in the algorithm
-2. ensure user has enough day trades left(might be able to do this through the alpaca api) DONE
-*/
-func (a *AccountService) algoOrder(c *gin.Context) {
-	id, ok := c.Get("id")
-	if !ok {
-		log.Println("Invalid request: ")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Couldn't create order.",
-		})
+	if err1 != nil {
+		totalReward = totalReward + 0
 	}
-	user := a.svc.GetProfile(c, id.(int))
-	if user != nil && user.AccountID != "" {
-
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		// access the account to get details for checking trade constraints
-		apiBase, ok := os.LookupEnv("BROKER_API_BASE")
-		if !ok {
-			log.Println("Environment variable not set: ")
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Couldn't create order.",
-			})
-		}
-		req, err := http.NewRequest("GET", apiBase+"/v1/trading/accounts/"+accountID+"/account", nil)
-		if err != nil {
-			log.Println(err)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Couldn't create order.",
-			})
-		}
-
-		brokerToken, ok := os.LookupEnv("BROKER_TOKEN")
-		if !ok {
-			log.Println("Environment variable not set: ")
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Couldn't create order.",
-			})
-		}
-		req.Header.Add("Authorization", brokerToken)
-
-		res, err := client.Do(req)
-		if err != nil {
-			log.Println(err)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Couldn't create order.",
-			})
-		}
-		defer res.Body.Close()
-
-		responseData, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			log.Println("ioutil error: ", zap.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Couldn't create order.",
-			})
-		}
-
-		// Unmarshal the byte slice into the Account struct
-		var account Account
-		err = json.Unmarshal(responseData, &account)
-		if err != nil {
-			log.Println("Failed to unmarshal HTTP response: ", zap.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Couldn't create order.",
-			})
-		}
-
-		// Get the value of DaytradeCount
-		daytradeCount := account.DaytradeCount
 
-		// Check if the user has already made 3 day trades
-		if daytradeCount >= 3 {
-			log.Println("Too many day trades. ")
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Couldn't create order.",
-			})
-		}
-
-		// Create the order
-		orderReq, err := http.NewRequest("POST", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/orders", c.Request.Body)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		orderReq.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
+	totalReward = referralReward.RewardValue + referreReward.RewardValue
 
-		if err != nil {
-			fmt.Print(err.Error())
-		}
+	c.JSON(http.StatusOK, gin.H{
+		"reward_earned":  totalReward,
+		"people_invited": peopleInvited,
+	})
+}
 
-		orderResponseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
+// MARK: Watchlists
+type AssetRequest struct {
+	Symbol string `json:"symbol"`
+}
 
-		var responseObject interface{}
-		json.Unmarshal(orderResponseData, &responseObject)
+type WatchlistResponse struct {
+	ID        string           `json:"id"`
+	AccountID string           `json:"account_id"`
+	Name      string           `json:"name"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Assets    []WatchlistAsset `json:"assets"`
+}
 
-		c.JSON(response.StatusCode, responseObject)
-		return
+type WatchlistAsset struct {
+	Symbol string           `json:"symbol"`
+	Name   string           `json:"name,omitempty"`
+	Ticker *broker.Snapshot `json:"ticker"`
+}
+
+func newWatchlistResponse(w *broker.Watchlist, snapshots map[string]*broker.Snapshot) WatchlistResponse {
+	res := WatchlistResponse{
+		ID:        w.ID,
+		AccountID: w.AccountID,
+		Name:      w.Name,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+		Assets:    make([]WatchlistAsset, 0, len(w.Assets)),
+	}
+	for _, asset := range w.Assets {
+		res.Assets = append(res.Assets, WatchlistAsset{
+			Symbol: asset.Symbol,
+			Name:   asset.Name,
+			Ticker: snapshots[asset.Symbol],
+		})
 	}
-	c.JSON(http.StatusBadRequest, gin.H{
-		"message": "Couldn't create order.",
-	})
-
+	return res
 }
 
-func (a *AccountService) createOrder(c *gin.Context) {
-	// print the gin context parameter to console
+func (a *AccountService) createWatchlist(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
-	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		req, err := http.NewRequest("POST", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/orders", c.Request.Body)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
+	if user == nil || user.AccountID == "" {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
+		return
+	}
 
-		c.JSON(response.StatusCode, responseObject)
+	var req WatchlistResponse
+	if err := c.BindJSON(&req); err != nil {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid request."))
 		return
 	}
-	c.JSON(http.StatusBadRequest, gin.H{
-		"message": "Couldn't create order.",
+	symbols := make([]string, 0, len(req.Assets))
+	for _, asset := range req.Assets {
+		symbols = append(symbols, asset.Symbol)
+	}
+
+	watchlist, err := a.broker.CreateWatchlist(c.Request.Context(), user.AccountID, &broker.WatchlistRequest{
+		Name:    req.Name,
+		Symbols: symbols,
 	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newWatchlistResponse(watchlist, nil))
 }
 
-func (a *AccountService) getOrderDetails(c *gin.Context) {
+func (a *AccountService) updateWatchlist(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
-	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		orderID := c.Param("order_id")
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/orders/"+orderID, nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
+	if user == nil || user.AccountID == "" {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
+		return
+	}
 
-		c.JSON(response.StatusCode, responseObject)
+	var req = struct {
+		Name   string   `json:"name"`
+		Assets []string `json:"assets"`
+	}{}
+	if err := c.BindJSON(&req); err != nil {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid request."))
 		return
 	}
-	c.JSON(http.StatusBadRequest, gin.H{
-		"message": "Couldn't get order details.",
+
+	watchlist, err := a.broker.UpdateWatchlist(c.Request.Context(), user.AccountID, c.Param("watchlist_id"), &broker.WatchlistRequest{
+		Name:    req.Name,
+		Symbols: req.Assets,
 	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newWatchlistResponse(watchlist, nil))
 }
 
-func (a *AccountService) replaceOrder(c *gin.Context) {
+func (a *AccountService) deleteWatchlist(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
-	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-		orderID := c.Param("order_id")
-
-		req, err := http.NewRequest("PATCH", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/orders/"+orderID, c.Request.Body)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
+	if user == nil || user.AccountID == "" {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
+		return
+	}
 
-		c.JSON(response.StatusCode, responseObject)
+	if err := a.broker.DeleteWatchlist(c.Request.Context(), user.AccountID, c.Param("watchlist_id")); err != nil {
+		apperr.Response(c, err)
 		return
 	}
-	c.JSON(http.StatusBadRequest, gin.H{
-		"message": "Couldn't replace the order.",
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Watchlist deleted successfully.",
 	})
 }
 
-func (a *AccountService) cancelAllOrders(c *gin.Context) {
+func (a *AccountService) getAllWatchlists(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
-	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		req, err := http.NewRequest("DELETE", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/orders", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
+	if user == nil || user.AccountID == "" {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
+		return
+	}
 
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
+	ctx := c.Request.Context()
+	watchlists, err := a.broker.ListWatchlists(ctx, user.AccountID)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
 
-		c.JSON(response.StatusCode, responseObject)
+	// Fetch market data of assets in every watchlist at once
+	var symbols []string
+	for i := range watchlists {
+		symbols = append(symbols, watchlists[i].Symbols()...)
+	}
+	snapshots, err := a.broker.GetSnapshots(ctx, symbols)
+	if err != nil {
+		apperr.Response(c, err)
 		return
 	}
-	c.JSON(http.StatusBadRequest, gin.H{
-		"message": "Couldn't cancel orders.",
-	})
+	res := make([]WatchlistResponse, 0, len(watchlists))
+	for i := range watchlists {
+		res = append(res, newWatchlistResponse(&watchlists[i], snapshots))
+	}
+	c.JSON(http.StatusOK, res)
 }
 
-func (a *AccountService) cancelOrder(c *gin.Context) {
+func (a *AccountService) getWatchlist(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
-	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-		orderID := c.Param("order_id")
-
-		req, err := http.NewRequest("DELETE", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/orders/"+orderID, nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
-		return
-	}
-	c.JSON(http.StatusBadRequest, gin.H{
-		"message": "Couldn't cancel order.",
-	})
-}
-
-func (a *AccountService) portfolioHistory(c *gin.Context) {
-	id, _ := c.Get("id")
-	user := a.svc.GetProfile(c, id.(int))
-	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/account/portfolio/history?period="+url.QueryEscape(c.Query("period"))+"&timeframe="+url.QueryEscape(c.Query("timeframe"))+"&date_end="+url.QueryEscape(c.Query("date_end"))+"&extended_hours="+url.QueryEscape(c.Query("extended_hours"))+"", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
-		return
-	}
-	c.JSON(http.StatusBadRequest, gin.H{
-		"message": "Couldn't get profile history.",
-	})
-}
-
-func (a *AccountService) tradingProfile(c *gin.Context) {
-	id, _ := c.Get("id")
-	user := a.svc.GetProfile(c, id.(int))
-
-	if user.AccountID == "" {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
-		return
-	}
-
-	client := &http.Client{}
-	accountID := user.AccountID
-
-	req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/account", nil)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	response, _ := client.Do(req)
-	responseData, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-
-	var responseObject interface{}
-	json.Unmarshal(responseData, &responseObject)
-	c.JSON(response.StatusCode, responseObject)
-}
-
-func (a *AccountService) stats(c *gin.Context) {
-	id, _ := c.Get("id")
-
-	peopleInvited, _ := a.db.Model(&model.UserReward{}).Where(`referred_by = ?`, id.(int)).Count()
-
-	referralReward := new(model.UserReward)
-	referreReward := new(model.UserReward)
-	_, err := a.db.Model((*model.UserReward)(nil)).QueryOne(referralReward, `
-		SELECT SUM(reward_value) reward_value from user_rewards where referred_by = ? AND reward_transfer_status = ?;`, id, true)
-
-	_, err1 := a.db.Model((*model.UserReward)(nil)).QueryOne(referreReward, `
-	SELECT SUM(reward_value) reward_value from user_rewards where user_id = ? AND reward_transfer_status = ?;`, id, true)
-	var totalReward float32 = 0
-	if err != nil {
-		totalReward = 0
-	}
-
-	if err1 != nil {
-		totalReward = totalReward + 0
-	}
-
-	totalReward = referralReward.RewardValue + referreReward.RewardValue
-
-	c.JSON(http.StatusOK, gin.H{
-		"reward_earned":  totalReward,
-		"people_invited": peopleInvited,
-	})
-}
-
-// MARK: Watchlists
-type AssetRequest struct {
-	Symbol string `json:"symbol"`
-}
-
-func (a *AccountService) createWatchlist(c *gin.Context) {
-	id, _ := c.Get("id")
-	user := a.svc.GetProfile(c, id.(int))
-	if user.AccountID == "" {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
-		return
-	}
-
-	var request WatchlistResponse
-	err := c.BindJSON(&request)
-	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid request."))
-		return
-	}
-
-	client := &http.Client{}
-	accountID := user.AccountID
-
-	reqBody, err := json.Marshal(request)
-	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid request."))
-		return
-	}
-
-	req, err := http.NewRequest("POST", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists", bytes.NewBuffer(reqBody))
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-	req.Header.Add("Content-Type", "application/json")
-
-	response, _ := client.Do(req)
-	responseData, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-	var watchlist WatchlistResponse
-	json.Unmarshal(responseData, &watchlist)
-
-	c.JSON(http.StatusOK, watchlist)
-}
-
-func (a *AccountService) updateWatchlist(c *gin.Context) {
-	id, _ := c.Get("id")
-	user := a.svc.GetProfile(c, id.(int))
-	if user.AccountID == "" {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
-		return
-	}
-
-	watchlistID := c.Param("watchlist_id")
-
-	var request = struct {
-		Name   string   `json:"name"`
-		Assets []string `json:"assets"`
-	}{}
-	err := c.BindJSON(&request)
-	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid request."))
-		return
-	}
-
-	client := &http.Client{}
-	accountID := user.AccountID
-
-	reqBody, err := json.Marshal(request)
-	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid request."))
-		return
-	}
-
-	req, err := http.NewRequest("PUT", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+watchlistID, bytes.NewBuffer(reqBody))
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-	req.Header.Add("Content-Type", "application/json")
-
-	response, _ := client.Do(req)
-	responseData, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-
-	var watchlist WatchlistResponse
-	json.Unmarshal(responseData, &watchlist)
-
-	c.JSON(http.StatusOK, watchlist)
-}
-
-func (a *AccountService) deleteWatchlist(c *gin.Context) {
-	id, _ := c.Get("id")
-	user := a.svc.GetProfile(c, id.(int))
-	if user.AccountID == "" {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
-		return
-	}
-
-	watchlistID := c.Param("watchlist_id")
-
-	client := &http.Client{}
-	accountID := user.AccountID
-
-	req, err := http.NewRequest("DELETE", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+watchlistID, nil)
-	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "trouble deleting watchlist"))
-		return
-	}
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	response, _ := client.Do(req)
-	if response.StatusCode != http.StatusNoContent {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Watchlist deleted successfully.",
-	})
-}
-
-type AssetsResponse struct {
-	Class        string `json:"class"`
-	EasyToBorrow bool   `json:"easy_to_borrow"`
-	Exchange     string `json:"exchange"`
-	ID           string `json:"id"`
-	Marginable   bool   `json:"marginable"`
-	Shortable    bool   `json:"shortable"`
-	Status       string `json:"status"`
-	Symbol       string `json:"symbol"`
-	Tradable     bool   `json:"tradable"`
-}
-
-type WatchList struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	AccountID string `json:"account_id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Assets    []AssetsResponse
-}
-type WatchlistResponse struct {
-	ID        string           `json:"id"`
-	AccountID string           `json:"account_id"`
-	Name      string           `json:"name"`
-	Assets    []WatchlistAsset `json:"assets"`
-}
-
-type WatchlistAsset struct {
-	Symbol string                 `json:"symbol"`
-	Ticker map[string]interface{} `json:"ticker"`
-}
-
-func (a *AccountService) getAllWatchlists(c *gin.Context) {
-	id, _ := c.Get("id")
-	user := a.svc.GetProfile(c, id.(int))
-	if user.AccountID == "" {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
-		return
-	}
-
-	client := &http.Client{}
-	accountID := user.AccountID
-
-	req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists", nil)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	response, _ := client.Do(req)
-	responseData, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-	var watchlists []WatchlistResponse
-	json.Unmarshal(responseData, &watchlists)
-
-	// Fetch market data of assets in each watchlist
-	for i := range watchlists {
-		symbolNames := make([]string, 0, len(watchlists[i].Assets))
-		for _, asset := range watchlists[i].Assets {
-			symbolNames = append(symbolNames, asset.Symbol)
-		}
-
-		if len(symbolNames) > 0 {
-			req2, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/snapshots?symbols="+url.QueryEscape(strings.Join(symbolNames, ",")), nil)
-			if err != nil {
-				fmt.Print(err.Error())
-			}
-
-			req2.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-			response2, err := client.Do(req2)
-
-			if err != nil {
-				fmt.Print(err.Error())
-			}
-
-			responseData2, err := ioutil.ReadAll(response2.Body)
-			if err != nil {
-				log.Fatal(err)
-			}
-			fmt.Println(string(responseData2))
-			var marketData map[string]interface{}
-			json.Unmarshal(responseData2, &marketData)
-
-			for j := range watchlists[i].Assets {
-				ticker, ok := marketData[watchlists[i].Assets[j].Symbol].(map[string]interface{})
-				if ok {
-					watchlists[i].Assets[j].Ticker = ticker
-				}
-			}
-		}
-	}
-	fmt.Println(watchlists)
-	c.JSON(response.StatusCode, watchlists)
-}
-
-func (a *AccountService) getWatchlist(c *gin.Context) {
-	id, _ := c.Get("id")
-	user := a.svc.GetProfile(c, id.(int))
-	assets := []interface{}{}
-
-	if user.AccountID == "" {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
-		return
-	}
+	if user == nil || user.AccountID == "" {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
+		return
+	}
 
 	watchlistID := c.Param("watchlist_id")
 	if watchlistID == "" {
@@ -1428,67 +797,23 @@ func (a *AccountService) getWatchlist(c *gin.Context) {
 		return
 	}
 
-	client := &http.Client{}
-	accountID := user.AccountID
-
-	req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+watchlistID, nil)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	response, _ := client.Do(req)
-	responseData, err := ioutil.ReadAll(response.Body)
+	ctx := c.Request.Context()
+	watchlist, err := a.broker.GetWatchlist(ctx, user.AccountID, watchlistID)
 	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
+		apperr.Response(c, err)
 		return
 	}
-	var watchlistResponseObject map[string]interface{}
-	json.Unmarshal(responseData, &watchlistResponseObject)
-
-	// Get symbol names
-	var symbolNames []string
-	for _, ass := range watchlistResponseObject["assets"].([]interface{}) {
-		ass, _ := ass.(map[string]interface{})
-		symbolNames = append(symbolNames, ass["symbol"].(string))
-		assets = append(assets, ass)
-	}
-	// fmt.Println(assets)
-	// fmt.Println(symbolNames)
 
 	// fetch market data of assets
-	if len(symbolNames) > 0 {
-		req2, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/snapshots?symbols="+url.QueryEscape(strings.Join(symbolNames[:], ",")), nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req2.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response2, err := client.Do(req2)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData2, err := ioutil.ReadAll(response2.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
-		// fmt.Printf("%v", string(responseData))
-
-		var responseObject map[string]interface{}
-		json.Unmarshal(responseData2, &responseObject)
-
-		for index := range assets {
-			assets[index].(map[string]interface{})["ticker"] = responseObject[assets[index].(map[string]interface{})["symbol"].(string)]
-		}
-		watchlistResponseObject["assets"] = assets
+	snapshots, err := a.broker.GetSnapshots(ctx, watchlist.Symbols())
+	if err != nil {
+		apperr.Response(c, err)
+		return
 	}
-	c.JSON(response.StatusCode, watchlistResponseObject)
+	c.JSON(http.StatusOK, newWatchlistResponse(watchlist, snapshots))
 }
 
-type Asset struct {
-	Symbol string `json:"symbol"`
-}
-
-// Credentials stores the username and password provided in the request
+// UpdateAsset holds the symbol to add to a watchlist
 type UpdateAsset struct {
 	Symbol      string `json:"symbol" binding:"required"`
 	WatchlistID string `json:"watchlist_id" binding:"required"`
@@ -1497,7 +822,7 @@ type UpdateAsset struct {
 func (a *AccountService) addAssetToWatchlist(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
-	if user.AccountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
@@ -1507,330 +832,137 @@ func (a *AccountService) addAssetToWatchlist(c *gin.Context) {
 		return
 	}
 
-	watchlistID := addPayload.WatchlistID
-
-	watchlistJson, _ := json.Marshal(map[string]interface{}{
-		"symbol": addPayload.Symbol,
-	})
-	watchlistBody := bytes.NewBuffer(watchlistJson)
-
-	client := &http.Client{}
-	accountID := user.AccountID
-
-	req, err := http.NewRequest("POST", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+watchlistID, watchlistBody)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-	req.Header.Add("Content-Type", "application/json")
-
-	response, _ := client.Do(req)
-	responseData, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-
-	var responseObject WatchList
-	json.Unmarshal(responseData, &responseObject)
-
-	// Now that we have the watchlist ID, we can retrieve the updated list of assets
-	req, err = http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+watchlistID, nil)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	response, _ = client.Do(req)
-	responseData, err = ioutil.ReadAll(response.Body)
+	watchlist, err := a.broker.AddAssetToWatchlist(c.Request.Context(), user.AccountID, addPayload.WatchlistID, addPayload.Symbol)
 	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
+		apperr.Response(c, err)
 		return
 	}
-
-	// Parse the response to get the complete list of assets
-	var watchlist WatchList
-	json.Unmarshal(responseData, &watchlist)
-
-	c.JSON(http.StatusOK, watchlist)
+	c.JSON(http.StatusOK, newWatchlistResponse(watchlist, nil))
 }
 
 func (a *AccountService) addAssetInWatchList(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
-
-	accountID := user.AccountID
-	if accountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
 
-	var assets Asset
-	c.BindJSON(&assets)
+	var asset AssetRequest
+	c.BindJSON(&asset)
 
-	if assets.Symbol == "" {
+	if asset.Symbol == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Symbol is required."))
 		return
 	}
 
-	var symbolList [1]string
-	symbolList[0] = assets.Symbol
-
-	if user.WatchlistID == "" {
-		client := &http.Client{}
-		watchlistJson, _ := json.Marshal(map[string]interface{}{
-			"symbols": symbolList,
-			"name":    "Watchlist assets",
-		})
-		watchlistBody := bytes.NewBuffer(watchlistJson)
-
-		fmt.Println(watchlistBody)
-
-		req, err := http.NewRequest("POST", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists", watchlistBody)
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-		response, _ := client.Do(req)
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-			return
-		}
-
-		var responseObject WatchList
-		json.Unmarshal(responseData, &responseObject)
-
-		reqUser := request.Update{
-			ID:          id.(int),
-			AccountID:   &accountID,
-			WatchlistID: &responseObject.ID,
-		}
-
-		_, error := a.svc.UpdateProfile(c, &reqUser)
-		if error != nil {
-			apperr.Response(c, apperr.New(http.StatusBadRequest, "Something went wrong. Try again"))
-			return
-		}
-
-		c.JSON(response.StatusCode, responseObject)
-	} else {
-		client := &http.Client{}
-		watchlistJson, _ := json.Marshal(map[string]interface{}{
-			"symbol": assets.Symbol,
-		})
-		watchlistBody := bytes.NewBuffer(watchlistJson)
-
-		req, err := http.NewRequest("POST", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+user.WatchlistID, watchlistBody)
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
+	ctx := c.Request.Context()
+	if user.WatchlistID != "" {
+		watchlist, err := a.broker.AddAssetToWatchlist(ctx, user.AccountID, user.WatchlistID, asset.Symbol)
 		if err != nil {
-			fmt.Printf("%s Problem with watchlist post request %s", watchlistBody, user.WatchlistID)
-			return
-		}
-
-		response, _ := client.Do(req)
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-			return
-		}
-
-		if response.StatusCode != 200 {
-			errorBody := ErrorBody{}
-			json.Unmarshal(responseData, &errorBody)
-			apperr.Response(c, apperr.New(response.StatusCode, errorBody.Message))
+			apperr.Response(c, err)
 			return
 		}
+		c.JSON(http.StatusOK, newWatchlistResponse(watchlist, nil))
+		return
+	}
 
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-		c.JSON(response.StatusCode, responseObject)
+	watchlist, err := a.broker.CreateWatchlist(ctx, user.AccountID, &broker.WatchlistRequest{
+		Name:    "Watchlist assets",
+		Symbols: []string{asset.Symbol},
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
 	}
 
+	reqUser := request.Update{
+		ID:          id.(int),
+		AccountID:   &user.AccountID,
+		WatchlistID: &watchlist.ID,
+	}
+	if _, err := a.svc.UpdateProfile(c, &reqUser); err != nil {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Something went wrong. Try again"))
+		return
+	}
+	c.JSON(http.StatusOK, newWatchlistResponse(watchlist, nil))
 }
 
 func (a *AccountService) removeAssetFromWatchlist(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
-	if user.AccountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
 
-	watchlistID := c.Param("watchlist_id")
-	symbol := c.Param("symbol")
-
-	client := &http.Client{}
-	accountID := user.AccountID
-
-	req, err := http.NewRequest("DELETE", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+watchlistID+"/"+symbol, nil)
+	watchlist, err := a.broker.RemoveAssetFromWatchlist(c.Request.Context(), user.AccountID, c.Param("watchlist_id"), c.Param("symbol"))
 	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, "trouble deleting asset from watchlist"))
-		return
-	}
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	response, err := client.Do(req)
-	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-
-	// Now that we have the watchlist ID, we can retrieve the updated list of assets
-	req, err = http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+watchlistID, nil)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	response, _ = client.Do(req)
-	responseData, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
+		apperr.Response(c, err)
 		return
 	}
-
-	// Parse the response to get the complete list of assets
-	var watchlist WatchList
-	json.Unmarshal(responseData, &watchlist)
-
-	c.JSON(http.StatusOK, watchlist)
+	c.JSON(http.StatusOK, newWatchlistResponse(watchlist, nil))
 }
 
-// func (a *AccountService) removeAssetFromWatchList(c *gin.Context) {
-// 	id, _ := c.Get("id")
-// 	user := a.svc.GetProfile(c, id.(int))
-
-// 	if user.AccountID == "" {
-// 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
-// 		return
-// 	}
-
-// 	if user.WatchlistID == "" {
-// 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Asset not found."))
-// 	}
-
-// 	symbol := c.Param("symbol")
-
-// 	client := &http.Client{}
-// 	accountID := user.AccountID
-
-// 	req, err := http.NewRequest("DELETE", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/watchlists/"+user.WatchlistID+"/"+symbol, nil)
-// 	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-// 	response, _ := client.Do(req)
-// 	responseData, err := ioutil.ReadAll(response.Body)
-// 	if err != nil {
-// 		apperr.Response(c, apperr.New(response.StatusCode, "Something went wrong. Try again later."))
-// 		return
-// 	}
-
-// 	if response.StatusCode != 200 {
-// 		errorBody := ErrorBody{}
-// 		json.Unmarshal(responseData, &errorBody)
-// 		apperr.Response(c, apperr.New(response.StatusCode, errorBody.Message))
-// 		return
-// 	}
-
-// 	var responseObject interface{}
-// 	json.Unmarshal(responseData, &responseObject)
-// 	c.JSON(response.StatusCode, responseObject)
-// }
+// PositionResponse is an open position decorated with asset details and market data
+type PositionResponse struct {
+	broker.Position
+	Name          string           `json:"name"`
+	Ticker        *broker.Snapshot `json:"ticker"`
+	IsWatchlisted bool             `json:"is_watchlisted"`
+}
 
 func (a *AccountService) getPositions(c *gin.Context) {
 	id, _ := c.Get("id")
-	assets := []interface{}{}
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/positions", nil)
+		ctx := c.Request.Context()
+		positions, err := a.broker.ListPositions(ctx, user.AccountID)
 		if err != nil {
-			fmt.Print(err.Error())
+			apperr.Response(c, err)
+			return
 		}
 
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
+		// Get symbol names
+		symbols := make([]string, 0, len(positions))
+		for _, p := range positions {
+			symbols = append(symbols, p.Symbol)
 		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		snapshots, err := a.broker.GetSnapshots(ctx, symbols)
 		if err != nil {
-			log.Fatal(err)
-		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		// Get symbol names
-		var symbolNames []string
-		for _, ass := range responseObject.([]interface{}) {
-			ass, _ := ass.(map[string]interface{})
-			symbolNames = append(symbolNames, ass["symbol"].(string))
-
-			for _, ass2 := range AssetsList {
-				if ass2.Symbol == ass["symbol"] {
-					ass["name"] = ass2.Name
-					ass["ticker"] = gin.H{}
-					ass["is_watchlisted"] = false
-				}
-			}
-			assets = append(assets, ass)
-
+			apperr.Response(c, err)
+			return
 		}
 
-		if len(symbolNames) > 0 {
-			client := &http.Client{}
-
-			req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/snapshots?symbols="+url.QueryEscape(strings.Join(symbolNames[:], ",")), nil)
-			if err != nil {
-				fmt.Print(err.Error())
-			}
-
-			req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-			response, err := client.Do(req)
-
+		// Watchlisted flag
+		watchlisted := map[string]bool{}
+		if user.WatchlistID != "" && len(positions) > 0 {
+			watchlist, err := a.broker.GetWatchlist(ctx, user.AccountID, user.WatchlistID)
 			if err != nil {
-				fmt.Print(err.Error())
+				apperr.Response(c, err)
+				return
 			}
-
-			responseData, err := ioutil.ReadAll(response.Body)
-			if err != nil {
-				log.Fatal(err)
+			for _, s := range watchlist.Symbols() {
+				watchlisted[s] = true
 			}
-			// fmt.Printf("%v", string(responseData))
-
-			var responseObject map[string]interface{}
-			json.Unmarshal(responseData, &responseObject)
+		}
 
-			for index := range assets {
-				assets[index].(map[string]interface{})["ticker"] = responseObject[assets[index].(map[string]interface{})["symbol"].(string)]
+		res := make([]PositionResponse, 0, len(positions))
+		for _, p := range positions {
+			pos := PositionResponse{
+				Position:      p,
+				Ticker:        snapshots[p.Symbol],
+				IsWatchlisted: watchlisted[p.Symbol],
 			}
-
-			// Watchlisted flag
-			if user.WatchlistID != "" {
-				req2, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+user.AccountID+"/watchlists/"+user.WatchlistID, nil)
-				req2.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-				response2, _ := client.Do(req2)
-				responseData2, err := ioutil.ReadAll(response2.Body)
-				if err != nil {
-					apperr.Response(c, apperr.New(response2.StatusCode, "Something went wrong. Try again later."))
-					return
-				}
-				json.Unmarshal(responseData2, &responseObject)
-				for index := range assets {
-					isWatchlisted := false
-					for _, ass := range responseObject["assets"].([]interface{}) {
-						ass, _ := ass.(map[string]interface{})
-						if ass["symbol"] == assets[index].(map[string]interface{})["symbol"] {
-							isWatchlisted = true
-							break
-						}
-					}
-
-					assets[index].(map[string]interface{})["is_watchlisted"] = isWatchlisted
+			for _, asset := range AssetsList {
+				if asset.Symbol == p.Symbol {
+					pos.Name = asset.Name
+					break
 				}
 			}
+			res = append(res, pos)
 		}
-
-		// fmt.Println(assets)
-		// fmt.Println(symbolNames)
-
-		c.JSON(response.StatusCode, assets)
+		c.JSON(http.StatusOK, res)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -1842,32 +974,12 @@ func (a *AccountService) getOneOpenPosition(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		symbol := c.Param("symbol")
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/positions/"+symbol, nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		position, err := a.broker.GetPosition(c.Request.Context(), user.AccountID, c.Param("symbol"))
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, position)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -1879,30 +991,12 @@ func (a *AccountService) closePositions(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		req, err := http.NewRequest("DELETE", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/positions", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		statuses, err := a.broker.CloseAllPositions(c.Request.Context(), user.AccountID)
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusMultiStatus, statuses)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -1914,31 +1008,12 @@ func (a *AccountService) closeOnePosition(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-		symbol := c.Param("symbol")
-
-		req, err := http.NewRequest("DELETE", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/positions/"+symbol, nil)
+		order, err := a.broker.ClosePosition(c.Request.Context(), user.AccountID, c.Param("symbol"))
 		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, order)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -1950,30 +1025,12 @@ func (a *AccountService) getCalendar(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		// accountID := user.AccountID
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v2/calendar", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		days, err := a.broker.GetCalendar(c.Request.Context(), c.Query("start"), c.Query("end"))
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, days)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -1985,30 +1042,12 @@ func (a *AccountService) getAccount(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		accountID := user.AccountID
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+accountID+"/account", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		account, err := a.broker.GetTradingAccount(c.Request.Context(), user.AccountID)
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, account)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -2020,31 +1059,17 @@ func (a *AccountService) getMarketTradesBySymbol(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		// accountID := user.AccountID
-		symbol := c.Param("symbol")
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/"+symbol+"/trades?start="+url.QueryEscape(c.Query("start"))+"&end="+url.QueryEscape(c.Query("end"))+"&limit="+url.QueryEscape(c.Query("limit"))+"&page_token="+url.QueryEscape(c.Query("page_token"))+"", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
+		p := new(broker.MarketDataRequest)
+		if err := c.ShouldBindQuery(p); err != nil {
+			apperr.Response(c, err)
+			return
 		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		trades, err := a.broker.GetTrades(c.Request.Context(), c.Param("symbol"), p)
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, trades)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -2056,31 +1081,12 @@ func (a *AccountService) getMarketLatestTradeBySymbol(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		// accountID := user.AccountID
-		symbol := c.Param("symbol")
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/"+symbol+"/trades/latest", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		trade, err := a.broker.GetLatestTrade(c.Request.Context(), c.Param("symbol"))
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, trade)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -2092,31 +1098,17 @@ func (a *AccountService) getMarketQuotesBySymbol(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		// accountID := user.AccountID
-		symbol := c.Param("symbol")
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/"+symbol+"/quotes?start="+url.QueryEscape(c.Query("start"))+"&end="+url.QueryEscape(c.Query("end"))+"&limit="+url.QueryEscape(c.Query("limit"))+"&page_token="+url.QueryEscape(c.Query("page_token"))+"", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
+		p := new(broker.MarketDataRequest)
+		if err := c.ShouldBindQuery(p); err != nil {
+			apperr.Response(c, err)
+			return
 		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		quotes, err := a.broker.GetQuotes(c.Request.Context(), c.Param("symbol"), p)
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, quotes)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -2128,31 +1120,12 @@ func (a *AccountService) getMarketLatestQuoteBySymbol(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		// accountID := user.AccountID
-		symbol := c.Param("symbol")
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/"+symbol+"/quotes/latest", nil)
+		quote, err := a.broker.GetLatestQuote(c.Request.Context(), c.Param("symbol"))
 		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, quote)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -2164,31 +1137,17 @@ func (a *AccountService) getMarketBarsBySymbol(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil {
-		client := &http.Client{}
-		// accountID := user.AccountID
-		symbol := c.Param("symbol")
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/"+symbol+"/bars?start="+url.QueryEscape(c.Query("start"))+"&end="+url.QueryEscape(c.Query("end"))+"&limit="+url.QueryEscape(c.Query("limit"))+"&page_token="+url.QueryEscape(c.Query("page_token"))+"&timeframe="+url.QueryEscape(c.Query("timeframe"))+"", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
+		p := new(broker.MarketDataRequest)
+		if err := c.ShouldBindQuery(p); err != nil {
+			apperr.Response(c, err)
+			return
 		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		bars, err := a.broker.GetBars(c.Request.Context(), c.Param("symbol"), p)
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, bars)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -2196,192 +1155,108 @@ func (a *AccountService) getMarketBarsBySymbol(c *gin.Context) {
 	})
 }
 
-type LastQuote struct {
-	P float64 `json:"P"`
-	S int64   `json:"S"`
-	p float64 `json:"p"`
-	s int64   `json:"s"`
-	t int64   `json:"t"`
-}
-
-type LastTrade struct {
-	C []int64 `json:"c"`
-	I string  `json:"i"`
-	P float64 `json:"p"`
-	S int64   `json:"s"`
-	T int64   `json:"t"`
-	X int64   `json:"x"`
-}
-
-type Bar struct {
-	AV int64   `json:"av",omitempty`
-	C  float64 `json:"c"`
-	H  float64 `json:"h"`
-	L  float64 `json:"l"`
-	O  float64 `json:"o"`
-	V  int64   `json:"v"`
-	VW float64 `json:"vw"`
-}
-
-type Ticker struct {
-	Day              Bar       `json:"day"`
-	LastQuote        LastQuote `json:"lastQuote"`
-	LastTrade        LastTrade `json:"lastTrade"`
-	Min              Bar       `json:"min"`
-	PrevDay          Bar       `json:"prevDay"`
-	TodaysChange     float64   `json:"todaysChange"`
-	TodaysChangePerc float64   `json:"todaysChangePerc"`
-	Ticker           string    `json:"ticker"`
-	Updated          int64     `json:"updated"`
-	
+// IndicatorsResponse holds indicator series aligned to the timestamps of the bars they were
+// computed over, keyed by indicator spec and then by line. Values are null until enough bars
+// are available.
+type IndicatorsResponse struct {
+	Symbol     string                           `json:"symbol"`
+	Timeframe  string                           `json:"timeframe"`
+	Timestamps []time.Time                      `json:"timestamps"`
+	Indicators map[string]map[string][]*float64 `json:"indicators"`
 }
 
-type PolygonResponse struct {
-	Status  string   `json:"status"`
-	Tickers []Ticker `json:"tickers"`
+// indicatorLookback is how far back indicators are computed from by default, per timeframe
+var indicatorLookback = map[string]time.Duration{
+	"1Min":  24 * time.Hour,
+	"5Min":  5 * 24 * time.Hour,
+	"15Min": 5 * 24 * time.Hour,
+	"1Hour": 30 * 24 * time.Hour,
+	"1Day":  365 * 24 * time.Hour,
 }
 
-func getTopGainers() ([]Ticker, error) {
-	return getTopMoversByDirection("gainers")
-}
-
-func getTopLosers() ([]Ticker, error) {
-	return getTopMoversByDirection("losers")
-}
-
-func getTopMoversByDirection(direction string) ([]Ticker, error) {
-	apiURL := fmt.Sprintf("https://api.polygon.io/v2/snapshot/locale/us/markets/stocks/%s?apiKey=%s", direction, os.Getenv("POLYGON_API_KEY"))
-	response, err := http.Get(apiURL)
-	if err != nil {
-		return nil, err
+func (a *AccountService) getMarketIndicatorsBySymbol(c *gin.Context) {
+	id, _ := c.Get("id")
+	user := a.svc.GetProfile(c, id.(int))
+	if user == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Couldn't get market data.",
+		})
+		return
 	}
-	defer response.Body.Close()
-
-	responseData, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+	p := new(broker.MarketDataRequest)
+	if err := c.ShouldBindQuery(p); err != nil {
+		apperr.Response(c, err)
+		return
 	}
-
-	var responseObject PolygonResponse
-	json.Unmarshal(responseData, &responseObject)
-
-	return responseObject.Tickers, nil
-}
-
-type NewsResult struct {
-	Count      int            `json:"count"`
-	NextURL    string         `json:"next_url"`
-	RequestID  string         `json:"request_id"`
-	Results    []NewsArticle  `json:"results"`
-	Status     string         `json:"status"`
-}
-
-type NewsArticle struct {
-	AmpURL        string   `json:"amp_url"`
-	ArticleURL    string   `json:"article_url"`
-	Author        string   `json:"author"`
-	Description   string   `json:"description"`
-	ID            string   `json:"id"`
-	ImageURL      string   `json:"image_url"`
-	Keywords      []string `json:"keywords"`
-	PublishedUTC  string   `json:"published_utc"`
-	Publisher     Publisher `json:"publisher"`
-	Tickers       []string `json:"tickers"`
-	Title         string   `json:"title"`
-}
-
-type Publisher struct {
-	FaviconURL  string `json:"favicon_url"`
-	HomepageURL string `json:"homepage_url"`
-	LogoURL     string `json:"logo_url"`
-	Name        string `json:"name"`
-}
-
-
-func (a *AccountService) getMarketNews(c *gin.Context) {
-	tickers := c.Query("tickers")
-	tickerList := strings.Split(tickers, ",")
-
-	var allResults []NewsArticle
-
-	for _, ticker := range tickerList {
-		url := fmt.Sprintf("https://api.polygon.io/v2/reference/news?ticker=%s&apiKey=%s", ticker, os.Getenv("POLYGON_API_KEY"))
-		resp, err := http.Get(url)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching data from Polygon API"})
-			return
-		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
+	if c.Query("indicators") == "" {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "indicators is required."))
+		return
+	}
+	var specs []*indicator.Spec
+	for _, s := range strings.Split(c.Query("indicators"), ",") {
+		spec, err := indicator.Parse(s)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error reading Polygon API response"})
+			apperr.Response(c, apperr.New(http.StatusBadRequest, err.Error()))
 			return
 		}
-
-		var newsResult NewsResult
-		if err := json.Unmarshal(body, &newsResult); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unmarshalling Polygon API response"})
-			return
+		specs = append(specs, spec)
+	}
+	if p.Timeframe == "" {
+		p.Timeframe = "1Day"
+	}
+	if p.Start == "" {
+		lookback, ok := indicatorLookback[p.Timeframe]
+		if !ok {
+			lookback = indicatorLookback["1Day"]
 		}
-
-		allResults = append(allResults, newsResult.Results...)
+		p.Start = time.Now().Add(-lookback).UTC().Format(time.RFC3339)
 	}
+	p.Limit, p.PageToken = 10000, ""
 
-	c.JSON(http.StatusOK, allResults)
-}
-
-func (a *AccountService) getMarketTopMovers(c *gin.Context) {
-	gainers, err := getTopGainers()
+	bars, err := broker.ListBars(c.Request.Context(), a.broker, c.Param("symbol"), *p)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Couldn't get top gainers.",
-		})
+		apperr.Response(c, err)
 		return
 	}
-
-	losers, err := getTopLosers()
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Couldn't get top losers.",
-		})
-		return
+	res := IndicatorsResponse{
+		Symbol:     strings.ToUpper(c.Param("symbol")),
+		Timeframe:  p.Timeframe,
+		Timestamps: make([]time.Time, len(bars)),
+		Indicators: map[string]map[string][]*float64{},
+	}
+	for i, b := range bars {
+		res.Timestamps[i] = b.Timestamp
+	}
+	for _, spec := range specs {
+		lines := map[string][]*float64{}
+		for line, values := range spec.Compute(bars) {
+			series := make([]*float64, len(values))
+			for i := range values {
+				if !math.IsNaN(values[i]) {
+					series[i] = &values[i]
+				}
+			}
+			lines[line] = series
+		}
+		res.Indicators[spec.String()] = lines
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"gainers": gainers,
-		"losers":  losers,
-	})
+	c.JSON(http.StatusOK, res)
 }
 
 func (a *AccountService) getMarketTickers(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		// accountID := user.AccountID
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/snapshots?symbols="+url.QueryEscape(c.Query("symbols")), nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
+		var symbols []string
+		if s := c.Query("symbols"); s != "" {
+			symbols = strings.Split(s, ",")
 		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
+		snapshots, err := a.broker.GetSnapshots(c.Request.Context(), symbols)
 		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, snapshots)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -2393,31 +1268,12 @@ func (a *AccountService) getMarketTickerBySymbol(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.svc.GetProfile(c, id.(int))
 	if user != nil && user.AccountID != "" {
-		client := &http.Client{}
-		// accountID := user.AccountID
-		symbol := c.Param("symbol")
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/"+symbol+"/snapshot", nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
+		snapshot, err := a.broker.GetSnapshot(c.Request.Context(), c.Param("symbol"))
 		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
+			apperr.Response(c, err)
+			return
 		}
-
-		var responseObject interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		c.JSON(response.StatusCode, responseObject)
+		c.JSON(http.StatusOK, snapshot)
 		return
 	}
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -3,10 +3,14 @@ package service_test
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
 	"github.com/zcoriarty/Backend/mock"
 	"github.com/zcoriarty/Backend/mock/mockdb"
 	"github.com/zcoriarty/Backend/model"
@@ -80,7 +84,7 @@ func TestCreate(t *testing.T) {
 			r := gin.New()
 			rg := r.Group("/v1")
 			accountService := account.NewAccountService(nil, tt.accountRepo, tt.rbac, secret.New())
-			service.AccountRouter(accountService, rg)
+			service.AccountRouter(accountService, nil, nil, rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/v1/users"
@@ -160,7 +164,7 @@ func TestChangePassword(t *testing.T) {
 			r := gin.New()
 			rg := r.Group("/v1")
 			accountService := account.NewAccountService(tt.userRepo, tt.accountRepo, tt.rbac, secret.New())
-			service.AccountRouter(accountService, rg)
+			service.AccountRouter(accountService, nil, nil, rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/v1/users/" + tt.id + "/password"
@@ -177,3 +181,73 @@ func TestChangePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestIndicators(t *testing.T) {
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	start := time.Date(2021, 3, 1, 5, 0, 0, 0, time.UTC)
+	var bars []broker.Bar
+	for i := 0; i < 5; i++ {
+		c := float64(10 + i)
+		bars = append(bars, broker.Bar{Timestamp: start.AddDate(0, 0, i), Open: c, High: c + 1, Low: c - 1, Close: c, Volume: 100})
+	}
+	brk.SetBars("AAPL", bars)
+
+	cases := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantResp   string
+	}{
+		{
+			name:       "Missing indicators",
+			query:      "start=2021-03-01",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown indicator",
+			query:      "start=2021-03-01&indicators=sma,kama",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			query:      "start=2021-03-01&end=2021-03-06&indicators=sma:3,macd:2:3:2",
+			wantStatus: http.StatusOK,
+			wantResp: `{"symbol":"AAPL","timeframe":"1Day","timestamps":["2021-03-01T05:00:00Z","2021-03-02T05:00:00Z","2021-03-03T05:00:00Z","2021-03-04T05:00:00Z","2021-03-05T05:00:00Z"],` +
+				`"indicators":{"macd:2:3:2":{"histogram":[null,null,null,0,0],"macd":[null,null,0.5,0.5,0.5],"signal":[null,null,null,0.5,0.5]},` +
+				`"sma:3":{"value":[null,null,11,12,13]}}}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			rg := r.Group("/v1")
+			rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+			userRepo := &mockdb.User{
+				ViewFn: func(id int) (*model.User, error) {
+					return &model.User{ID: id}, nil
+				},
+			}
+			rbac := &mock.RBAC{
+				EnforceUserFn: func(c *gin.Context, id int) bool {
+					return true
+				},
+			}
+			accountService := account.NewAccountService(userRepo, nil, rbac, secret.New())
+			service.AccountRouter(accountService, nil, brk.Broker(), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/market/stocks/AAPL/indicators?" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantResp != "" {
+				body, _ := ioutil.ReadAll(res.Body)
+				assert.JSONEq(t, tt.wantResp, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/alerts"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Alert represents the alert http service
+type Alert struct {
+	svc *alerts.Service
+}
+
+// AlertRouter declares the routes for alerts router group
+func AlertRouter(svc *alerts.Service, r *gin.RouterGroup) {
+	a := Alert{
+		svc: svc,
+	}
+	ar := r.Group("/alerts")
+	ar.GET("", a.list)
+	ar.POST("", a.create)
+	ar.GET("/history", a.history)
+	ar.GET("/:id", a.view)
+	ar.PATCH("/:id", a.update)
+	ar.DELETE("/:id", a.delete)
+}
+
+func (a *Alert) list(c *gin.Context) {
+	result, err := a.svc.List(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.Alert{}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Alert) create(c *gin.Context) {
+	r, err := request.CreateAlert(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Create(c, &model.Alert{
+		Symbol:    r.Symbol,
+		Condition: r.Condition,
+		Threshold: r.Threshold,
+		Channel:   r.Channel,
+		Recurring: r.Recurring,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+func (a *Alert) view(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.View(c, id)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Alert) update(c *gin.Context) {
+	r, err := request.UpdateAlert(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Update(c, &alerts.Update{
+		ID:        r.ID,
+		Symbol:    r.Symbol,
+		Condition: r.Condition,
+		Threshold: r.Threshold,
+		Channel:   r.Channel,
+		Recurring: r.Recurring,
+		Active:    r.Active,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Alert) delete(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	if err := a.svc.Delete(c, id); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+type alertHistoryResponse struct {
+	Events []model.AlertEvent `json:"events"`
+	Page   int                `json:"page"`
+}
+
+func (a *Alert) history(c *gin.Context) {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.History(c, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.AlertEvent{}
+	}
+	c.JSON(http.StatusOK, alertHistoryResponse{
+		Events: result,
+		Page:   p.Page,
+	})
+}
@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/alerts"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlerts(t *testing.T) {
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAsset(broker.Asset{Symbol: "AAPL", Tradable: true})
+
+	var created []model.Alert
+	alertRepo := &mockdb.Alert{
+		CountFn: func(int) (int, error) { return len(created), nil },
+		CreateFn: func(a *model.Alert) (*model.Alert, error) {
+			a.ID = len(created) + 1
+			created = append(created, *a)
+			return a, nil
+		},
+		ViewFn: func(id int) (*model.Alert, error) {
+			return &model.Alert{ID: id, UserID: 2, Symbol: "AAPL"}, nil
+		},
+		ListEventsFn: func(userID int, p *model.Pagination) ([]model.AlertEvent, error) {
+			return []model.AlertEvent{{ID: 1, UserID: userID, Symbol: "AAPL", Status: model.AlertSent}}, nil
+		},
+	}
+	userRepo := &mockdb.User{ViewFn: func(id int) (*model.User, error) {
+		return &model.User{ID: id, Email: "a@b.c"}, nil
+	}}
+	deviceRepo := &mockdb.Device{ListFn: func(int) ([]model.Device, error) { return nil, nil }}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	service.AlertRouter(alerts.NewAlertService(userRepo, alertRepo, deviceRepo, brk.Broker()), rg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	post := func(body string) (int, map[string]interface{}) {
+		res, err := http.Post(ts.URL+"/v1/alerts", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		out := map[string]interface{}{}
+		json.NewDecoder(res.Body).Decode(&out)
+		return res.StatusCode, out
+	}
+
+	status, out := post(`{"symbol":"aapl","condition":"above","threshold":150,"channel":"email"}`)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "AAPL", out["symbol"])
+	assert.Equal(t, true, out["active"])
+	assert.Equal(t, 1, created[0].UserID)
+
+	cases := map[string]string{
+		`{"symbol":"AAPL","condition":"sideways","threshold":1,"channel":"email"}`:    "Unknown condition.",
+		`{"symbol":"AAPL","condition":"change_up","threshold":150,"channel":"email"}`: "Threshold must be a percentage between 0 and 100.",
+		`{"symbol":"AAPL","condition":"below","threshold":150,"channel":"sms"}`:       "A mobile number is required for SMS alerts.",
+		`{"symbol":"NOPE","condition":"below","threshold":150,"channel":"email"}`:     "Unknown symbol.",
+		`{"symbol":"AAPL","condition":"below","threshold":150,"channel":"push"}`:      "A device is required for push alerts.",
+	}
+	for body, msg := range cases {
+		status, out := post(body)
+		assert.Equal(t, http.StatusBadRequest, status, body)
+		assert.Equal(t, msg, out["message"], body)
+	}
+
+	// alerts of other users are not found, and the history is not mistaken for an alert
+	res, err := http.Get(ts.URL + "/v1/alerts/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res, err = http.Get(ts.URL + "/v1/alerts/history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	history := struct {
+		Events []model.AlertEvent `json:"events"`
+	}{}
+	json.NewDecoder(res.Body).Decode(&history)
+	assert.Len(t, history.Events, 1)
+	assert.Equal(t, 1, history.Events[0].UserID)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/algorithm"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Algorithm represents the algorithm http service
+type Algorithm struct {
+	svc *algorithm.Service
+}
+
+// AlgorithmRouter declares the routes for algorithms router group
+func AlgorithmRouter(svc *algorithm.Service, r *gin.RouterGroup) {
+	a := Algorithm{
+		svc: svc,
+	}
+	ar := r.Group("/algorithms")
+	ar.GET("", a.list)
+	ar.POST("", a.create)
+	ar.GET("/:id", a.view)
+	ar.PATCH("/:id", a.update)
+	ar.DELETE("/:id", a.delete)
+	ar.POST("/:id/halt", a.halt)
+	ar.POST("/:id/resume", a.resume)
+	ar.GET("/:id/budget", a.budget)
+	ar.PUT("/:id/budget", a.allocate)
+	ar.GET("/:id/orders", a.orders)
+	ar.POST("/:id/orders", a.createOrder)
+	ar.DELETE("/:id/orders/:order_id", a.cancelOrder)
+
+	br := r.Group("/budgets")
+	br.GET("", a.budgets)
+}
+
+type algorithmListResponse struct {
+	Algorithms []model.Algorithm `json:"algorithms"`
+	Page       int               `json:"page"`
+}
+
+func (a *Algorithm) list(c *gin.Context) {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.List(c, c.Query("q"), &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.Algorithm{}
+	}
+	c.JSON(http.StatusOK, algorithmListResponse{
+		Algorithms: result,
+		Page:       p.Page,
+	})
+}
+
+func (a *Algorithm) create(c *gin.Context) {
+	r, err := request.CreateAlgorithm(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Create(c, &model.Algorithm{
+		Name:        r.Name,
+		Description: r.Description,
+		Strategy:    r.Strategy,
+		Parameters:  r.Parameters,
+		Symbols:     r.Symbols,
+		Interval:    r.Interval,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+func (a *Algorithm) view(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.View(c, id)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) update(c *gin.Context) {
+	r, err := request.UpdateAlgorithm(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Update(c, &algorithm.Update{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Strategy:    r.Strategy,
+		Parameters:  r.Parameters,
+		Symbols:     r.Symbols,
+		Interval:    r.Interval,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) delete(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	if err := a.svc.Delete(c, id); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (a *Algorithm) halt(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Halt(c, id)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) resume(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Resume(c, id)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) budgets(c *gin.Context) {
+	result, err := a.svc.Budgets(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.InvestmentLimit{}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) budget(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Budget(c, id)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) allocate(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	r, err := request.BudgetAlgorithm(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Allocate(c, id, r.Amount)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) orders(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	result, err := a.svc.Orders(c, id)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.AlgorithmOrder{}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) createOrder(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	o := new(broker.OrderRequest)
+	if err := c.ShouldBindJSON(o); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	result, err := a.svc.PlaceOrder(c, id, o)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Algorithm) cancelOrder(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	if err := a.svc.CancelOrder(c, id, c.Param("order_id")); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
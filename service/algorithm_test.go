@@ -0,0 +1,501 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/algorithm"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAlgorithms(t *testing.T) {
+	type listResponse struct {
+		Algorithms []model.Algorithm `json:"algorithms"`
+		Page       int               `json:"page"`
+	}
+	cases := []struct {
+		name          string
+		req           string
+		wantStatus    int
+		wantResp      *listResponse
+		algorithmRepo *mockdb.Algorithm
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?limit=2222&page=-1`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Fail on query list",
+			req:  `?q=trend`,
+			algorithmRepo: &mockdb.Algorithm{
+				ListFn: func(string, *model.Pagination) ([]model.Algorithm, error) {
+					return nil, apperr.DB
+				},
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Success",
+			req:  `?q=trend&limit=10&page=1`,
+			algorithmRepo: &mockdb.Algorithm{
+				ListFn: func(q string, p *model.Pagination) ([]model.Algorithm, error) {
+					if q == "trend" && p.Limit == 10 && p.Offset == 10 {
+						return []model.Algorithm{
+							{
+								Base: model.Base{
+									CreatedAt: mock.TestTime(2001),
+									UpdatedAt: mock.TestTime(2002),
+								},
+								ID:          1,
+								Name:        "Trend Following",
+								Description: "Follows trends in the market.",
+								Parameters:  map[string]interface{}{"lookback_period": 10.0},
+							},
+						}, nil
+					}
+					return nil, apperr.DB
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{
+				Algorithms: []model.Algorithm{
+					{
+						Base: model.Base{
+							CreatedAt: mock.TestTime(2001),
+							UpdatedAt: mock.TestTime(2002),
+						},
+						ID:          1,
+						Name:        "Trend Following",
+						Description: "Follows trends in the market.",
+						Parameters:  map[string]interface{}{"lookback_period": 10.0},
+					},
+				},
+				Page: 1,
+			},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			rg := r.Group("/v1")
+			algorithmService := algorithm.NewAlgorithmService(nil, tt.algorithmRepo, nil, nil, nil)
+			service.AlgorithmRouter(algorithmService, rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/algorithms" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCreateAlgorithm(t *testing.T) {
+	cases := []struct {
+		name          string
+		req           string
+		wantStatus    int
+		wantResp      *model.Algorithm
+		algorithmRepo *mockdb.Algorithm
+		rbac          *mock.RBAC
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"description":"no name"}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `{"name":"Trend Following","strategy":"momentum"}`,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return false
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Fail on parameters",
+			req:  `{"name":"Trend Following","strategy":"momentum","parameters":{"lookback_period":2.5}}`,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return true
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `{"name":"Trend Following","strategy":"momentum","parameters":{"lookback_period":10},"symbols":["AAPL"]}`,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return true
+				},
+			},
+			algorithmRepo: &mockdb.Algorithm{
+				CreateFn: func(a *model.Algorithm) (*model.Algorithm, error) {
+					a.ID = 1
+					return a, nil
+				},
+			},
+			wantStatus: http.StatusCreated,
+			wantResp: &model.Algorithm{
+				ID:         1,
+				Name:       "Trend Following",
+				Strategy:   "momentum",
+				Parameters: map[string]interface{}{"lookback_period": 10.0, "threshold": 0.0},
+				Symbols:    []string{"AAPL"},
+				Interval:   60,
+				Active:     true,
+			},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			rg := r.Group("/v1")
+			algorithmService := algorithm.NewAlgorithmService(nil, tt.algorithmRepo, nil, tt.rbac, nil)
+			service.AlgorithmRouter(algorithmService, rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/algorithms", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Algorithm)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUpdateAlgorithm(t *testing.T) {
+	cases := []struct {
+		name          string
+		id            string
+		req           string
+		wantStatus    int
+		wantResp      *model.Algorithm
+		algorithmRepo *mockdb.Algorithm
+		rbac          *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			id:   `1`,
+			req:  `{"name":"Mean Reversion"}`,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return false
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Fail on view",
+			id:   `1`,
+			req:  `{"name":"Mean Reversion"}`,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return true
+				},
+			},
+			algorithmRepo: &mockdb.Algorithm{
+				ViewFn: func(int) (*model.Algorithm, error) {
+					return nil, apperr.New(http.StatusNotFound, "Algorithm not found.")
+				},
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "Success",
+			id:   `1`,
+			req:  `{"name":"Mean Reversion","strategy":"rsi_reversion","parameters":{"period":20}}`,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return true
+				},
+			},
+			algorithmRepo: &mockdb.Algorithm{
+				ViewFn: func(id int) (*model.Algorithm, error) {
+					return &model.Algorithm{
+						ID:          id,
+						Name:        "Trend Following",
+						Description: "Follows trends in the market.",
+						Strategy:    "momentum",
+						Parameters:  map[string]interface{}{"lookback_period": 10.0, "threshold": 0.0},
+					}, nil
+				},
+				UpdateFn: func(a *model.Algorithm) (*model.Algorithm, error) {
+					return a, nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &model.Algorithm{
+				ID:          1,
+				Name:        "Mean Reversion",
+				Description: "Follows trends in the market.",
+				Strategy:    "rsi_reversion",
+				Parameters:  map[string]interface{}{"period": 20.0, "oversold": 30.0, "overbought": 70.0},
+			},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			rg := r.Group("/v1")
+			algorithmService := algorithm.NewAlgorithmService(nil, tt.algorithmRepo, nil, tt.rbac, nil)
+			service.AlgorithmRouter(algorithmService, rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/v1/algorithms/"+tt.id, bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Algorithm)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestPlaceAlgorithmOrder(t *testing.T) {
+	type settlement struct {
+		status     string
+		qty, price float64
+	}
+	cases := []struct {
+		name          string
+		req           string
+		reserve       func(*model.AlgorithmOrder) error
+		brokerFails   bool
+		wantStatus    int
+		wantPositions int
+		wantSettle    *settlement
+	}{
+		{
+			name: "Fail on budget",
+			req:  `{"symbol":"AAPL","qty":"3","side":"buy","type":"market","time_in_force":"day"}`,
+			reserve: func(o *model.AlgorithmOrder) error {
+				if o.Reserved != 300 {
+					t.Errorf("reserved %v, want 300", o.Reserved)
+				}
+				return apperr.New(http.StatusForbidden, "Insufficient algorithm budget.")
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Fail on pre-trade checks",
+			req:        `{"symbol":"AAPL","qty":"30","side":"buy","type":"market","time_in_force":"day"}`,
+			reserve:    func(*model.AlgorithmOrder) error { return nil },
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Release on broker rejection",
+			req:         `{"symbol":"AAPL","qty":"3","side":"buy","type":"market","time_in_force":"day"}`,
+			reserve:     func(*model.AlgorithmOrder) error { return nil },
+			brokerFails: true,
+			wantStatus:  http.StatusForbidden,
+			wantSettle:  &settlement{model.AlgorithmOrderRejected, 0, 0},
+		},
+		{
+			name:          "Success",
+			req:           `{"symbol":"AAPL","qty":"2","side":"buy","type":"market","time_in_force":"day"}`,
+			reserve:       func(*model.AlgorithmOrder) error { return nil },
+			wantStatus:    http.StatusOK,
+			wantPositions: 1,
+			wantSettle:    &settlement{model.AlgorithmOrderFilled, 2, 100},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := brokertest.NewServer()
+			defer brk.Close()
+			brk.AddAccount("acc", 1000)
+			brk.SetPrice("AAPL", 100)
+			if tt.brokerFails {
+				brk.Fail(http.MethodPost, "/v1/trading/accounts/acc/orders", http.StatusForbidden, 1)
+			}
+
+			var settled *settlement
+			userRepo := &mockdb.User{
+				ViewFn: func(id int) (*model.User, error) {
+					return &model.User{ID: id, AccountID: "acc"}, nil
+				},
+			}
+			algorithmRepo := &mockdb.Algorithm{
+				ViewFn: func(id int) (*model.Algorithm, error) {
+					return &model.Algorithm{ID: id, Active: true}, nil
+				},
+			}
+			budgetRepo := &mockdb.Budget{
+				ReserveFn: tt.reserve,
+				SubmitFn:  func(*model.AlgorithmOrder) error { return nil },
+				SettleFn: func(o *model.AlgorithmOrder, status string, qty, price float64, at time.Time) error {
+					settled = &settlement{status, qty, price}
+					// fills are recorded at the time the broker filled them
+					assert.Equal(t, qty > 0, !at.IsZero())
+					return nil
+				},
+			}
+
+			r := gin.New()
+			rg := r.Group("/v1")
+			algorithmService := algorithm.NewAlgorithmService(userRepo, algorithmRepo, budgetRepo, nil, brk.Broker())
+			service.AlgorithmRouter(algorithmService, rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/algorithms/1/orders", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, tt.wantSettle, settled)
+			assert.Len(t, brk.Positions("acc"), tt.wantPositions)
+		})
+	}
+}
+
+func TestHaltAlgorithm(t *testing.T) {
+	cases := []struct {
+		name       string
+		rbac       *mock.RBAC
+		wantStatus int
+		wantCancel bool
+	}{
+		{
+			name: "Fail on RBAC",
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return false
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return true
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantCancel: true,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := brokertest.NewServer()
+			defer brk.Close()
+			brk.AddAccount("acc", 1000)
+			brk.SetPrice("AAPL", 100)
+			qty, limit := 1.0, 50.0
+			order, err := brk.Broker().CreateOrder(context.Background(), "acc", &broker.OrderRequest{
+				Symbol: "AAPL", Qty: &qty, Side: broker.Buy, Type: "limit", TimeInForce: "day", LimitPrice: &limit,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var updated *model.Algorithm
+			var settled string
+			userRepo := &mockdb.User{
+				ViewFn: func(id int) (*model.User, error) {
+					return &model.User{ID: id, AccountID: "acc"}, nil
+				},
+			}
+			algorithmRepo := &mockdb.Algorithm{
+				ViewFn: func(id int) (*model.Algorithm, error) {
+					return &model.Algorithm{ID: id, Active: true}, nil
+				},
+				UpdateFn: func(a *model.Algorithm) (*model.Algorithm, error) {
+					updated = a
+					return a, nil
+				},
+			}
+			budgetRepo := &mockdb.Budget{
+				SubscribersFn: func(int) ([]model.InvestmentLimit, error) {
+					return []model.InvestmentLimit{{UserID: 1}}, nil
+				},
+				OrdersFn: func(int, int, string) ([]model.AlgorithmOrder, error) {
+					return []model.AlgorithmOrder{{OrderID: order.ID, Status: model.AlgorithmOrderOpen}}, nil
+				},
+				SettleFn: func(o *model.AlgorithmOrder, status string, qty, price float64, at time.Time) error {
+					settled = status
+					return nil
+				},
+			}
+
+			r := gin.New()
+			rg := r.Group("/v1")
+			algorithmService := algorithm.NewAlgorithmService(userRepo, algorithmRepo, budgetRepo, tt.rbac, brk.Broker())
+			service.AlgorithmRouter(algorithmService, rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/algorithms/1/halt", "application/json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantCancel {
+				assert.False(t, updated.Active)
+				assert.Equal(t, model.AlgorithmOrderCanceled, settled)
+			}
+		})
+	}
+}
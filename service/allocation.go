@@ -0,0 +1,88 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/allocations"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Allocation represents the target allocation http service
+type Allocation struct {
+	svc *allocations.Service
+}
+
+// AllocationRouter declares the routes for the target allocation router group
+func AllocationRouter(svc *allocations.Service, r *gin.RouterGroup) {
+	a := Allocation{
+		svc: svc,
+	}
+	ar := r.Group("/allocation")
+	ar.GET("", a.view)
+	ar.PUT("", a.save)
+	ar.DELETE("", a.delete)
+	ar.GET("/rebalance", a.preview)
+	ar.POST("/rebalance", a.rebalance)
+}
+
+func (a *Allocation) view(c *gin.Context) {
+	result, err := a.svc.View(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Allocation) save(c *gin.Context) {
+	r, err := request.SaveAllocation(c)
+	if err != nil {
+		return
+	}
+	targets := make([]model.AllocationTarget, len(r.Targets))
+	for i, t := range r.Targets {
+		targets[i] = model.AllocationTarget(t)
+	}
+	result, err := a.svc.Save(c, &model.Allocation{
+		Targets:         targets,
+		Threshold:       *r.Threshold,
+		MinTradeSize:    *r.MinTradeSize,
+		AutoRebalance:   r.AutoRebalance,
+		CloseUntargeted: r.CloseUntargeted,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Allocation) delete(c *gin.Context) {
+	if err := a.svc.Delete(c); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (a *Allocation) preview(c *gin.Context) {
+	result, err := a.svc.Preview(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *Allocation) rebalance(c *gin.Context) {
+	result, err := a.svc.Rebalance(c, c.GetHeader(idempotencyKeyHeader))
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,352 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/rebalance"
+	"github.com/zcoriarty/Backend/repository/allocations"
+	"github.com/zcoriarty/Backend/repository/order"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// allocationStore is an in-memory allocations table, next to the budget of an algorithm of the
+// user and the shares it holds
+type allocationStore struct {
+	allocation *model.Allocation
+	rebalanced int
+	limit      *model.InvestmentLimit
+	algorithms map[string]float64
+}
+
+func (s *allocationStore) budget() *mockdb.Budget {
+	return &mockdb.Budget{
+		LimitsFn: func(userID int) ([]model.InvestmentLimit, error) {
+			if s.limit == nil {
+				return nil, nil
+			}
+			return []model.InvestmentLimit{*s.limit}, nil
+		},
+		HoldingsFn: func(userID, algorithmID int) (map[string]float64, error) {
+			return s.algorithms, nil
+		},
+	}
+}
+
+func (s *allocationStore) repo() *mockdb.Allocation {
+	return &mockdb.Allocation{
+		ViewFn: func(userID int) (*model.Allocation, error) {
+			if s.allocation == nil || s.allocation.UserID != userID {
+				return nil, apperr.New(http.StatusNotFound, "Allocation not found.")
+			}
+			cp := *s.allocation
+			return &cp, nil
+		},
+		SaveFn: func(a *model.Allocation) error {
+			a.ID = 1
+			cp := *a
+			s.allocation = &cp
+			return nil
+		},
+		DeleteFn: func(userID int) error {
+			s.allocation = nil
+			return nil
+		},
+		MarkRebalancedFn: func(id int, at time.Time) error {
+			s.rebalanced++
+			s.allocation.LastRebalancedAt = &at
+			return nil
+		},
+	}
+}
+
+func newAllocationServer(brk *brokertest.Server, store *allocationStore, l *ledger) *httptest.Server {
+	userRepo := &mockdb.User{
+		ViewFn: func(id int) (*model.User, error) {
+			return &model.User{ID: id, AccountID: "acc"}, nil
+		},
+	}
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	orders := order.NewOrderService(userRepo, l.repo(), brk.Broker())
+	service.AllocationRouter(allocations.NewAllocationService(userRepo, store.repo(), store.budget(), orders, brk.Broker()), rg)
+	return httptest.NewServer(r)
+}
+
+func allocationBroker() *brokertest.Server {
+	brk := brokertest.NewServer()
+	brk.AddAccount("acc", 10000)
+	brk.AddAsset(broker.Asset{Symbol: "VTI", Tradable: true, Fractionable: true})
+	brk.AddAsset(broker.Asset{Symbol: "BND", Tradable: true, Fractionable: true})
+	brk.AddAsset(broker.Asset{Symbol: "BRK.A", Tradable: true})
+	brk.SetPrice("VTI", 100)
+	brk.SetPrice("BND", 50)
+	brk.SetPrice("BRK.A", 400000)
+	return brk
+}
+
+func TestSaveAllocation(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Allocation
+	}{
+		{
+			name:       "Fail on binding",
+			req:        `{"targets":[{"symbol":"VTI","weight":1.5}]}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Fail on duplicate symbol",
+			req:        `{"targets":[{"symbol":"VTI","weight":0.5},{"symbol":"vti","weight":0.3}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on weights above 1",
+			req:        `{"targets":[{"symbol":"VTI","weight":0.7},{"symbol":"BND","weight":0.4}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on unknown symbol",
+			req:        `{"targets":[{"symbol":"NOPE","weight":0.5}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on whole shares",
+			req:        `{"targets":[{"symbol":"BRK.A","weight":0.5}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success with defaults",
+			req:        `{"targets":[{"symbol":" vti ","weight":0.6},{"symbol":"BND","weight":0.3}],"auto_rebalance":true}`,
+			wantStatus: http.StatusOK,
+			wantResp: &model.Allocation{
+				ID:            1,
+				UserID:        1,
+				Targets:       []model.AllocationTarget{{Symbol: "VTI", Weight: 0.6}, {Symbol: "BND", Weight: 0.3}},
+				Threshold:     0.05,
+				MinTradeSize:  1,
+				AutoRebalance: true,
+			},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := allocationBroker()
+			defer brk.Close()
+			store := &allocationStore{}
+			ts := newAllocationServer(brk, store, &ledger{})
+			defer ts.Close()
+
+			req, err := http.NewRequest(http.MethodPut, ts.URL+"/v1/allocation", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantResp != nil {
+				resp := new(model.Allocation)
+				if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, resp)
+				assert.Equal(t, tt.wantResp, store.allocation)
+			} else {
+				assert.Nil(t, store.allocation)
+			}
+		})
+	}
+}
+
+func TestRebalanceAllocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	brk := allocationBroker()
+	defer brk.Close()
+	// 80% VTI and 20% cash against 60% VTI, 30% BND and 10% cash
+	qty := 80.0
+	if _, err := brk.Broker().CreateOrder(context.Background(), "acc", &broker.OrderRequest{Symbol: "VTI", Qty: &qty, Side: broker.Buy, Type: "market", TimeInForce: "day"}); err != nil {
+		t.Fatal(err)
+	}
+	store := &allocationStore{allocation: &model.Allocation{
+		ID:           1,
+		UserID:       1,
+		Targets:      []model.AllocationTarget{{Symbol: "VTI", Weight: 0.6}, {Symbol: "BND", Weight: 0.3}},
+		Threshold:    0.05,
+		MinTradeSize: 1,
+	}}
+	l := &ledger{}
+	ts := newAllocationServer(brk, store, l)
+	defer ts.Close()
+	url := ts.URL + "/v1/allocation/rebalance"
+	want := []rebalance.Order{
+		{Symbol: "VTI", Side: broker.Sell, Qty: 20, Value: 2000},
+		{Symbol: "BND", Side: broker.Buy, Notional: 3000, Value: 3000},
+	}
+
+	// previews place nothing
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := new(rebalance.Plan)
+	err = json.NewDecoder(res.Body).Decode(plan)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, want, plan.Orders)
+	assert.InDelta(t, 0.2, plan.CashWeight, 1e-9)
+	assert.Len(t, brk.Orders("acc"), 1)
+
+	post := func(key string) *allocations.Result {
+		req, err := http.NewRequest(http.MethodPost, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Idempotency-Key", key)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		result := new(allocations.Result)
+		if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := post("rb-1")
+	assert.Equal(t, want, result.Plan.Orders)
+	assert.Len(t, result.Submitted, 2)
+	for _, s := range result.Submitted {
+		assert.Empty(t, s.Error)
+		assert.Equal(t, broker.OrderFilled, s.Placed.Status)
+		assert.Equal(t, "rb-1:"+s.Symbol, s.Placed.IdempotencyKey)
+	}
+	assert.Len(t, brk.Orders("acc"), 3)
+	assert.Len(t, l.orders, 2)
+	assert.Equal(t, 1, store.rebalanced)
+	assert.InDelta(t, 1000, brk.Cash("acc"), 1e-6)
+	positions := map[string]float64{}
+	for _, p := range brk.Positions("acc") {
+		positions[p.Symbol] = p.Qty
+	}
+	assert.Equal(t, map[string]float64{"VTI": 60, "BND": 60}, positions)
+
+	// the portfolio is back on target, so a second rebalance trades nothing
+	result = post("rb-2")
+	assert.Empty(t, result.Plan.Orders)
+	assert.Len(t, brk.Orders("acc"), 3)
+	assert.Equal(t, 1, store.rebalanced)
+}
+
+func TestRebalanceKeepsOtherPositions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name            string
+		closeUntargeted bool
+		want            []rebalance.Order
+	}{
+		{
+			name: "Keep positions without a target",
+			want: []rebalance.Order{
+				{Symbol: "VTI", Side: broker.Sell, Qty: 11, Value: 1100},
+				{Symbol: "BND", Side: broker.Buy, Notional: 1600, Value: 1600},
+			},
+		},
+		{
+			name:            "Close positions without a target when opted in",
+			closeUntargeted: true,
+			want: []rebalance.Order{
+				{Symbol: "AAPL", Side: broker.Sell, Qty: 10, Value: 1000},
+				{Symbol: "VTI", Side: broker.Sell, Qty: 11, Value: 1100},
+				{Symbol: "BND", Side: broker.Buy, Notional: 1950, Value: 1950},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := allocationBroker()
+			defer brk.Close()
+			brk.AddAsset(broker.Asset{Symbol: "AAPL", Tradable: true, Fractionable: true})
+			brk.SetPrice("AAPL", 100)
+			for sym, qty := range map[string]float64{"VTI": 80, "AAPL": 10} {
+				qty := qty
+				if _, err := brk.Broker().CreateOrder(context.Background(), "acc", &broker.OrderRequest{Symbol: sym, Qty: &qty, Side: broker.Buy, Type: "market", TimeInForce: "day"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// 30 of the VTI shares are held by an algorithm, which has 500 of its budget left,
+			// leaving 5000 of VTI, 1000 of AAPL and 500 of cash to the allocation
+			store := &allocationStore{
+				allocation: &model.Allocation{
+					ID:              1,
+					UserID:          1,
+					Targets:         []model.AllocationTarget{{Symbol: "VTI", Weight: 0.6}, {Symbol: "BND", Weight: 0.3}},
+					Threshold:       0.05,
+					MinTradeSize:    1,
+					CloseUntargeted: tt.closeUntargeted,
+				},
+				limit:      &model.InvestmentLimit{UserID: 1, AlgorithmID: 1, TotalAllowed: 3500, TotalInvested: 3000},
+				algorithms: map[string]float64{"VTI": 30},
+			}
+			ts := newAllocationServer(brk, store, &ledger{})
+			defer ts.Close()
+
+			res, err := http.Get(ts.URL + "/v1/allocation/rebalance")
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan := new(rebalance.Plan)
+			err = json.NewDecoder(res.Body).Decode(plan)
+			res.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.InDelta(t, 6500, plan.Total, 1e-6)
+			assert.InDelta(t, 500, plan.Cash, 1e-6)
+			assert.Equal(t, tt.want, plan.Orders)
+		})
+	}
+}
+
+func TestRebalanceWithoutAllocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	brk := allocationBroker()
+	defer brk.Close()
+	ts := newAllocationServer(brk, &allocationStore{}, &ledger{})
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/v1/allocation/rebalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
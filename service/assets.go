@@ -1,25 +1,20 @@
 package service
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"log"
 	"net/http"
-	"net/url"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
 	account "github.com/zcoriarty/Backend/repository/account"
 	"github.com/zcoriarty/Backend/repository/assets"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AssetsRouter(svc *assets.Service, acc *account.Service, r *gin.RouterGroup) {
-	a := Assets{svc, acc}
+func AssetsRouter(svc *assets.Service, acc *account.Service, brk broker.Service, r *gin.RouterGroup) {
+	a := Assets{svc, acc, brk}
 
 	ar := r.Group("/assets")
 	ar.GET("/", a.getAssetsList)
@@ -30,25 +25,44 @@ func AssetsRouter(svc *assets.Service, acc *account.Service, r *gin.RouterGroup)
 
 // Auth represents auth http service
 type Assets struct {
-	svc *assets.Service
-	acc *account.Service
+	svc    *assets.Service
+	acc    *account.Service
+	broker broker.Service
 }
 
 type AssetObj struct {
-	ID                           string   `json:"id"`
-	Class                        string   `json:"class"`
-	Exchange                     string   `json:"exchange"`
-	Symbol                       string   `json:"symbol"`
-	Name                         string   `json:"name"`
-	Status                       string   `json:"status"`
-	Tradable                     bool     `json:"tradable"`
-	Marginable                   bool     `json:"marginable"`
-	MaintenanceMarginRequirement float64  `json:"maintenance_margin_requirement"`
-	Shortable                    bool     `json:"shortable"`
-	EasyToBorrow                 bool     `json:"easy_to_borrow"`
-	Fractionable                 bool     `json:"fractionable"`
-	Attributes                   []string `json:"attributes"`
-	Ticker                       interface{}   `json:"ticker"`
+	ID                           string           `json:"id"`
+	Class                        string           `json:"class"`
+	Exchange                     string           `json:"exchange"`
+	Symbol                       string           `json:"symbol"`
+	Name                         string           `json:"name"`
+	Status                       string           `json:"status"`
+	Tradable                     bool             `json:"tradable"`
+	Marginable                   bool             `json:"marginable"`
+	MaintenanceMarginRequirement float64          `json:"maintenance_margin_requirement"`
+	Shortable                    bool             `json:"shortable"`
+	EasyToBorrow                 bool             `json:"easy_to_borrow"`
+	Fractionable                 bool             `json:"fractionable"`
+	Attributes                   []string         `json:"attributes"`
+	Ticker                       *broker.Snapshot `json:"ticker"`
+}
+
+func newAssetObj(a *broker.Asset) AssetObj {
+	return AssetObj{
+		ID:                           a.ID,
+		Class:                        a.Class,
+		Exchange:                     a.Exchange,
+		Symbol:                       a.Symbol,
+		Name:                         a.Name,
+		Status:                       a.Status,
+		Tradable:                     a.Tradable,
+		Marginable:                   a.Marginable,
+		MaintenanceMarginRequirement: a.MaintenanceMarginRequirement,
+		Shortable:                    a.Shortable,
+		EasyToBorrow:                 a.EasyToBorrow,
+		Fractionable:                 a.Fractionable,
+		Attributes:                   a.Attributes,
+	}
 }
 
 type SymbolTicker struct {
@@ -288,68 +302,28 @@ var AssetsList = []AssetObj{
 // var token string = "Basic Q0tWTU9JRUNPQk9PWVMwMEZKRVQ6MW9HcXFkdGNSWkRNeUhuWkg1d1N4dE94SEswbXZDWnlyOUdZUHlSUw=="
 
 func (a *Assets) getSingleAsset(c *gin.Context) {
-
-	tickersStr := c.Query("tickers")
-	tickers := strings.Split(tickersStr, ",")
-	client := &http.Client{}
+	ctx := c.Request.Context()
+	tickers := strings.Split(c.Query("tickers"), ",")
 	_assets := []AssetObj{}
 
 	for _, symbol := range tickers {
-        // create request for each ticker in tickers list
-        assetReq, _ := http.NewRequest("GET", fmt.Sprintf("%s/v1/assets/%s", os.Getenv("BROKER_API_BASE"), symbol), nil)
-        assetReq.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-        // execute request and handle any errors
-        assetResp, err := client.Do(assetReq)
-        if err != nil {
-            apperr.Response(c, apperr.New(assetResp.StatusCode, "Something went wrong. Try again later."))
-            return
-        }
-        defer assetResp.Body.Close()
-
-        // read response body and unmarshal into AssetObj
-        assetData, err := ioutil.ReadAll(assetResp.Body)
-        if err != nil {
-            log.Fatal(err)
-        }
-
-        var asset AssetObj
-        err = json.Unmarshal(assetData, &asset)
-        if err != nil {
-            log.Fatal(err)
-        }
-
-        // append asset to _assets slice
-        _assets = append(_assets, asset)
-    }
-
-	req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/snapshots?symbols="+url.QueryEscape(strings.Join(tickers[:], ",")), nil)
-	if err != nil {
-		fmt.Print(err.Error())
-	}
-
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-	response, err := client.Do(req)
-
-	if err != nil {
-		fmt.Print(err.Error())
+		asset, err := a.broker.GetAsset(ctx, symbol)
+		if err != nil {
+			apperr.Response(c, err)
+			return
+		}
+		_assets = append(_assets, newAssetObj(asset))
 	}
 
-	responseData, err := ioutil.ReadAll(response.Body)
+	snapshots, err := a.broker.GetSnapshots(ctx, tickers)
 	if err != nil {
-		log.Fatal(err)
+		apperr.Response(c, err)
+		return
 	}
-	// fmt.Printf("%v", string(responseData))
-
-	var responseObject map[string]interface{}
-	json.Unmarshal(responseData, &responseObject)
-
 	for index := range _assets {
-		_assets[index].Ticker = responseObject[_assets[index].Symbol]
+		_assets[index].Ticker = snapshots[_assets[index].Symbol]
 	}
 
-
-
 	c.JSON(http.StatusOK, _assets)
 }
 
@@ -400,59 +374,13 @@ func (a *Assets) getAssetsList(c *gin.Context) {
 	}
 
 	// fetch market data of _assets
-	if len(symbolNames) > 0 {
-		client := &http.Client{}
-
-		req, err := http.NewRequest("GET", os.Getenv("BROKER_API_DATA_BASE")+"/v2/stocks/snapshots?symbols="+url.QueryEscape(strings.Join(symbolNames[:], ",")), nil)
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-		response, err := client.Do(req)
-
-		if err != nil {
-			fmt.Print(err.Error())
-		}
-
-		responseData, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal(err)
-		}
-		// fmt.Printf("%v", string(responseData))
-
-		var responseObject map[string]interface{}
-		json.Unmarshal(responseData, &responseObject)
-
-		for index := range _assets {
-			_assets[index].Ticker = responseObject[_assets[index].Symbol]
-		}
-
-		// Watchlisted flag
-		// if user.AccountID != "" && user.WatchlistID != "" {
-		// 	req2, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v1/trading/accounts/"+user.AccountID+"/watchlists/"+user.WatchlistID, nil)
-		// 	req2.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-		// 	response2, _ := client.Do(req2)
-		// 	responseData2, err := ioutil.ReadAll(response2.Body)
-		// 	if err != nil {
-		// 		apperr.Response(c, apperr.New(response2.StatusCode, "Something went wrong. Try again later."))
-		// 		return
-		// 	}
-		// 	json.Unmarshal(responseData2, &responseObject)
-		// 	for index := range _assets {
-		// 		// isWatchlisted := false
-		// 		for _, ass := range responseObject["assets"].([]interface{}) {
-		// 			ass, _ := ass.(map[string]interface{})
-		// 			if ass["symbol"] == _assets[index].Symbol {
-		// 				// isWatchlisted = true
-		// 				break
-		// 			}
-		// 		}
-
-		// 		// _assets[index].IsWatchlisted = isWatchlisted
-		// 	}
-		// }
+	snapshots, err := a.broker.GetSnapshots(c.Request.Context(), symbolNames)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	for index := range _assets {
+		_assets[index].Ticker = snapshots[_assets[index].Symbol]
 	}
 
 	c.JSON(http.StatusOK, _assets)
@@ -461,34 +389,17 @@ func (a *Assets) getAssetsList(c *gin.Context) {
 func (a *Assets) getAssetDetail(c *gin.Context) {
 	id := c.Param("id")
 
-	for i := range AssetsList {
-		if AssetsList[i].ID == id {
+	for _, asset := range AssetsList {
+		if asset.ID == id {
 			// fetch market data of assets
-			client := &http.Client{}
-
-			req, err := http.NewRequest("GET", os.Getenv("BROKER_API_BASE")+"/v2/stocks/snapshots?symbols="+url.QueryEscape(AssetsList[i].Symbol), nil)
-			if err != nil {
-				fmt.Print(err.Error())
-			}
-
-			req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-			response, err := client.Do(req)
-
+			snapshots, err := a.broker.GetSnapshots(c.Request.Context(), []string{asset.Symbol})
 			if err != nil {
-				fmt.Print(err.Error())
+				apperr.Response(c, err)
+				return
 			}
-
-			responseData, err := ioutil.ReadAll(response.Body)
-			if err != nil {
-				log.Fatal(err)
-			}
-			// fmt.Printf("%v", string(responseData))
-
-			var responseObject map[string]interface{}
-			json.Unmarshal(responseData, &responseObject)
-
-			AssetsList[i].Ticker = responseObject[AssetsList[i].Symbol]
-			c.JSON(200, AssetsList[i])
+			asset.Ticker = snapshots[asset.Symbol]
+			c.JSON(http.StatusOK, asset)
+			return
 		}
 	}
 	c.JSON(http.StatusNotFound, gin.H{
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/backtest"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Backtest represents the backtest http service
+type Backtest struct {
+	svc *backtest.Service
+}
+
+// BacktestRouter declares the routes for backtests router group
+func BacktestRouter(svc *backtest.Service, r *gin.RouterGroup) {
+	b := Backtest{
+		svc: svc,
+	}
+	br := r.Group("/backtests")
+	br.GET("", b.list)
+	br.POST("", b.create)
+	br.GET("/:id", b.view)
+}
+
+type backtestListResponse struct {
+	Backtests []model.Backtest `json:"backtests"`
+	Page      int              `json:"page"`
+}
+
+func (b *Backtest) list(c *gin.Context) {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return
+	}
+	result, err := b.svc.List(c, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.Backtest{}
+	}
+	c.JSON(http.StatusOK, backtestListResponse{
+		Backtests: result,
+		Page:      p.Page,
+	})
+}
+
+func (b *Backtest) create(c *gin.Context) {
+	r, err := request.CreateBacktest(c)
+	if err != nil {
+		return
+	}
+	result, err := b.svc.Run(c, &model.Backtest{
+		Strategy:       r.Strategy,
+		Parameters:     r.Parameters,
+		Symbol:         r.Symbol,
+		StartDate:      r.Start,
+		EndDate:        r.End,
+		InitialCash:    r.InitialCash,
+		Slippage:       r.Slippage,
+		Commission:     r.Commission,
+		CommissionRate: r.CommissionRate,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+type backtestResponse struct {
+	*model.Backtest
+	Benchmark *benchmark.Comparison `json:"benchmark,omitempty"`
+}
+
+func (b *Backtest) view(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	r, err := request.CompareBenchmark(c)
+	if err != nil {
+		return
+	}
+	result, err := b.svc.View(c, id)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	res := backtestResponse{Backtest: result}
+	if r.Symbol != "" {
+		if res.Benchmark, err = b.svc.Benchmark(c, result, r.Symbol); err != nil {
+			apperr.Response(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,228 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/backtest"
+	"github.com/zcoriarty/Backend/repository/bars"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// storedBars returns a bars store whose database holds daily, complete every day
+func storedBars(brk broker.Service, daily map[string][]broker.Bar) *bars.Store {
+	return bars.NewStore(brk, &mockdb.Bar{
+		ListFn: func(symbol, timeframe string, start, end time.Time) ([]model.Bar, error) {
+			var res []model.Bar
+			for _, b := range daily[symbol] {
+				if !b.Timestamp.Before(start) && !b.Timestamp.After(end) {
+					res = append(res, model.Bar{Symbol: symbol, Timeframe: timeframe, Timestamp: b.Timestamp, Close: b.Close})
+				}
+			}
+			return res, nil
+		},
+		RangesFn: func(symbol, timeframe string, start, end time.Time) ([]model.BarRange, error) {
+			return []model.BarRange{{Symbol: symbol, Timeframe: timeframe, StartAt: start, EndAt: end}}, nil
+		},
+	}, zap.NewNop())
+}
+
+func TestRunBacktest(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Backtest
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"strategy":"buy_and_hold","symbol":"AAPL"}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Fail on date",
+			req:        `{"strategy":"buy_and_hold","symbol":"AAPL","start_date":"01/02/2023","end_date":"2023-01-31","initial_cash":1000}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on unknown strategy",
+			req:        `{"strategy":"astrology","symbol":"AAPL","start_date":"2023-01-01","end_date":"2023-01-31","initial_cash":1000}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on missing bars",
+			req:        `{"strategy":"buy_and_hold","symbol":"MSFT","start_date":"2023-01-01","end_date":"2023-01-31","initial_cash":1000}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"strategy":"buy_and_hold","symbol":"AAPL","start_date":"2023-01-01","end_date":"2023-01-31","initial_cash":1000}`,
+			wantStatus: http.StatusCreated,
+			wantResp: &model.Backtest{
+				ID:          1,
+				Strategy:    "buy_and_hold",
+				Parameters:  map[string]interface{}{},
+				Symbol:      "AAPL",
+				StartDate:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:     time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC),
+				InitialCash: 1000,
+				TotalReturn: 0.5,
+				Sharpe:      101.02474944289631,
+				EquityCurve: []model.EquityPoint{
+					{Time: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Equity: 1000},
+					{Time: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), Equity: 1200},
+					{Time: time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC), Equity: 1500},
+				},
+				Trades: []model.BacktestTrade{
+					{Time: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), Side: broker.Buy, Qty: 100, Price: 10},
+				},
+			},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := brokertest.NewServer()
+			defer brk.Close()
+			brk.SetBars("AAPL", []broker.Bar{
+				{Timestamp: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Open: 10, Close: 10},
+				{Timestamp: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), Open: 10, Close: 12},
+				{Timestamp: time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC), Open: 12, Close: 15},
+				{Timestamp: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), Open: 15, Close: 20},
+			})
+			backtestRepo := &mockdb.Backtest{
+				CreateFn: func(b *model.Backtest) (*model.Backtest, error) {
+					b.ID = 1
+					return b, nil
+				},
+			}
+
+			r := gin.New()
+			rg := r.Group("/v1")
+			service.BacktestRouter(backtest.NewBacktestService(backtestRepo, brk.Broker(), nil), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/backtests", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Backtest)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestViewBacktest(t *testing.T) {
+	cases := []struct {
+		name       string
+		userID     int
+		wantStatus int
+	}{
+		{
+			name:       "Fail on other user's backtest",
+			userID:     2,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Success",
+			userID:     1,
+			wantStatus: http.StatusOK,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			backtestRepo := &mockdb.Backtest{
+				ViewFn: func(id int) (*model.Backtest, error) {
+					return &model.Backtest{ID: id, UserID: 1}, nil
+				},
+			}
+			r := gin.New()
+			rg := r.Group("/v1")
+			rg.Use(func(c *gin.Context) { c.Set("id", tt.userID) })
+			service.BacktestRouter(backtest.NewBacktestService(backtestRepo, nil, nil), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/backtests/1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestBacktestBenchmark(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC) }
+	backtestRepo := &mockdb.Backtest{
+		ViewFn: func(id int) (*model.Backtest, error) {
+			return &model.Backtest{ID: id, UserID: 1, EquityCurve: []model.EquityPoint{
+				{Time: day(2), Equity: 1000}, {Time: day(3), Equity: 1200}, {Time: day(4), Equity: 1500},
+			}}, nil
+		},
+	}
+	store := storedBars(nil, map[string][]broker.Bar{
+		"SPY": {{Timestamp: day(2), Close: 100}, {Timestamp: day(3), Close: 110}, {Timestamp: day(4), Close: 121}},
+	})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	service.BacktestRouter(backtest.NewBacktestService(backtestRepo, nil, store), rg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/v1/backtests/1?benchmark=spy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var out struct {
+		ID        int `json:"id"`
+		Benchmark struct {
+			Symbol          string  `json:"symbol"`
+			Return          float64 `json:"return"`
+			BenchmarkReturn float64 `json:"benchmark_return"`
+			Beta            float64 `json:"beta"`
+			Series          []interface{}
+		} `json:"benchmark"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, out.ID)
+	assert.Equal(t, "SPY", out.Benchmark.Symbol)
+	assert.InDelta(t, 0.5, out.Benchmark.Return, 1e-9)
+	assert.InDelta(t, 0.21, out.Benchmark.BenchmarkReturn, 1e-9)
+	assert.Len(t, out.Benchmark.Series, 3)
+
+	res, err = http.Get(ts.URL + "/v1/backtests/1?benchmark=QQQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
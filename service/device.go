@@ -0,0 +1,82 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/devices"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Device represents the device http service
+type Device struct {
+	svc *devices.Service
+}
+
+// DeviceRouter declares the routes for devices router group
+func DeviceRouter(svc *devices.Service, r *gin.RouterGroup) {
+	d := Device{
+		svc: svc,
+	}
+	dr := r.Group("/devices")
+	dr.GET("", d.list)
+	dr.POST("", d.register)
+	dr.DELETE("", d.unregisterToken)
+	dr.DELETE("/:id", d.unregister)
+}
+
+func (d *Device) list(c *gin.Context) {
+	result, err := d.svc.List(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.Device{}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (d *Device) register(c *gin.Context) {
+	r, err := request.RegisterDevice(c)
+	if err != nil {
+		return
+	}
+	result, err := d.svc.Register(c, &model.Device{
+		Platform:   r.Platform,
+		Token:      r.Token,
+		AppVersion: r.AppVersion,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (d *Device) unregister(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	if err := d.svc.Unregister(c, id); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (d *Device) unregisterToken(c *gin.Context) {
+	r, err := request.UnregisterDevice(c)
+	if err != nil {
+		return
+	}
+	if err := d.svc.UnregisterToken(c, r.Token); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
@@ -0,0 +1,111 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/devices"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevices(t *testing.T) {
+	registered := []model.Device{{ID: 1, UserID: 2, Platform: model.PlatformIOS, Token: "other"}}
+	deviceRepo := &mockdb.Device{
+		RegisterFn: func(d *model.Device) (*model.Device, error) {
+			for i := range registered {
+				if registered[i].Token == d.Token {
+					d.ID = registered[i].ID
+					registered[i] = *d
+					return d, nil
+				}
+			}
+			d.ID = len(registered) + 1
+			registered = append(registered, *d)
+			return d, nil
+		},
+		ListFn: func(userID int) ([]model.Device, error) {
+			var res []model.Device
+			for _, d := range registered {
+				if d.UserID == userID {
+					res = append(res, d)
+				}
+			}
+			return res, nil
+		},
+		ViewFn: func(id int) (*model.Device, error) {
+			for _, d := range registered {
+				if d.ID == id {
+					return &d, nil
+				}
+			}
+			return nil, apperr.New(http.StatusNotFound, "Device not found.")
+		},
+		DeleteFn: func(d *model.Device) error {
+			for i := range registered {
+				if registered[i].ID == d.ID {
+					registered = append(registered[:i], registered[i+1:]...)
+					break
+				}
+			}
+			return nil
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	service.DeviceRouter(devices.NewDeviceService(deviceRepo), rg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	do := func(method, path, body string, out interface{}) int {
+		req, _ := http.NewRequest(method, ts.URL+"/v1/devices"+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		json.NewDecoder(res.Body).Decode(out)
+		return res.StatusCode
+	}
+
+	var d model.Device
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "", `{"platform":"ios","token":"phone","app_version":"1.2.0"}`, &d))
+	assert.Equal(t, 1, d.UserID)
+	assert.False(t, d.LastSeenAt.IsZero())
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "", `{"platform":"android","token":"tablet"}`, &d))
+
+	// a token registered again is refreshed, and moves to the user registering it
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "", `{"platform":"ios","token":"other","app_version":"1.3.0"}`, &d))
+	assert.Equal(t, 1, d.ID)
+	assert.Equal(t, 1, d.UserID)
+
+	var out map[string]interface{}
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "", `{"platform":"web","token":"browser"}`, &out))
+	assert.Equal(t, "Unknown platform.", out["message"])
+
+	var list []model.Device
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "", "", &list))
+	assert.Len(t, list, 3)
+
+	// devices are unregistered by ID or by token, but only the ones of the current user
+	registered = append(registered, model.Device{ID: 9, UserID: 2, Platform: model.PlatformIOS, Token: "stranger"})
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/9", "", &out))
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "", `{"token":"stranger"}`, &out))
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/2", "", &out))
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "", `{"token":"tablet"}`, &out))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "", "", &list))
+	assert.Len(t, list, 1)
+	assert.Equal(t, "other", list[0].Token)
+}
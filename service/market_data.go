@@ -0,0 +1,33 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/repository/marketdata"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarketData represents the market data http service
+type MarketData struct {
+	svc *marketdata.Service
+}
+
+// MarketDataRouter declares the routes of the market data cache
+func MarketDataRouter(svc *marketdata.Service, r *gin.RouterGroup) {
+	m := MarketData{
+		svc: svc,
+	}
+	mr := r.Group("/market-data")
+	mr.GET("/stats", m.stats)
+}
+
+func (m *MarketData) stats(c *gin.Context) {
+	stats, err := m.svc.Stats(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
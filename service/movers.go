@@ -0,0 +1,38 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/repository/movers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Movers represents the market movers http service
+type Movers struct {
+	svc *movers.Service
+}
+
+// MoversRouter declares the routes of the market movers
+func MoversRouter(svc *movers.Service, r *gin.RouterGroup) {
+	m := Movers{
+		svc: svc,
+	}
+	mr := r.Group("/market")
+	mr.GET("/stocks/top-movers", m.list)
+}
+
+func (m *Movers) list(c *gin.Context) {
+	var f movers.Filter
+	if err := c.ShouldBindQuery(&f); err != nil {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid filters."))
+		return
+	}
+	lists, err := m.svc.List(f)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, lists)
+}
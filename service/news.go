@@ -0,0 +1,61 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/repository/news"
+
+	"github.com/gin-gonic/gin"
+)
+
+// News represents the news http service
+type News struct {
+	svc *news.Service
+}
+
+// NewsRouter declares the routes of the news feeds
+func NewsRouter(svc *news.Service, r *gin.RouterGroup) {
+	n := News{
+		svc: svc,
+	}
+	nr := r.Group("/market/stocks/news")
+	nr.GET("", n.list)
+	nr.GET("/feed", n.feed)
+}
+
+// NewsRequest pages the articles of a feed
+type NewsRequest struct {
+	Tickers string `form:"tickers"`
+	Cursor  string `form:"cursor"`
+	Limit   int    `form:"limit"`
+}
+
+func (n *News) list(c *gin.Context) {
+	var req NewsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid request."))
+		return
+	}
+	page, err := n.svc.List(c.Request.Context(), strings.Split(req.Tickers, ","), req.Cursor, req.Limit)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (n *News) feed(c *gin.Context) {
+	var req NewsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid request."))
+		return
+	}
+	page, err := n.svc.Feed(c, req.Cursor, req.Limit)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
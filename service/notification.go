@@ -0,0 +1,120 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/notifications"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Notification represents the notification http service
+type Notification struct {
+	svc *notifications.Service
+}
+
+// NotificationRouter declares the routes for notifications router group
+func NotificationRouter(svc *notifications.Service, r *gin.RouterGroup) {
+	n := Notification{
+		svc: svc,
+	}
+	nr := r.Group("/notifications")
+	nr.GET("", n.list)
+	nr.PATCH("", n.updateAll)
+	nr.GET("/preferences", n.preferences)
+	nr.PATCH("/preferences", n.updatePreferences)
+	nr.PATCH("/:id", n.update)
+}
+
+type notificationListResponse struct {
+	Notifications []model.Notification `json:"notifications"`
+	UnreadCount   int                  `json:"unread_count"`
+	Page          int                  `json:"page"`
+}
+
+func (n *Notification) list(c *gin.Context) {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return
+	}
+	r, err := request.ListNotifications(c)
+	if err != nil {
+		return
+	}
+	result, unread, err := n.svc.List(c, r.Unread, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.Notification{}
+	}
+	c.JSON(http.StatusOK, notificationListResponse{
+		Notifications: result,
+		UnreadCount:   unread,
+		Page:          p.Page,
+	})
+}
+
+func (n *Notification) update(c *gin.Context) {
+	r, err := request.UpdateNotification(c)
+	if err != nil {
+		return
+	}
+	result, err := n.svc.SetRead(c, r.ID, *r.Read)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (n *Notification) updateAll(c *gin.Context) {
+	r, err := request.UpdateNotifications(c)
+	if err != nil {
+		return
+	}
+	unread, err := n.svc.SetReadAll(c, r.IDs, *r.Read)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+func (n *Notification) preferences(c *gin.Context) {
+	result, err := n.svc.Preferences(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (n *Notification) updatePreferences(c *gin.Context) {
+	r, err := request.UpdateNotificationPreferences(c)
+	if err != nil {
+		return
+	}
+	updates := make([]notifications.PreferenceUpdate, len(r.Preferences))
+	for i, p := range r.Preferences {
+		updates[i] = notifications.PreferenceUpdate{
+			Category: p.Category,
+			Email:    p.Email,
+			SMS:      p.SMS,
+			Push:     p.Push,
+			InApp:    p.InApp,
+		}
+	}
+	result, err := n.svc.UpdatePreferences(c, updates)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
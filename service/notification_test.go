@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/notifications"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifications(t *testing.T) {
+	inbox := []model.Notification{
+		{ID: 1, UserID: 1, Category: model.NotifyTrades, Title: "AAPL order filled"},
+		{ID: 2, UserID: 1, Category: model.NotifyRewards, Title: "Reward paid"},
+		{ID: 3, UserID: 2, Category: model.NotifyRewards, Title: "Reward paid"},
+	}
+	var saved []model.NotificationPreference
+	notificationRepo := &mockdb.Notification{
+		ListFn: func(userID int, unread bool, p *model.Pagination) ([]model.Notification, error) {
+			var res []model.Notification
+			for _, n := range inbox {
+				if n.UserID == userID && (!unread || n.ReadAt == nil) {
+					res = append(res, n)
+				}
+			}
+			return res, nil
+		},
+		CountUnreadFn: func(userID int) (int, error) {
+			c := 0
+			for _, n := range inbox {
+				if n.UserID == userID && n.ReadAt == nil {
+					c++
+				}
+			}
+			return c, nil
+		},
+		ViewFn: func(id int) (*model.Notification, error) {
+			n := inbox[id-1]
+			return &n, nil
+		},
+		UpdateReadFn: func(userID int, ids []int, read bool) error {
+			now := time.Now()
+			for i := range inbox {
+				if inbox[i].UserID != userID {
+					continue
+				}
+				for _, id := range ids {
+					if inbox[i].ID == id {
+						inbox[i].ReadAt = &now
+					}
+				}
+				if len(ids) == 0 {
+					inbox[i].ReadAt = &now
+				}
+			}
+			return nil
+		},
+		PreferencesFn: func(int) ([]model.NotificationPreference, error) {
+			return saved, nil
+		},
+		SavePreferenceFn: func(p *model.NotificationPreference) error {
+			saved = append(saved, *p)
+			return nil
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	service.NotificationRouter(notifications.NewNotificationService(&mockdb.User{}, notificationRepo, nil), rg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	do := func(method, path, body string, out interface{}) int {
+		req, _ := http.NewRequest(method, ts.URL+"/v1/notifications"+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		json.NewDecoder(res.Body).Decode(out)
+		return res.StatusCode
+	}
+
+	var list struct {
+		Notifications []model.Notification `json:"notifications"`
+		UnreadCount   int                  `json:"unread_count"`
+	}
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "", "", &list))
+	assert.Len(t, list.Notifications, 2)
+	assert.Equal(t, 2, list.UnreadCount)
+
+	var n model.Notification
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/1", `{"read":true}`, &n))
+	assert.NotNil(t, n.ReadAt)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPatch, "/3", `{"read":true}`, &n))
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "?unread=true", "", &list))
+	assert.Len(t, list.Notifications, 1)
+	assert.Equal(t, 1, list.UnreadCount)
+
+	var unread map[string]int
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "", `{"read":true}`, &unread))
+	assert.Equal(t, 0, unread["unread_count"])
+	assert.Nil(t, inbox[2].ReadAt)
+
+	// preferences are listed for every category, with the defaults of those not set
+	var prefs []model.NotificationPreference
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/preferences", `{"preferences":[{"category":"trades","push":false,"email":true}]}`, &prefs))
+	assert.Len(t, prefs, len(model.NotifyCategories))
+	assert.Equal(t, model.NotificationPreference{Category: model.NotifyTrades, Email: true, InApp: true}, prefs[0])
+	assert.True(t, prefs[1].Push)
+	assert.Equal(t, 1, saved[0].UserID)
+
+	var e map[string]string
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/preferences", `{"preferences":[{"category":"weather","push":true}]}`, &e))
+	assert.Equal(t, "Unknown category.", e["message"])
+}
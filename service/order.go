@@ -0,0 +1,119 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/order"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyKeyHeader is the header clients send so retried orders are submitted once
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Order represents the order http service
+type Order struct {
+	svc *order.Service
+}
+
+// OrderRouter declares the routes for orders router group
+func OrderRouter(svc *order.Service, r *gin.RouterGroup) {
+	o := Order{
+		svc: svc,
+	}
+	or := r.Group("/orders")
+	or.GET("", o.list)
+	or.POST("", o.create)
+	or.GET("/:order_id", o.view)
+	or.PATCH("/:order_id", o.replace)
+	or.DELETE("", o.cancelAll)
+	or.DELETE("/:order_id", o.cancel)
+}
+
+type orderListResponse struct {
+	Orders []model.Order `json:"orders"`
+	Page   int           `json:"page"`
+}
+
+func (o *Order) list(c *gin.Context) {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return
+	}
+	f, err := request.ListOrders(c)
+	if err != nil {
+		return
+	}
+	result, err := o.svc.List(c, f, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.Order{}
+	}
+	c.JSON(http.StatusOK, orderListResponse{
+		Orders: result,
+		Page:   p.Page,
+	})
+}
+
+func (o *Order) create(c *gin.Context) {
+	req := new(broker.OrderRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	result, err := o.svc.Create(c, c.GetHeader(idempotencyKeyHeader), req)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (o *Order) view(c *gin.Context) {
+	result, err := o.svc.View(c, c.Param("order_id"))
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (o *Order) replace(c *gin.Context) {
+	req := new(broker.ReplaceOrderRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	result, err := o.svc.Replace(c, c.GetHeader(idempotencyKeyHeader), c.Param("order_id"), req)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (o *Order) cancelAll(c *gin.Context) {
+	result, err := o.svc.CancelAll(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusMultiStatus, result)
+}
+
+func (o *Order) cancel(c *gin.Context) {
+	if err := o.svc.Cancel(c, c.Param("order_id")); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,418 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/order"
+	"github.com/zcoriarty/Backend/risk"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// ledger is an in-memory order ledger
+type ledger struct {
+	mu     sync.Mutex
+	orders []*model.Order
+	// failUpdates is the number of updates failing next
+	failUpdates int
+}
+
+func (l *ledger) repo() *mockdb.Order {
+	find := func(pred func(o *model.Order) bool) *model.Order {
+		for _, o := range l.orders {
+			if pred(o) {
+				return o
+			}
+		}
+		return nil
+	}
+	insert := func(o *model.Order) {
+		o.ID = len(l.orders) + 1
+		if o.CreatedAt.IsZero() {
+			o.CreatedAt = time.Now()
+		}
+		cp := *o
+		l.orders = append(l.orders, &cp)
+	}
+	return &mockdb.Order{
+		ReserveFn: func(o *model.Order) (*model.Order, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if found := find(func(r *model.Order) bool { return r.UserID == o.UserID && r.IdempotencyKey == o.IdempotencyKey }); found != nil {
+				cp := *found
+				return &cp, nil
+			}
+			insert(o)
+			return nil, nil
+		},
+		CreateFn: func(o *model.Order) error {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			insert(o)
+			return nil
+		},
+		UpdateFn: func(o *model.Order) error {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.failUpdates > 0 {
+				l.failUpdates--
+				return apperr.DB
+			}
+			*l.orders[o.ID-1] = *o
+			return nil
+		},
+		DeleteFn: func(o *model.Order) error {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.orders[o.ID-1] = &model.Order{ID: o.ID}
+			return nil
+		},
+		ViewFn: func(userID int, orderID string) (*model.Order, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if found := find(func(r *model.Order) bool { return r.UserID == userID && r.OrderID == orderID }); found != nil {
+				cp := *found
+				return &cp, nil
+			}
+			return nil, apperr.New(http.StatusNotFound, "Order not found.")
+		},
+		ListFn: func(userID int, f *model.OrderFilter, p *model.Pagination) ([]model.Order, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			var res []model.Order
+			for i := len(l.orders) - 1; i >= 0; i-- {
+				o := l.orders[i]
+				if o.UserID == userID && o.Status != model.OrderPending && (f.Status == "" || (f.Status == "closed") == o.Closed()) {
+					res = append(res, *o)
+				}
+			}
+			return res, nil
+		},
+		OpenFn: func(userID int) ([]model.Order, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			var res []model.Order
+			for _, o := range l.orders {
+				if o.UserID == userID && o.OrderID != "" && !o.Closed() {
+					res = append(res, *o)
+				}
+			}
+			return res, nil
+		},
+		CountFn: func(userID int) (int, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			n := 0
+			for _, o := range l.orders {
+				if o.UserID == userID {
+					n++
+				}
+			}
+			return n, nil
+		},
+	}
+}
+
+func newOrderServer(brk *brokertest.Server, l *ledger, maxOrderSize float64) *httptest.Server {
+	userRepo := &mockdb.User{
+		ViewFn: func(id int) (*model.User, error) {
+			return &model.User{ID: id, AccountID: "acc", MaxOrderSize: maxOrderSize}, nil
+		},
+	}
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	service.OrderRouter(order.NewOrderService(userRepo, l.repo(), brk.Broker()), rg)
+	return httptest.NewServer(r)
+}
+
+func postOrder(t *testing.T, url, key, body string) (*http.Response, *model.Order) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	o := new(model.Order)
+	if res.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(res.Body).Decode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res, o
+}
+
+func TestCreateOrder(t *testing.T) {
+	cases := []struct {
+		name         string
+		req          string
+		maxOrderSize float64
+		wantStatus   int
+		wantReasons  []string
+		wantOrders   int
+	}{
+		{
+			name:        "Fail on buying power and fractions",
+			req:         `{"symbol":"AAPL","qty":"10.5","side":"buy","type":"market","time_in_force":"day"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantReasons: []string{risk.InsufficientBuyingPower, risk.AssetNotFractionable},
+		},
+		{
+			name:         "Fail on max order size",
+			req:          `{"symbol":"AAPL","qty":"3","side":"buy","type":"market","time_in_force":"day"}`,
+			maxOrderSize: 250,
+			wantStatus:   http.StatusUnprocessableEntity,
+			wantReasons:  []string{risk.MaxOrderSize},
+		},
+		{
+			name:       "Success",
+			req:        `{"symbol":"AAPL","qty":"3","side":"buy","type":"market","time_in_force":"day"}`,
+			wantStatus: http.StatusOK,
+			wantOrders: 1,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := brokertest.NewServer()
+			defer brk.Close()
+			brk.AddAccount("acc", 1000)
+			brk.SetPrice("AAPL", 100)
+			brk.AddAsset(broker.Asset{Symbol: "AAPL", Tradable: true})
+			l := &ledger{}
+			ts := newOrderServer(brk, l, tt.maxOrderSize)
+			defer ts.Close()
+
+			res, err := http.Post(ts.URL+"/v1/orders", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantReasons != nil {
+				var body struct {
+					Reasons []risk.Reason `json:"reasons"`
+				}
+				if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+				var codes []string
+				for _, r := range body.Reasons {
+					codes = append(codes, r.Code)
+				}
+				assert.Equal(t, tt.wantReasons, codes)
+			}
+			orders := brk.Orders("acc")
+			assert.Len(t, orders, tt.wantOrders)
+			assert.Len(t, l.orders, tt.wantOrders)
+			if tt.wantOrders > 0 {
+				assert.Equal(t, orders[0].ID, l.orders[0].OrderID)
+				assert.Equal(t, broker.OrderFilled, l.orders[0].Status)
+			}
+		})
+	}
+}
+
+func TestCreateOrderIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAccount("acc", 1000)
+	brk.SetPrice("AAPL", 100)
+	l := &ledger{}
+	ts := newOrderServer(brk, l, 0)
+	defer ts.Close()
+	url := ts.URL + "/v1/orders"
+	body := `{"symbol":"AAPL","qty":"2","side":"buy","type":"limit","limit_price":"90","time_in_force":"gtc"}`
+
+	// retries with the same key return the first order
+	res, first := postOrder(t, url, "key-1", body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "key-1", first.ClientOrderID)
+	res, retry := postOrder(t, url, "key-1", body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, first.ID, retry.ID)
+	assert.Equal(t, first.OrderID, retry.OrderID)
+	assert.Len(t, brk.Orders("acc"), 1)
+
+	// a key cannot be reused for another order
+	res, _ = postOrder(t, url, "key-1", `{"symbol":"MSFT","qty":"2","side":"buy","type":"market","time_in_force":"day"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	// an order still being submitted is not submitted twice
+	pending := &model.Order{ID: len(l.orders) + 1, UserID: 1, IdempotencyKey: "key-2", Symbol: "AAPL", Side: broker.Buy, Status: model.OrderPending}
+	pending.CreatedAt = time.Now()
+	l.orders = append(l.orders, pending)
+	res, _ = postOrder(t, url, "key-2", body)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	// a rejected order releases its key
+	res, _ = postOrder(t, url, "key-3", `{"symbol":"AAPL","qty":"20","side":"buy","type":"market","time_in_force":"day"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	res, _ = postOrder(t, url, "key-3", `{"symbol":"AAPL","qty":"1","side":"buy","type":"market","time_in_force":"day"}`)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, brk.Orders("acc"), 2)
+
+	// the ledger follows the broker order
+	brk.SetPrice("AAPL", 89)
+	var list struct {
+		Orders []model.Order `json:"orders"`
+	}
+	res, err := http.Get(url + "?status=closed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, list.Orders, 2) {
+		assert.Equal(t, first.OrderID, list.Orders[1].OrderID)
+		assert.Equal(t, broker.OrderFilled, list.Orders[1].Status)
+	}
+}
+
+func TestCreateOrderStaleReservation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAccount("acc", 1000)
+	brk.SetPrice("AAPL", 100)
+	l := &ledger{}
+	ts := newOrderServer(brk, l, 0)
+	defer ts.Close()
+	url := ts.URL + "/v1/orders"
+	body := `{"symbol":"AAPL","qty":"1","side":"buy","type":"market","time_in_force":"day"}`
+
+	// an update failing after the broker accepted the order is retried
+	l.failUpdates = 1
+	res, o := postOrder(t, url, "key-1", body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, broker.OrderFilled, o.Status)
+
+	// a reservation left pending is recovered from the broker once stale
+	l.failUpdates = 3
+	res, _ = postOrder(t, url, "key-2", body)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, model.OrderPending, l.orders[1].Status)
+	res, _ = postOrder(t, url, "key-2", body)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+	l.orders[1].CreatedAt = time.Now().Add(-2 * time.Minute)
+	res, o = postOrder(t, url, "key-2", body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, brk.Orders("acc")[1].ID, o.OrderID)
+	assert.Equal(t, broker.OrderFilled, l.orders[1].Status)
+	assert.Len(t, brk.Orders("acc"), 2)
+
+	// a stale reservation the broker never received is released and submitted again
+	stale := &model.Order{ID: len(l.orders) + 1, UserID: 1, AccountID: "acc", IdempotencyKey: "key-3", Symbol: "AAPL", Side: broker.Buy, Status: model.OrderPending}
+	stale.CreatedAt = time.Now().Add(-2 * time.Minute)
+	l.orders = append(l.orders, stale)
+	res, o = postOrder(t, url, "key-3", body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "key-3", o.ClientOrderID)
+	assert.Len(t, brk.Orders("acc"), 3)
+}
+
+func TestListOrdersImport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAccount("acc", 1000)
+	brk.SetPrice("AAPL", 100)
+	q := 1.0
+	for i := 0; i < 2; i++ {
+		if _, err := brk.Broker().CreateOrder(context.Background(), "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: &q, Side: broker.Buy, Type: "market", TimeInForce: "day"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l := &ledger{}
+	ts := newOrderServer(brk, l, 0)
+	defer ts.Close()
+
+	// orders placed before the ledger existed are imported
+	res, err := http.Get(ts.URL + "/v1/orders?status=all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var list struct {
+		Orders []model.Order `json:"orders"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, list.Orders, 2)
+	assert.Len(t, l.orders, 2)
+}
+
+func TestReplaceOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAccount("acc", 1000)
+	brk.SetPrice("AAPL", 100)
+	l := &ledger{}
+	ts := newOrderServer(brk, l, 0)
+	defer ts.Close()
+
+	res, placed := postOrder(t, ts.URL+"/v1/orders", "", `{"symbol":"AAPL","qty":"2","side":"buy","type":"limit","limit_price":"90","time_in_force":"gtc"}`)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	req, err := http.NewRequest(http.MethodPatch, ts.URL+"/v1/orders/"+placed.OrderID, bytes.NewBufferString(`{"qty":"20"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPatch, ts.URL+"/v1/orders/"+placed.OrderID, bytes.NewBufferString(`{"qty":"3"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Idempotency-Key", "replace-1")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	replacement := new(model.Order)
+	if err := json.NewDecoder(res.Body).Decode(replacement); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, placed.OrderID, replacement.Replaces)
+	assert.Equal(t, 3.0, *replacement.Qty)
+	assert.Equal(t, "replace-1", replacement.ClientOrderID)
+	if assert.Len(t, l.orders, 2) {
+		assert.Equal(t, broker.OrderReplaced, l.orders[0].Status)
+		assert.Equal(t, replacement.OrderID, l.orders[0].ReplacedBy)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/performance"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Performance represents the performance http service
+type Performance struct {
+	svc *performance.Service
+}
+
+// PerformanceRouter declares the routes for algorithm and user performance
+func PerformanceRouter(svc *performance.Service, r *gin.RouterGroup) {
+	p := Performance{
+		svc: svc,
+	}
+	r.GET("/leaderboard", p.leaderboard)
+	r.GET("/algorithms/:id/performance", p.algorithm)
+
+	pr := r.Group("/performance")
+	pr.GET("", p.breakdown)
+	pr.POST("/refresh", p.refresh)
+}
+
+type leaderboardResponse struct {
+	Algorithms []model.AlgorithmPerformance `json:"algorithms"`
+	Page       int                          `json:"page"`
+}
+
+func (p *Performance) leaderboard(c *gin.Context) {
+	pg, err := request.Paginate(c)
+	if err != nil {
+		return
+	}
+	result, err := p.svc.Leaderboard(c, &model.Pagination{
+		Limit: pg.Limit, Offset: pg.Offset,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []model.AlgorithmPerformance{}
+	}
+	c.JSON(http.StatusOK, leaderboardResponse{
+		Algorithms: result,
+		Page:       pg.Page,
+	})
+}
+
+type algorithmPerformanceResponse struct {
+	*model.AlgorithmPerformance
+	Benchmark *benchmark.Comparison `json:"benchmark,omitempty"`
+}
+
+func (p *Performance) algorithm(c *gin.Context) {
+	id, err := request.ID(c)
+	if err != nil {
+		return
+	}
+	b, err := request.CompareBenchmark(c)
+	if err != nil {
+		return
+	}
+	result, err := p.svc.Algorithm(c, id)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	res := algorithmPerformanceResponse{AlgorithmPerformance: result}
+	if b.Symbol != "" {
+		if res.Benchmark, err = p.svc.Benchmark(c, id, b.Symbol); err != nil {
+			apperr.Response(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (p *Performance) breakdown(c *gin.Context) {
+	result, err := p.svc.Breakdown(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (p *Performance) refresh(c *gin.Context) {
+	if err := p.svc.Refresh(c); err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
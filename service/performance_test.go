@@ -0,0 +1,192 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/performance"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshPerformance(t *testing.T) {
+	cases := []struct {
+		name       string
+		admin      bool
+		wantStatus int
+		wantSaved  []model.UserPerformance
+	}{
+		{
+			name:       "Fail on RBAC",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			admin:      true,
+			wantStatus: http.StatusOK,
+			wantSaved: []model.UserPerformance{
+				{UserID: 1, AlgorithmID: 1, TotalReturn: 0.2, TotalTrades: 1, ProfitLoss: 100, PLPercentage: 50},
+			},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := brokertest.NewServer()
+			defer brk.Close()
+			brk.SetPrice("AAPL", 150)
+
+			var saved *model.PerformanceSnapshot
+			performanceRepo := &mockdb.Performance{
+				TradesFn: func() ([]model.Trade, error) {
+					return []model.Trade{
+						{UserID: 1, AlgorithmID: 1, Symbol: "AAPL", TradeType: "buy", Amount: 2, ExecutionPrice: 100},
+					}, nil
+				},
+				LimitsFn: func() ([]model.InvestmentLimit, error) {
+					return []model.InvestmentLimit{{UserID: 1, AlgorithmID: 1, TotalAllowed: 500}}, nil
+				},
+				SaveFn: func(s *model.PerformanceSnapshot) error {
+					saved = s
+					return nil
+				},
+			}
+			rbac := &mock.RBAC{
+				EnforceRoleFn: func(*gin.Context, model.AccessRole) bool {
+					return tt.admin
+				},
+			}
+
+			r := gin.New()
+			rg := r.Group("/v1")
+			service.PerformanceRouter(performance.NewPerformanceService(performanceRepo, rbac, brk.Broker(), nil), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/performance/refresh", "application/json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantSaved != nil && assert.NotNil(t, saved) {
+				assert.Equal(t, tt.wantSaved, saved.Users)
+			}
+		})
+	}
+}
+
+func TestPerformanceBreakdown(t *testing.T) {
+	performanceRepo := &mockdb.Performance{
+		UsersFn: func(userID int) ([]model.UserPerformance, error) {
+			return []model.UserPerformance{
+				{UserID: userID, AlgorithmID: 1, Algorithm: &model.Algorithm{ID: 1, Name: "Momentum"}, ProfitLoss: 10},
+				{UserID: userID, AlgorithmID: 2, Algorithm: &model.Algorithm{ID: 2, Name: "Breakout"}, ProfitLoss: -5},
+			}, nil
+		},
+		SummariesFn: func(userID int) ([]model.TradeSummary, error) {
+			return []model.TradeSummary{{UserID: userID, AlgorithmID: 2, TradesCount: 3}}, nil
+		},
+		InvestmentsFn: func(userID int) ([]model.Investment, error) {
+			return []model.Investment{{UserID: userID, AlgorithmID: 1, CurrentValue: 110}}, nil
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	service.PerformanceRouter(performance.NewPerformanceService(performanceRepo, nil, nil, nil), rg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/v1/performance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var breakdown []model.PerformanceBreakdown
+	if err := json.NewDecoder(res.Body).Decode(&breakdown); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, breakdown, 2) {
+		assert.Equal(t, "Momentum", breakdown[0].Algorithm.Name)
+		assert.Nil(t, breakdown[0].Summary)
+		assert.Equal(t, 110.0, breakdown[0].Investment.CurrentValue)
+		assert.Equal(t, "Breakout", breakdown[1].Algorithm.Name)
+		assert.Equal(t, 3, breakdown[1].Summary.TradesCount)
+		assert.Nil(t, breakdown[1].Investment)
+	}
+}
+
+func TestAlgorithmBenchmark(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC) }
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	store := storedBars(brk.Broker(), map[string][]broker.Bar{
+		"AAPL": {{Timestamp: day(2), Close: 100}, {Timestamp: day(3), Close: 110}, {Timestamp: day(4), Close: 121}},
+		"SPY":  {{Timestamp: day(2), Close: 400}, {Timestamp: day(3), Close: 404}, {Timestamp: day(4), Close: 400}},
+	})
+	performanceRepo := &mockdb.Performance{
+		AlgorithmFn: func(id int) (*model.AlgorithmPerformance, error) {
+			return &model.AlgorithmPerformance{AlgorithmID: id, ProfitLoss: 210}, nil
+		},
+		AlgorithmTradesFn: func(id int) ([]model.Trade, error) {
+			if id != 1 {
+				return nil, nil
+			}
+			return []model.Trade{
+				{UserID: 1, AlgorithmID: 1, Symbol: "AAPL", TradeType: "buy", Amount: 10, ExecutionPrice: 100, ExecutedAt: day(2).Add(time.Hour)},
+			}, nil
+		},
+		AlgorithmLimitsFn: func(id int) ([]model.InvestmentLimit, error) {
+			if id != 1 {
+				return nil, nil
+			}
+			return []model.InvestmentLimit{{UserID: 1, AlgorithmID: 1, TotalAllowed: 1000}}, nil
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	service.PerformanceRouter(performance.NewPerformanceService(performanceRepo, nil, brk.Broker(), store), rg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	get := func(path string) (int, map[string]interface{}) {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		out := map[string]interface{}{}
+		json.NewDecoder(res.Body).Decode(&out)
+		return res.StatusCode, out
+	}
+
+	status, out := get("/v1/algorithms/1/performance")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 210.0, out["profit_loss"])
+	assert.Nil(t, out["benchmark"])
+
+	status, out = get("/v1/algorithms/1/performance?benchmark=SPY")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 210.0, out["profit_loss"])
+	b := out["benchmark"].(map[string]interface{})
+	assert.InDelta(t, 0.21, b["return"], 1e-9)
+	assert.InDelta(t, 0, b["benchmark_return"], 1e-9)
+	assert.Len(t, b["series"], 3)
+
+	status, out = get("/v1/algorithms/3/performance?benchmark=SPY")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "The algorithm has no trades yet.", out["message"])
+}
@@ -1,13 +1,10 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
-	"io/ioutil"
 	"net/http"
-	"os"
 
 	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
 	"github.com/zcoriarty/Backend/repository/account"
 	"github.com/zcoriarty/Backend/repository/plaid"
 	"github.com/zcoriarty/Backend/request"
@@ -15,8 +12,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func PlaidRouter(svc *plaid.Service, acc *account.Service, r *gin.RouterGroup) {
-	a := Plaid{svc, acc}
+func PlaidRouter(svc *plaid.Service, acc *account.Service, brk broker.Service, r *gin.RouterGroup) {
+	a := Plaid{svc, acc, brk}
 
 	ar := r.Group("/plaid")
 	ar.GET("/create_link_token", a.createLinkToken)
@@ -27,8 +24,9 @@ func PlaidRouter(svc *plaid.Service, acc *account.Service, r *gin.RouterGroup) {
 
 // Auth represents auth http service
 type Plaid struct {
-	svc *plaid.Service
-	acc *account.Service
+	svc    *plaid.Service
+	acc    *account.Service
+	broker broker.Service
 }
 
 func (a *Plaid) createLinkToken(c *gin.Context) {
@@ -55,14 +53,14 @@ func (a *Plaid) setAccessToken(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.acc.GetProfile(c, id.(int))
 
-	if user.AccountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
 
 	response, err := a.svc.SetAccessToken(c, id.(int), user.AccountID, data)
 	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusBadRequest, err.Error()))
+		apperr.ResponseV2(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusOK, response)
@@ -71,62 +69,32 @@ func (a *Plaid) setAccessToken(c *gin.Context) {
 func (a *Plaid) accountsList(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.acc.GetProfile(c, id.(int))
-	accountID := user.AccountID
 
-	if accountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
 
-	client := &http.Client{}
-	acountStatus, _ := json.Marshal(map[string]string{
-		"statuses": "QUEUED,APPROVED,PENDING",
-	})
-	accountStatuses := bytes.NewBuffer(acountStatus)
-
-	getAchAccountsList := os.Getenv("BROKER_API_BASE") + "/v1/accounts/" + accountID + "/ach_relationships"
-
-	req, _ := http.NewRequest("GET", getAchAccountsList, accountStatuses)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	response, _ := client.Do(req)
-	responseData, err := ioutil.ReadAll(response.Body)
+	relationships, err := a.broker.ListACHRelationships(c.Request.Context(), user.AccountID, "QUEUED", "APPROVED", "PENDING")
 	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusInternalServerError, "Something went wrong. Try again later."))
+		apperr.Response(c, err)
 		return
 	}
-
-	var responseObject interface{}
-	json.Unmarshal(responseData, &responseObject)
-	c.JSON(response.StatusCode, responseObject)
+	c.JSON(http.StatusOK, relationships)
 }
 
 func (a *Plaid) detachAccount(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.acc.GetProfile(c, id.(int))
 
-	accountID := user.AccountID
-	bankID := c.Param("bank_id")
-
-	if accountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
 
-	deleteAccountAPIURL := os.Getenv("BROKER_API_BASE") + "/v1/accounts/" + accountID + "/ach_relationships/" + bankID
-
-	client := &http.Client{}
-	req, _ := http.NewRequest("DELETE", deleteAccountAPIURL, nil)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-	response, _ := client.Do(req)
-
-	responseData, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		apperr.Response(c, apperr.New(http.StatusInternalServerError, "Something went wrong. Try again later."))
+	if err := a.broker.DeleteACHRelationship(c.Request.Context(), user.AccountID, c.Param("bank_id")); err != nil {
+		apperr.Response(c, err)
 		return
 	}
-
-	var responseObject interface{}
-	json.Unmarshal(responseData, &responseObject)
-	c.JSON(response.StatusCode, responseObject)
+	c.Status(http.StatusNoContent)
 }
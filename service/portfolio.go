@@ -0,0 +1,60 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/optimizer"
+	"github.com/zcoriarty/Backend/repository/portfolio"
+	"github.com/zcoriarty/Backend/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Portfolio represents the portfolio optimization http service
+type Portfolio struct {
+	svc *portfolio.Service
+}
+
+// PortfolioRouter declares the routes for the portfolio router group
+func PortfolioRouter(svc *portfolio.Service, r *gin.RouterGroup) {
+	p := Portfolio{
+		svc: svc,
+	}
+	pr := r.Group("/portfolio")
+	pr.POST("/optimize", p.optimize)
+}
+
+type optimizeResponse struct {
+	*optimizer.Result
+	Allocation *model.Allocation `json:"allocation,omitempty"`
+}
+
+func (p *Portfolio) optimize(c *gin.Context) {
+	r, err := request.OptimizePortfolio(c)
+	if err != nil {
+		return
+	}
+	result, err := p.svc.Optimize(c, r.Symbols, r.Lookback, optimizer.Constraints{
+		MaxWeight:    r.MaxWeight,
+		RiskFreeRate: r.RiskFreeRate,
+		Points:       r.Points,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	res := optimizeResponse{Result: result}
+	if r.Allocate != "" {
+		chosen := &result.MaxSharpe
+		if r.Allocate == portfolio.MinVariance {
+			chosen = &result.MinVariance
+		}
+		if res.Allocation, err = p.svc.Allocate(c, chosen); err != nil {
+			apperr.Response(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/optimizer"
+	"github.com/zcoriarty/Backend/repository/allocations"
+	"github.com/zcoriarty/Backend/repository/order"
+	"github.com/zcoriarty/Backend/repository/portfolio"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPortfolioServer(brk *brokertest.Server, store *allocationStore, daily map[string][]broker.Bar) *httptest.Server {
+	userRepo := &mockdb.User{
+		ViewFn: func(id int) (*model.User, error) {
+			return &model.User{ID: id, AccountID: "acc"}, nil
+		},
+	}
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	orders := order.NewOrderService(userRepo, (&ledger{}).repo(), brk.Broker())
+	allocationService := allocations.NewAllocationService(userRepo, store.repo(), store.budget(), orders, brk.Broker())
+	service.PortfolioRouter(portfolio.NewPortfolioService(storedBars(brk.Broker(), daily), allocationService), rg)
+	return httptest.NewServer(r)
+}
+
+// dailyBars returns the daily bars of the last 200 days of a symbol growing by drift and
+// swinging by swing every other day
+func dailyBars(drift, swing float64) []broker.Bar {
+	var res []broker.Bar
+	close := 100.0
+	start := time.Now().AddDate(0, 0, -200)
+	for d := 0; d < 200; d++ {
+		close *= 1 + drift + swing*math.Sin(float64(d))
+		res = append(res, broker.Bar{Timestamp: start.AddDate(0, 0, d), Close: close})
+	}
+	return res
+}
+
+func TestOptimizePortfolio(t *testing.T) {
+	cases := []struct {
+		name           string
+		req            string
+		current        *model.Allocation
+		wantStatus     int
+		wantAllocation *model.Allocation
+	}{
+		{
+			name:       "Fail on binding",
+			req:        `{"symbols":["VTI"]}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Fail on too many points",
+			req:        `{"symbols":["A","B","C","D","E","F","G","H","I","J","K"],"points":50}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on duplicate symbol",
+			req:        `{"symbols":["VTI","vti"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on missing history",
+			req:        `{"symbols":["VTI","NOPE"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on infeasible max weight",
+			req:        `{"symbols":["VTI","BND"],"max_weight":0.4}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"symbols":["VTI","BND"],"points":5}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:           "Success with allocation",
+			req:            `{"symbols":["VTI","BND"],"max_weight":0.7,"allocate":"min_variance"}`,
+			current:        &model.Allocation{ID: 1, UserID: 1, Threshold: 0.1, MinTradeSize: 5, AutoRebalance: true},
+			wantStatus:     http.StatusOK,
+			wantAllocation: &model.Allocation{ID: 1, UserID: 1, Threshold: 0.1, MinTradeSize: 5, AutoRebalance: true},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	daily := map[string][]broker.Bar{
+		"VTI": dailyBars(0.001, 0.02),
+		"BND": dailyBars(0.0002, 0.004),
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := allocationBroker()
+			defer brk.Close()
+			store := &allocationStore{allocation: tt.current}
+			ts := newPortfolioServer(brk, store, daily)
+			defer ts.Close()
+
+			res, err := http.Post(ts.URL+"/v1/portfolio/optimize", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				optimizer.Result
+				Allocation *model.Allocation `json:"allocation"`
+			}
+			if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, 199, resp.Days)
+			assert.Len(t, resp.Assets, 2)
+			assert.NotEmpty(t, resp.Frontier)
+			assert.True(t, resp.MaxSharpe.Sharpe >= resp.MinVariance.Sharpe)
+			if tt.wantAllocation == nil {
+				assert.Nil(t, resp.Allocation)
+				assert.Nil(t, store.allocation)
+				return
+			}
+			// weights are rounded down to basis points, and capped
+			want := *tt.wantAllocation
+			for _, w := range resp.MinVariance.Weights {
+				want.Targets = append(want.Targets, model.AllocationTarget{Symbol: w.Symbol, Weight: math.Floor(w.Weight*1e4+1e-6) / 1e4})
+			}
+			assert.Equal(t, &want, resp.Allocation)
+			assert.Equal(t, &want, store.allocation)
+			assert.InDelta(t, 0.7, want.Targets[0].Weight, 1e-4)
+		})
+	}
+}
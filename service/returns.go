@@ -0,0 +1,51 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/benchmark"
+	"github.com/zcoriarty/Backend/repository/returns"
+	"github.com/zcoriarty/Backend/request"
+	returnsmetrics "github.com/zcoriarty/Backend/returns"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Returns represents the account performance http service
+type Returns struct {
+	svc *returns.Service
+}
+
+// ReturnsRouter declares the routes for the performance of the account
+func ReturnsRouter(svc *returns.Service, r *gin.RouterGroup) {
+	rt := Returns{
+		svc: svc,
+	}
+	r.GET("/account/performance", rt.performance)
+}
+
+type performanceResponse struct {
+	*returnsmetrics.Metrics
+	Benchmark *benchmark.Comparison `json:"benchmark,omitempty"`
+}
+
+func (rt *Returns) performance(c *gin.Context) {
+	r, err := request.AccountPerformance(c)
+	if err != nil {
+		return
+	}
+	result, err := rt.svc.Performance(c, r.Period)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	res := performanceResponse{Metrics: result}
+	if r.Benchmark != "" {
+		if res.Benchmark, err = rt.svc.Benchmark(c, result, r.Benchmark); err != nil {
+			apperr.Response(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
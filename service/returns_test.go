@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/returns"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountPerformance(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	day := func(d int) time.Time { return time.Date(2025, time.March, d, 0, 0, 0, 0, ny) }
+
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAccount("acc", 1000)
+	// 10% on the day of a deposit of 1000, 5% the next day and back to 10% on the last one
+	brk.SetPortfolioHistory("acc", broker.PortfolioHistory{
+		Timestamp: []int64{day(3).Unix(), day(4).Unix(), day(5).Unix(), day(6).Unix()},
+		Equity:    []float64{1000, 2100, 2205, 2100},
+		Timeframe: "1D",
+	})
+	transferRepo := &mockdb.Transfer{CompletedFn: func(int) ([]model.Transfer, error) {
+		at := func(t time.Time) model.Base { return model.Base{UpdatedAt: t} }
+		return []model.Transfer{
+			{Base: at(day(1)), Direction: broker.Incoming, Amount: 1000, Status: "COMPLETE"},
+			{Base: at(day(4).Add(15 * time.Hour)), Direction: broker.Incoming, Amount: 1000, Status: "COMPLETE"},
+			{Base: at(day(9)), Direction: broker.Outgoing, Amount: 500, Status: "COMPLETE"},
+		}, nil
+	}}
+	accountID := "acc"
+	userRepo := &mockdb.User{ViewFn: func(id int) (*model.User, error) {
+		return &model.User{ID: id, AccountID: accountID}, nil
+	}}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	service.ReturnsRouter(returns.NewReturnsService(userRepo, transferRepo, brk.Broker(), storedBars(nil, map[string][]broker.Bar{
+		"SPY": {{Timestamp: day(3), Close: 100}, {Timestamp: day(4), Close: 102}, {Timestamp: day(5), Close: 104}, {Timestamp: day(6), Close: 103}},
+	})), rg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	get := func(query string) (int, map[string]interface{}) {
+		res, err := http.Get(ts.URL + "/v1/account/performance" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		out := map[string]interface{}{}
+		json.NewDecoder(res.Body).Decode(&out)
+		return res.StatusCode, out
+	}
+
+	status, out := get("?period=3M")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1000.0, out["net_deposits"])
+	assert.InDelta(t, 100, out["gain"], 1e-9)
+	assert.InDelta(t, 0.1, out["time_weighted_return"], 1e-9)
+	assert.InDelta(t, 0.0476, out["max_drawdown"], 1e-4)
+	assert.Len(t, out["series"], 4)
+	mwr, _ := out["money_weighted_return"].(float64)
+	assert.True(t, mwr > 0 && mwr < 0.1)
+	assert.Nil(t, out["benchmark"])
+
+	// the time-weighted return is compared day by day
+	status, out = get("?period=3M&benchmark=spy")
+	assert.Equal(t, http.StatusOK, status)
+	b := out["benchmark"].(map[string]interface{})
+	assert.Equal(t, "SPY", b["symbol"])
+	assert.InDelta(t, 0.1, b["return"], 1e-9)
+	assert.InDelta(t, 0.03, b["benchmark_return"], 1e-9)
+	assert.InDelta(t, 0.07, b["excess_return"], 1e-9)
+	assert.Len(t, b["series"], 4)
+
+	// the series ends before this year
+	status, out = get("?period=YTD")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "Not enough history for this period.", out["message"])
+
+	status, _ = get("?period=2W")
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	accountID = ""
+	status, out = get("")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "Account not found.", out["message"])
+}
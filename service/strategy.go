@@ -0,0 +1,31 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/strategy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StrategyRouter declares the routes listing the registered strategies and the JSON schemas
+// of their parameters
+func StrategyRouter(r *gin.RouterGroup) {
+	sr := r.Group("/strategies")
+	sr.GET("", listStrategies)
+	sr.GET("/:name", viewStrategy)
+}
+
+func listStrategies(c *gin.Context) {
+	c.JSON(http.StatusOK, strategy.Definitions())
+}
+
+func viewStrategy(c *gin.Context) {
+	d, err := strategy.Lookup(c.Param("name"))
+	if err != nil {
+		apperr.Response(c, apperr.New(http.StatusNotFound, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
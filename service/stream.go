@@ -0,0 +1,44 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/repository/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// upgrader upgrades /stream requests to WebSockets. Clients authenticate with their JWT rather
+// than with cookies, so connections from any origin are accepted.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Stream represents the real-time stream http service
+type Stream struct {
+	hub *stream.Hub
+}
+
+// StreamRouter declares the routes for the real-time stream
+func StreamRouter(hub *stream.Hub, r *gin.RouterGroup) {
+	s := Stream{
+		hub: hub,
+	}
+	r.GET("/stream", s.connect)
+}
+
+func (s *Stream) connect(c *gin.Context) {
+	client, err := s.hub.Client(c)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade responded with the error already
+		return
+	}
+	s.hub.Serve(client, conn)
+}
@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/broker/brokertest"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/events"
+	"github.com/zcoriarty/Backend/repository/stream"
+	"github.com/zcoriarty/Backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// newStreamServer serves /stream to user ids passed in the user query parameter, user 1 owning
+// account acc
+func newStreamServer(t *testing.T, brk *brokertest.Server) (*httptest.Server, func()) {
+	userRepo := &mockdb.User{
+		ViewFn: func(id int) (*model.User, error) {
+			if id == 1 {
+				return &model.User{ID: 1, AccountID: "acc"}, nil
+			}
+			return &model.User{ID: id}, nil
+		},
+	}
+	bus := events.NewBus()
+	hub := stream.NewHub(brk.Broker(), userRepo, bus, zap.NewNop())
+	hub.RetryWait = time.Millisecond
+	relay := events.NewRelay(brk.Broker(), bus, zap.NewNop(), broker.TradeStream)
+	relay.RetryWait = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	go hub.Run(ctx)
+	go relay.Run(ctx)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Query("user"))
+		c.Set("id", id)
+	})
+	service.StreamRouter(hub, rg)
+	ts := httptest.NewServer(r)
+	return ts, func() {
+		cancel()
+		ts.Close()
+	}
+}
+
+func dialStream(t *testing.T, ts *httptest.Server, user int) *websocket.Conn {
+	u := "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/stream?user=" + strconv.Itoa(user)
+	conn, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// next returns the next message of conn of the given type, skipping the others
+func next(t *testing.T, conn *websocket.Conn, typ string) *stream.Message {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		m := new(stream.Message)
+		if err := conn.ReadJSON(m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Type == typ {
+			return m
+		}
+	}
+}
+
+// eventually waits for cond to hold
+func eventually(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamMarketData(t *testing.T) {
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	ts, stop := newStreamServer(t, brk)
+	defer stop()
+	subscriptions := func() string {
+		trades, quotes := brk.StreamSubscriptions()
+		return strings.Join(trades, ",") + "|" + strings.Join(quotes, ",")
+	}
+
+	a, b := dialStream(t, ts, 1), dialStream(t, ts, 2)
+	defer a.Close()
+	defer b.Close()
+	assert.Nil(t, a.WriteJSON(stream.Request{Action: "subscribe", Trades: []string{"aapl"}, Quotes: []string{"MSFT"}}))
+	sub := next(t, a, stream.SubscriptionMessage).Subscription
+	assert.Equal(t, []string{"AAPL"}, sub.Trades)
+	assert.Equal(t, []string{"MSFT"}, sub.Quotes)
+	assert.Nil(t, b.WriteJSON(stream.Request{Action: "subscribe", Trades: []string{"AAPL", "TSLA"}}))
+	next(t, b, stream.SubscriptionMessage)
+
+	// every client shares a single upstream connection
+	eventually(t, func() bool { return subscriptions() == "AAPL,TSLA|MSFT" })
+	assert.Equal(t, 1, brk.StreamConnections())
+
+	brk.SetPrice("AAPL", 150)
+	for _, conn := range []*websocket.Conn{a, b} {
+		m := next(t, conn, stream.TradeMessage)
+		assert.Equal(t, "AAPL", m.Symbol)
+		assert.Equal(t, 150.0, m.Trade.Price)
+	}
+	brk.SetPrice("MSFT", 300)
+	assert.Equal(t, 300.0, next(t, a, stream.QuoteMessage).Quote.AskPrice)
+
+	// symbols stay subscribed upstream while any client is subscribed to them
+	assert.Nil(t, a.WriteJSON(stream.Request{Action: "unsubscribe", Trades: []string{"AAPL"}}))
+	next(t, a, stream.SubscriptionMessage)
+	assert.Equal(t, "AAPL,TSLA|MSFT", subscriptions())
+	b.Close()
+	eventually(t, func() bool { return subscriptions() == "|MSFT" })
+
+	// subscriptions are restored after the upstream connection drops
+	brk.DropMarketStreams()
+	eventually(t, func() bool { return brk.StreamConnections() == 1 && subscriptions() == "|MSFT" })
+
+	assert.Nil(t, a.WriteJSON(stream.Request{Action: "watch"}))
+	assert.Equal(t, "Action must be subscribe or unsubscribe.", next(t, a, stream.ErrorMessage).Message)
+}
+
+func TestStreamOrders(t *testing.T) {
+	brk := brokertest.NewServer()
+	defer brk.Close()
+	brk.AddAccount("acc", 1000)
+	brk.AddAccount("other", 1000)
+	brk.SetPrice("AAPL", 100)
+	ts, stop := newStreamServer(t, brk)
+	defer stop()
+
+	conn := dialStream(t, ts, 1)
+	defer conn.Close()
+	assert.Nil(t, conn.WriteJSON(stream.Request{Action: "subscribe", Orders: true, Positions: true}))
+	next(t, conn, stream.SubscriptionMessage)
+
+	// the relay only follows the events that follow its start, so wait for it to connect
+	eventually(t, func() bool { return brk.Calls(http.MethodGet, "/v1/events/trades") > 0 })
+	time.Sleep(50 * time.Millisecond)
+
+	ctx := context.Background()
+	one, two := 1.0, 2.0
+	_, err := brk.Broker().CreateOrder(ctx, "other", &broker.OrderRequest{Symbol: "AAPL", Qty: &one, Side: broker.Buy, Type: "market", TimeInForce: "day"})
+	assert.Nil(t, err)
+	o, err := brk.Broker().CreateOrder(ctx, "acc", &broker.OrderRequest{Symbol: "AAPL", Qty: &two, Side: broker.Buy, Type: "market", TimeInForce: "day"})
+	assert.Nil(t, err)
+
+	// only the orders of the user's own account are streamed
+	m := next(t, conn, stream.OrderMessage)
+	assert.Equal(t, o.ID, m.Order.ID)
+	assert.Equal(t, "new", m.Event)
+	m = next(t, conn, stream.OrderMessage)
+	assert.Equal(t, "fill", m.Event)
+	p := next(t, conn, stream.PositionMessage).Position
+	assert.Equal(t, "AAPL", p.Symbol)
+	assert.Equal(t, 2.0, p.Qty)
+	assert.Equal(t, 100.0, *p.Price)
+}
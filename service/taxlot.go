@@ -0,0 +1,75 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/taxlots"
+	"github.com/zcoriarty/Backend/request"
+	"github.com/zcoriarty/Backend/taxlot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaxLot represents the tax lot http service
+type TaxLot struct {
+	svc *taxlots.Service
+}
+
+// TaxLotRouter declares the routes for tax lots router group
+func TaxLotRouter(svc *taxlots.Service, r *gin.RouterGroup) {
+	t := TaxLot{
+		svc: svc,
+	}
+	tr := r.Group("/taxlots")
+	tr.GET("", t.list)
+	tr.GET("/realized", t.realized)
+	tr.PUT("/relief/:order_id", t.setRelief)
+}
+
+func (t *TaxLot) list(c *gin.Context) {
+	r, err := request.ListTaxLots(c)
+	if err != nil {
+		return
+	}
+	result, err := t.svc.Lots(c, r.Symbol)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	if result == nil {
+		result = []taxlot.Lot{}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (t *TaxLot) realized(c *gin.Context) {
+	r, err := request.ReportRealizedGains(c)
+	if err != nil {
+		return
+	}
+	result, err := t.svc.Realized(c, r.Year)
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (t *TaxLot) setRelief(c *gin.Context) {
+	r, err := request.SetLotRelief(c)
+	if err != nil {
+		return
+	}
+	result, err := t.svc.SetRelief(c, &model.LotRelief{
+		OrderID: r.OrderID,
+		Method:  r.Method,
+		LotIDs:  r.LotIDs,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/mock/mockdb"
+	"github.com/zcoriarty/Backend/model"
+	"github.com/zcoriarty/Backend/repository/taxlots"
+	"github.com/zcoriarty/Backend/service"
+	"github.com/zcoriarty/Backend/taxlot"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxLots(t *testing.T) {
+	at := func(s string) *time.Time {
+		v, _ := time.Parse(time.RFC3339, s)
+		return &v
+	}
+	price := func(v float64) *float64 { return &v }
+	// the first buy filled before fills were recorded, and the second one partly
+	orders := []model.Order{
+		{UserID: 1, OrderID: "buy1", Symbol: "AAPL", Side: broker.Buy, FilledQty: 10, FilledAvgPrice: price(100), Status: "filled", FilledAt: at("2024-01-10T15:00:00Z")},
+		{UserID: 1, OrderID: "buy2", Symbol: "AAPL", Side: broker.Buy, FilledQty: 10, FilledAvgPrice: price(125), Status: "filled", FilledAt: at("2025-06-02T15:00:00Z")},
+		{UserID: 1, OrderID: "sell", Symbol: "AAPL", Side: broker.Sell, FilledQty: 5, FilledAvgPrice: price(140), Status: "filled", FilledAt: at("2025-08-01T15:00:00Z")},
+		{UserID: 1, OrderID: "old", Symbol: "AAPL", Side: broker.Sell, Status: "filled", FilledAt: at("2024-02-01T15:00:00Z")},
+	}
+	fills := []model.Fill{
+		{UserID: 1, OrderID: "buy2", ExecutionID: "e1", Symbol: "AAPL", Side: broker.Buy, Qty: 4, Price: 120, FilledAt: *at("2025-06-02T14:00:00Z")},
+		{UserID: 1, OrderID: "sell", ExecutionID: "e2", Symbol: "AAPL", Side: broker.Sell, Qty: 5, Price: 140, FilledAt: *at("2025-08-01T15:00:00Z")},
+	}
+	var reliefs []model.LotRelief
+	orderRepo := &mockdb.Order{
+		ListFn: func(userID int, f *model.OrderFilter, p *model.Pagination) ([]model.Order, error) {
+			return orders, nil
+		},
+		ViewFn: func(userID int, orderID string) (*model.Order, error) {
+			for _, o := range orders {
+				if o.UserID == userID && o.OrderID == orderID {
+					return &o, nil
+				}
+			}
+			return nil, apperr.New(http.StatusNotFound, "Order not found.")
+		},
+	}
+	taxLotRepo := &mockdb.TaxLot{
+		FillsFn:   func(int) ([]model.Fill, error) { return fills, nil },
+		ReliefsFn: func(int) ([]model.LotRelief, error) { return reliefs, nil },
+		SaveReliefFn: func(l *model.LotRelief) error {
+			reliefs = append(reliefs, *l)
+			return nil
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rg := r.Group("/v1")
+	rg.Use(func(c *gin.Context) { c.Set("id", 1) })
+	service.TaxLotRouter(taxlots.NewTaxLotService(orderRepo, taxLotRepo), rg)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	do := func(method, path, body string, out interface{}) int {
+		req, _ := http.NewRequest(method, ts.URL+"/v1/taxlots"+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		json.NewDecoder(res.Body).Decode(out)
+		return res.StatusCode
+	}
+
+	// the sell relieves the first lot by FIFO, and the second lot mixes recorded and ledger fills
+	var lots []taxlot.Lot
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "?symbol=aapl", "", &lots))
+	assert.Len(t, lots, 3)
+	assert.Equal(t, "buy1", lots[0].ID)
+	assert.Equal(t, 5.0, lots[0].Qty)
+	assert.Equal(t, 480.0, lots[1].CostBasis)
+	assert.Equal(t, 6.0, lots[2].Qty)
+	assert.Equal(t, 770.0, lots[2].CostBasis)
+
+	var report taxlots.Report
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/realized?year=2025", "", &report))
+	assert.Len(t, report.Realized, 1)
+	assert.Equal(t, 200.0, report.LongTerm.Gain)
+	assert.Equal(t, 0.0, report.ShortTerm.Gain)
+	assert.Equal(t, 200.0, report.Total.Gain)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/realized?year=2024", "", &report))
+	assert.Empty(t, report.Realized)
+
+	// the lots of the sell are chosen until it settles
+	var e map[string]string
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/relief/sell", `{"method":"specific_lot"}`, &e))
+	assert.Equal(t, "Lots are required for specific lot relief.", e["message"])
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/relief/buy1", `{"method":"lifo"}`, &e))
+	assert.Equal(t, "Lots are only relieved by sell orders.", e["message"])
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/relief/old", `{"method":"lifo"}`, &e))
+	assert.Equal(t, "The lots of settled orders cannot be changed.", e["message"])
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/relief/nope", `{"method":"lifo"}`, &e))
+
+	orders[2].FilledAt = at(time.Now().UTC().Format(time.RFC3339))
+	fills[1].FilledAt = *orders[2].FilledAt
+	var relief model.LotRelief
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/relief/sell", `{"method":"highest_cost"}`, &relief))
+	assert.Equal(t, taxlot.HighestCost, relief.Method)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "", "", &lots))
+	assert.Equal(t, 10.0, lots[0].Qty)
+	assert.Equal(t, 1.0, lots[2].Qty)
+}
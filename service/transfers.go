@@ -1,27 +1,19 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
 
 	"github.com/zcoriarty/Backend/apperr"
+	"github.com/zcoriarty/Backend/broker"
 	"github.com/zcoriarty/Backend/repository/account"
 	"github.com/zcoriarty/Backend/repository/transfer"
 
 	"github.com/gin-gonic/gin"
 )
 
-type ErrorBody struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
-
-func TransferRouter(svc *transfer.Service, acc *account.Service, r *gin.RouterGroup) {
-	a := Transfer{svc, acc}
+func TransferRouter(svc *transfer.Service, acc *account.Service, brk broker.Service, r *gin.RouterGroup) {
+	a := Transfer{svc, acc, brk}
 
 	ar := r.Group("/transfer")
 	ar.GET("", a.transfer)
@@ -32,46 +24,41 @@ func TransferRouter(svc *transfer.Service, acc *account.Service, r *gin.RouterGr
 
 // Auth represents auth http service
 type Transfer struct {
-	svc *transfer.Service
-	acc *account.Service
+	svc    *transfer.Service
+	acc    *account.Service
+	broker broker.Service
 }
 
 func (a *Transfer) transfer(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.acc.GetProfile(c, id.(int))
 
-	if user.AccountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
 
-	limit := c.DefaultQuery("limit", "10000")
-	offset := c.DefaultQuery("offset", "0")
-	direction := c.DefaultQuery("direction", "")
-
-	transferListURL := os.Getenv("BROKER_API_BASE") + "/v1/accounts/" + user.AccountID + "/transfers?limit=" + limit + "&offset=" + offset + "&direction=" + direction
-
-	client := &http.Client{}
-	transferListRequest, _ := http.NewRequest("GET", transferListURL, nil)
-	transferListRequest.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	transferList, _ := client.Do(transferListRequest)
-	transferListBody, err := ioutil.ReadAll(transferList.Body)
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10000"))
 	if err != nil {
-		apperr.Response(c, apperr.New(transferList.StatusCode, "Something went wrong. Try again later."))
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid limit."))
 		return
 	}
-
-	if transferList.StatusCode != 200 {
-		errorBody := ErrorBody{}
-		json.Unmarshal(transferListBody, &errorBody)
-		apperr.Response(c, apperr.New(transferList.StatusCode, errorBody.Message))
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		apperr.Response(c, apperr.New(http.StatusBadRequest, "Invalid offset."))
 		return
 	}
 
-	var transferListJSON interface{}
-	json.Unmarshal(transferListBody, &transferListJSON)
-	c.JSON(transferList.StatusCode, transferListJSON)
+	transfers, err := a.broker.ListTransfers(c.Request.Context(), user.AccountID, &broker.ListTransfersRequest{
+		Direction: c.Query("direction"),
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		apperr.Response(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, transfers)
 }
 
 func (a *Transfer) createNewTransfer(c *gin.Context) {
@@ -90,76 +77,39 @@ func (a *Transfer) createNewTransfer(c *gin.Context) {
 		return
 	}
 
-	if user.AccountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
 
-	createNewTransactionURL := os.Getenv("BROKER_API_BASE") + "/v1/accounts/" + user.AccountID + "/transfers"
-
-	client := &http.Client{}
-	createNewTransfer, _ := json.Marshal(map[string]interface{}{
-		"transfer_type":   "ach",
-		"relationship_id": bankID,
-		"amount":          amount,
-		"direction":       "INCOMING",
+	transfer, err := a.broker.CreateTransfer(c.Request.Context(), user.AccountID, &broker.TransferRequest{
+		TransferType:   "ach",
+		RelationshipID: bankID,
+		Amount:         amount,
+		Direction:      broker.Incoming,
 	})
-	tranferBody := bytes.NewBuffer(createNewTransfer)
-
-	req, err := http.NewRequest("POST", createNewTransactionURL, tranferBody)
-	req.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	createTransfer, err := client.Do(req)
-	responseData, err := ioutil.ReadAll(createTransfer.Body)
 	if err != nil {
-		apperr.Response(c, apperr.New(createTransfer.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-
-	if createTransfer.StatusCode != 200 {
-		errorBody := ErrorBody{}
-		json.Unmarshal(responseData, &errorBody)
-		apperr.Response(c, apperr.New(createTransfer.StatusCode, errorBody.Message))
+		apperr.Response(c, err)
 		return
 	}
-
-	var responseObject map[string]interface{}
-	json.Unmarshal(responseData, &responseObject)
-
-	c.JSON(createTransfer.StatusCode, responseObject)
+	// the transfer exists at the broker already, so failing to record it, which the repo
+	// logs, must not make the client request it again
+	_ = a.svc.Record(user, transfer)
+	c.JSON(http.StatusOK, transfer)
 }
 
 func (a *Transfer) deleteTransfer(c *gin.Context) {
 	id, _ := c.Get("id")
 	user := a.acc.GetProfile(c, id.(int))
 
-	if user.AccountID == "" {
+	if user == nil || user.AccountID == "" {
 		apperr.Response(c, apperr.New(http.StatusBadRequest, "Account not found."))
 		return
 	}
-	transferID := c.Param("transfer_id")
-
-	deleteTransfersListURL := os.Getenv("BROKER_API_BASE") + "/v1/accounts/" + user.AccountID + "/transfers/" + transferID
 
-	client := &http.Client{}
-	transferDeleteRequest, _ := http.NewRequest("DELETE", deleteTransfersListURL, nil)
-	transferDeleteRequest.Header.Add("Authorization", os.Getenv("BROKER_TOKEN"))
-
-	transferDeleteResponse, _ := client.Do(transferDeleteRequest)
-	transferDeleteBody, err := ioutil.ReadAll(transferDeleteResponse.Body)
-	if err != nil {
-		apperr.Response(c, apperr.New(transferDeleteResponse.StatusCode, "Something went wrong. Try again later."))
-		return
-	}
-
-	if transferDeleteResponse.StatusCode != 200 {
-		errorBody := ErrorBody{}
-		json.Unmarshal(transferDeleteBody, &errorBody)
-		apperr.Response(c, apperr.New(transferDeleteResponse.StatusCode, errorBody.Message))
+	if err := a.broker.DeleteTransfer(c.Request.Context(), user.AccountID, c.Param("transfer_id")); err != nil {
+		apperr.Response(c, err)
 		return
 	}
-
-	var responseObject interface{}
-	json.Unmarshal(transferDeleteBody, &responseObject)
-	c.JSON(transferDeleteResponse.StatusCode, responseObject)
+	c.Status(http.StatusNoContent)
 }
@@ -70,12 +70,13 @@ func (u *User) update(c *gin.Context) {
 		return
 	}
 	userUpdate, err := u.svc.Update(c, &user.Update{
-		ID:        updateUser.ID,
-		FirstName: updateUser.FirstName,
-		LastName:  updateUser.LastName,
-		Mobile:    updateUser.Mobile,
-		Phone:     updateUser.Phone,
-		Address:   updateUser.Address,
+		ID:           updateUser.ID,
+		FirstName:    updateUser.FirstName,
+		LastName:     updateUser.LastName,
+		Mobile:       updateUser.Mobile,
+		Phone:        updateUser.Phone,
+		Address:      updateUser.Address,
+		MaxOrderSize: updateUser.MaxOrderSize,
 	})
 	if err != nil {
 		apperr.Response(c, err)
@@ -0,0 +1,45 @@
+package strategy
+
+import (
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/indicator"
+)
+
+func init() {
+	Register(Definition{
+		Name:        "breakout",
+		Description: "Trend following: buys a close above the highest high of the entry period and sells a close below the lowest low of the exit period.",
+		Schema: object(map[string]Property{
+			"entry_period": integer("Bars whose highest high a close must break to enter", 20, 1, 500),
+			"exit_period":  integer("Bars whose lowest low a close must break to exit", 10, 1, 500),
+		}),
+		New: func(p Params) (Strategy, error) {
+			return Breakout{Entry: p.Int("entry_period"), Exit: p.Int("exit_period")}, nil
+		},
+	})
+}
+
+// Breakout is a Donchian channel breakout: it enters when a close breaks above the highest
+// high of the previous Entry bars and exits when a close breaks below the lowest low of the
+// previous Exit bars
+type Breakout struct {
+	Entry int
+	Exit  int
+}
+
+// Target implements Strategy. The position is that of the latest signal, so the strategy
+// needs no state between calls.
+func (s Breakout) Target(bars []broker.Bar) float64 {
+	// the channels of bar i are those of the bars before it, up to i-1
+	high := indicator.Highest(indicator.Highs(bars), s.Entry)
+	low := indicator.Lowest(indicator.Lows(bars), s.Exit)
+	for i := len(bars) - 1; i > 0; i-- {
+		if bars[i].Close > high[i-1] {
+			return 1
+		}
+		if bars[i].Close < low[i-1] {
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package strategy
+
+import (
+	"errors"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/indicator"
+)
+
+func init() {
+	Register(Definition{
+		Name:        "ma_crossover",
+		Description: "Trend following: invested while the fast simple moving average of closes is above the slow one.",
+		Schema: object(map[string]Property{
+			"fast_period": integer("Bars in the fast moving average", 10, 1, 500),
+			"slow_period": integer("Bars in the slow moving average", 30, 2, 1000),
+		}),
+		New: func(p Params) (Strategy, error) {
+			s := MACrossover{Fast: p.Int("fast_period"), Slow: p.Int("slow_period")}
+			if s.Fast >= s.Slow {
+				return nil, errors.New("fast_period must be shorter than slow_period")
+			}
+			return s, nil
+		},
+	})
+}
+
+// MACrossover holds the symbol while its fast moving average is above its slow one
+type MACrossover struct {
+	Fast int
+	Slow int
+}
+
+// Target implements Strategy
+func (s MACrossover) Target(bars []broker.Bar) float64 {
+	if len(bars) < s.Slow {
+		return 0
+	}
+	closes := indicator.Closes(bars[len(bars)-s.Slow:])
+	fast, slow := indicator.SMA(closes, s.Fast), indicator.SMA(closes, s.Slow)
+	if fast[len(closes)-1] > slow[len(closes)-1] {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package strategy
+
+import (
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/indicator"
+)
+
+func init() {
+	Register(Definition{
+		Name:        "momentum",
+		Description: "Trend following: invested while the return over the lookback period is above the threshold.",
+		Schema: object(map[string]Property{
+			"lookback_period": integer("Bars over which the return is measured", 20, 1, 1000),
+			"threshold":       decimal("Return above which the symbol is held, e.g. 0.05 for 5%", 0, -1, 10),
+		}),
+		New: func(p Params) (Strategy, error) {
+			return Momentum{Lookback: p.Int("lookback_period"), Threshold: p.Float("threshold")}, nil
+		},
+	})
+}
+
+// Momentum holds the symbol while its return over the lookback period beats a threshold
+type Momentum struct {
+	Lookback  int
+	Threshold float64
+}
+
+// Target implements Strategy
+func (s Momentum) Target(bars []broker.Bar) float64 {
+	if len(bars) <= s.Lookback {
+		return 0
+	}
+	// a NaN rate of change, after a zero close, is not above any threshold
+	roc := indicator.ROC(indicator.Closes(bars[len(bars)-1-s.Lookback:]), s.Lookback)
+	if roc[s.Lookback] > s.Threshold {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package strategy
+
+import (
+	"errors"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/indicator"
+)
+
+func init() {
+	Register(Definition{
+		Name:        "rsi_reversion",
+		Description: "Mean reversion: buys when the RSI falls below the oversold level and sells when it rises above the overbought level.",
+		Schema: object(map[string]Property{
+			"period":     integer("Bars in the RSI", 14, 2, 500),
+			"oversold":   decimal("RSI below which the symbol is bought", 30, 0, 100),
+			"overbought": decimal("RSI above which the symbol is sold", 70, 0, 100),
+		}),
+		New: func(p Params) (Strategy, error) {
+			s := RSIReversion{Period: p.Int("period"), Oversold: p.Float("oversold"), Overbought: p.Float("overbought")}
+			if s.Oversold >= s.Overbought {
+				return nil, errors.New("oversold must be lower than overbought")
+			}
+			return s, nil
+		},
+	})
+}
+
+// RSIReversion buys oversold symbols and sells them once overbought
+type RSIReversion struct {
+	Period     int
+	Oversold   float64
+	Overbought float64
+}
+
+// Target implements Strategy. The position is that of the latest signal, so the strategy
+// needs no state between calls.
+func (s RSIReversion) Target(bars []broker.Bar) float64 {
+	rsi := indicator.RSI(indicator.Closes(bars), s.Period)
+	for i := len(bars) - 1; i >= s.Period; i-- {
+		if rsi[i] < s.Oversold {
+			return 1
+		}
+		if rsi[i] > s.Overbought {
+			return 0
+		}
+	}
+	return 0
+}
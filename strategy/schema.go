@@ -0,0 +1,113 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Schema is the JSON schema of a strategy's parameters. Parameters are numbers, every one of
+// them has a default, and unknown parameters are rejected.
+type Schema struct {
+	Type                 string              `json:"type"`
+	Properties           map[string]Property `json:"properties"`
+	AdditionalProperties bool                `json:"additionalProperties"`
+}
+
+// Property is the JSON schema of a single parameter
+type Property struct {
+	// Type is "integer" or "number"
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Default     float64  `json:"default"`
+	Minimum     *float64 `json:"minimum,omitempty"`
+	Maximum     *float64 `json:"maximum,omitempty"`
+}
+
+// Params are validated strategy parameters
+type Params map[string]float64
+
+// Int returns the integer parameter name
+func (p Params) Int(name string) int {
+	return int(p[name])
+}
+
+// Float returns the number parameter name
+func (p Params) Float(name string) float64 {
+	return p[name]
+}
+
+// Map returns the parameters in the form they are stored on algorithms and backtests
+func (p Params) Map() map[string]interface{} {
+	res := make(map[string]interface{}, len(p))
+	for k, v := range p {
+		res[k] = v
+	}
+	return res
+}
+
+// Validate checks raw against the schema and returns the parameters with defaults filled in
+func (s Schema) Validate(raw map[string]interface{}) (Params, error) {
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	p := Params{}
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+		v, ok := number(raw[name])
+		if !ok {
+			return nil, fmt.Errorf("%s must be a number", name)
+		}
+		if prop.Type == "integer" && v != math.Trunc(v) {
+			return nil, fmt.Errorf("%s must be an integer", name)
+		}
+		if prop.Minimum != nil && v < *prop.Minimum {
+			return nil, fmt.Errorf("%s must be at least %v", name, *prop.Minimum)
+		}
+		if prop.Maximum != nil && v > *prop.Maximum {
+			return nil, fmt.Errorf("%s must be at most %v", name, *prop.Maximum)
+		}
+		p[name] = v
+	}
+	for name, prop := range s.Properties {
+		if _, ok := p[name]; !ok {
+			p[name] = prop.Default
+		}
+	}
+	return p, nil
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func object(props map[string]Property) Schema {
+	if props == nil {
+		props = map[string]Property{}
+	}
+	return Schema{Type: "object", Properties: props}
+}
+
+func integer(description string, def, min, max float64) Property {
+	return Property{Type: "integer", Description: description, Default: def, Minimum: &min, Maximum: &max}
+}
+
+func decimal(description string, def, min, max float64) Property {
+	return Property{Type: "number", Description: description, Default: def, Minimum: &min, Maximum: &max}
+}
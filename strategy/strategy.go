@@ -0,0 +1,105 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zcoriarty/Backend/broker"
+)
+
+// Strategy decides the position to hold in a symbol from its price history. The same
+// strategies drive backtests and live algorithms.
+type Strategy interface {
+	// Target receives the bars seen so far, oldest first, and returns the fraction of equity
+	// to hold in the symbol after the latest bar, from 0 (flat) to 1 (fully invested)
+	Target(bars []broker.Bar) float64
+}
+
+// Definition describes a registered strategy: its parameters and how to build it
+type Definition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Schema      Schema `json:"schema"`
+	// New builds the strategy from parameters already validated against Schema
+	New func(Params) (Strategy, error) `json:"-"`
+}
+
+var registry = map[string]*Definition{}
+
+// Register adds a strategy to the registry. It panics if the name is taken.
+func Register(d Definition) {
+	if _, ok := registry[d.Name]; ok {
+		panic("strategy: " + d.Name + " registered twice")
+	}
+	registry[d.Name] = &d
+}
+
+// Lookup returns the definition of the strategy registered under name
+func Lookup(name string) (*Definition, error) {
+	d, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q", name)
+	}
+	return d, nil
+}
+
+// Definitions returns every registered strategy, sorted by name
+func Definitions() []Definition {
+	res := make([]Definition, 0, len(registry))
+	for _, d := range registry {
+		res = append(res, *d)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// Validate checks raw parameters against the schema of the strategy registered under name
+// and returns them with defaults filled in
+func Validate(name string, raw map[string]interface{}) (Params, error) {
+	d, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.Schema.Validate(raw)
+	if err != nil {
+		return nil, err
+	}
+	// constructors reject combinations the schema cannot express, like a fast period longer
+	// than the slow one
+	if _, err := d.New(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// New returns the strategy registered under name, configured with raw parameters
+func New(name string, raw map[string]interface{}) (Strategy, error) {
+	d, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.Schema.Validate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return d.New(p)
+}
+
+func init() {
+	Register(Definition{
+		Name:        "buy_and_hold",
+		Description: "Fully invested from the first bar on.",
+		Schema:      object(nil),
+		New: func(Params) (Strategy, error) {
+			return BuyAndHold{}, nil
+		},
+	})
+}
+
+// BuyAndHold is fully invested from the first bar on
+type BuyAndHold struct{}
+
+// Target implements Strategy
+func (BuyAndHold) Target([]broker.Bar) float64 {
+	return 1
+}
@@ -0,0 +1,187 @@
+package strategy_test
+
+import (
+	"testing"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/strategy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// closes returns bars with the given closes, each bar's high and low equal to its close
+func closes(cs ...float64) []broker.Bar {
+	res := make([]broker.Bar, len(cs))
+	for i, c := range cs {
+		res[i] = broker.Bar{Open: c, High: c, Low: c, Close: c}
+	}
+	return res
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		strategy string
+		params   map[string]interface{}
+		want     strategy.Params
+		wantErr  string
+	}{
+		{
+			name:     "Unknown strategy",
+			strategy: "astrology",
+			wantErr:  `unknown strategy "astrology"`,
+		},
+		{
+			name:     "Unknown parameter",
+			strategy: "momentum",
+			params:   map[string]interface{}{"window": 10.0},
+			wantErr:  `unknown parameter "window"`,
+		},
+		{
+			name:     "Not a number",
+			strategy: "momentum",
+			params:   map[string]interface{}{"lookback_period": "10"},
+			wantErr:  "lookback_period must be a number",
+		},
+		{
+			name:     "Not an integer",
+			strategy: "momentum",
+			params:   map[string]interface{}{"lookback_period": 2.5},
+			wantErr:  "lookback_period must be an integer",
+		},
+		{
+			name:     "Below minimum",
+			strategy: "momentum",
+			params:   map[string]interface{}{"lookback_period": 0.0},
+			wantErr:  "lookback_period must be at least 1",
+		},
+		{
+			name:     "Invalid combination",
+			strategy: "ma_crossover",
+			params:   map[string]interface{}{"fast_period": 50.0},
+			wantErr:  "fast_period must be shorter than slow_period",
+		},
+		{
+			name:     "Defaults",
+			strategy: "rsi_reversion",
+			params:   map[string]interface{}{"period": 10.0},
+			want:     strategy.Params{"period": 10, "oversold": 30, "overbought": 70},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := strategy.Validate(tt.strategy, tt.params)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+		})
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	var names []string
+	for _, d := range strategy.Definitions() {
+		names = append(names, d.Name)
+		assert.Equal(t, "object", d.Schema.Type)
+		// the defaults of every strategy are valid
+		_, err := strategy.New(d.Name, nil)
+		assert.NoError(t, err, d.Name)
+	}
+	assert.Equal(t, []string{"breakout", "buy_and_hold", "ma_crossover", "momentum", "rsi_reversion"}, names)
+}
+
+func TestTarget(t *testing.T) {
+	cases := []struct {
+		name     string
+		strategy string
+		params   map[string]interface{}
+		bars     []broker.Bar
+		want     float64
+	}{
+		{
+			name:     "Crossover without enough bars",
+			strategy: "ma_crossover",
+			params:   map[string]interface{}{"fast_period": 2.0, "slow_period": 4.0},
+			bars:     closes(1, 2, 3),
+		},
+		{
+			name:     "Crossover above",
+			strategy: "ma_crossover",
+			params:   map[string]interface{}{"fast_period": 2.0, "slow_period": 4.0},
+			bars:     closes(1, 2, 3, 4),
+			want:     1,
+		},
+		{
+			name:     "Crossover below",
+			strategy: "ma_crossover",
+			params:   map[string]interface{}{"fast_period": 2.0, "slow_period": 4.0},
+			bars:     closes(4, 3, 2, 1),
+		},
+		{
+			name:     "Breakout entered",
+			strategy: "breakout",
+			params:   map[string]interface{}{"entry_period": 3.0, "exit_period": 2.0},
+			bars:     closes(10, 10, 10, 11, 10.5),
+			want:     1,
+		},
+		{
+			name:     "Breakout exited",
+			strategy: "breakout",
+			params:   map[string]interface{}{"entry_period": 3.0, "exit_period": 2.0},
+			bars:     closes(10, 10, 10, 11, 10.5, 9),
+		},
+		{
+			name:     "RSI oversold",
+			strategy: "rsi_reversion",
+			params:   map[string]interface{}{"period": 3.0},
+			bars:     closes(10, 9, 8, 7),
+			want:     1,
+		},
+		{
+			name:     "RSI holds until overbought",
+			strategy: "rsi_reversion",
+			params:   map[string]interface{}{"period": 3.0},
+			bars:     closes(10, 9, 8, 7, 7.5, 7),
+			want:     1,
+		},
+		{
+			name:     "RSI overbought",
+			strategy: "rsi_reversion",
+			params:   map[string]interface{}{"period": 3.0},
+			bars:     closes(10, 9, 8, 7, 8, 9, 10),
+		},
+		{
+			// the last two changes are gains, but Wilder's smoothing remembers the earlier loss
+			name:     "RSI smoothed like the indicator",
+			strategy: "rsi_reversion",
+			params:   map[string]interface{}{"period": 2.0},
+			bars:     closes(10, 1, 2, 3),
+			want:     1,
+		},
+		{
+			name:     "Momentum above threshold",
+			strategy: "momentum",
+			params:   map[string]interface{}{"lookback_period": 2.0, "threshold": 0.05},
+			bars:     closes(10, 10, 11),
+			want:     1,
+		},
+		{
+			name:     "Momentum below threshold",
+			strategy: "momentum",
+			params:   map[string]interface{}{"lookback_period": 2.0, "threshold": 0.05},
+			bars:     closes(10, 10, 10.4),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := strategy.New(tt.strategy, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, s.Target(tt.bars))
+		})
+	}
+}
@@ -0,0 +1,357 @@
+// Package taxlot tracks the tax lots of positions from their fills. Sells relieve lots by
+// FIFO, LIFO, highest cost or specific lot identification and realize short-term or long-term
+// gains, and losses are disallowed as wash sales when replacement shares are bought within 30
+// days of the sale.
+package taxlot
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+)
+
+// Relief methods, choosing the lots a sell relieves
+const (
+	FIFO        = "fifo"
+	LIFO        = "lifo"
+	HighestCost = "highest_cost"
+	// SpecificLot relieves the lots named by the seller first, and the others by FIFO
+	SpecificLot = "specific_lot"
+)
+
+// Methods lists the relief methods
+var Methods = []string{FIFO, LIFO, HighestCost, SpecificLot}
+
+// WashWindow is how long before and after a loss sale buying the same security disallows
+// the loss
+const WashWindow = 30 * 24 * time.Hour
+
+// epsilon absorbs the rounding of fractional quantities
+const epsilon = 1e-9
+
+// Fill is the execution of a buy or a sell
+type Fill struct {
+	ID      string
+	OrderID string
+	Symbol  string
+	Side    string
+	Qty     float64
+	Price   float64
+	At      time.Time
+}
+
+// Relief chooses the lots relieved by the fills of a sell order
+type Relief struct {
+	Method string
+	LotIDs []string
+}
+
+// Lot is a quantity of shares bought together. Lots split by a wash sale keep the ID of their
+// lot with a suffix.
+type Lot struct {
+	ID         string    `json:"id"`
+	Symbol     string    `json:"symbol"`
+	Qty        float64   `json:"qty"`
+	CostBasis  float64   `json:"cost_basis"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	// HoldingStart starts the holding period, which includes the holding period of the shares
+	// sold in a wash sale for replacement shares
+	HoldingStart time.Time `json:"holding_start"`
+	// WashAdjustment is the disallowed loss added to the cost basis of replacement shares
+	WashAdjustment float64 `json:"wash_adjustment"`
+
+	// origin is the ID of the fill that bought the lot
+	origin      string
+	replacement bool
+	splits      int
+}
+
+// CostPerShare returns the cost basis of a share of l
+func (l *Lot) CostPerShare() float64 {
+	return l.CostBasis / l.Qty
+}
+
+// LongTerm reports whether l is held long-term at t, that is for more than a year
+func (l *Lot) LongTerm(t time.Time) bool {
+	return t.After(l.HoldingStart.AddDate(1, 0, 0))
+}
+
+// Realization is the gain or loss realized by selling shares of a lot
+type Realization struct {
+	Symbol     string    `json:"symbol"`
+	LotID      string    `json:"lot_id"`
+	OrderID    string    `json:"order_id"`
+	Qty        float64   `json:"qty"`
+	Proceeds   float64   `json:"proceeds"`
+	CostBasis  float64   `json:"cost_basis"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	SoldAt     time.Time `json:"sold_at"`
+	LongTerm   bool      `json:"long_term"`
+	// WashDisallowed is the part of the loss disallowed by a wash sale, which Gain excludes
+	WashDisallowed float64 `json:"wash_disallowed"`
+	Gain           float64 `json:"gain"`
+}
+
+// pendingLoss is a loss sale whose shares were not all matched with replacement shares yet
+type pendingLoss struct {
+	r            *Realization
+	qty          float64
+	lossPerShare float64
+	holding      time.Duration
+}
+
+// Book holds the open lots and realized gains of an account
+type Book struct {
+	lots     map[string][]*Lot
+	realized []*Realization
+	pending  map[string][]*pendingLoss
+	// Uncovered is the quantity sold of every symbol without a lot to relieve, e.g. of shares
+	// transferred in
+	Uncovered map[string]float64
+}
+
+// New returns an empty book
+func New() *Book {
+	return &Book{
+		lots:      map[string][]*Lot{},
+		pending:   map[string][]*pendingLoss{},
+		Uncovered: map[string]float64{},
+	}
+}
+
+// Compute replays fills in the order they were executed. The fills of sell orders relieve
+// lots as chosen by reliefs, keyed by order ID, and by FIFO for the orders without one.
+func Compute(fills []Fill, reliefs map[string]Relief) *Book {
+	sorted := make([]Fill, len(fills))
+	copy(sorted, fills)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+	b := New()
+	for _, f := range sorted {
+		if f.Side == broker.Sell {
+			b.Sell(f, reliefs[f.OrderID])
+		} else {
+			b.Buy(f)
+		}
+	}
+	return b
+}
+
+// Buy opens a lot with f. Shares bought within WashWindow of a loss sale replace the shares
+// sold, and take the loss disallowed in their cost basis.
+func (b *Book) Buy(f Fill) {
+	if f.Qty <= epsilon {
+		return
+	}
+	l := &Lot{
+		ID:           f.ID,
+		Symbol:       f.Symbol,
+		Qty:          f.Qty,
+		CostBasis:    f.Qty * f.Price,
+		AcquiredAt:   f.At,
+		HoldingStart: f.At,
+		origin:       f.ID,
+	}
+	var pending []*pendingLoss
+	for _, p := range b.pending[f.Symbol] {
+		if f.At.Sub(p.r.SoldAt) > WashWindow {
+			continue
+		}
+		if l != nil && p.qty > epsilon {
+			matched := b.split(l, p.qty)
+			wash(matched, p.r, p.lossPerShare, p.holding)
+			b.lots[f.Symbol] = append(b.lots[f.Symbol], matched)
+			p.qty -= matched.Qty
+			if matched == l {
+				l = nil
+			}
+		}
+		if p.qty > epsilon {
+			pending = append(pending, p)
+		}
+	}
+	b.pending[f.Symbol] = pending
+	if l != nil {
+		b.lots[f.Symbol] = append(b.lots[f.Symbol], l)
+	}
+}
+
+// Sell relieves the lots of its symbol by f as chosen by relief, realizing a gain or a loss
+// on each of them. Losses are disallowed as far as shares were bought within WashWindow before
+// the sale, and the remainder waits for the shares bought within WashWindow after it.
+func (b *Book) Sell(f Fill, relief Relief) {
+	type loss struct {
+		r       *Realization
+		holding time.Duration
+		origin  string
+	}
+	var losses []loss
+	remaining := f.Qty
+	for _, l := range b.order(f.Symbol, relief) {
+		if remaining <= epsilon {
+			break
+		}
+		qty := remaining
+		if qty > l.Qty {
+			qty = l.Qty
+		}
+		remaining -= qty
+		cost := qty * l.CostPerShare()
+		r := &Realization{
+			Symbol:     f.Symbol,
+			LotID:      l.ID,
+			OrderID:    f.OrderID,
+			Qty:        qty,
+			Proceeds:   qty * f.Price,
+			CostBasis:  cost,
+			AcquiredAt: l.AcquiredAt,
+			SoldAt:     f.At,
+			LongTerm:   l.LongTerm(f.At),
+			Gain:       qty*f.Price - cost,
+		}
+		b.realized = append(b.realized, r)
+		l.CostBasis -= cost
+		l.Qty -= qty
+		if l.Qty <= epsilon {
+			b.remove(l)
+		}
+		if r.Gain < 0 {
+			losses = append(losses, loss{r, f.At.Sub(l.HoldingStart), l.origin})
+		}
+	}
+	if remaining > epsilon {
+		b.Uncovered[f.Symbol] += remaining
+	}
+	// the shares sold together do not replace each other
+	for _, l := range losses {
+		b.washBefore(l.r, l.holding, l.origin)
+	}
+}
+
+// washBefore matches the loss r with the shares bought within WashWindow before its sale,
+// and keeps the unmatched remainder pending
+func (b *Book) washBefore(r *Realization, holding time.Duration, origin string) {
+	p := &pendingLoss{r: r, qty: r.Qty, lossPerShare: -r.Gain / r.Qty, holding: holding}
+	for _, l := range b.byAcquisition(r.Symbol) {
+		if p.qty <= epsilon {
+			break
+		}
+		if l.replacement || l.origin == origin || r.SoldAt.Sub(l.AcquiredAt) > WashWindow {
+			continue
+		}
+		matched := b.split(l, p.qty)
+		if matched != l {
+			b.lots[r.Symbol] = append(b.lots[r.Symbol], matched)
+		}
+		wash(matched, r, p.lossPerShare, p.holding)
+		p.qty -= matched.Qty
+	}
+	if p.qty > epsilon {
+		b.pending[r.Symbol] = append(b.pending[r.Symbol], p)
+	}
+}
+
+// wash disallows the loss of r on the shares of l, which replace them
+func wash(l *Lot, r *Realization, lossPerShare float64, holding time.Duration) {
+	d := l.Qty * lossPerShare
+	l.CostBasis += d
+	l.WashAdjustment += d
+	l.HoldingStart = l.HoldingStart.Add(-holding)
+	l.replacement = true
+	r.WashDisallowed += d
+	r.Gain += d
+}
+
+// split returns l when it holds at most qty shares, and otherwise takes qty shares out of it
+// into a new lot
+func (b *Book) split(l *Lot, qty float64) *Lot {
+	if l.Qty <= qty+epsilon {
+		return l
+	}
+	l.splits++
+	part := *l
+	part.ID = l.ID + "." + strconv.Itoa(l.splits)
+	part.splits = 0
+	part.Qty = qty
+	part.CostBasis = qty * l.CostPerShare()
+	l.CostBasis -= part.CostBasis
+	l.Qty -= qty
+	return &part
+}
+
+// order returns the open lots of symbol in the order relief relieves them
+func (b *Book) order(symbol string, relief Relief) []*Lot {
+	lots := b.byAcquisition(symbol)
+	switch relief.Method {
+	case LIFO:
+		sort.SliceStable(lots, func(i, j int) bool { return lots[i].AcquiredAt.After(lots[j].AcquiredAt) })
+	case HighestCost:
+		sort.SliceStable(lots, func(i, j int) bool { return lots[i].CostPerShare() > lots[j].CostPerShare() })
+	case SpecificLot:
+		sort.SliceStable(lots, func(i, j int) bool { return rank(lots[i], relief.LotIDs) < rank(lots[j], relief.LotIDs) })
+	}
+	return lots
+}
+
+// rank returns the position of the lot among the lots named by ids, which include the lots
+// split from them, or the number of ids when it is not named
+func rank(l *Lot, ids []string) int {
+	for i, id := range ids {
+		if l.ID == id || strings.HasPrefix(l.ID, id+".") {
+			return i
+		}
+	}
+	return len(ids)
+}
+
+// byAcquisition returns the open lots of symbol, oldest first
+func (b *Book) byAcquisition(symbol string) []*Lot {
+	lots := make([]*Lot, len(b.lots[symbol]))
+	copy(lots, b.lots[symbol])
+	sort.Slice(lots, func(i, j int) bool { return before(lots[i], lots[j]) })
+	return lots
+}
+
+// before orders lots by acquisition, and the lots split from a lot after it
+func before(a, b *Lot) bool {
+	if !a.AcquiredAt.Equal(b.AcquiredAt) {
+		return a.AcquiredAt.Before(b.AcquiredAt)
+	}
+	return a.ID < b.ID
+}
+
+func (b *Book) remove(l *Lot) {
+	lots := b.lots[l.Symbol]
+	for i := range lots {
+		if lots[i] == l {
+			b.lots[l.Symbol] = append(lots[:i], lots[i+1:]...)
+			return
+		}
+	}
+}
+
+// Lots returns the open lots of symbol, or of every symbol when it is empty, oldest first
+func (b *Book) Lots(symbol string) []Lot {
+	var res []Lot
+	for s, lots := range b.lots {
+		if symbol != "" && s != symbol {
+			continue
+		}
+		for _, l := range lots {
+			res = append(res, *l)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return before(&res[i], &res[j]) })
+	return res
+}
+
+// Realized returns the realizations of the sales, in the order of the sales
+func (b *Book) Realized() []Realization {
+	res := make([]Realization, len(b.realized))
+	for i, r := range b.realized {
+		res[i] = *r
+	}
+	return res
+}
@@ -0,0 +1,137 @@
+package taxlot_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/zcoriarty/Backend/broker"
+	"github.com/zcoriarty/Backend/taxlot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+
+func day(n int) time.Time {
+	return start.AddDate(0, 0, n)
+}
+
+func buy(id string, qty, price float64, at time.Time) taxlot.Fill {
+	return taxlot.Fill{ID: id, OrderID: id, Symbol: "AAPL", Side: broker.Buy, Qty: qty, Price: price, At: at}
+}
+
+func sell(id string, qty, price float64, at time.Time) taxlot.Fill {
+	return taxlot.Fill{ID: id, OrderID: id, Symbol: "AAPL", Side: broker.Sell, Qty: qty, Price: price, At: at}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func TestRelief(t *testing.T) {
+	fills := []taxlot.Fill{
+		buy("a", 10, 100, day(0)),
+		buy("b", 10, 120, day(30)),
+		buy("c", 10, 90, day(60)),
+		sell("s", 10, 110, day(400)),
+	}
+	cases := map[string]struct {
+		relief   taxlot.Relief
+		lot      string
+		gain     float64
+		longTerm bool
+	}{
+		"fifo":         {taxlot.Relief{}, "a", 100, true},
+		"lifo":         {taxlot.Relief{Method: taxlot.LIFO}, "c", 200, false},
+		"highest cost": {taxlot.Relief{Method: taxlot.HighestCost}, "b", -100, true},
+		"specific lot": {taxlot.Relief{Method: taxlot.SpecificLot, LotIDs: []string{"c"}}, "c", 200, false},
+	}
+	for name, tc := range cases {
+		b := taxlot.Compute(fills, map[string]taxlot.Relief{"s": tc.relief})
+		realized := b.Realized()
+		assert.Len(t, realized, 1, name)
+		assert.Equal(t, tc.lot, realized[0].LotID, name)
+		assert.Equal(t, tc.gain, round(realized[0].Gain), name)
+		assert.Equal(t, tc.longTerm, realized[0].LongTerm, name)
+		assert.Len(t, b.Lots("AAPL"), 2, name)
+	}
+
+	// a sell spans lots, and what no lot covers is reported
+	b := taxlot.Compute(append(fills, sell("t", 25, 100, day(401))), nil)
+	assert.Len(t, b.Realized(), 3)
+	assert.Empty(t, b.Lots(""))
+	assert.Equal(t, 5.0, b.Uncovered["AAPL"])
+}
+
+func TestWashSale(t *testing.T) {
+	// shares bought after the loss sale replace the shares sold
+	b := taxlot.Compute([]taxlot.Fill{
+		buy("a", 10, 100, day(0)),
+		sell("s", 10, 80, day(40)),
+		buy("b", 10, 85, day(50)),
+	}, nil)
+	r := b.Realized()[0]
+	assert.Equal(t, 200.0, round(r.WashDisallowed))
+	assert.Equal(t, 0.0, round(r.Gain))
+	lots := b.Lots("AAPL")
+	assert.Len(t, lots, 1)
+	assert.Equal(t, 1050.0, round(lots[0].CostBasis))
+	assert.Equal(t, 200.0, round(lots[0].WashAdjustment))
+	assert.Equal(t, day(10), lots[0].HoldingStart)
+
+	// and so do shares bought before it, splitting the lots only partly replacing them
+	b = taxlot.Compute([]taxlot.Fill{
+		buy("a", 10, 100, day(0)),
+		buy("b", 5, 90, day(35)),
+		sell("s", 10, 80, day(40)),
+		buy("c", 10, 85, day(60)),
+		buy("d", 10, 85, day(65)),
+	}, nil)
+	r = b.Realized()[0]
+	assert.Equal(t, 200.0, round(r.WashDisallowed))
+	lots = b.Lots("AAPL")
+	assert.Len(t, lots, 4)
+	assert.Equal(t, []string{"b", "c", "c.1", "d"}, []string{lots[0].ID, lots[1].ID, lots[2].ID, lots[3].ID})
+	assert.Equal(t, 550.0, round(lots[0].CostBasis))
+	assert.Equal(t, 425.0, round(lots[1].CostBasis))
+	assert.Equal(t, 525.0, round(lots[2].CostBasis))
+	assert.Equal(t, 0.0, lots[3].WashAdjustment)
+
+	// specific lot relief follows the lots split from the lot named
+	b = taxlot.Compute([]taxlot.Fill{
+		buy("a", 10, 100, day(0)),
+		buy("b", 5, 90, day(35)),
+		sell("s", 10, 80, day(40)),
+		buy("c", 10, 85, day(60)),
+		sell("x", 10, 90, day(200)),
+	}, map[string]taxlot.Relief{"x": {Method: taxlot.SpecificLot, LotIDs: []string{"c"}}})
+	realized := b.Realized()
+	assert.Len(t, realized, 3)
+	assert.Equal(t, []string{"c", "c.1"}, []string{realized[1].LotID, realized[2].LotID})
+
+	// the shares sold along and the rest of the lot sold do not replace them, nor do shares
+	// bought past the window
+	b = taxlot.Compute([]taxlot.Fill{
+		buy("a", 10, 100, day(0)),
+		buy("b", 10, 100, day(1)),
+		sell("s", 15, 80, day(5)),
+		buy("c", 10, 70, day(36)),
+	}, nil)
+	realized = b.Realized()
+	assert.Equal(t, []string{"a", "b"}, []string{realized[0].LotID, realized[1].LotID})
+	assert.Equal(t, 100.0, round(realized[0].WashDisallowed))
+	assert.Equal(t, 0.0, realized[1].WashDisallowed)
+	lots = b.Lots("AAPL")
+	assert.Equal(t, 600.0, round(lots[0].CostBasis))
+	assert.Equal(t, 0.0, lots[1].WashAdjustment)
+
+	// gains are never disallowed
+	b = taxlot.Compute([]taxlot.Fill{
+		buy("a", 10, 100, day(0)),
+		sell("s", 10, 120, day(5)),
+		buy("b", 10, 110, day(6)),
+	}, nil)
+	assert.Equal(t, 200.0, round(b.Realized()[0].Gain))
+	assert.Equal(t, 1100.0, round(b.Lots("")[0].CostBasis))
+}